@@ -0,0 +1,958 @@
+package main
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gorilla/mux"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	sessionCookieName    = "hockey_session"
+	sessionTTL           = 30 * 24 * time.Hour
+	clientCookieName     = "hockey_client"
+	clientCookieTTL      = 365 * 24 * time.Hour
+	clientIDLength       = 24
+	passwordIterations   = 600000
+	passwordSaltBytes    = 16
+	passwordKeyBytes     = 32
+	minPasswordLen       = 8
+	maxPasswordLen       = 128
+	maxDisplayNameLen    = 40
+	maxFavourites        = 50
+	maxAuthBodyBytes     = 4 << 10
+	loginFailureLimit    = 10
+	loginIPFailureLimit  = 50
+	loginFailureWindow   = 15 * time.Minute
+	registerLimit        = 5
+	registerWindow       = time.Hour
+	usernameClaimTTL     = time.Minute
+	oidcStateTTL         = 10 * time.Minute
+	oidcStateCookieName  = "hockey_oidc_state"
+	oidcCookiePath       = "/auth/oidc/"
+	oidcDiscoveryTTL     = time.Hour
+	oidcHTTPTimeout      = 10 * time.Second
+	accountIDLength      = 12
+	sessionTokenLength   = 40
+	oidcVerifierLength   = 64
+	defaultOIDCScopes    = "openid email profile"
+	accountProviderLocal = "local"
+	accountProviderOIDC  = "oidc"
+)
+
+var (
+	errNotSignedIn     = errors.New("not signed in")
+	errAccountNotFound = errors.New("account not found")
+	errBadCredentials  = errors.New("wrong username or password")
+)
+
+var usernamePattern = regexp.MustCompile(`^[a-zA-Z0-9_.-]{3,32}$`)
+
+// accountMu serializes read-modify-write cycles on stored accounts.
+var accountMu sync.Mutex
+
+// Favourites are a user's saved teams (abbreviations) and players (ids).
+type Favourites struct {
+	Teams   []string `json:"teams"`
+	Players []int    `json:"players"`
+}
+
+// userAccount is an account as stored. Local accounts have a username and
+// password hash; OIDC accounts are keyed by issuer and subject.
+type userAccount struct {
+	ID           string     `json:"id"`
+	Username     string     `json:"username,omitempty"`
+	DisplayName  string     `json:"displayName"`
+	Email        string     `json:"email,omitempty"`
+	PasswordHash string     `json:"passwordHash,omitempty"`
+	OIDCIssuer   string     `json:"oidcIssuer,omitempty"`
+	OIDCSubject  string     `json:"oidcSubject,omitempty"`
+	Favourites   Favourites `json:"favourites"`
+	CreatedAt    time.Time  `json:"createdAt"`
+}
+
+// AccountView is the signed-in user as returned by /api/account.
+type AccountView struct {
+	ID          string     `json:"id"`
+	Username    string     `json:"username,omitempty"`
+	DisplayName string     `json:"displayName"`
+	Email       string     `json:"email,omitempty"`
+	Provider    string     `json:"provider"`
+	Favourites  Favourites `json:"favourites"`
+}
+
+func (a *userAccount) view() *AccountView {
+	provider := accountProviderLocal
+	if a.OIDCSubject != "" {
+		provider = accountProviderOIDC
+	}
+	return &AccountView{
+		ID:          a.ID,
+		Username:    a.Username,
+		DisplayName: a.DisplayName,
+		Email:       a.Email,
+		Provider:    provider,
+		Favourites:  a.Favourites,
+	}
+}
+
+func accountKey(id string) string {
+	return "account:" + id
+}
+
+func accountLoginKey(username string) string {
+	return "account-login:" + strings.ToLower(username)
+}
+
+func accountOIDCKey(issuer, subject string) string {
+	return "account-oidc:" + hashToken(issuer+"|"+subject)
+}
+
+func sessionKey(token string) string {
+	return "session:" + hashToken(token)
+}
+
+func loadAccount(id string) (*userAccount, error) {
+	data, err := getCachedRaw(accountKey(id))
+	if errors.Is(err, redis.Nil) {
+		return nil, errAccountNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var a userAccount
+	if err := json.Unmarshal(data, &a); err != nil {
+		return nil, fmt.Errorf("parsing account %s: %w", id, err)
+	}
+	if a.Favourites.Teams == nil {
+		a.Favourites.Teams = []string{}
+	}
+	if a.Favourites.Players == nil {
+		a.Favourites.Players = []int{}
+	}
+	return &a, nil
+}
+
+func saveAccount(a *userAccount) error {
+	data, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	return setCachedRaw(accountKey(a.ID), data, 0)
+}
+
+// loadAccountByIndex follows a login or OIDC index key to its account.
+func loadAccountByIndex(key string) (*userAccount, error) {
+	id, err := getCachedRaw(key)
+	if errors.Is(err, redis.Nil) {
+		return nil, errAccountNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return loadAccount(string(id))
+}
+
+// hashPassword derives a PBKDF2-SHA256 hash stored as
+// "pbkdf2-sha256$iterations$salt$key" (base64 salt and key).
+func hashPassword(password string) (string, error) {
+	salt := make([]byte, passwordSaltBytes)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, passwordIterations, passwordKeyBytes)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("pbkdf2-sha256$%d$%s$%s", passwordIterations,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func checkPassword(password, stored string) bool {
+	parts := strings.Split(stored, "$")
+	if len(parts) != 4 || parts[0] != "pbkdf2-sha256" {
+		return false
+	}
+	iter, err := strconv.Atoi(parts[1])
+	if err != nil || iter <= 0 {
+		return false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	want, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return false
+	}
+	got, err := pbkdf2.Key(sha256.New, password, salt, iter, len(want))
+	return err == nil && subtle.ConstantTimeCompare(got, want) == 1
+}
+
+// startSession stores a session for the account and sets its cookie.
+func startSession(w http.ResponseWriter, r *http.Request, a *userAccount) error {
+	token, err := randomString(shareIDAlphabet, sessionTokenLength)
+	if err != nil {
+		return err
+	}
+	if err := setCachedRaw(sessionKey(token), []byte(a.ID), sessionTTL); err != nil {
+		return err
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    token,
+		Path:     "/",
+		MaxAge:   int(sessionTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https",
+		SameSite: http.SameSiteLaxMode,
+	})
+	return nil
+}
+
+// clientKey identifies who is playing a daily puzzle: the account when
+// signed in, otherwise a long-lived random cookie set on first use.
+func clientKey(w http.ResponseWriter, r *http.Request) (string, error) {
+	if a, err := currentAccount(r); err == nil {
+		return "account:" + a.ID, nil
+	}
+	if c, err := r.Cookie(clientCookieName); err == nil && len(c.Value) == clientIDLength && strings.Trim(c.Value, shareIDAlphabet) == "" {
+		return "client:" + c.Value, nil
+	}
+	id, err := randomString(shareIDAlphabet, clientIDLength)
+	if err != nil {
+		return "", err
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     clientCookieName,
+		Value:    id,
+		Path:     "/",
+		MaxAge:   int(clientCookieTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https",
+		SameSite: http.SameSiteLaxMode,
+	})
+	return "client:" + id, nil
+}
+
+// currentAccount returns the account behind the request's session cookie.
+func currentAccount(r *http.Request) (*userAccount, error) {
+	c, err := r.Cookie(sessionCookieName)
+	if err != nil || c.Value == "" {
+		return nil, errNotSignedIn
+	}
+	id, err := getCachedRaw(sessionKey(c.Value))
+	if errors.Is(err, redis.Nil) {
+		return nil, errNotSignedIn
+	}
+	if err != nil {
+		return nil, err
+	}
+	a, err := loadAccount(string(id))
+	if errors.Is(err, errAccountNotFound) {
+		return nil, errNotSignedIn
+	}
+	return a, err
+}
+
+func writeAccountError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errNotSignedIn):
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	case errors.Is(err, errBadCredentials):
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	case redisClient == nil:
+		http.Error(w, "accounts unavailable", http.StatusServiceUnavailable)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func writeAccountJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Error writing account JSON: %v", err)
+	}
+}
+
+// requireAccount loads the signed-in account, writing the error response
+// itself when there is none.
+func requireAccount(w http.ResponseWriter, r *http.Request) (*userAccount, bool) {
+	a, err := currentAccount(r)
+	if err != nil {
+		writeAccountError(w, err)
+		return nil, false
+	}
+	return a, true
+}
+
+type credentialsRequest struct {
+	Username    string `json:"username"`
+	Password    string `json:"password"`
+	DisplayName string `json:"displayName"`
+}
+
+// clientIP is the address sign-in throttling counts against: the peer
+// address, or with TRUST_PROXY set the last X-Forwarded-For hop, which the
+// proxy in front of the server appended.
+func clientIP(r *http.Request) string {
+	if os.Getenv("TRUST_PROXY") != "" {
+		hops := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+		if ip := strings.TrimSpace(hops[len(hops)-1]); ip != "" {
+			return ip
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// countAttempt bumps a throttling counter and returns its new value; the
+// counter expires a window after the latest attempt.
+func countAttempt(key string, window time.Duration) (int64, error) {
+	pipe := redisClient.TxPipeline()
+	incr := pipe.Incr(redisCtx, key)
+	pipe.Expire(redisCtx, key, window)
+	if _, err := pipe.Exec(redisCtx); err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
+
+// sameSiteJSON guards the sign-in endpoints against login CSRF: a
+// cross-site form can only post form or text bodies, and browsers send an
+// Origin that names the other site.
+func sameSiteJSON(w http.ResponseWriter, r *http.Request) bool {
+	if mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mt != "application/json" {
+		http.Error(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+		return false
+	}
+	if origin := r.Header.Get("Origin"); origin != "" {
+		if u, err := url.Parse(origin); err != nil || u.Host != r.Host {
+			http.Error(w, "cross-origin request refused", http.StatusForbidden)
+			return false
+		}
+	}
+	return true
+}
+
+func decodeCredentials(w http.ResponseWriter, r *http.Request) (*credentialsRequest, bool) {
+	if !sameSiteJSON(w, r) {
+		return nil, false
+	}
+	var req credentialsRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAuthBodyBytes)).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		return nil, false
+	}
+	req.Username = strings.TrimSpace(req.Username)
+	req.DisplayName = strings.TrimSpace(req.DisplayName)
+	return &req, true
+}
+
+// handleAPIRegister creates a local account
+// ({"username","password","displayName"}) and signs it in. Each address
+// gets a few sign-ups an hour, checked before the costly password hash.
+func handleAPIRegister(w http.ResponseWriter, r *http.Request) {
+	if redisClient == nil {
+		http.Error(w, "accounts unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	req, ok := decodeCredentials(w, r)
+	if !ok {
+		return
+	}
+	if !usernamePattern.MatchString(req.Username) {
+		http.Error(w, "username must be 3-32 letters, digits, '.', '_' or '-'", http.StatusBadRequest)
+		return
+	}
+	if n := utf8.RuneCountInString(req.Password); n < minPasswordLen || n > maxPasswordLen {
+		http.Error(w, fmt.Sprintf("password must be %d-%d characters", minPasswordLen, maxPasswordLen), http.StatusBadRequest)
+		return
+	}
+	if req.DisplayName == "" {
+		req.DisplayName = req.Username
+	}
+	if utf8.RuneCountInString(req.DisplayName) > maxDisplayNameLen {
+		http.Error(w, fmt.Sprintf("display name must be at most %d characters", maxDisplayNameLen), http.StatusBadRequest)
+		return
+	}
+	n, err := countAttempt("register-attempts:"+clientIP(r), registerWindow)
+	if err != nil {
+		writeAccountError(w, err)
+		return
+	}
+	if n > registerLimit {
+		http.Error(w, "too many sign-ups, try again later", http.StatusTooManyRequests)
+		return
+	}
+	id, err := randomString(shareIDAlphabet, accountIDLength)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Claim the username first so two sign-ups cannot race for it. The
+	// claim expires unless the account is saved, so a crash in between
+	// does not leave the name taken forever.
+	loginKey := accountLoginKey(req.Username)
+	claimed, err := redisClient.SetNX(redisCtx, loginKey, id, usernameClaimTTL).Result()
+	if err != nil {
+		writeAccountError(w, err)
+		return
+	}
+	if !claimed {
+		http.Error(w, "username is taken", http.StatusConflict)
+		return
+	}
+	hash, err := hashPassword(req.Password)
+	if err != nil {
+		_ = redisClient.Del(redisCtx, loginKey).Err()
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	a := &userAccount{
+		ID:           id,
+		Username:     req.Username,
+		DisplayName:  req.DisplayName,
+		PasswordHash: hash,
+		Favourites:   Favourites{Teams: []string{}, Players: []int{}},
+		CreatedAt:    time.Now().UTC(),
+	}
+	if err := saveAccount(a); err != nil {
+		_ = redisClient.Del(redisCtx, loginKey).Err()
+		writeAccountError(w, err)
+		return
+	}
+	if err := redisClient.Persist(redisCtx, loginKey).Err(); err != nil {
+		_ = redisClient.Del(redisCtx, accountKey(id), loginKey).Err()
+		writeAccountError(w, err)
+		return
+	}
+	if err := startSession(w, r, a); err != nil {
+		writeAccountError(w, err)
+		return
+	}
+	writeAccountJSON(w, http.StatusCreated, a.view())
+}
+
+// handleAPILogin signs in a local account ({"username","password"}).
+// Failures are counted per address and username and per address, so
+// guessing from one address is slowed without letting it lock anyone else
+// out of their account.
+func handleAPILogin(w http.ResponseWriter, r *http.Request) {
+	if redisClient == nil {
+		http.Error(w, "accounts unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	req, ok := decodeCredentials(w, r)
+	if !ok {
+		return
+	}
+	ip := clientIP(r)
+	ipKey := "login-failures:" + ip
+	failKey := ipKey + ":" + strings.ToLower(req.Username)
+	userFailures, _ := redisClient.Get(redisCtx, failKey).Int()
+	ipFailures, _ := redisClient.Get(redisCtx, ipKey).Int()
+	if userFailures >= loginFailureLimit || ipFailures >= loginIPFailureLimit {
+		http.Error(w, "too many failed sign-ins, try again later", http.StatusTooManyRequests)
+		return
+	}
+
+	a, err := loadAccountByIndex(accountLoginKey(req.Username))
+	if err != nil && !errors.Is(err, errAccountNotFound) {
+		writeAccountError(w, err)
+		return
+	}
+	if a == nil || a.PasswordHash == "" || !checkPassword(req.Password, a.PasswordHash) {
+		for _, key := range []string{failKey, ipKey} {
+			if _, err := countAttempt(key, loginFailureWindow); err != nil {
+				log.Printf("login: counting failure: %v", err)
+			}
+		}
+		writeAccountError(w, errBadCredentials)
+		return
+	}
+	_ = redisClient.Del(redisCtx, failKey).Err()
+	if err := startSession(w, r, a); err != nil {
+		writeAccountError(w, err)
+		return
+	}
+	writeAccountJSON(w, http.StatusOK, a.view())
+}
+
+// handleAPILogout ends the current session.
+func handleAPILogout(w http.ResponseWriter, r *http.Request) {
+	if c, err := r.Cookie(sessionCookieName); err == nil && c.Value != "" && redisClient != nil {
+		if err := redisClient.Del(redisCtx, sessionKey(c.Value)).Err(); err != nil {
+			log.Printf("logout: deleting session: %v", err)
+		}
+	}
+	http.SetCookie(w, &http.Cookie{Name: sessionCookieName, Value: "", Path: "/", MaxAge: -1, HttpOnly: true, SameSite: http.SameSiteLaxMode})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleAPIAuthProviders tells the login page which sign-in methods are on.
+func handleAPIAuthProviders(w http.ResponseWriter, r *http.Request) {
+	_, oidc := oidcSettings(r)
+	writeAccountJSON(w, http.StatusOK, map[string]bool{
+		"available": redisClient != nil,
+		"local":     true,
+		"oidc":      oidc,
+	})
+}
+
+// handleAPIAccount returns the signed-in user with their favourites.
+func handleAPIAccount(w http.ResponseWriter, r *http.Request) {
+	a, ok := requireAccount(w, r)
+	if !ok {
+		return
+	}
+	writeAccountJSON(w, http.StatusOK, a.view())
+}
+
+// normalizeFavourites resolves team ids or abbreviations, drops duplicates
+// and checks the limits.
+func normalizeFavourites(f Favourites) (Favourites, error) {
+	out := Favourites{Teams: []string{}, Players: []int{}}
+	seenTeams := map[string]bool{}
+	for _, t := range f.Teams {
+		abbr, ok := resolveTeamAbbrev(t)
+		if !ok {
+			return out, fmt.Errorf("unknown team %q", t)
+		}
+		if !seenTeams[abbr] {
+			seenTeams[abbr] = true
+			out.Teams = append(out.Teams, abbr)
+		}
+	}
+	seenPlayers := map[int]bool{}
+	for _, id := range f.Players {
+		if id <= 0 {
+			return out, fmt.Errorf("invalid player id %d", id)
+		}
+		if !seenPlayers[id] {
+			seenPlayers[id] = true
+			out.Players = append(out.Players, id)
+		}
+	}
+	if len(out.Teams) > maxFavourites || len(out.Players) > maxFavourites {
+		return out, fmt.Errorf("at most %d favourite teams and %d players", maxFavourites, maxFavourites)
+	}
+	return out, nil
+}
+
+// updateFavourites applies fn to the signed-in account's favourites and
+// saves them, writing the response either way.
+func updateFavourites(w http.ResponseWriter, r *http.Request, fn func(f Favourites) Favourites) {
+	accountMu.Lock()
+	defer accountMu.Unlock()
+	a, ok := requireAccount(w, r)
+	if !ok {
+		return
+	}
+	fav, err := normalizeFavourites(fn(a.Favourites))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	a.Favourites = fav
+	if err := saveAccount(a); err != nil {
+		writeAccountError(w, err)
+		return
+	}
+	writeAccountJSON(w, http.StatusOK, a.Favourites)
+}
+
+// handleAPIPutFavourites replaces the favourites ({"teams":[],"players":[]}).
+func handleAPIPutFavourites(w http.ResponseWriter, r *http.Request) {
+	var req Favourites
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAuthBodyBytes)).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+	updateFavourites(w, r, func(Favourites) Favourites { return req })
+}
+
+// handleAPIFavouriteTeam adds (POST) or removes (DELETE) one team.
+func handleAPIFavouriteTeam(w http.ResponseWriter, r *http.Request) {
+	abbr, ok := resolveTeamAbbrev(mux.Vars(r)["teamId"])
+	if !ok {
+		http.Error(w, "unknown team", http.StatusNotFound)
+		return
+	}
+	updateFavourites(w, r, func(f Favourites) Favourites {
+		teams := []string{}
+		for _, t := range f.Teams {
+			if t != abbr {
+				teams = append(teams, t)
+			}
+		}
+		if r.Method == http.MethodPost {
+			teams = append(teams, abbr)
+		}
+		f.Teams = teams
+		return f
+	})
+}
+
+// handleAPIFavouritePlayer adds (POST) or removes (DELETE) one player.
+func handleAPIFavouritePlayer(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["playerId"])
+	if err != nil || id <= 0 {
+		http.Error(w, "invalid player id", http.StatusBadRequest)
+		return
+	}
+	updateFavourites(w, r, func(f Favourites) Favourites {
+		players := []int{}
+		for _, p := range f.Players {
+			if p != id {
+				players = append(players, p)
+			}
+		}
+		if r.Method == http.MethodPost {
+			players = append(players, id)
+		}
+		f.Players = players
+		return f
+	})
+}
+
+// oidcConfig is the OIDC client configuration from OIDC_ISSUER,
+// OIDC_CLIENT_ID, OIDC_CLIENT_SECRET and the optional OIDC_REDIRECT_URL
+// (default: this host's /auth/oidc/callback).
+type oidcConfig struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+}
+
+// oidcSettings reads the OIDC configuration; OIDC login is off unless the
+// issuer and client id are set.
+func oidcSettings(r *http.Request) (*oidcConfig, bool) {
+	cfg := &oidcConfig{
+		Issuer:       strings.TrimSuffix(os.Getenv("OIDC_ISSUER"), "/"),
+		ClientID:     os.Getenv("OIDC_CLIENT_ID"),
+		ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+		RedirectURL:  os.Getenv("OIDC_REDIRECT_URL"),
+	}
+	if cfg.Issuer == "" || cfg.ClientID == "" {
+		return nil, false
+	}
+	if cfg.RedirectURL == "" {
+		scheme := "http"
+		if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+			scheme = "https"
+		}
+		cfg.RedirectURL = fmt.Sprintf("%s://%s/auth/oidc/callback", scheme, r.Host)
+	}
+	return cfg, true
+}
+
+// oidcProvider is the part of the issuer's discovery document we use.
+type oidcProvider struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+}
+
+var (
+	oidcHTTPClient = &http.Client{Timeout: oidcHTTPTimeout}
+	oidcMu         sync.Mutex
+	oidcCached     *oidcProvider
+	oidcCachedFor  string
+	oidcCachedAt   time.Time
+)
+
+// discoverOIDC reads and caches the issuer's discovery document.
+func discoverOIDC(issuer string) (*oidcProvider, error) {
+	oidcMu.Lock()
+	defer oidcMu.Unlock()
+	if oidcCached != nil && oidcCachedFor == issuer && time.Since(oidcCachedAt) < oidcDiscoveryTTL {
+		return oidcCached, nil
+	}
+	resp, err := oidcHTTPClient.Get(issuer + "/.well-known/openid-configuration")
+	if err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oidc discovery: %s", resp.Status)
+	}
+	var p oidcProvider
+	if err := json.NewDecoder(resp.Body).Decode(&p); err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+	if strings.TrimSuffix(p.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("oidc discovery: issuer %q does not match %q", p.Issuer, issuer)
+	}
+	if p.AuthorizationEndpoint == "" || p.TokenEndpoint == "" || p.UserinfoEndpoint == "" {
+		return nil, fmt.Errorf("oidc discovery: missing endpoints")
+	}
+	oidcCached, oidcCachedFor, oidcCachedAt = &p, issuer, time.Now()
+	return &p, nil
+}
+
+// oidcPending is the state kept between the redirect to the provider and
+// the callback.
+type oidcPending struct {
+	Verifier string `json:"verifier"`
+	Next     string `json:"next"`
+}
+
+// safeNext keeps post-login redirects on this site.
+func safeNext(next string) string {
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		return "/"
+	}
+	return next
+}
+
+func pkceChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// handleOIDCLogin redirects to the provider's authorization endpoint using
+// the authorization code flow with PKCE. Optional query param: next.
+func handleOIDCLogin(w http.ResponseWriter, r *http.Request) {
+	cfg, ok := oidcSettings(r)
+	if !ok {
+		http.Error(w, "OIDC login is not configured", http.StatusNotFound)
+		return
+	}
+	if redisClient == nil {
+		http.Error(w, "accounts unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	provider, err := discoverOIDC(cfg.Issuer)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	state, err := randomString(shareIDAlphabet, 32)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	verifier, err := randomString(shareIDAlphabet, oidcVerifierLength)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	pending, _ := json.Marshal(oidcPending{Verifier: verifier, Next: safeNext(r.URL.Query().Get("next"))})
+	if err := setCachedRaw("oidc-state:"+state, pending, oidcStateTTL); err != nil {
+		writeAccountError(w, err)
+		return
+	}
+	// Ties the state to this browser, so a callback URL started elsewhere
+	// cannot sign it in to someone else's account.
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookieName,
+		Value:    hashToken(state),
+		Path:     oidcCookiePath,
+		MaxAge:   int(oidcStateTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https",
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	q := url.Values{}
+	q.Set("response_type", "code")
+	q.Set("client_id", cfg.ClientID)
+	q.Set("redirect_uri", cfg.RedirectURL)
+	q.Set("scope", defaultOIDCScopes)
+	q.Set("state", state)
+	q.Set("code_challenge", pkceChallenge(verifier))
+	q.Set("code_challenge_method", "S256")
+	sep := "?"
+	if strings.Contains(provider.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	http.Redirect(w, r, provider.AuthorizationEndpoint+sep+q.Encode(), http.StatusFound)
+}
+
+// oidcClaims are the userinfo fields used to build an account.
+type oidcClaims struct {
+	Subject           string `json:"sub"`
+	Email             string `json:"email"`
+	Name              string `json:"name"`
+	PreferredUsername string `json:"preferred_username"`
+}
+
+// exchangeOIDCCode trades the authorization code for an access token and
+// reads the user's claims from the userinfo endpoint. Claims come straight
+// from the provider over TLS, so the ID token is not needed.
+func exchangeOIDCCode(cfg *oidcConfig, provider *oidcProvider, code, verifier string) (*oidcClaims, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", cfg.RedirectURL)
+	form.Set("client_id", cfg.ClientID)
+	form.Set("code_verifier", verifier)
+	if cfg.ClientSecret != "" {
+		form.Set("client_secret", cfg.ClientSecret)
+	}
+	resp, err := oidcHTTPClient.PostForm(provider.TokenEndpoint, form)
+	if err != nil {
+		return nil, fmt.Errorf("oidc token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oidc token: %s", resp.Status)
+	}
+	var tok struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tok); err != nil || tok.AccessToken == "" {
+		return nil, fmt.Errorf("oidc token: no access token")
+	}
+
+	req, err := http.NewRequest(http.MethodGet, provider.UserinfoEndpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+tok.AccessToken)
+	uresp, err := oidcHTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("oidc userinfo: %w", err)
+	}
+	defer uresp.Body.Close()
+	if uresp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oidc userinfo: %s", uresp.Status)
+	}
+	var claims oidcClaims
+	if err := json.NewDecoder(uresp.Body).Decode(&claims); err != nil || claims.Subject == "" {
+		return nil, fmt.Errorf("oidc userinfo: no subject")
+	}
+	return &claims, nil
+}
+
+// oidcAccount finds the account linked to the provider subject, creating
+// one on first sign-in.
+func oidcAccount(issuer string, claims *oidcClaims) (*userAccount, error) {
+	accountMu.Lock()
+	defer accountMu.Unlock()
+	key := accountOIDCKey(issuer, claims.Subject)
+	a, err := loadAccountByIndex(key)
+	if err == nil {
+		return a, nil
+	}
+	if !errors.Is(err, errAccountNotFound) {
+		return nil, err
+	}
+	id, err := randomString(shareIDAlphabet, accountIDLength)
+	if err != nil {
+		return nil, err
+	}
+	name := claims.Name
+	for _, n := range []string{claims.PreferredUsername, claims.Email, "NHL fan"} {
+		if name == "" {
+			name = n
+		}
+	}
+	if utf8.RuneCountInString(name) > maxDisplayNameLen {
+		name = string([]rune(name)[:maxDisplayNameLen])
+	}
+	a = &userAccount{
+		ID:          id,
+		DisplayName: name,
+		Email:       claims.Email,
+		OIDCIssuer:  issuer,
+		OIDCSubject: claims.Subject,
+		Favourites:  Favourites{Teams: []string{}, Players: []int{}},
+		CreatedAt:   time.Now().UTC(),
+	}
+	if err := saveAccount(a); err != nil {
+		return nil, err
+	}
+	if err := setCachedRaw(key, []byte(id), 0); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// oidcFailed sends the browser back to the login page with a message.
+func oidcFailed(w http.ResponseWriter, r *http.Request, msg string) {
+	http.Redirect(w, r, "/login?error="+url.QueryEscape(msg), http.StatusFound)
+}
+
+// handleOIDCCallback completes an OIDC sign-in and redirects to the page
+// the user started from.
+func handleOIDCCallback(w http.ResponseWriter, r *http.Request) {
+	cfg, ok := oidcSettings(r)
+	if !ok {
+		http.Error(w, "OIDC login is not configured", http.StatusNotFound)
+		return
+	}
+	q := r.URL.Query()
+	if e := q.Get("error"); e != "" {
+		oidcFailed(w, r, "sign-in was cancelled or refused ("+e+")")
+		return
+	}
+	state := q.Get("state")
+	c, err := r.Cookie(oidcStateCookieName)
+	http.SetCookie(w, &http.Cookie{Name: oidcStateCookieName, Value: "", Path: oidcCookiePath, MaxAge: -1, HttpOnly: true, SameSite: http.SameSiteLaxMode})
+	if state == "" || redisClient == nil || err != nil || subtle.ConstantTimeCompare([]byte(c.Value), []byte(hashToken(state))) != 1 {
+		oidcFailed(w, r, "invalid sign-in state, please try again")
+		return
+	}
+	// GetDel makes each state single-use
+	data, err := redisClient.GetDel(redisCtx, "oidc-state:"+state).Bytes()
+	if err != nil {
+		oidcFailed(w, r, "sign-in expired, please try again")
+		return
+	}
+	var pending oidcPending
+	if err := json.Unmarshal(data, &pending); err != nil {
+		oidcFailed(w, r, "invalid sign-in state, please try again")
+		return
+	}
+
+	provider, err := discoverOIDC(cfg.Issuer)
+	if err != nil {
+		log.Printf("oidc callback: %v", err)
+		oidcFailed(w, r, "sign-in provider unavailable")
+		return
+	}
+	claims, err := exchangeOIDCCode(cfg, provider, q.Get("code"), pending.Verifier)
+	if err != nil {
+		log.Printf("oidc callback: %v", err)
+		oidcFailed(w, r, "sign-in failed")
+		return
+	}
+	a, err := oidcAccount(cfg.Issuer, claims)
+	if err != nil {
+		writeAccountError(w, err)
+		return
+	}
+	if err := startSession(w, r, a); err != nil {
+		writeAccountError(w, err)
+		return
+	}
+	http.Redirect(w, r, pending.Next, http.StatusFound)
+}
@@ -0,0 +1,633 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Play-by-play event keys that count as shot attempts.
+const (
+	eventGoal        = "goal"
+	eventShotOnGoal  = "shot-on-goal"
+	eventMissedShot  = "missed-shot"
+	eventBlockedShot = "blocked-shot"
+)
+
+// ShotAttempt is a single shot attempt (goal, on goal, missed or blocked)
+// parsed from play-by-play, seen from the shooting team's side.
+type ShotAttempt struct {
+	EventID       int     `json:"eventId"`
+	Period        int     `json:"period"`
+	PeriodType    string  `json:"periodType"`
+	TimeInPeriod  string  `json:"timeInPeriod"`
+	GameSeconds   int     `json:"gameSeconds"`
+	Type          string  `json:"type"`
+	TeamID        int     `json:"teamId"`
+	TeamAbbrev    string  `json:"teamAbbrev"`
+	IsHome        bool    `json:"isHome"`
+	ShooterID     int     `json:"shooterId"`
+	BlockerID     int     `json:"blockerId,omitempty"`
+	GoalieID      int     `json:"goalieId,omitempty"`
+	ShotType      string  `json:"shotType,omitempty"`
+	X             float64 `json:"x"`
+	Y             float64 `json:"y"`
+	Strength      string  `json:"strength"`      // skaters for v against, e.g. "5v4"
+	StrengthState string  `json:"strengthState"` // "EV", "PP" or "SH"
+	EmptyNet      bool    `json:"emptyNet"`
+	ScoreDiff     int     `json:"scoreDiff"` // shooting team's lead before the attempt
+}
+
+// IsFenwick reports whether the attempt was unblocked.
+func (a ShotAttempt) IsFenwick() bool {
+	return a.Type != eventBlockedShot
+}
+
+// IsOnGoal reports whether the attempt was a shot on goal (including goals).
+func (a ShotAttempt) IsOnGoal() bool {
+	return a.Type == eventShotOnGoal || a.Type == eventGoal
+}
+
+// ScoreState buckets ScoreDiff into "leading", "tied" or "trailing".
+func (a ShotAttempt) ScoreState() string {
+	switch {
+	case a.ScoreDiff > 0:
+		return "leading"
+	case a.ScoreDiff < 0:
+		return "trailing"
+	default:
+		return "tied"
+	}
+}
+
+// parseSituation decodes a play-by-play situationCode (away goalie, away
+// skaters, home skaters, home goalie) from one team's side. It returns the
+// strength label, the strength state and whether the opposing net is empty.
+func parseSituation(code string, isHome bool) (string, string, bool) {
+	if len(code) != 4 {
+		return "", "", false
+	}
+	digits := make([]int, 4)
+	for i, c := range code {
+		if c < '0' || c > '9' {
+			return "", "", false
+		}
+		digits[i] = int(c - '0')
+	}
+	ownGoalie, own, opp, oppGoalie := digits[0], digits[1], digits[2], digits[3]
+	if isHome {
+		ownGoalie, own, opp, oppGoalie = digits[3], digits[2], digits[1], digits[0]
+	}
+
+	// An extra attacker for a pulled goalie does not change the manpower state.
+	ownAdj, oppAdj := own, opp
+	if ownGoalie == 0 {
+		ownAdj--
+	}
+	if oppGoalie == 0 {
+		oppAdj--
+	}
+	state := "EV"
+	if ownAdj > oppAdj {
+		state = "PP"
+	} else if ownAdj < oppAdj {
+		state = "SH"
+	}
+	return fmt.Sprintf("%dv%d", own, opp), state, oppGoalie == 0
+}
+
+// clockSeconds converts an "MM:SS" clock into seconds.
+func clockSeconds(clock string) int {
+	parts := strings.SplitN(clock, ":", 2)
+	if len(parts) != 2 {
+		return 0
+	}
+	m, _ := strconv.Atoi(parts[0])
+	s, _ := strconv.Atoi(parts[1])
+	return m*60 + s
+}
+
+// ExtractShotAttempts walks a play-by-play feed in order and returns every
+// shot attempt outside the shootout with strength and score state attached.
+func ExtractShotAttempts(pbp *PlayByPlay) []ShotAttempt {
+	if pbp == nil {
+		return nil
+	}
+	playerTeam := make(map[int]int, len(pbp.RosterSpots))
+	for _, rs := range pbp.RosterSpots {
+		playerTeam[rs.PlayerID] = rs.TeamID
+	}
+	homeID, awayID := pbp.HomeTeam.ID, pbp.AwayTeam.ID
+
+	plays := make([]PlayEvent, len(pbp.Plays))
+	copy(plays, pbp.Plays)
+	sort.SliceStable(plays, func(i, j int) bool { return plays[i].SortOrder < plays[j].SortOrder })
+
+	var attempts []ShotAttempt
+	homeScore, awayScore := 0, 0
+	for _, p := range plays {
+		switch p.TypeDescKey {
+		case eventGoal, eventShotOnGoal, eventMissedShot, eventBlockedShot:
+		default:
+			continue
+		}
+		if strings.EqualFold(p.PeriodDescriptor.PeriodType, "SO") {
+			continue
+		}
+
+		d := p.Details
+		shooter := d.ShootingPlayerID
+		if p.TypeDescKey == eventGoal {
+			shooter = d.ScoringPlayerID
+		}
+
+		// Prefer the shooter's roster team; upstream attributes blocked shots
+		// to the blocking team, so flip the owner when we have to fall back.
+		teamID, ok := playerTeam[shooter]
+		if !ok {
+			teamID = d.EventOwnerTeamID
+			if p.TypeDescKey == eventBlockedShot {
+				if teamID == homeID {
+					teamID = awayID
+				} else {
+					teamID = homeID
+				}
+			}
+		}
+		isHome := teamID == homeID
+		abbrev := pbp.AwayTeam.Abbrev
+		diff := awayScore - homeScore
+		if isHome {
+			abbrev = pbp.HomeTeam.Abbrev
+			diff = homeScore - awayScore
+		}
+
+		strength, state, emptyNet := parseSituation(p.SituationCode, isHome)
+		attempts = append(attempts, ShotAttempt{
+			EventID:       p.EventID,
+			Period:        p.PeriodDescriptor.Number,
+			PeriodType:    p.PeriodDescriptor.PeriodType,
+			TimeInPeriod:  p.TimeInPeriod,
+			GameSeconds:   (p.PeriodDescriptor.Number-1)*1200 + clockSeconds(p.TimeInPeriod),
+			Type:          p.TypeDescKey,
+			TeamID:        teamID,
+			TeamAbbrev:    abbrev,
+			IsHome:        isHome,
+			ShooterID:     shooter,
+			BlockerID:     d.BlockingPlayerID,
+			GoalieID:      d.GoalieInNetID,
+			ShotType:      d.ShotType,
+			X:             d.XCoord,
+			Y:             d.YCoord,
+			Strength:      strength,
+			StrengthState: state,
+			EmptyNet:      emptyNet,
+			ScoreDiff:     diff,
+		})
+
+		if p.TypeDescKey == eventGoal {
+			if d.HomeScore != 0 || d.AwayScore != 0 {
+				homeScore, awayScore = d.HomeScore, d.AwayScore
+			} else if isHome {
+				homeScore++
+			} else {
+				awayScore++
+			}
+		}
+	}
+	return attempts
+}
+
+// PossessionSplit holds shot-attempt counts for and against one team.
+type PossessionSplit struct {
+	CF    int     `json:"cf"`
+	CA    int     `json:"ca"`
+	FF    int     `json:"ff"`
+	FA    int     `json:"fa"`
+	SF    int     `json:"sf"`
+	SA    int     `json:"sa"`
+	GF    int     `json:"gf"`
+	GA    int     `json:"ga"`
+	CFPct float64 `json:"cfPct"`
+	FFPct float64 `json:"ffPct"`
+}
+
+func (p *PossessionSplit) add(a ShotAttempt, isFor bool) {
+	if isFor {
+		p.CF++
+		if a.IsFenwick() {
+			p.FF++
+		}
+		if a.IsOnGoal() {
+			p.SF++
+		}
+		if a.Type == eventGoal {
+			p.GF++
+		}
+		return
+	}
+	p.CA++
+	if a.IsFenwick() {
+		p.FA++
+	}
+	if a.IsOnGoal() {
+		p.SA++
+	}
+	if a.Type == eventGoal {
+		p.GA++
+	}
+}
+
+func (p *PossessionSplit) merge(o PossessionSplit) {
+	p.CF += o.CF
+	p.CA += o.CA
+	p.FF += o.FF
+	p.FA += o.FA
+	p.SF += o.SF
+	p.SA += o.SA
+	p.GF += o.GF
+	p.GA += o.GA
+}
+
+// finalize computes the percentage fields from the raw counts.
+func (p *PossessionSplit) finalize() {
+	p.CFPct = pct(p.CF, p.CF+p.CA)
+	p.FFPct = pct(p.FF, p.FF+p.FA)
+}
+
+// pct returns num/den as a percentage rounded to one decimal, or 0.
+func pct(num, den int) float64 {
+	if den == 0 {
+		return 0
+	}
+	return float64(int(float64(num)/float64(den)*1000+0.5)) / 10
+}
+
+// TeamPossession is one team's Corsi/Fenwick profile. Score-state splits are
+// 5v5 only, which is the usual way to read them.
+type TeamPossession struct {
+	TeamID       int                         `json:"teamId"`
+	Abbrev       string                      `json:"abbrev"`
+	All          PossessionSplit             `json:"all"`
+	FiveOnFive   PossessionSplit             `json:"fiveOnFive"`
+	ByStrength   map[string]*PossessionSplit `json:"byStrength"`
+	ByScoreState map[string]*PossessionSplit `json:"byScoreState"`
+}
+
+func newTeamPossession(teamID int, abbrev string) *TeamPossession {
+	return &TeamPossession{
+		TeamID:       teamID,
+		Abbrev:       abbrev,
+		ByStrength:   map[string]*PossessionSplit{},
+		ByScoreState: map[string]*PossessionSplit{},
+	}
+}
+
+// add records an attempt as for or against this team.
+func (t *TeamPossession) add(a ShotAttempt) {
+	isFor := a.TeamID == t.TeamID
+	t.All.add(a, isFor)
+
+	// Strength and score state are stored from this team's side.
+	strength, scoreState := a.Strength, a.ScoreState()
+	if !isFor {
+		if parts := strings.SplitN(strength, "v", 2); len(parts) == 2 {
+			strength = parts[1] + "v" + parts[0]
+		}
+		switch scoreState {
+		case "leading":
+			scoreState = "trailing"
+		case "trailing":
+			scoreState = "leading"
+		}
+	}
+	if strength != "" {
+		if t.ByStrength[strength] == nil {
+			t.ByStrength[strength] = &PossessionSplit{}
+		}
+		t.ByStrength[strength].add(a, isFor)
+	}
+	if strength == "5v5" {
+		t.FiveOnFive.add(a, isFor)
+		if t.ByScoreState[scoreState] == nil {
+			t.ByScoreState[scoreState] = &PossessionSplit{}
+		}
+		t.ByScoreState[scoreState].add(a, isFor)
+	}
+}
+
+func (t *TeamPossession) merge(o *TeamPossession) {
+	t.All.merge(o.All)
+	t.FiveOnFive.merge(o.FiveOnFive)
+	for k, v := range o.ByStrength {
+		if t.ByStrength[k] == nil {
+			t.ByStrength[k] = &PossessionSplit{}
+		}
+		t.ByStrength[k].merge(*v)
+	}
+	for k, v := range o.ByScoreState {
+		if t.ByScoreState[k] == nil {
+			t.ByScoreState[k] = &PossessionSplit{}
+		}
+		t.ByScoreState[k].merge(*v)
+	}
+}
+
+func (t *TeamPossession) finalize() {
+	t.All.finalize()
+	t.FiveOnFive.finalize()
+	for _, v := range t.ByStrength {
+		v.finalize()
+	}
+	for _, v := range t.ByScoreState {
+		v.finalize()
+	}
+}
+
+// PlayerAttempts holds a player's own shot attempts (iCF/iFF). Play-by-play
+// does not list who was on the ice, so there is no on-ice Corsi for or
+// against here.
+type PlayerAttempts struct {
+	PlayerID        int    `json:"playerId"`
+	Name            string `json:"name"`
+	TeamID          int    `json:"teamId"`
+	TeamAbbrev      string `json:"teamAbbrev"`
+	Position        string `json:"position"`
+	Games           int    `json:"games"`
+	ICF             int    `json:"iCF"`
+	IFF             int    `json:"iFF"`
+	ISF             int    `json:"iSF"`
+	Goals           int    `json:"goals"`
+	ICF5v5          int    `json:"iCF5v5"`
+	IFF5v5          int    `json:"iFF5v5"`
+	AttemptsBlocked int    `json:"attemptsBlocked"`
+	ShotsBlocked    int    `json:"shotsBlocked"`
+}
+
+func (p *PlayerAttempts) merge(o PlayerAttempts) {
+	p.Games += o.Games
+	p.ICF += o.ICF
+	p.IFF += o.IFF
+	p.ISF += o.ISF
+	p.Goals += o.Goals
+	p.ICF5v5 += o.ICF5v5
+	p.IFF5v5 += o.IFF5v5
+	p.AttemptsBlocked += o.AttemptsBlocked
+	p.ShotsBlocked += o.ShotsBlocked
+}
+
+// GameAdvanced is the /api/game/{id}/advanced response.
+type GameAdvanced struct {
+	GameID         int64            `json:"gameId"`
+	GameDate       string           `json:"gameDate"`
+	GameState      string           `json:"gameState"`
+	Home           *TeamPossession  `json:"home"`
+	Away           *TeamPossession  `json:"away"`
+	PlayerAttempts []PlayerAttempts `json:"playerAttempts"`
+	Attempts       []ShotAttempt    `json:"attempts"`
+}
+
+// ComputeGameAdvanced builds team and player possession metrics for one game.
+func ComputeGameAdvanced(pbp *PlayByPlay) *GameAdvanced {
+	attempts := ExtractShotAttempts(pbp)
+	home := newTeamPossession(pbp.HomeTeam.ID, pbp.HomeTeam.Abbrev)
+	away := newTeamPossession(pbp.AwayTeam.ID, pbp.AwayTeam.Abbrev)
+
+	players := make(map[int]*PlayerAttempts, len(pbp.RosterSpots))
+	for _, rs := range pbp.RosterSpots {
+		abbrev := pbp.AwayTeam.Abbrev
+		if rs.TeamID == pbp.HomeTeam.ID {
+			abbrev = pbp.HomeTeam.Abbrev
+		}
+		players[rs.PlayerID] = &PlayerAttempts{
+			PlayerID:   rs.PlayerID,
+			Name:       strings.TrimSpace(rs.FirstName.Default + " " + rs.LastName.Default),
+			TeamID:     rs.TeamID,
+			TeamAbbrev: abbrev,
+			Position:   rs.PositionCode,
+			Games:      1,
+		}
+	}
+
+	for _, a := range attempts {
+		home.add(a)
+		away.add(a)
+		if p, ok := players[a.ShooterID]; ok {
+			p.ICF++
+			if a.IsFenwick() {
+				p.IFF++
+			} else {
+				p.AttemptsBlocked++
+			}
+			if a.IsOnGoal() {
+				p.ISF++
+			}
+			if a.Type == eventGoal {
+				p.Goals++
+			}
+			if a.Strength == "5v5" {
+				p.ICF5v5++
+				if a.IsFenwick() {
+					p.IFF5v5++
+				}
+			}
+		}
+		if p, ok := players[a.BlockerID]; ok && a.Type == eventBlockedShot {
+			p.ShotsBlocked++
+		}
+	}
+	home.finalize()
+	away.finalize()
+
+	list := make([]PlayerAttempts, 0, len(players))
+	for _, p := range players {
+		if p.Position == "G" {
+			continue
+		}
+		list = append(list, *p)
+	}
+	sortPlayerAttempts(list)
+
+	return &GameAdvanced{
+		GameID:         pbp.ID,
+		GameDate:       pbp.GameDate,
+		GameState:      pbp.GameState,
+		Home:           home,
+		Away:           away,
+		PlayerAttempts: list,
+		Attempts:       attempts,
+	}
+}
+
+func sortPlayerAttempts(list []PlayerAttempts) {
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].ICF != list[j].ICF {
+			return list[i].ICF > list[j].ICF
+		}
+		return list[i].PlayerID < list[j].PlayerID
+	})
+}
+
+// GetGameAdvanced fetches play-by-play for a game and computes possession metrics.
+func GetGameAdvanced(gameID string) (*GameAdvanced, error) {
+	pbp, err := GetPlayByPlay(gameID)
+	if err != nil {
+		return nil, err
+	}
+	return ComputeGameAdvanced(pbp), nil
+}
+
+// TeamGamePossession is one game's line in a team's season possession log.
+type TeamGamePossession struct {
+	GameID     int64           `json:"gameId"`
+	GameDate   string          `json:"gameDate"`
+	Opponent   string          `json:"opponent"`
+	IsHome     bool            `json:"isHome"`
+	All        PossessionSplit `json:"all"`
+	FiveOnFive PossessionSplit `json:"fiveOnFive"`
+}
+
+// TeamAdvanced is the /api/team/{id}/advanced response.
+type TeamAdvanced struct {
+	Team           string               `json:"team"`
+	Season         string               `json:"season"`
+	GameType       int                  `json:"gameType"`
+	GamesAnalyzed  int                  `json:"gamesAnalyzed"`
+	Totals         *TeamPossession      `json:"totals"`
+	PlayerAttempts []PlayerAttempts     `json:"playerAttempts"`
+	Games          []TeamGamePossession `json:"games"`
+}
+
+// GetTeamAdvanced aggregates possession metrics across every finished game
+// of the given type in a team's season schedule.
+func GetTeamAdvanced(teamAbbrev, season string, gameType int) (*TeamAdvanced, error) {
+	sched, err := GetClubSchedule(teamAbbrev, season)
+	if err != nil {
+		return nil, err
+	}
+	if season == "" && sched.CurrentSeason != 0 {
+		season = strconv.Itoa(sched.CurrentSeason)
+	}
+
+	teamID := abbrevToTeamID[teamAbbrev]
+	out := &TeamAdvanced{
+		Team:           teamAbbrev,
+		Season:         season,
+		GameType:       gameType,
+		Totals:         newTeamPossession(teamID, teamAbbrev),
+		PlayerAttempts: []PlayerAttempts{},
+		Games:          []TeamGamePossession{},
+	}
+	players := map[int]*PlayerAttempts{}
+
+	for _, g := range sched.Games {
+		if g.GameType != gameType || !isGameFinal(g.GameState) {
+			continue
+		}
+		ga, err := GetGameAdvanced(strconv.FormatInt(g.ID, 10))
+		if err != nil {
+			log.Printf("GetTeamAdvanced: skipping game %d for %s: %v", g.ID, teamAbbrev, err)
+			continue
+		}
+		side, opp := ga.Away, ga.Home
+		isHome := ga.Home.Abbrev == teamAbbrev
+		if isHome {
+			side, opp = ga.Home, ga.Away
+		}
+		out.Totals.TeamID = side.TeamID
+		out.Totals.merge(side)
+		out.Games = append(out.Games, TeamGamePossession{
+			GameID:     ga.GameID,
+			GameDate:   ga.GameDate,
+			Opponent:   opp.Abbrev,
+			IsHome:     isHome,
+			All:        side.All,
+			FiveOnFive: side.FiveOnFive,
+		})
+		for _, p := range ga.PlayerAttempts {
+			if p.TeamID != side.TeamID {
+				continue
+			}
+			if players[p.PlayerID] == nil {
+				players[p.PlayerID] = &PlayerAttempts{
+					PlayerID:   p.PlayerID,
+					Name:       p.Name,
+					TeamID:     p.TeamID,
+					TeamAbbrev: p.TeamAbbrev,
+					Position:   p.Position,
+				}
+			}
+			players[p.PlayerID].merge(p)
+		}
+		out.GamesAnalyzed++
+	}
+	out.Totals.finalize()
+	for _, p := range players {
+		out.PlayerAttempts = append(out.PlayerAttempts, *p)
+	}
+	sortPlayerAttempts(out.PlayerAttempts)
+	return out, nil
+}
+
+// handleAPIGameAdvanced returns Corsi/Fenwick for both teams and all skaters in a game.
+func handleAPIGameAdvanced(w http.ResponseWriter, r *http.Request) {
+	gameID := mux.Vars(r)["gameId"]
+	if _, err := strconv.ParseInt(gameID, 10, 64); err != nil {
+		http.Error(w, "invalid game id", http.StatusBadRequest)
+		return
+	}
+
+	adv, err := GetGameAdvanced(gameID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(adv); err != nil {
+		log.Printf("Error writing game advanced JSON: %v", err)
+	}
+}
+
+var teamAdvancedBuilds = newBackgroundBuilds()
+
+// handleAPITeamAdvanced returns a team's season possession totals.
+// Optional query params: season (e.g. 20242025) and gameType (2 regular, 3 playoffs).
+func handleAPITeamAdvanced(w http.ResponseWriter, r *http.Request) {
+	abbr, ok := resolveTeamAbbrev(mux.Vars(r)["teamId"])
+	if !ok {
+		http.Error(w, "unknown team", http.StatusNotFound)
+		return
+	}
+	season, gameType, ok := seasonAndGameType(w, r)
+	if !ok {
+		return
+	}
+
+	// A season is up to ~100 play-by-play fetches, so it is built in the
+	// background and the first request answers 503 until it is ready.
+	cacheKey := fmt.Sprintf("advanced:team:%s:%s:%d", abbr, season, gameType)
+	data, err := teamAdvancedBuilds.get(cacheKey, time.Hour, func() ([]byte, error) {
+		adv, err := GetTeamAdvanced(abbr, season, gameType)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(adv)
+	})
+	if err != nil {
+		writeStillBuilding(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(data); err != nil {
+		log.Printf("Error writing team advanced JSON: %v", err)
+	}
+}
@@ -0,0 +1,762 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	chatReplyWait        = 2500 * time.Millisecond
+	chatFollowUpTime     = 30 * time.Second
+	chatMaxBodyBytes     = 64 << 10
+	slackMaxClockSkew    = 5 * time.Minute
+	defaultDiscordAPI    = "https://discord.com/api/v10"
+	discordColor         = 0x0d47a1
+	chatPlayerMatches    = 5
+	webhookFormatJSON    = "json"
+	webhookFormatSlack   = "slack"
+	webhookFormatDiscord = "discord"
+)
+
+var errChatUsage = errors.New("usage")
+
+var chatHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// chatFormat renders markup for one chat platform. text escapes plain
+// text such as names and titles; bold and link escape their text too.
+type chatFormat struct {
+	text func(string) string
+	bold func(string) string
+	link func(href, text string) string
+	// when renders a timestamp in each reader's own timezone
+	when func(time.Time) string
+}
+
+// slackEscape escapes the characters Slack mrkdwn reserves for links and
+// mentions.
+var slackEscape = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace
+
+var slackFormat = chatFormat{
+	text: slackEscape,
+	bold: func(s string) string { return "*" + slackEscape(s) + "*" },
+	link: func(href, text string) string { return fmt.Sprintf("<%s|%s>", href, slackEscape(text)) },
+	when: func(t time.Time) string {
+		return fmt.Sprintf("<!date^%d^{date_short_pretty} at {time}|%s>", t.Unix(), t.UTC().Format("Jan 2 15:04 UTC"))
+	},
+}
+
+var discordFormat = chatFormat{
+	text: func(s string) string { return s },
+	bold: func(s string) string { return "**" + s + "**" },
+	link: func(href, text string) string { return fmt.Sprintf("[%s](%s)", text, href) },
+	when: func(t time.Time) string { return fmt.Sprintf("<t:%d:f>", t.Unix()) },
+}
+
+// chatReply is a command answer before platform-specific wrapping.
+type chatReply struct {
+	Title string
+	URL   string
+	Text  string
+}
+
+const chatHelp = "Commands: `/score TEAM`, `/standings [league|east|west|atlantic|metro|central|pacific]`, `/player NAME`, `/next TEAM`"
+
+// runChatCommand answers one slash command. base is this site's URL for
+// links; it may be empty.
+func runChatCommand(cmd, arg string, f chatFormat, base string) (*chatReply, error) {
+	arg = strings.TrimSpace(arg)
+	switch strings.ToLower(strings.TrimPrefix(cmd, "/")) {
+	case "score":
+		return chatScore(arg, f, base)
+	case "standings":
+		return chatStandings(arg, f, base)
+	case "player":
+		return chatPlayer(arg, f, base)
+	case "next":
+		return chatNext(arg, f, base)
+	case "nhl", "hockey":
+		// One umbrella command: "/nhl score TOR"
+		sub, rest, _ := strings.Cut(arg, " ")
+		if sub == "" || sub == "nhl" || sub == "hockey" || sub == "help" {
+			return &chatReply{Text: chatHelp}, nil
+		}
+		return runChatCommand(sub, rest, f, base)
+	case "help":
+		return &chatReply{Text: chatHelp}, nil
+	}
+	return nil, fmt.Errorf("%w: unknown command %q. %s", errChatUsage, cmd, chatHelp)
+}
+
+func chatTeam(arg string) (string, error) {
+	if arg == "" {
+		return "", fmt.Errorf("%w: name a team, e.g. TOR", errChatUsage)
+	}
+	if abbr, ok := resolveTeamAbbrev(arg); ok {
+		return abbr, nil
+	}
+	if teams := searchTeams(foldText(arg)); len(teams) == 1 {
+		return teams[0], nil
+	}
+	return "", fmt.Errorf("%w: unknown team %q", errChatUsage, arg)
+}
+
+func scoreLine(g ClubScheduleGame, f chatFormat) string {
+	away := fmt.Sprintf("%s %d", g.AwayTeam.Abbrev, g.AwayTeam.Score)
+	home := fmt.Sprintf("%d %s", g.HomeTeam.Score, g.HomeTeam.Abbrev)
+	if isGameFinal(g.GameState) {
+		if g.AwayTeam.Score > g.HomeTeam.Score {
+			away = f.bold(away)
+		} else {
+			home = f.bold(home)
+		}
+	}
+	return away + " – " + home
+}
+
+// chatScore shows a team's live game, else today's, else its last result.
+func chatScore(arg string, f chatFormat, base string) (*chatReply, error) {
+	abbr, err := chatTeam(arg)
+	if err != nil {
+		return nil, err
+	}
+	sched, err := GetClubSchedule(abbr, "")
+	if err != nil {
+		return nil, err
+	}
+	today := time.Now().Format("2006-01-02")
+	var pick *ClubScheduleGame
+	for i := range sched.Games {
+		g := &sched.Games[i]
+		switch {
+		case isGameLive(g.GameState):
+			pick = g
+		case g.GameDate == today && (pick == nil || !isGameLive(pick.GameState)):
+			pick = g
+		case isGameFinal(g.GameState) && g.GameDate < today && (pick == nil || pick.GameDate < today):
+			pick = g
+		}
+	}
+	if pick == nil {
+		return &chatReply{Title: teamFullName(abbr), Text: "No games played yet this season."}, nil
+	}
+
+	reply := &chatReply{Title: teamFullName(abbr)}
+	if base != "" {
+		reply.URL = fmt.Sprintf("%s/game/%d", base, pick.ID)
+	}
+	switch {
+	case isGameLive(pick.GameState):
+		status := "Live"
+		if landing, err := GetGameLanding(strconv.FormatInt(pick.ID, 10)); err == nil {
+			pick.AwayTeam.Score, pick.HomeTeam.Score = int(landing.AwayTeam.Score), int(landing.HomeTeam.Score)
+			if c := ClockText(landing); c != "" {
+				status = c
+			}
+		}
+		reply.Text = fmt.Sprintf("🔴 %s · %s", scoreLine(*pick, f), status)
+	case isGameFinal(pick.GameState):
+		final := "Final"
+		if t := pick.GameOutcome.LastPeriodType; t == "OT" || t == "SO" {
+			final += "/" + t
+		}
+		reply.Text = fmt.Sprintf("%s · %s (%s)", scoreLine(*pick, f), final, pick.GameDate)
+	default:
+		start, _ := time.Parse(time.RFC3339, pick.StartTimeUTC)
+		reply.Text = fmt.Sprintf("%s @ %s · %s", pick.AwayTeam.Abbrev, pick.HomeTeam.Abbrev, f.when(start))
+	}
+	return reply, nil
+}
+
+// standingsScopes maps command arguments to a division or conference name.
+var standingsScopes = map[string][2]string{
+	"atlantic": {"division", "Atlantic"}, "atl": {"division", "Atlantic"},
+	"metropolitan": {"division", "Metropolitan"}, "metro": {"division", "Metropolitan"},
+	"central": {"division", "Central"}, "cen": {"division", "Central"},
+	"pacific": {"division", "Pacific"}, "pac": {"division", "Pacific"},
+	"eastern": {"conference", "Eastern"}, "east": {"conference", "Eastern"},
+	"western": {"conference", "Western"}, "west": {"conference", "Western"},
+}
+
+// chatStandings renders a standings table as a code block, which both
+// platforms show in a fixed-width font.
+func chatStandings(arg string, f chatFormat, base string) (*chatReply, error) {
+	key := strings.ToLower(strings.TrimSpace(arg))
+	scope, ok := standingsScopes[key]
+	if !ok && key != "" && key != "league" && key != "nhl" {
+		return nil, fmt.Errorf("%w: unknown division or conference %q", errChatUsage, arg)
+	}
+	resp, err := GetAllTeams()
+	if err != nil {
+		return nil, err
+	}
+	var teams []Team
+	for _, t := range resp.Teams {
+		if scope[0] == "division" && t.Division != scope[1] || scope[0] == "conference" && t.Conference != scope[1] {
+			continue
+		}
+		teams = append(teams, t)
+	}
+	rank := func(t Team) int {
+		switch scope[0] {
+		case "division":
+			return t.DivisionRank
+		case "conference":
+			return t.ConferenceRank
+		}
+		return t.LeagueRank
+	}
+	sort.SliceStable(teams, func(i, j int) bool { return rank(teams[i]) < rank(teams[j]) })
+
+	title := "NHL Standings"
+	if scope[1] != "" {
+		title = fmt.Sprintf("%s %s", scope[1], map[string]string{"division": "Division", "conference": "Conference"}[scope[0]])
+	}
+	var b strings.Builder
+	b.WriteString("```\n #  Team  GP  W-L-OT     PTS  P%\n")
+	for _, t := range teams {
+		name := t.Abbrev
+		if t.ClinchIndicator != "" {
+			name = t.ClinchIndicator + "-" + t.Abbrev
+		}
+		record := fmt.Sprintf("%d-%d-%d", t.Record.Wins, t.Record.Losses, t.Record.OvertimeLosses)
+		fmt.Fprintf(&b, "%2d  %-5s %3d  %-9s %4d  %.3f\n", rank(t), name, t.GamesPlayed, record, t.Record.Points, t.PointPct)
+	}
+	b.WriteString("```")
+	reply := &chatReply{Title: title, Text: b.String()}
+	if base != "" {
+		reply.URL = base + "/standings"
+	}
+	return reply, nil
+}
+
+// chatPlayer finds a player through the search index and shows their
+// current NHL season and career lines.
+func chatPlayer(arg string, f chatFormat, base string) (*chatReply, error) {
+	if arg == "" {
+		return nil, fmt.Errorf("%w: name a player, e.g. matthews", errChatUsage)
+	}
+	var hit *SearchResult
+	for _, r := range globalSearchIndex.search(foldText(arg), chatPlayerMatches) {
+		if r.Type == "player" {
+			hit = &r
+			break
+		}
+	}
+	if hit == nil {
+		return nil, fmt.Errorf("%w: no player matches %q", errChatUsage, arg)
+	}
+	p, err := GetPlayerLanding(hit.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var season PlayerStatLine
+	latest := 0
+	for _, st := range p.SeasonTotals {
+		if st.LeagueAbbrev != "NHL" || st.GameTypeID != 2 {
+			continue
+		}
+		switch {
+		case st.Season > latest:
+			latest, season = st.Season, st.PlayerStatLine
+		case st.Season == latest:
+			season = mergeStatLine(season, st.PlayerStatLine)
+		}
+	}
+	line := func(s PlayerStatLine) string {
+		if p.Position == "G" {
+			return fmt.Sprintf("%d GP · %d-%d-%d · %.2f GAA · %.3f SV%% · %d SO",
+				s.GamesPlayed, s.Wins, s.Losses, s.OTLosses, s.GoalsAgainstAvg, s.SavePctg, s.Shutouts)
+		}
+		return fmt.Sprintf("%d GP · %d G · %d A · %d P · %+d", s.GamesPlayed, s.Goals, s.Assists, s.Points, s.PlusMinus)
+	}
+
+	name := strings.TrimSpace(p.FirstName.Default + " " + p.LastName.Default)
+	header := []string{}
+	if p.SweaterNumber > 0 {
+		header = append(header, fmt.Sprintf("#%d", p.SweaterNumber))
+	}
+	header = append(header, p.Position)
+	if p.CurrentTeamAbbrev != "" {
+		header = append(header, p.CurrentTeamAbbrev)
+	}
+	lines := []string{strings.Join(header, " · ")}
+	if latest > 0 {
+		lines = append(lines, fmt.Sprintf("%s %d-%02d: %s", f.bold("NHL"), latest/10000, latest%100, line(season)))
+	}
+	lines = append(lines, fmt.Sprintf("%s: %s", f.bold("Career"), line(p.CareerTotals.RegularSeason)))
+	reply := &chatReply{Title: name, Text: strings.Join(lines, "\n")}
+	if base != "" {
+		reply.URL = fmt.Sprintf("%s/player/%d", base, p.PlayerID)
+	}
+	return reply, nil
+}
+
+// chatNext shows a team's next unplayed game.
+func chatNext(arg string, f chatFormat, base string) (*chatReply, error) {
+	abbr, err := chatTeam(arg)
+	if err != nil {
+		return nil, err
+	}
+	sched, err := GetClubSchedule(abbr, "")
+	if err != nil {
+		return nil, err
+	}
+	reply := &chatReply{Title: teamFullName(abbr) + " · next game"}
+	for _, g := range sched.Games {
+		if isGameFinal(g.GameState) || isGameLive(g.GameState) {
+			continue
+		}
+		start, _ := time.Parse(time.RFC3339, g.StartTimeUTC)
+		opp, vs := g.HomeTeam.Abbrev, "@"
+		if g.HomeTeam.Abbrev == abbr {
+			opp, vs = g.AwayTeam.Abbrev, "vs"
+		}
+		var tv []string
+		for _, b := range g.TVBroadcasts {
+			if !containsString(tv, b.Network) {
+				tv = append(tv, b.Network)
+			}
+		}
+		reply.Text = fmt.Sprintf("%s %s %s · %s", abbr, vs, f.bold(teamFullName(opp)), f.when(start))
+		if g.Venue.Default != "" {
+			reply.Text += " · " + f.text(g.Venue.Default)
+		}
+		if len(tv) > 0 {
+			reply.Text += "\n📺 " + f.text(strings.Join(tv, ", "))
+		}
+		if base != "" {
+			reply.URL = fmt.Sprintf("%s/matchup/%s/%s", base, strings.ToLower(abbr), strings.ToLower(opp))
+		}
+		return reply, nil
+	}
+	reply.Text = "No upcoming games on the schedule."
+	return reply, nil
+}
+
+// publicBaseURL is this site's URL for links in chat replies and feeds:
+// PUBLIC_BASE_URL, else the host the request was sent to.
+func publicBaseURL(r *http.Request) string {
+	if v := os.Getenv("PUBLIC_BASE_URL"); v != "" {
+		return strings.TrimSuffix(v, "/")
+	}
+	if r == nil {
+		return ""
+	}
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+// chatAnswer runs a command, turning user mistakes into a usage message
+// and upstream failures into a short apology.
+func chatAnswer(cmd, arg string, f chatFormat, base string) *chatReply {
+	reply, err := runChatCommand(cmd, arg, f, base)
+	if errors.Is(err, errChatUsage) {
+		return &chatReply{Text: f.text(strings.TrimPrefix(err.Error(), errChatUsage.Error()+": "))}
+	}
+	if err != nil {
+		log.Printf("chat: %s %q: %v", cmd, arg, err)
+		return &chatReply{Text: "Sorry, the NHL data is unavailable right now. Try again shortly."}
+	}
+	return reply
+}
+
+// answerWithin runs the command and returns its reply if it is ready within
+// chatReplyWait, or nil plus a channel that will deliver it later.
+func answerWithin(cmd, arg string, f chatFormat, base string) (*chatReply, <-chan *chatReply) {
+	ch := make(chan *chatReply, 1)
+	go func() { ch <- chatAnswer(cmd, arg, f, base) }()
+	select {
+	case reply := <-ch:
+		return reply, nil
+	case <-time.After(chatReplyWait):
+		return nil, ch
+	}
+}
+
+func postChatJSON(method, target string, body interface{}, header http.Header) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(method, target, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := chatHTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s %s: %s", method, target, resp.Status)
+	}
+	return nil
+}
+
+func writeChatJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Error writing chat JSON: %v", err)
+	}
+}
+
+// slackMessage wraps a reply as a Slack message.
+func slackMessage(reply *chatReply) map[string]interface{} {
+	text := reply.Text
+	if reply.Title != "" {
+		title := slackFormat.bold(reply.Title)
+		if reply.URL != "" {
+			title = slackFormat.link(reply.URL, reply.Title)
+		}
+		text = title + "\n" + text
+	}
+	return map[string]interface{}{
+		"response_type": "in_channel",
+		"text":          text,
+		"blocks": []map[string]interface{}{{
+			"type": "section",
+			"text": map[string]string{"type": "mrkdwn", "text": text},
+		}},
+	}
+}
+
+// verifySlackRequest checks X-Slack-Signature, the HMAC-SHA256 of
+// "v0:{timestamp}:{body}" with the app's signing secret.
+func verifySlackRequest(r *http.Request, body []byte, secret string) bool {
+	ts := r.Header.Get("X-Slack-Request-Timestamp")
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return false
+	}
+	if d := time.Since(time.Unix(sec, 0)); d > slackMaxClockSkew || d < -slackMaxClockSkew {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "v0:%s:", ts)
+	mac.Write(body)
+	want := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(want), []byte(r.Header.Get("X-Slack-Signature")))
+}
+
+// handleSlackCommand answers Slack slash commands (/score, /standings,
+// /player, /next, or /nhl with a subcommand). Slow answers are acknowledged
+// at once and posted to the command's response_url.
+func handleSlackCommand(w http.ResponseWriter, r *http.Request) {
+	secret := os.Getenv("SLACK_SIGNING_SECRET")
+	if secret == "" {
+		http.Error(w, "Slack integration is not configured", http.StatusNotFound)
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, chatMaxBodyBytes))
+	if err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	if !verifySlackRequest(r, body, secret) {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		http.Error(w, "invalid form", http.StatusBadRequest)
+		return
+	}
+
+	reply, later := answerWithin(form.Get("command"), form.Get("text"), slackFormat, publicBaseURL(r))
+	if reply != nil {
+		writeChatJSON(w, slackMessage(reply))
+		return
+	}
+	responseURL := form.Get("response_url")
+	go func() {
+		select {
+		case reply := <-later:
+			if responseURL == "" {
+				return
+			}
+			if err := postChatJSON(http.MethodPost, responseURL, slackMessage(reply), nil); err != nil {
+				log.Printf("chat: slack follow-up: %v", err)
+			}
+		case <-time.After(chatFollowUpTime):
+			log.Printf("chat: slack command %s timed out", form.Get("command"))
+		}
+	}()
+	writeChatJSON(w, map[string]string{"response_type": "in_channel"})
+}
+
+// discordMessage wraps a reply as Discord message data with one embed.
+func discordMessage(reply *chatReply) map[string]interface{} {
+	if reply.Title == "" {
+		return map[string]interface{}{"content": reply.Text}
+	}
+	embed := map[string]interface{}{
+		"title":       reply.Title,
+		"description": reply.Text,
+		"color":       discordColor,
+	}
+	if reply.URL != "" {
+		embed["url"] = reply.URL
+	}
+	return map[string]interface{}{"embeds": []interface{}{embed}}
+}
+
+// discordAPIBase is the Discord REST API root; DISCORD_API_BASE points it at
+// a local stand-in for testing.
+func discordAPIBase() string {
+	if v := os.Getenv("DISCORD_API_BASE"); v != "" {
+		return strings.TrimSuffix(v, "/")
+	}
+	return defaultDiscordAPI
+}
+
+// verifyDiscordRequest checks the Ed25519 signature over timestamp + body
+// against the application's public key (hex).
+func verifyDiscordRequest(r *http.Request, body []byte, publicKeyHex string) bool {
+	key, err := hex.DecodeString(publicKeyHex)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return false
+	}
+	sig, err := hex.DecodeString(r.Header.Get("X-Signature-Ed25519"))
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return false
+	}
+	msg := append([]byte(r.Header.Get("X-Signature-Timestamp")), body...)
+	return ed25519.Verify(ed25519.PublicKey(key), msg, sig)
+}
+
+// discordInteraction is the part of an interaction payload we read.
+type discordInteraction struct {
+	Type          int    `json:"type"`
+	ApplicationID string `json:"application_id"`
+	Token         string `json:"token"`
+	Data          struct {
+		Name    string `json:"name"`
+		Options []struct {
+			Name    string          `json:"name"`
+			Type    int             `json:"type"`
+			Value   json.RawMessage `json:"value"`
+			Options []struct {
+				Name  string          `json:"name"`
+				Value json.RawMessage `json:"value"`
+			} `json:"options"`
+		} `json:"options"`
+	} `json:"data"`
+}
+
+// Discord interaction and response types.
+const (
+	discordPing                = 1
+	discordApplicationCommand  = 2
+	discordPong                = 1
+	discordChannelMessage      = 4
+	discordDeferredChannelMsg  = 5
+	discordSubcommandOptionTyp = 1
+)
+
+func optionString(raw json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	return strings.Trim(string(raw), `"`)
+}
+
+// commandAndArg flattens /score team:TOR or /nhl score team:TOR into a
+// command name and its argument.
+func (in *discordInteraction) commandAndArg() (string, string) {
+	cmd := in.Data.Name
+	for _, o := range in.Data.Options {
+		if o.Type == discordSubcommandOptionTyp {
+			cmd = o.Name
+			if len(o.Options) > 0 {
+				return cmd, optionString(o.Options[0].Value)
+			}
+			return cmd, ""
+		}
+		return cmd, optionString(o.Value)
+	}
+	return cmd, ""
+}
+
+// handleDiscordInteraction answers Discord application commands. Slow
+// answers are deferred and the original response edited when ready.
+func handleDiscordInteraction(w http.ResponseWriter, r *http.Request) {
+	publicKey := os.Getenv("DISCORD_PUBLIC_KEY")
+	if publicKey == "" {
+		http.Error(w, "Discord integration is not configured", http.StatusNotFound)
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, chatMaxBodyBytes))
+	if err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	if !verifyDiscordRequest(r, body, publicKey) {
+		http.Error(w, "invalid request signature", http.StatusUnauthorized)
+		return
+	}
+	var in discordInteraction
+	if err := json.Unmarshal(body, &in); err != nil {
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+	switch in.Type {
+	case discordPing:
+		writeChatJSON(w, map[string]int{"type": discordPong})
+		return
+	case discordApplicationCommand:
+	default:
+		http.Error(w, "unsupported interaction type", http.StatusBadRequest)
+		return
+	}
+
+	cmd, arg := in.commandAndArg()
+	reply, later := answerWithin(cmd, arg, discordFormat, publicBaseURL(r))
+	if reply != nil {
+		writeChatJSON(w, map[string]interface{}{"type": discordChannelMessage, "data": discordMessage(reply)})
+		return
+	}
+	go func() {
+		select {
+		case reply := <-later:
+			target := fmt.Sprintf("%s/webhooks/%s/%s/messages/@original", discordAPIBase(), in.ApplicationID, in.Token)
+			if err := postChatJSON(http.MethodPatch, target, discordMessage(reply), nil); err != nil {
+				log.Printf("chat: discord follow-up: %v", err)
+			}
+		case <-time.After(chatFollowUpTime):
+			log.Printf("chat: discord command %s timed out", cmd)
+		}
+	}()
+	writeChatJSON(w, map[string]int{"type": discordDeferredChannelMsg})
+}
+
+// discordCommands are the global application commands registered by
+// handleAPIRegisterDiscordCommands.
+func discordCommands() []map[string]interface{} {
+	str := func(name, desc string, required bool) map[string]interface{} {
+		return map[string]interface{}{"type": 3, "name": name, "description": desc, "required": required}
+	}
+	return []map[string]interface{}{
+		{"name": "score", "description": "A team's live, today's or last game", "options": []interface{}{str("team", "Team, e.g. TOR", true)}},
+		{"name": "standings", "description": "League, conference or division standings", "options": []interface{}{str("scope", "league, east, west, atlantic, metro, central or pacific", false)}},
+		{"name": "player", "description": "A player's season and career line", "options": []interface{}{str("name", "Player name, e.g. matthews", true)}},
+		{"name": "next", "description": "A team's next game", "options": []interface{}{str("team", "Team, e.g. MTL", true)}},
+	}
+}
+
+// handleAPIRegisterDiscordCommands registers the slash commands with
+// Discord (DISCORD_APPLICATION_ID and DISCORD_BOT_TOKEN; admin only).
+func handleAPIRegisterDiscordCommands(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	appID, token := os.Getenv("DISCORD_APPLICATION_ID"), os.Getenv("DISCORD_BOT_TOKEN")
+	if appID == "" || token == "" {
+		http.Error(w, "DISCORD_APPLICATION_ID and DISCORD_BOT_TOKEN must be set", http.StatusServiceUnavailable)
+		return
+	}
+	target := fmt.Sprintf("%s/applications/%s/commands", discordAPIBase(), appID)
+	header := http.Header{"Authorization": {"Bot " + token}}
+	if err := postChatJSON(http.MethodPut, target, discordCommands(), header); err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// chatEventText describes a webhook event for a channel post; ok is false
+// for events with nothing to say.
+func chatEventText(ev *WebhookEvent, f chatFormat) (string, bool) {
+	switch d := ev.Data.(type) {
+	case GameStartData:
+		return fmt.Sprintf("🏒 %s @ %s is under way", d.AwayTeam, d.HomeTeam), true
+	case GoalData:
+		text := fmt.Sprintf("🚨 %s goal! %s", d.Team, f.bold(d.Scorer.Name))
+		if len(d.Assists) > 0 {
+			names := make([]string, len(d.Assists))
+			for i, a := range d.Assists {
+				names[i] = f.text(a.Name)
+			}
+			text += " (" + strings.Join(names, ", ") + ")"
+		}
+		if s := strings.ToUpper(d.Strength); s == "PP" || s == "SH" {
+			text += " · " + s
+		}
+		text += fmt.Sprintf("\n%s %d – %d %s · %s %s", d.AwayTeam, d.AwayScore, d.HomeScore, d.HomeTeam, periodName(d.Period, d.PeriodType), d.TimeInPeriod)
+		return text, true
+	case PeriodEndData:
+		return fmt.Sprintf("⏱️ End of %s: %s %d – %d %s", periodName(d.Period, d.PeriodType), d.AwayTeam, d.AwayScore, d.HomeScore, d.HomeTeam), true
+	case FinalData:
+		final := "Final"
+		if d.LastPeriodType == "OT" || d.LastPeriodType == "SO" {
+			final += "/" + d.LastPeriodType
+		}
+		return fmt.Sprintf("🏁 %s: %s %d – %d %s · %s win", final, d.AwayTeam, d.AwayScore, d.HomeScore, d.HomeTeam, f.bold(d.Winner)), true
+	case TransactionData:
+		return fmt.Sprintf("📝 %s: %s", d.Team, f.text(d.Title)), true
+	}
+	if ev.Type == webhookEventPing {
+		return "👋 Webhook connected", true
+	}
+	return "", false
+}
+
+// periodName labels a period for chat: "1st", "OT", "2OT", "SO".
+func periodName(n int, typ string) string {
+	switch {
+	case typ == "SO":
+		return "SO"
+	case typ == "OT" || n > 3:
+		if n <= 4 {
+			return "OT"
+		}
+		return fmt.Sprintf("%dOT", n-3)
+	}
+	return ordinal(n)
+}
+
+// chatEventPayload formats an event as a Slack or Discord incoming-webhook
+// message, for subscriptions that post to a channel.
+func chatEventPayload(format string, ev *WebhookEvent) ([]byte, bool) {
+	switch format {
+	case webhookFormatSlack:
+		text, ok := chatEventText(ev, slackFormat)
+		if !ok {
+			return nil, false
+		}
+		data, err := json.Marshal(map[string]string{"text": text})
+		return data, err == nil
+	case webhookFormatDiscord:
+		text, ok := chatEventText(ev, discordFormat)
+		if !ok {
+			return nil, false
+		}
+		data, err := json.Marshal(map[string]string{"content": text})
+		return data, err == nil
+	}
+	return nil, false
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	minComparePlayers = 2
+	maxComparePlayers = 4
+)
+
+// CompareRates are per-game or per-82 rates for one stat line. Skater and
+// goalie fields share one struct; the unused side stays zero.
+type CompareRates struct {
+	Goals        float64 `json:"goals"`
+	Assists      float64 `json:"assists"`
+	Points       float64 `json:"points"`
+	Shots        float64 `json:"shots"`
+	PIM          float64 `json:"pim"`
+	PlusMinus    float64 `json:"plusMinus"`
+	Wins         float64 `json:"wins"`
+	Shutouts     float64 `json:"shutouts"`
+	GoalsAgainst float64 `json:"goalsAgainst"`
+}
+
+// CompareLine is a season, career or playoff line with its rates.
+type CompareLine struct {
+	Season  string         `json:"season,omitempty"`
+	Age     int            `json:"age,omitempty"`
+	Teams   []string       `json:"teams,omitempty"`
+	Totals  PlayerStatLine `json:"totals"`
+	PerGame CompareRates   `json:"perGame"`
+	Per82   CompareRates   `json:"per82"`
+}
+
+// ComparePlayer is one player's normalized NHL career.
+type ComparePlayer struct {
+	PlayerID       int           `json:"playerId"`
+	Name           string        `json:"name"`
+	Position       string        `json:"position"`
+	Goalie         bool          `json:"goalie"`
+	BirthDate      string        `json:"birthDate"`
+	Headshot       string        `json:"headshot,omitempty"`
+	TeamAbbrev     string        `json:"teamAbbrev,omitempty"`
+	Seasons        []CompareLine `json:"seasons"`
+	PlayoffSeasons []CompareLine `json:"playoffSeasons"`
+	Career         CompareLine   `json:"career"`
+	Playoffs       CompareLine   `json:"playoffs"`
+}
+
+// CompareAgeRow lines up each player's regular season at one age. Lines is
+// indexed like Comparison.Players; nil means no NHL season at that age.
+type CompareAgeRow struct {
+	Age   int            `json:"age"`
+	Lines []*CompareLine `json:"lines"`
+}
+
+// Comparison is the /api/compare response.
+type Comparison struct {
+	Players []ComparePlayer `json:"players"`
+	ByAge   []CompareAgeRow `json:"byAge"`
+}
+
+// rates divides a stat line by games played and scales it by games.
+func rates(s PlayerStatLine, games float64) CompareRates {
+	if s.GamesPlayed == 0 {
+		return CompareRates{}
+	}
+	f := games / float64(s.GamesPlayed)
+	return CompareRates{
+		Goals:        round3(float64(s.Goals) * f),
+		Assists:      round3(float64(s.Assists) * f),
+		Points:       round3(float64(s.Points) * f),
+		Shots:        round3(float64(s.Shots) * f),
+		PIM:          round3(float64(s.PIM) * f),
+		PlusMinus:    round3(float64(s.PlusMinus) * f),
+		Wins:         round3(float64(s.Wins) * f),
+		Shutouts:     round3(float64(s.Shutouts) * f),
+		GoalsAgainst: round3(float64(s.GoalsAgainst) * f),
+	}
+}
+
+func newCompareLine(season string, age int, teams []string, s PlayerStatLine) CompareLine {
+	return CompareLine{
+		Season:  season,
+		Age:     age,
+		Teams:   teams,
+		Totals:  s,
+		PerGame: rates(s, 1),
+		Per82:   rates(s, regularSeasonGames),
+	}
+}
+
+// mergeStatLine adds b into a. GAA is weighted by games played since the
+// landing totals carry no time on ice; save percentage is recomputed.
+func mergeStatLine(a, b PlayerStatLine) PlayerStatLine {
+	gp := a.GamesPlayed + b.GamesPlayed
+	if gp > 0 {
+		a.GoalsAgainstAvg = round3((a.GoalsAgainstAvg*float64(a.GamesPlayed) + b.GoalsAgainstAvg*float64(b.GamesPlayed)) / float64(gp))
+	}
+	a.GamesPlayed = gp
+	a.Goals += b.Goals
+	a.Assists += b.Assists
+	a.Points += b.Points
+	a.PlusMinus += b.PlusMinus
+	a.PIM += b.PIM
+	a.Shots += b.Shots
+	a.PowerPlayGoals += b.PowerPlayGoals
+	a.Wins += b.Wins
+	a.Losses += b.Losses
+	a.OTLosses += b.OTLosses
+	a.Shutouts += b.Shutouts
+	a.ShotsAgainst += b.ShotsAgainst
+	a.GoalsAgainst += b.GoalsAgainst
+	if a.ShotsAgainst > 0 {
+		a.SavePctg = round3(float64(a.ShotsAgainst-a.GoalsAgainst) / float64(a.ShotsAgainst))
+	}
+	return a
+}
+
+// seasonAge is the player's age on February 1 of the season, the usual
+// cut-off for age-season comparisons.
+func seasonAge(birthDate string, season int) int {
+	born, err := time.Parse("2006-01-02", birthDate)
+	if err != nil || season < 10000000 {
+		return 0
+	}
+	ref := time.Date(season%10000, time.February, 1, 0, 0, 0, 0, time.UTC)
+	age := ref.Year() - born.Year()
+	if born.Month() > ref.Month() || (born.Month() == ref.Month() && born.Day() > ref.Day()) {
+		age--
+	}
+	return age
+}
+
+// nhlSeasonLines folds a landing's NHL rows of one game type into a line per
+// season, merging the rows of players traded mid-season.
+func nhlSeasonLines(l *PlayerLanding, gameType int) []CompareLine {
+	bySeason := map[int]*CompareLine{}
+	var order []int
+	for _, st := range l.SeasonTotals {
+		if st.LeagueAbbrev != "NHL" || st.GameTypeID != gameType {
+			continue
+		}
+		team := teamNameToAbbr[st.TeamName.Default]
+		if team == "" {
+			team = st.TeamName.Default
+		}
+		line, ok := bySeason[st.Season]
+		if !ok {
+			line = &CompareLine{
+				Season: strconv.Itoa(st.Season),
+				Age:    seasonAge(l.BirthDate, st.Season),
+			}
+			bySeason[st.Season] = line
+			order = append(order, st.Season)
+		}
+		line.Totals = mergeStatLine(line.Totals, st.PlayerStatLine)
+		line.Teams = append(line.Teams, team)
+	}
+	sort.Ints(order)
+	out := make([]CompareLine, 0, len(order))
+	for _, season := range order {
+		line := bySeason[season]
+		out = append(out, newCompareLine(line.Season, line.Age, line.Teams, line.Totals))
+	}
+	return out
+}
+
+// comparePlayerFrom normalizes a landing payload.
+func comparePlayerFrom(l *PlayerLanding) ComparePlayer {
+	return ComparePlayer{
+		PlayerID:       l.PlayerID,
+		Name:           strings.TrimSpace(l.FirstName.Default + " " + l.LastName.Default),
+		Position:       l.Position,
+		Goalie:         l.Position == "G",
+		BirthDate:      l.BirthDate,
+		Headshot:       l.Headshot,
+		TeamAbbrev:     l.CurrentTeamAbbrev,
+		Seasons:        nhlSeasonLines(l, 2),
+		PlayoffSeasons: nhlSeasonLines(l, 3),
+		Career:         newCompareLine("", 0, nil, l.CareerTotals.RegularSeason),
+		Playoffs:       newCompareLine("", 0, nil, l.CareerTotals.Playoffs),
+	}
+}
+
+// GetComparison loads each player's landing and aligns their regular
+// seasons by age.
+func GetComparison(playerIDs []string) (*Comparison, error) {
+	c := &Comparison{Players: []ComparePlayer{}, ByAge: []CompareAgeRow{}}
+	for _, id := range playerIDs {
+		landing, err := GetPlayerLanding(id)
+		if err != nil {
+			return nil, fmt.Errorf("player %s: %w", id, err)
+		}
+		c.Players = append(c.Players, comparePlayerFrom(landing))
+	}
+
+	rows := map[int]*CompareAgeRow{}
+	for i := range c.Players {
+		for j := range c.Players[i].Seasons {
+			line := &c.Players[i].Seasons[j]
+			if line.Age == 0 {
+				continue
+			}
+			row, ok := rows[line.Age]
+			if !ok {
+				row = &CompareAgeRow{Age: line.Age, Lines: make([]*CompareLine, len(c.Players))}
+				rows[line.Age] = row
+			}
+			row.Lines[i] = line
+		}
+	}
+	for _, row := range rows {
+		c.ByAge = append(c.ByAge, *row)
+	}
+	sort.Slice(c.ByAge, func(i, j int) bool { return c.ByAge[i].Age < c.ByAge[j].Age })
+	return c, nil
+}
+
+func handleAPICompare(w http.ResponseWriter, r *http.Request) {
+	var ids []string
+	seen := map[string]bool{}
+	for _, id := range strings.Split(r.URL.Query().Get("players"), ",") {
+		id = strings.TrimSpace(id)
+		if id == "" || seen[id] {
+			continue
+		}
+		if _, err := strconv.Atoi(id); err != nil {
+			http.Error(w, fmt.Sprintf("invalid player id %q", id), http.StatusBadRequest)
+			return
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	if len(ids) < minComparePlayers || len(ids) > maxComparePlayers {
+		http.Error(w, fmt.Sprintf("players must list %d to %d player ids", minComparePlayers, maxComparePlayers), http.StatusBadRequest)
+		return
+	}
+
+	c, err := GetComparison(ids)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(c); err != nil {
+		log.Printf("Error writing comparison JSON: %v", err)
+	}
+}
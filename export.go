@@ -0,0 +1,379 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// exportFlushRows is how many rows are buffered before flushing to the
+// client, so large tables stream instead of arriving all at once.
+const exportFlushRows = 50
+
+// exportContentTypes are the ?format= values served as tables.
+var exportContentTypes = map[string]string{
+	"csv":    "text/csv; charset=utf-8",
+	"tsv":    "text/tab-separated-values; charset=utf-8",
+	"ndjson": "application/x-ndjson",
+}
+
+// Column layouts of each export. Columns are only ever appended, so
+// spreadsheets and scripts that read them by position keep working; the
+// README documents each one.
+var (
+	teamsExportColumns = []string{
+		"leagueRank", "conferenceRank", "divisionRank", "wildcardRank",
+		"teamId", "abbrev", "name", "conference", "division",
+		"gamesPlayed", "wins", "losses", "overtimeLosses", "points", "pointPct",
+		"regulationWins", "regulationPlusOtWins", "goalsFor", "goalsAgainst", "goalDiff",
+		"lastTen", "streak", "playoffSeed", "clinchIndicator", "magicNumber", "tragicNumber",
+	}
+	rosterExportColumns = []string{
+		"playerId", "number", "name", "position", "fullPosition", "shootsCatches", "birthPlace",
+		"games", "goals", "assists", "points", "plusMinus", "pim", "shots",
+		"gamesStarted", "wins", "losses", "goalsAgainst", "gaa", "savePct",
+	}
+	prospectsExportColumns = []string{
+		"playerId", "number", "firstName", "lastName", "position", "shootsCatches",
+		"birthDate", "birthCity", "birthCountry", "heightInches", "weightPounds",
+	}
+	seasonsExportColumns = []string{
+		"season", "gameType", "league", "team", "teamAbbrev", "sequence",
+		"gamesPlayed", "goals", "assists", "points", "plusMinus", "pim", "shots", "powerPlayGoals",
+		"wins", "losses", "otLosses", "shutouts", "shotsAgainst", "goalsAgainst", "gaa", "savePct",
+	}
+	scheduleExportColumns = []string{
+		"gameId", "season", "gameType", "gameDate", "startTimeUTC", "gameState",
+		"homeAway", "opponent", "awayTeam", "awayScore", "homeTeam", "homeScore",
+		"result", "lastPeriodType", "venue", "tvNetworks",
+	}
+)
+
+// exportFormat returns the requested ?format=, or "" for the usual JSON.
+func exportFormat(r *http.Request) (string, error) {
+	format := strings.ToLower(r.URL.Query().Get("format"))
+	if format == "" || format == "json" {
+		return "", nil
+	}
+	if _, ok := exportContentTypes[format]; !ok {
+		return "", fmt.Errorf("format must be json, csv, tsv or ndjson")
+	}
+	return format, nil
+}
+
+// tableWriter streams rows as CSV, TSV or NDJSON. CSV and TSV start with
+// a header row; NDJSON writes one object per row with keys in column order.
+type tableWriter struct {
+	w       http.ResponseWriter
+	columns []string
+	csv     *csv.Writer
+	rows    int
+}
+
+// newTableWriter sets the response headers and writes the header row.
+// filename gets the format's extension.
+func newTableWriter(w http.ResponseWriter, format, filename string, columns []string) *tableWriter {
+	w.Header().Set("Content-Type", exportContentTypes[format])
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+"."+format))
+	t := &tableWriter{w: w, columns: columns}
+	if format != "ndjson" {
+		t.csv = csv.NewWriter(w)
+		if format == "tsv" {
+			t.csv.Comma = '\t'
+		}
+		if err := t.csv.Write(columns); err != nil {
+			log.Printf("Error writing %s header: %v", format, err)
+		}
+	}
+	return t
+}
+
+func exportCell(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case *int:
+		if v == nil {
+			return ""
+		}
+		return strconv.Itoa(*v)
+	}
+	return fmt.Sprint(v)
+}
+
+// Row writes one row; values line up with the columns. nil is an empty
+// cell in CSV/TSV and null in NDJSON.
+func (t *tableWriter) Row(values ...interface{}) error {
+	if len(values) != len(t.columns) {
+		return fmt.Errorf("export row has %d values for %d columns", len(values), len(t.columns))
+	}
+	if t.csv != nil {
+		cells := make([]string, len(values))
+		for i, v := range values {
+			cells[i] = exportCell(v)
+		}
+		if err := t.csv.Write(cells); err != nil {
+			return err
+		}
+	} else {
+		var b bytes.Buffer
+		b.WriteByte('{')
+		for i, v := range values {
+			if p, ok := v.(*int); ok && p == nil {
+				v = nil
+			}
+			key, _ := json.Marshal(t.columns[i])
+			val, err := json.Marshal(v)
+			if err != nil {
+				return err
+			}
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			b.Write(key)
+			b.WriteByte(':')
+			b.Write(val)
+		}
+		b.WriteString("}\n")
+		if _, err := t.w.Write(b.Bytes()); err != nil {
+			return err
+		}
+	}
+	t.rows++
+	if t.rows%exportFlushRows == 0 {
+		t.flush()
+	}
+	return nil
+}
+
+func (t *tableWriter) flush() {
+	if t.csv != nil {
+		t.csv.Flush()
+	}
+	if f, ok := t.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Close flushes any buffered rows.
+func (t *tableWriter) Close() error {
+	t.flush()
+	if t.csv != nil {
+		return t.csv.Error()
+	}
+	return nil
+}
+
+// writeTable streams rows through a tableWriter, logging write errors;
+// once the header is out there is no way to report them to the client.
+func writeTable(w http.ResponseWriter, format, filename string, columns []string, rows func(t *tableWriter) error) {
+	t := newTableWriter(w, format, filename, columns)
+	if err := rows(t); err != nil {
+		log.Printf("Error writing %s export: %v", filename, err)
+	}
+	if err := t.Close(); err != nil {
+		log.Printf("Error flushing %s export: %v", filename, err)
+	}
+}
+
+// exportTeams writes standings as of date, one team per row in the same
+// order as the JSON.
+func exportTeams(w http.ResponseWriter, format, date string, teams *TeamsResponse) {
+	filename := fmt.Sprintf("standings-%d-%s", seasonForDate(date), date)
+	writeTable(w, format, filename, teamsExportColumns, func(t *tableWriter) error {
+		for _, tm := range teams.Teams {
+			if err := t.Row(
+				tm.LeagueRank, tm.ConferenceRank, tm.DivisionRank, tm.WildcardRank,
+				tm.ID, tm.Abbrev, tm.Name, tm.Conference, tm.Division,
+				tm.GamesPlayed, tm.Record.Wins, tm.Record.Losses, tm.Record.OvertimeLosses, tm.Record.Points, tm.PointPct,
+				tm.RegulationWins, tm.RegulationPlusOtWins, tm.GoalsFor, tm.GoalsAgainst, tm.GoalDiff,
+				tm.LastTen, tm.Streak, tm.PlayoffSeed, tm.ClinchIndicator, tm.MagicNumber, tm.TragicNumber,
+			); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// exportRoster writes a team's current roster with season stats.
+func exportRoster(w http.ResponseWriter, format, teamID string, roster *RosterResponse) {
+	abbr, ok := resolveTeamAbbrev(teamID)
+	if !ok {
+		abbr = strings.ToUpper(teamID)
+	}
+	filename := fmt.Sprintf("roster-%s-%s", abbr, currentSeasonID())
+	writeTable(w, format, filename, rosterExportColumns, func(t *tableWriter) error {
+		for _, p := range roster.Players {
+			s := p.Stats
+			if s == nil {
+				s = &PlayerStats{}
+			}
+			if err := t.Row(
+				p.ID, p.Number, p.Name, p.Position, p.FullPosition, p.ShootsCatches, p.BirthPlace,
+				s.Games, s.Goals, s.Assists, s.Points, s.PlusMinus, s.PIM, s.Shots,
+				s.GamesStarted, s.Wins, s.Losses, s.GoalsAgainst, s.GAA, s.SavePercentage,
+			); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// prospectExportPlayer adds the bio fields the prospects endpoint carries.
+type prospectExportPlayer struct {
+	RosterPlayer
+	BirthDate      string            `json:"birthDate"`
+	BirthCity      map[string]string `json:"birthCity"`
+	BirthCountry   string            `json:"birthCountry"`
+	HeightInInches int               `json:"heightInInches"`
+	WeightInPounds int               `json:"weightInPounds"`
+}
+
+// exportProspects writes a team's prospects from the raw upstream payload,
+// forwards first, then defensemen and goalies.
+func exportProspects(w http.ResponseWriter, format, teamAbbrev string, data []byte) error {
+	var prospects struct {
+		Forwards   []prospectExportPlayer `json:"forwards"`
+		Defensemen []prospectExportPlayer `json:"defensemen"`
+		Goalies    []prospectExportPlayer `json:"goalies"`
+	}
+	if err := json.Unmarshal(data, &prospects); err != nil {
+		return fmt.Errorf("parsing prospects: %w", err)
+	}
+	filename := fmt.Sprintf("prospects-%s-%s", strings.ToUpper(teamAbbrev), currentSeasonID())
+	writeTable(w, format, filename, prospectsExportColumns, func(t *tableWriter) error {
+		for _, group := range [][]prospectExportPlayer{prospects.Forwards, prospects.Defensemen, prospects.Goalies} {
+			for _, p := range group {
+				if err := t.Row(
+					p.ID, p.SweaterNumber, pickName(p.FirstName), pickName(p.LastName), p.PositionCode, p.ShootsCatches,
+					p.BirthDate, pickName(p.BirthCity), p.BirthCountry, p.HeightInInches, p.WeightInPounds,
+				); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	return nil
+}
+
+// handlePlayerSeasonsExport writes a player's seasonTotals, one row per
+// season, league, team and game type, in upstream order (oldest first).
+func handlePlayerSeasonsExport(w http.ResponseWriter, format, playerID string) {
+	p, err := GetPlayerLanding(playerID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	name := strings.ToLower(foldText(p.LastName.Default))
+	name = strings.Join(strings.Fields(strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' {
+			return r
+		}
+		return ' '
+	}, name)), "-")
+	filename := fmt.Sprintf("player-%d-%s-seasons", p.PlayerID, name)
+	if name == "" {
+		filename = fmt.Sprintf("player-%d-seasons", p.PlayerID)
+	}
+	writeTable(w, format, filename, seasonsExportColumns, func(t *tableWriter) error {
+		for _, st := range p.SeasonTotals {
+			if err := t.Row(
+				st.Season, st.GameTypeID, st.LeagueAbbrev, st.TeamName.Default, seasonTeamAbbrev(st), st.Sequence,
+				st.GamesPlayed, st.Goals, st.Assists, st.Points, st.PlusMinus, st.PIM, st.Shots, st.PowerPlayGoals,
+				st.Wins, st.Losses, st.OTLosses, st.Shutouts, st.ShotsAgainst, st.GoalsAgainst, st.GoalsAgainstAvg, st.SavePctg,
+			); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// scheduleResult is a finished game's result for team: W, L, OTL or SOL.
+func scheduleResult(g ClubScheduleGame, team string) string {
+	if !isGameFinal(g.GameState) {
+		return ""
+	}
+	own, opp := g.HomeTeam.Score, g.AwayTeam.Score
+	if g.AwayTeam.Abbrev == team {
+		own, opp = opp, own
+	}
+	switch {
+	case own > opp:
+		return "W"
+	case g.GameOutcome.LastPeriodType == "OT":
+		return "OTL"
+	case g.GameOutcome.LastPeriodType == "SO":
+		return "SOL"
+	}
+	return "L"
+}
+
+// handleTeamScheduleExport writes a team's season schedule (?season=, the
+// current one by default). Scores are empty until a game starts.
+func handleTeamScheduleExport(w http.ResponseWriter, r *http.Request, format, teamID string) {
+	abbr, ok := resolveTeamAbbrev(teamID)
+	if !ok {
+		http.Error(w, "Team not found", http.StatusNotFound)
+		return
+	}
+	season := r.URL.Query().Get("season")
+	if season != "" && !validSeason(season) {
+		http.Error(w, "season must look like 20242025", http.StatusBadRequest)
+		return
+	}
+	sched, err := GetClubSchedule(abbr, season)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	if season == "" {
+		season = strconv.Itoa(sched.CurrentSeason)
+		if sched.CurrentSeason == 0 {
+			season = currentSeasonID()
+		}
+	}
+	filename := fmt.Sprintf("schedule-%s-%s", abbr, season)
+	writeTable(w, format, filename, scheduleExportColumns, func(t *tableWriter) error {
+		for _, g := range sched.Games {
+			homeAway, opponent := "home", g.AwayTeam.Abbrev
+			if g.AwayTeam.Abbrev == abbr {
+				homeAway, opponent = "away", g.HomeTeam.Abbrev
+			}
+			var awayScore, homeScore interface{}
+			if isGameFinal(g.GameState) || isGameLive(g.GameState) {
+				awayScore, homeScore = g.AwayTeam.Score, g.HomeTeam.Score
+			}
+			var tv []string
+			for _, b := range g.TVBroadcasts {
+				if !containsString(tv, b.Network) {
+					tv = append(tv, b.Network)
+				}
+			}
+			if err := t.Row(
+				g.ID, g.Season, g.GameType, g.GameDate, g.StartTimeUTC, g.GameState,
+				homeAway, opponent, g.AwayTeam.Abbrev, awayScore, g.HomeTeam.Abbrev, homeScore,
+				scheduleResult(g, abbr), g.GameOutcome.LastPeriodType, g.Venue.Default, strings.Join(tv, "; "),
+			); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
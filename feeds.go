@@ -0,0 +1,435 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	feedCacheTTL   = 10 * time.Minute
+	feedChangedTTL = 7 * 24 * time.Hour
+	feedScoresDays = 7
+	feedMaxScores  = 50
+	feedAuthor     = "NHL.com"
+)
+
+// feedItem is one entry, shared by the RSS and Atom renderings. Links
+// starting with "/" are on this site and made absolute when rendered.
+type feedItem struct {
+	GUID      string    `json:"guid"`
+	PermaLink bool      `json:"permaLink"`
+	Title     string    `json:"title"`
+	Link      string    `json:"link"`
+	Summary   string    `json:"summary,omitempty"`
+	Published time.Time `json:"published"`
+}
+
+// feedDoc is a feed before rendering; it is what gets cached.
+type feedDoc struct {
+	Title       string     `json:"title"`
+	Link        string     `json:"link"`
+	Description string     `json:"description"`
+	Items       []feedItem `json:"items"`
+	// Changed is when the content last changed, for Last-Modified
+	Changed time.Time `json:"changed"`
+}
+
+// feedChange remembers a feed's content hash and when it last changed.
+type feedChange struct {
+	Hash string    `json:"hash"`
+	At   time.Time `json:"at"`
+}
+
+// feedChangedAt is when a freshly built feed's content last changed: the
+// earlier time if it matches the previous build, else now. Entry dates
+// cannot serve, since a final keeps its game's start time and a late story
+// can carry an older date than those already in the feed.
+func feedChangedAt(cacheKey string, d *feedDoc) time.Time {
+	data, _ := json.Marshal(d)
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	key := cacheKey + ":changed"
+	var prev feedChange
+	if cached, err := getCachedRaw(key); err == nil && json.Unmarshal(cached, &prev) == nil && prev.Hash == hash {
+		return prev.At
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	if data, err := json.Marshal(feedChange{Hash: hash, At: now}); err == nil {
+		if err := setCachedRaw(key, data, feedChangedTTL); err != nil {
+			log.Printf("Failed to cache %s: %v", key, err)
+		}
+	}
+	return now
+}
+
+// updated is the newest entry's date, so a feed's dates and ETag only
+// change when its content does.
+func (d *feedDoc) updated() time.Time {
+	var newest time.Time
+	for _, it := range d.Items {
+		if it.Published.After(newest) {
+			newest = it.Published
+		}
+	}
+	return newest
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	Description string  `xml:"description,omitempty"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate,omitempty"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	Language      string    `xml:"language"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	TTL           int       `xml:"ttl"`
+	Self          atomLink  `xml:"atom:link"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssDoc struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	AtomNS  string     `xml:"xmlns:atom,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomEntry struct {
+	Title     string   `xml:"title"`
+	ID        string   `xml:"id"`
+	Updated   string   `xml:"updated"`
+	Published string   `xml:"published,omitempty"`
+	Link      atomLink `xml:"link"`
+	Summary   string   `xml:"summary,omitempty"`
+}
+
+type atomFeed struct {
+	XMLName  xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle,omitempty"`
+	ID       string      `xml:"id"`
+	Updated  string      `xml:"updated"`
+	Author   string      `xml:"author>name"`
+	Links    []atomLink  `xml:"link"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+func absoluteLink(base, link string) string {
+	if strings.HasPrefix(link, "/") {
+		return base + link
+	}
+	return link
+}
+
+// renderRSS renders an RSS 2.0 document; self is the feed's own URL.
+func renderRSS(d *feedDoc, base, self string) ([]byte, error) {
+	doc := rssDoc{
+		Version: "2.0",
+		AtomNS:  "http://www.w3.org/2005/Atom",
+		Channel: rssChannel{
+			Title:       d.Title,
+			Link:        absoluteLink(base, d.Link),
+			Description: d.Description,
+			Language:    "en",
+			TTL:         int(feedCacheTTL.Minutes()),
+			Self:        atomLink{Href: self, Rel: "self", Type: "application/rss+xml"},
+		},
+	}
+	if u := d.updated(); !u.IsZero() {
+		doc.Channel.LastBuildDate = u.UTC().Format(time.RFC1123Z)
+	}
+	for _, it := range d.Items {
+		item := rssItem{
+			Title:       it.Title,
+			Link:        absoluteLink(base, it.Link),
+			Description: it.Summary,
+			GUID:        rssGUID{IsPermaLink: it.PermaLink, Value: it.GUID},
+		}
+		if !it.Published.IsZero() {
+			item.PubDate = it.Published.UTC().Format(time.RFC1123Z)
+		}
+		doc.Channel.Items = append(doc.Channel.Items, item)
+	}
+	return marshalFeed(doc)
+}
+
+// renderAtom renders an Atom 1.0 feed; self is the feed's own URL and id.
+func renderAtom(d *feedDoc, base, self string) ([]byte, error) {
+	updated := d.updated()
+	if updated.IsZero() {
+		// Atom requires a date; the Unix epoch keeps an empty feed's ETag stable
+		updated = time.Unix(0, 0)
+	}
+	feed := atomFeed{
+		Title:    d.Title,
+		Subtitle: d.Description,
+		ID:       self,
+		Updated:  updated.UTC().Format(time.RFC3339),
+		Author:   feedAuthor,
+		Links: []atomLink{
+			{Href: self, Rel: "self", Type: "application/atom+xml"},
+			{Href: absoluteLink(base, d.Link), Rel: "alternate", Type: "text/html"},
+		},
+	}
+	for _, it := range d.Items {
+		entry := atomEntry{
+			Title:   it.Title,
+			ID:      it.GUID,
+			Updated: feed.Updated,
+			Link:    atomLink{Href: absoluteLink(base, it.Link), Rel: "alternate"},
+			Summary: it.Summary,
+		}
+		if !it.Published.IsZero() {
+			entry.Updated = it.Published.UTC().Format(time.RFC3339)
+			entry.Published = entry.Updated
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	return marshalFeed(feed)
+}
+
+func marshalFeed(v interface{}) ([]byte, error) {
+	data, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+// serveFeed serves a feed as RSS or Atom ({format} route variable). The
+// built feed is cached for ten minutes, and responses carry an ETag and
+// Last-Modified (when the content last changed) so readers polling with
+// If-None-Match or If-Modified-Since get a 304.
+func serveFeed(w http.ResponseWriter, r *http.Request, cacheKey string, build func() (*feedDoc, error)) {
+	var doc feedDoc
+	if cached, err := getCachedRaw(cacheKey); err == nil && json.Unmarshal(cached, &doc) == nil {
+		log.Printf("Cache hit for %s", cacheKey)
+	} else {
+		built, err := build()
+		if err != nil {
+			log.Printf("Error building feed %s: %v", cacheKey, err)
+			http.Error(w, "Failed to fetch feed content", http.StatusBadGateway)
+			return
+		}
+		built.Changed = feedChangedAt(cacheKey, built)
+		doc = *built
+		if data, err := json.Marshal(doc); err == nil {
+			if err := setCachedRaw(cacheKey, data, feedCacheTTL); err != nil {
+				log.Printf("Failed to cache %s: %v", cacheKey, err)
+			}
+		}
+	}
+
+	base := publicBaseURL(r)
+	self := base + r.URL.Path
+	var body []byte
+	var err error
+	contentType := "application/rss+xml; charset=utf-8"
+	if mux.Vars(r)["format"] == "atom" {
+		contentType = "application/atom+xml; charset=utf-8"
+		body, err = renderAtom(&doc, base, self)
+	} else {
+		body, err = renderRSS(&doc, base, self)
+	}
+	if err != nil {
+		log.Printf("Error rendering feed %s: %v", cacheKey, err)
+		http.Error(w, "Encoding error", http.StatusInternalServerError)
+		return
+	}
+
+	sum := sha256.Sum256(body)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:8])+`"`)
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(feedCacheTTL.Seconds())))
+	http.ServeContent(w, r, "", doc.Changed, bytes.NewReader(body))
+}
+
+// teamNewsFeed builds a team's news feed, falling back to the cached
+// stories when the news API rate-limits us.
+func teamNewsFeed(abbr string) (*feedDoc, error) {
+	teamID := transactionsTeamID(abbr)
+	news, err := GetTeamNews(teamID)
+	if errors.Is(err, errTeamNewsRateLimited) {
+		cached, cacheErr := getCachedRaw(fmt.Sprintf("team-news:%s", teamID))
+		if cacheErr != nil {
+			return nil, err
+		}
+		news = &TeamNewsResponse{}
+		err = json.Unmarshal(cached, news)
+	}
+	if err != nil {
+		return nil, err
+	}
+	doc := &feedDoc{
+		Title:       teamFullName(abbr) + " News",
+		Link:        "/team/" + strings.ToLower(abbr),
+		Description: "Latest " + teamFullName(abbr) + " stories from NHL.com",
+		Items:       []feedItem{},
+	}
+	for _, s := range news.Stories {
+		link := nhlStoryURL(s.URL)
+		doc.Items = append(doc.Items, feedItem{
+			GUID:      link,
+			PermaLink: true,
+			Title:     s.Title,
+			Link:      link,
+			Published: parseContentDate(s.ContentDate),
+		})
+	}
+	return doc, nil
+}
+
+// teamTransactionsFeed builds a team's transactions feed, falling back to
+// the cached list when the API rate-limits us.
+func teamTransactionsFeed(abbr string) (*feedDoc, error) {
+	teamID := transactionsTeamID(abbr)
+	txs, err := GetTeamTransactions(teamID)
+	if errors.Is(err, errTransactionsRateLimited) {
+		cached, cacheErr := getCachedRaw(fmt.Sprintf("team-transactions:%s", teamID))
+		if cacheErr != nil {
+			return nil, err
+		}
+		txs = &TransactionsResponse{}
+		err = json.Unmarshal(cached, txs)
+	}
+	if err != nil {
+		return nil, err
+	}
+	doc := &feedDoc{
+		Title:       teamFullName(abbr) + " Transactions",
+		Link:        "/team/" + strings.ToLower(abbr),
+		Description: "Signings, trades, recalls and other " + teamFullName(abbr) + " roster moves",
+		Items:       []feedItem{},
+	}
+	for _, tx := range txs.Transactions {
+		link := nhlStoryURL(tx.URL)
+		doc.Items = append(doc.Items, feedItem{
+			GUID:      link,
+			PermaLink: true,
+			Title:     tx.Title,
+			Link:      link,
+			Summary:   tx.Summary,
+			Published: parseContentDate(tx.Date),
+		})
+	}
+	return doc, nil
+}
+
+// scoresFeed builds the league-wide final scores of the last week, newest
+// first.
+func scoresFeed() (*feedDoc, error) {
+	start := time.Now().AddDate(0, 0, 1-feedScoresDays).Format("2006-01-02")
+	data, err := readURL(fmt.Sprintf("%s/schedule/%s", BaseURL, start))
+	if err != nil {
+		return nil, err
+	}
+	// A schedule request returns the week starting at that date
+	var sched struct {
+		GameWeek []struct {
+			Games []ClubScheduleGame `json:"games"`
+		} `json:"gameWeek"`
+	}
+	if err := json.Unmarshal(data, &sched); err != nil {
+		return nil, fmt.Errorf("parsing schedule: %w", err)
+	}
+	var finals []ClubScheduleGame
+	for _, day := range sched.GameWeek {
+		for _, g := range day.Games {
+			if isGameFinal(g.GameState) {
+				finals = append(finals, g)
+			}
+		}
+	}
+	sort.SliceStable(finals, func(i, j int) bool {
+		if finals[i].StartTimeUTC != finals[j].StartTimeUTC {
+			return finals[i].StartTimeUTC > finals[j].StartTimeUTC
+		}
+		return finals[i].ID > finals[j].ID
+	})
+	if len(finals) > feedMaxScores {
+		finals = finals[:feedMaxScores]
+	}
+
+	doc := &feedDoc{
+		Title:       "NHL Final Scores",
+		Link:        "/scores",
+		Description: "Final scores from around the NHL",
+		Items:       []feedItem{},
+	}
+	for _, g := range finals {
+		started, _ := time.Parse(time.RFC3339, g.StartTimeUTC)
+		winner, loser := g.HomeTeam, g.AwayTeam
+		if g.AwayTeam.Score > g.HomeTeam.Score {
+			winner, loser = g.AwayTeam, g.HomeTeam
+		}
+		summary := fmt.Sprintf("%s %d, %s %d", teamFullName(winner.Abbrev), winner.Score, teamFullName(loser.Abbrev), loser.Score)
+		if t := g.GameOutcome.LastPeriodType; t == "OT" || t == "SO" {
+			summary += " (" + t + ")"
+		}
+		if g.Venue.Default != "" {
+			summary += " at " + g.Venue.Default
+		}
+		doc.Items = append(doc.Items, feedItem{
+			GUID:      fmt.Sprintf("urn:nhl:game:%d:final", g.ID),
+			Title:     gameTitle(g),
+			Link:      fmt.Sprintf("/game/%d", g.ID),
+			Summary:   summary,
+			Published: started,
+		})
+	}
+	return doc, nil
+}
+
+// handleTeamNewsFeed serves /feeds/team/{teamId}/news.{rss|atom}.
+func handleTeamNewsFeed(w http.ResponseWriter, r *http.Request) {
+	abbr, ok := resolveTeamAbbrev(mux.Vars(r)["teamId"])
+	if !ok {
+		http.Error(w, "Unknown team", http.StatusNotFound)
+		return
+	}
+	serveFeed(w, r, "feed:news:"+abbr, func() (*feedDoc, error) { return teamNewsFeed(abbr) })
+}
+
+// handleTeamTransactionsFeed serves /feeds/team/{teamId}/transactions.{rss|atom}.
+func handleTeamTransactionsFeed(w http.ResponseWriter, r *http.Request) {
+	abbr, ok := resolveTeamAbbrev(mux.Vars(r)["teamId"])
+	if !ok {
+		http.Error(w, "Unknown team", http.StatusNotFound)
+		return
+	}
+	serveFeed(w, r, "feed:transactions:"+abbr, func() (*feedDoc, error) { return teamTransactionsFeed(abbr) })
+}
+
+// handleScoresFeed serves /feeds/scores.{rss|atom}.
+func handleScoresFeed(w http.ResponseWriter, r *http.Request) {
+	serveFeed(w, r, "feed:scores", scoresFeed)
+}
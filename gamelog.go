@@ -0,0 +1,272 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// GameLogAverage is a rolling per-game average over the last Games games.
+// Skater and goalie fields share one struct; the unused side stays zero.
+type GameLogAverage struct {
+	Games        int     `json:"games"`
+	Goals        float64 `json:"goals"`
+	Assists      float64 `json:"assists"`
+	Points       float64 `json:"points"`
+	Shots        float64 `json:"shots"`
+	GoalsAgainst float64 `json:"goalsAgainst"`
+	SavePct      float64 `json:"savePct"`
+}
+
+// GameLogGame is one game's line with rolling averages up to that game.
+type GameLogGame struct {
+	PlayerGameLogEntry
+	Saves     int            `json:"saves"`
+	Rolling5  GameLogAverage `json:"rolling5"`
+	Rolling10 GameLogAverage `json:"rolling10"`
+}
+
+// GameLogSplit totals a subset of games: home/road, a month or an opponent.
+type GameLogSplit struct {
+	Key          string  `json:"key"`
+	GamesPlayed  int     `json:"gamesPlayed"`
+	Goals        int     `json:"goals"`
+	Assists      int     `json:"assists"`
+	Points       int     `json:"points"`
+	Shots        int     `json:"shots"`
+	PlusMinus    int     `json:"plusMinus"`
+	PIM          int     `json:"pim"`
+	Wins         int     `json:"wins"`
+	Losses       int     `json:"losses"`
+	OTLosses     int     `json:"otLosses"`
+	ShotsAgainst int     `json:"shotsAgainst"`
+	Saves        int     `json:"saves"`
+	GoalsAgainst int     `json:"goalsAgainst"`
+	Shutouts     int     `json:"shutouts"`
+	SavePct      float64 `json:"savePct"`
+}
+
+// GameLogStreaks are the current and season-long streaks. Skaters get point
+// and goal streaks; goalies get win and unbeaten (W or OTL) streaks.
+type GameLogStreaks struct {
+	PointStreak        int `json:"pointStreak"`
+	GoalStreak         int `json:"goalStreak"`
+	LongestPointStreak int `json:"longestPointStreak"`
+	LongestGoalStreak  int `json:"longestGoalStreak"`
+	WinStreak          int `json:"winStreak"`
+	UnbeatenStreak     int `json:"unbeatenStreak"`
+	LongestWinStreak   int `json:"longestWinStreak"`
+}
+
+// PlayerGameLogSummary is the /api/player/{id}/game-log response. Games are
+// newest first, as upstream returns them.
+type PlayerGameLogSummary struct {
+	PlayerID  string         `json:"playerId"`
+	Season    string         `json:"season"`
+	GameType  int            `json:"gameType"`
+	Goalie    bool           `json:"goalie"`
+	Totals    GameLogSplit   `json:"totals"`
+	Streaks   GameLogStreaks `json:"streaks"`
+	Home      GameLogSplit   `json:"home"`
+	Road      GameLogSplit   `json:"road"`
+	Months    []GameLogSplit `json:"months"`
+	Opponents []GameLogSplit `json:"opponents"`
+	Games     []GameLogGame  `json:"games"`
+}
+
+func (s *GameLogSplit) add(g PlayerGameLogEntry) {
+	s.GamesPlayed++
+	s.Goals += g.Goals
+	s.Assists += g.Assists
+	s.Points += g.Points
+	s.Shots += g.Shots
+	s.PlusMinus += g.PlusMinus
+	s.PIM += g.PIM
+	switch g.Decision {
+	case "W":
+		s.Wins++
+	case "L":
+		s.Losses++
+	case "O":
+		s.OTLosses++
+	}
+	s.ShotsAgainst += g.ShotsAgainst
+	s.GoalsAgainst += g.GoalsAgainst
+	s.Saves += g.ShotsAgainst - g.GoalsAgainst
+	s.Shutouts += g.Shutouts
+	if s.ShotsAgainst > 0 {
+		s.SavePct = round3(float64(s.Saves) / float64(s.ShotsAgainst))
+	}
+}
+
+// rollingAverage averages the window of games (any order).
+func rollingAverage(window []PlayerGameLogEntry) GameLogAverage {
+	var s GameLogSplit
+	for _, g := range window {
+		s.add(g)
+	}
+	n := float64(len(window))
+	if n == 0 {
+		return GameLogAverage{}
+	}
+	return GameLogAverage{
+		Games:        len(window),
+		Goals:        round3(float64(s.Goals) / n),
+		Assists:      round3(float64(s.Assists) / n),
+		Points:       round3(float64(s.Points) / n),
+		Shots:        round3(float64(s.Shots) / n),
+		GoalsAgainst: round3(float64(s.GoalsAgainst) / n),
+		SavePct:      s.SavePct,
+	}
+}
+
+// gameLogStreaks walks games oldest first, tracking the running and longest
+// streaks; the running values at the end are the current streaks.
+func gameLogStreaks(chrono []PlayerGameLogEntry) GameLogStreaks {
+	var st GameLogStreaks
+	for _, g := range chrono {
+		if g.Points > 0 {
+			st.PointStreak++
+		} else {
+			st.PointStreak = 0
+		}
+		if g.Goals > 0 {
+			st.GoalStreak++
+		} else {
+			st.GoalStreak = 0
+		}
+		// Goalies who came on in relief without a decision don't break streaks.
+		switch g.Decision {
+		case "W":
+			st.WinStreak++
+			st.UnbeatenStreak++
+		case "O":
+			st.WinStreak = 0
+			st.UnbeatenStreak++
+		case "L":
+			st.WinStreak = 0
+			st.UnbeatenStreak = 0
+		}
+		st.LongestPointStreak = max(st.LongestPointStreak, st.PointStreak)
+		st.LongestGoalStreak = max(st.LongestGoalStreak, st.GoalStreak)
+		st.LongestWinStreak = max(st.LongestWinStreak, st.WinStreak)
+	}
+	return st
+}
+
+// SummarizeGameLog adds rolling averages, streaks and splits to a game log.
+func SummarizeGameLog(playerID string, goalie bool, gl *PlayerGameLog) *PlayerGameLogSummary {
+	out := &PlayerGameLogSummary{
+		PlayerID:  playerID,
+		Season:    strconv.Itoa(gl.SeasonID),
+		GameType:  gl.GameType,
+		Goalie:    goalie,
+		Totals:    GameLogSplit{Key: "season"},
+		Home:      GameLogSplit{Key: "home"},
+		Road:      GameLogSplit{Key: "road"},
+		Months:    []GameLogSplit{},
+		Opponents: []GameLogSplit{},
+		Games:     []GameLogGame{},
+	}
+
+	// Upstream lists newest first; work oldest first for rolling windows.
+	chrono := make([]PlayerGameLogEntry, len(gl.GameLog))
+	for i, g := range gl.GameLog {
+		chrono[len(gl.GameLog)-1-i] = g
+	}
+
+	months := map[string]*GameLogSplit{}
+	opponents := map[string]*GameLogSplit{}
+	games := make([]GameLogGame, len(chrono))
+	for i, g := range chrono {
+		out.Totals.add(g)
+		if g.HomeRoadFlag == "H" {
+			out.Home.add(g)
+		} else {
+			out.Road.add(g)
+		}
+		if len(g.GameDate) >= 7 {
+			m := g.GameDate[:7]
+			if months[m] == nil {
+				months[m] = &GameLogSplit{Key: m}
+			}
+			months[m].add(g)
+		}
+		if opponents[g.OpponentAbbrev] == nil {
+			opponents[g.OpponentAbbrev] = &GameLogSplit{Key: g.OpponentAbbrev}
+		}
+		opponents[g.OpponentAbbrev].add(g)
+
+		games[len(chrono)-1-i] = GameLogGame{
+			PlayerGameLogEntry: g,
+			Saves:              g.ShotsAgainst - g.GoalsAgainst,
+			Rolling5:           rollingAverage(chrono[max(0, i-4) : i+1]),
+			Rolling10:          rollingAverage(chrono[max(0, i-9) : i+1]),
+		}
+	}
+	out.Games = games
+	out.Streaks = gameLogStreaks(chrono)
+
+	for _, s := range months {
+		out.Months = append(out.Months, *s)
+	}
+	sort.Slice(out.Months, func(i, j int) bool { return out.Months[i].Key < out.Months[j].Key })
+	for _, s := range opponents {
+		out.Opponents = append(out.Opponents, *s)
+	}
+	sort.Slice(out.Opponents, func(i, j int) bool {
+		if out.Opponents[i].GamesPlayed != out.Opponents[j].GamesPlayed {
+			return out.Opponents[i].GamesPlayed > out.Opponents[j].GamesPlayed
+		}
+		return out.Opponents[i].Key < out.Opponents[j].Key
+	})
+	return out
+}
+
+// isGoalie reads the player's position from the cached landing payload.
+func isGoalie(playerID string) bool {
+	data, err := GetPlayer(playerID)
+	if err != nil {
+		log.Printf("isGoalie: landing for %s: %v", playerID, err)
+		return false
+	}
+	var landing struct {
+		Position string `json:"position"`
+	}
+	if err := json.Unmarshal(data, &landing); err != nil {
+		return false
+	}
+	return landing.Position == "G"
+}
+
+func handleAPIPlayerGameLog(w http.ResponseWriter, r *http.Request) {
+	playerID := mux.Vars(r)["playerId"]
+	if _, err := strconv.Atoi(playerID); err != nil {
+		http.Error(w, "invalid player id", http.StatusBadRequest)
+		return
+	}
+	season, gameType, ok := seasonAndGameType(w, r)
+	if !ok {
+		return
+	}
+
+	gl, err := GetPlayerGameLog(playerID, season, gameType)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to get game log: %v", err), http.StatusBadGateway)
+		return
+	}
+	summary := SummarizeGameLog(playerID, isGoalie(playerID), gl)
+	if summary.Season == "0" {
+		summary.Season = season
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(summary); err != nil {
+		log.Printf("Error writing game log JSON: %v", err)
+	}
+}
@@ -0,0 +1,503 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	// A quality start beats the league save percentage, or stops at least
+	// .885 on a light night of 20 shots or fewer (Vollman's definition).
+	qualityStartLightShots  = 20
+	qualityStartLightSvPct  = 0.885
+	reallyBadStartSvPct     = 0.850
+	fallbackLeagueSavePct   = 0.900
+	defaultGoalieMinStarts  = 5
+	goalieRestBucketMaxDays = 3
+)
+
+// GoalieSplit is save percentage over a subset of shots or starts.
+type GoalieSplit struct {
+	Key          string  `json:"key"`
+	Games        int     `json:"games,omitempty"`
+	ShotsAgainst int     `json:"shotsAgainst"`
+	GoalsAgainst int     `json:"goalsAgainst"`
+	SavePct      float64 `json:"savePct"`
+}
+
+func (s *GoalieSplit) add(shots, goals int) {
+	s.ShotsAgainst += shots
+	s.GoalsAgainst += goals
+	if s.ShotsAgainst > 0 {
+		s.SavePct = round3(float64(s.ShotsAgainst-s.GoalsAgainst) / float64(s.ShotsAgainst))
+	}
+}
+
+// GoalieGame is one appearance with its start classification and xG.
+type GoalieGame struct {
+	GameID         int64   `json:"gameId"`
+	GameDate       string  `json:"gameDate"`
+	Opponent       string  `json:"opponent"`
+	HomeRoadFlag   string  `json:"homeRoadFlag"`
+	Started        bool    `json:"started"`
+	Decision       string  `json:"decision,omitempty"`
+	ShotsAgainst   int     `json:"shotsAgainst"`
+	GoalsAgainst   int     `json:"goalsAgainst"`
+	SavePct        float64 `json:"savePct"`
+	RestDays       int     `json:"restDays"` // -1 for the first appearance
+	BackToBack     bool    `json:"backToBack"`
+	QualityStart   bool    `json:"qualityStart"`
+	ReallyBadStart bool    `json:"reallyBadStart"`
+	XGA            float64 `json:"xga"`
+	GSAx           float64 `json:"gsax"`
+}
+
+// GoalieRanks places a goalie among the league's qualified goalies.
+type GoalieRanks struct {
+	SavePct         int `json:"savePct"`
+	GSAx            int `json:"gsax"`
+	GSAxPer60       int `json:"gsaxPer60"`
+	QualityStartPct int `json:"qualityStartPct"`
+	Qualified       int `json:"qualified"`
+}
+
+// GoalieAnalytics is the /api/goalie/{id}/analytics response.
+type GoalieAnalytics struct {
+	PlayerID         int           `json:"playerId"`
+	Name             string        `json:"name"`
+	TeamAbbrev       string        `json:"teamAbbrev,omitempty"`
+	Season           string        `json:"season"`
+	GameType         int           `json:"gameType"`
+	GamesPlayed      int           `json:"gamesPlayed"`
+	GamesStarted     int           `json:"gamesStarted"`
+	Wins             int           `json:"wins"`
+	Losses           int           `json:"losses"`
+	OTLosses         int           `json:"otLosses"`
+	ShotsAgainst     int           `json:"shotsAgainst"`
+	GoalsAgainst     int           `json:"goalsAgainst"`
+	SavePct          float64       `json:"savePct"`
+	TOISeconds       int           `json:"toiSeconds"`
+	LeagueSavePct    float64       `json:"leagueSavePct"`
+	QualityStarts    int           `json:"qualityStarts"`
+	QualityStartPct  float64       `json:"qualityStartPct"`
+	ReallyBadStarts  int           `json:"reallyBadStarts"`
+	BackToBackStarts int           `json:"backToBackStarts"`
+	XGA              float64       `json:"xga"`
+	GSAx             float64       `json:"gsax"`
+	GSAxPer60        float64       `json:"gsaxPer60"`
+	ByStrength       []GoalieSplit `json:"byStrength"`
+	ByRest           []GoalieSplit `json:"byRest"`
+	Ranks            *GoalieRanks  `json:"ranks,omitempty"`
+	Games            []GoalieGame  `json:"games,omitempty"`
+}
+
+// LeagueGoalies is the /api/goalies/analytics response.
+type LeagueGoalies struct {
+	Season        string            `json:"season"`
+	GameType      int               `json:"gameType"`
+	MinStarts     int               `json:"minStarts"`
+	LeagueSavePct float64           `json:"leagueSavePct"`
+	Goalies       []GoalieAnalytics `json:"goalies"`
+}
+
+// leagueSavePct is the league-wide save percentage from the goalie summary.
+func leagueSavePct(season string, gameType int) float64 {
+	rows, err := fetchStatsSummary(true, season, gameType, false)
+	if err != nil {
+		log.Printf("leagueSavePct: %v", err)
+		return fallbackLeagueSavePct
+	}
+	var saves, shots int
+	for _, row := range rows {
+		saves += jsonInt(row["saves"])
+		shots += jsonInt(row["shotsAgainst"])
+	}
+	if shots == 0 {
+		return fallbackLeagueSavePct
+	}
+	return round3(float64(saves) / float64(shots))
+}
+
+// goalieStrength turns the shooting team's strength state into the
+// goalie's: a shot on the power play is faced shorthanded.
+func goalieStrength(shooterState string) string {
+	switch shooterState {
+	case "PP":
+		return "SH"
+	case "SH":
+		return "PP"
+	}
+	return "EV"
+}
+
+// restBucket labels days of rest since the previous appearance.
+func restBucket(days int) string {
+	if days >= goalieRestBucketMaxDays {
+		return fmt.Sprintf("%d+", goalieRestBucketMaxDays)
+	}
+	return strconv.Itoa(days)
+}
+
+// GetGoalieAnalytics computes a goalie's starts, workload, splits and
+// goals saved above expected from their game log and each game's
+// play-by-play.
+func GetGoalieAnalytics(playerID, season string, gameType int) (*GoalieAnalytics, error) {
+	id, err := strconv.Atoi(playerID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid player id: %s", playerID)
+	}
+	gl, err := GetPlayerGameLog(playerID, season, gameType)
+	if err != nil {
+		return nil, err
+	}
+	out := &GoalieAnalytics{
+		PlayerID:      id,
+		Season:        season,
+		GameType:      gameType,
+		LeagueSavePct: leagueSavePct(season, gameType),
+		ByStrength:    []GoalieSplit{},
+		ByRest:        []GoalieSplit{},
+		Games:         []GoalieGame{},
+	}
+	if landing, err := GetPlayerLanding(playerID); err == nil {
+		out.Name = strings.TrimSpace(landing.FirstName.Default + " " + landing.LastName.Default)
+		out.TeamAbbrev = landing.CurrentTeamAbbrev
+	}
+
+	// Upstream lists newest first; rest days need oldest first.
+	chrono := make([]PlayerGameLogEntry, len(gl.GameLog))
+	for i, g := range gl.GameLog {
+		chrono[len(gl.GameLog)-1-i] = g
+	}
+
+	model := activeXGModel()
+	strength := map[string]*GoalieSplit{}
+	rest := map[string]*GoalieSplit{}
+	var prev time.Time
+	var xga float64
+	var xGoals int
+	for _, e := range chrono {
+		g := GoalieGame{
+			GameID:       e.GameID,
+			GameDate:     e.GameDate,
+			Opponent:     e.OpponentAbbrev,
+			HomeRoadFlag: e.HomeRoadFlag,
+			Started:      e.GamesStarted > 0,
+			Decision:     e.Decision,
+			ShotsAgainst: e.ShotsAgainst,
+			GoalsAgainst: e.GoalsAgainst,
+			RestDays:     -1,
+		}
+		g.SavePct = 1
+		if e.ShotsAgainst > 0 {
+			g.SavePct = round3(float64(e.ShotsAgainst-e.GoalsAgainst) / float64(e.ShotsAgainst))
+		}
+		if day, err := time.Parse("2006-01-02", e.GameDate); err == nil {
+			if !prev.IsZero() {
+				g.RestDays = int(day.Sub(prev).Hours()/24) - 1
+				g.BackToBack = g.RestDays == 0
+			}
+			prev = day
+		}
+
+		out.GamesPlayed++
+		out.ShotsAgainst += e.ShotsAgainst
+		out.GoalsAgainst += e.GoalsAgainst
+		out.TOISeconds += clockSeconds(e.TOI)
+		switch e.Decision {
+		case "W":
+			out.Wins++
+		case "L":
+			out.Losses++
+		case "O":
+			out.OTLosses++
+		}
+		if g.Started {
+			out.GamesStarted++
+			g.QualityStart = g.SavePct >= out.LeagueSavePct ||
+				(e.ShotsAgainst <= qualityStartLightShots && g.SavePct >= qualityStartLightSvPct)
+			g.ReallyBadStart = g.SavePct < reallyBadStartSvPct
+			if g.QualityStart {
+				out.QualityStarts++
+			}
+			if g.ReallyBadStart {
+				out.ReallyBadStarts++
+			}
+			if g.BackToBack {
+				out.BackToBackStarts++
+			}
+			if g.RestDays >= 0 {
+				key := restBucket(g.RestDays)
+				if rest[key] == nil {
+					rest[key] = &GoalieSplit{Key: key}
+				}
+				rest[key].Games++
+				rest[key].add(e.ShotsAgainst, e.GoalsAgainst)
+			}
+		}
+
+		pbp, err := GetPlayByPlay(strconv.FormatInt(e.GameID, 10))
+		if err != nil {
+			log.Printf("GetGoalieAnalytics: skipping play-by-play for %d: %v", e.GameID, err)
+			out.Games = append(out.Games, g)
+			continue
+		}
+		gameGoals := 0
+		for _, s := range ExtractXGShots(pbp, model) {
+			if s.GoalieID != id || s.EmptyNet {
+				continue
+			}
+			goal := 0
+			if s.Type == eventGoal {
+				goal = 1
+			}
+			g.XGA += s.XG
+			gameGoals += goal
+			if s.IsOnGoal() {
+				key := goalieStrength(s.StrengthState)
+				if strength[key] == nil {
+					strength[key] = &GoalieSplit{Key: key}
+				}
+				strength[key].add(1, goal)
+			}
+		}
+		// Same goals as the season total, so the games add up to it
+		g.GSAx = round3(g.XGA - float64(gameGoals))
+		xga += g.XGA
+		xGoals += gameGoals
+		g.XGA = round3(g.XGA)
+		out.Games = append(out.Games, g)
+	}
+
+	if out.ShotsAgainst > 0 {
+		out.SavePct = round3(float64(out.ShotsAgainst-out.GoalsAgainst) / float64(out.ShotsAgainst))
+	}
+	if out.GamesStarted > 0 {
+		out.QualityStartPct = round3(float64(out.QualityStarts) / float64(out.GamesStarted))
+	}
+	// Compare xG with the goals the play-by-play saw, so games with missing
+	// feeds don't count as saved goals.
+	out.XGA = round3(xga)
+	out.GSAx = round3(xga - float64(xGoals))
+	if out.TOISeconds > 0 {
+		out.GSAxPer60 = round3(out.GSAx * 3600 / float64(out.TOISeconds))
+	}
+	for _, key := range []string{"EV", "PP", "SH"} {
+		if s := strength[key]; s != nil {
+			out.ByStrength = append(out.ByStrength, *s)
+		}
+	}
+	for d := 0; d <= goalieRestBucketMaxDays; d++ {
+		if s := rest[restBucket(d)]; s != nil {
+			out.ByRest = append(out.ByRest, *s)
+		}
+	}
+	// Newest first, like the game log.
+	sort.Slice(out.Games, func(i, j int) bool { return out.Games[i].GameDate > out.Games[j].GameDate })
+	return out, nil
+}
+
+// cachedGoalieAnalytics memoises GetGoalieAnalytics; completed seasons
+// never change.
+func cachedGoalieAnalytics(playerID, season string, gameType int) (*GoalieAnalytics, error) {
+	cacheKey := fmt.Sprintf("goalie-analytics:%s:%s:%d", playerID, season, gameType)
+	data, err := getCachedOrFetchWithBackoff(cacheKey, func() ([]byte, error) {
+		a, err := GetGoalieAnalytics(playerID, season, gameType)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(a)
+	}, seasonTTL(season))
+	if err != nil {
+		return nil, err
+	}
+	var a GoalieAnalytics
+	if err := json.Unmarshal(data, &a); err != nil {
+		return nil, fmt.Errorf("parsing goalie analytics: %w", err)
+	}
+	return &a, nil
+}
+
+// rankGoalies fills in each goalie's league ranks. Ties share a rank.
+func rankGoalies(goalies []GoalieAnalytics) {
+	rank := func(value func(GoalieAnalytics) float64, set func(*GoalieRanks, int)) {
+		order := make([]int, len(goalies))
+		for i := range order {
+			order[i] = i
+		}
+		sort.SliceStable(order, func(a, b int) bool { return value(goalies[order[a]]) > value(goalies[order[b]]) })
+		prev := 0
+		for pos, i := range order {
+			r := pos + 1
+			if pos > 0 && value(goalies[i]) == value(goalies[order[pos-1]]) {
+				r = prev
+			}
+			prev = r
+			set(goalies[i].Ranks, r)
+		}
+	}
+	for i := range goalies {
+		goalies[i].Ranks = &GoalieRanks{Qualified: len(goalies)}
+	}
+	rank(func(g GoalieAnalytics) float64 { return g.SavePct }, func(r *GoalieRanks, n int) { r.SavePct = n })
+	rank(func(g GoalieAnalytics) float64 { return g.GSAx }, func(r *GoalieRanks, n int) { r.GSAx = n })
+	rank(func(g GoalieAnalytics) float64 { return g.GSAxPer60 }, func(r *GoalieRanks, n int) { r.GSAxPer60 = n })
+	rank(func(g GoalieAnalytics) float64 { return g.QualityStartPct }, func(r *GoalieRanks, n int) { r.QualityStartPct = n })
+}
+
+// GetLeagueGoalies computes analytics for every goalie who started a game.
+// Ranks are left to qualified, since they depend on the minimum starts.
+func GetLeagueGoalies(season string, gameType int) (*LeagueGoalies, error) {
+	rows, err := fetchStatsSummary(true, season, gameType, false)
+	if err != nil {
+		return nil, err
+	}
+	out := &LeagueGoalies{
+		Season:        season,
+		GameType:      gameType,
+		LeagueSavePct: leagueSavePct(season, gameType),
+		Goalies:       []GoalieAnalytics{},
+	}
+	for _, row := range rows {
+		if jsonInt(row["gamesStarted"]) < 1 {
+			continue
+		}
+		id := strconv.Itoa(jsonInt(row["playerId"]))
+		a, err := cachedGoalieAnalytics(id, season, gameType)
+		if err != nil {
+			log.Printf("GetLeagueGoalies: skipping goalie %s: %v", id, err)
+			continue
+		}
+		if a.Name == "" {
+			a.Name, _ = row["goalieFullName"].(string)
+		}
+		if a.TeamAbbrev == "" {
+			teams, _ := row["teamAbbrevs"].(string)
+			if i := strings.LastIndex(teams, ","); i >= 0 {
+				teams = teams[i+1:]
+			}
+			a.TeamAbbrev = strings.TrimSpace(teams)
+		}
+		a.Games = nil
+		out.Goalies = append(out.Goalies, *a)
+	}
+	return out, nil
+}
+
+// qualified keeps the goalies with at least minStarts starts, ranks them
+// against each other and orders them by GSAx.
+func (lg *LeagueGoalies) qualified(minStarts int) {
+	kept := []GoalieAnalytics{}
+	for _, g := range lg.Goalies {
+		if g.GamesStarted >= minStarts {
+			kept = append(kept, g)
+		}
+	}
+	rankGoalies(kept)
+	sort.SliceStable(kept, func(i, j int) bool { return kept[i].GSAx > kept[j].GSAx })
+	lg.MinStarts = minStarts
+	lg.Goalies = kept
+}
+
+var leagueGoalieBuilds = newBackgroundBuilds()
+
+// leagueGoalies returns the season's league goalie table. Building it is a
+// play-by-play fetch for every start in the league, so it happens in the
+// background and errStillBuilding is returned until it is ready.
+func leagueGoalies(season string, gameType int) (*LeagueGoalies, error) {
+	cacheKey := fmt.Sprintf("goalie-league:%s:%d", season, gameType)
+	data, err := leagueGoalieBuilds.get(cacheKey, seasonTTL(season), func() ([]byte, error) {
+		lg, err := GetLeagueGoalies(season, gameType)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(lg)
+	})
+	if err != nil {
+		return nil, err
+	}
+	var lg LeagueGoalies
+	if err := json.Unmarshal(data, &lg); err != nil {
+		return nil, fmt.Errorf("parsing league goalies: %w", err)
+	}
+	return &lg, nil
+}
+
+// startGoalieIndexer keeps the current regular season's league goalie table
+// built, checking every interval, so the page rarely has to wait for it.
+func startGoalieIndexer(interval time.Duration) {
+	go func() {
+		for {
+			if _, err := leagueGoalies(currentSeasonID(), 2); err != nil && !errors.Is(err, errStillBuilding) {
+				log.Printf("goalie index: %v", err)
+			}
+			time.Sleep(interval)
+		}
+	}()
+}
+
+func handleAPIGoalieAnalytics(w http.ResponseWriter, r *http.Request) {
+	playerID := mux.Vars(r)["playerId"]
+	if _, err := strconv.Atoi(playerID); err != nil {
+		http.Error(w, "invalid player id", http.StatusBadRequest)
+		return
+	}
+	season, gameType, ok := seasonAndGameType(w, r)
+	if !ok {
+		return
+	}
+	if !isGoalie(playerID) {
+		http.Error(w, "player is not a goalie", http.StatusNotFound)
+		return
+	}
+
+	a, err := cachedGoalieAnalytics(playerID, season, gameType)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to get goalie analytics: %v", err), http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(a); err != nil {
+		log.Printf("Error writing goalie analytics JSON: %v", err)
+	}
+}
+
+func handleAPILeagueGoalies(w http.ResponseWriter, r *http.Request) {
+	season, gameType, ok := seasonAndGameType(w, r)
+	if !ok {
+		return
+	}
+	minStarts := defaultGoalieMinStarts
+	if v := r.URL.Query().Get("minStarts"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			http.Error(w, "minStarts must be a positive number", http.StatusBadRequest)
+			return
+		}
+		minStarts = n
+	}
+
+	lg, err := leagueGoalies(season, gameType)
+	if errors.Is(err, errStillBuilding) {
+		writeStillBuilding(w)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	lg.qualified(minStarts)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(lg); err != nil {
+		log.Printf("Error writing league goalies JSON: %v", err)
+	}
+}
@@ -0,0 +1,738 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"math"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	gridSize          = 3
+	minCellAnswers    = 3 // known players per cell, so no cell hinges on one name
+	maxGridAttempts   = 500
+	gridRandomTTL     = 24 * time.Hour
+	gridPicksTTL      = 30 * 24 * time.Hour
+	gridTopPicks      = 5
+	gridTeamPrefix    = "team:"
+	minGridIndexSize  = 200
+	maxGridGuessBytes = 1 << 10
+	maxMemoryGrids    = 1000
+	gridBoardTTL      = 7 * 24 * time.Hour
+)
+
+// errGridIndexBuilding is returned while the career index is too small to
+// build a grid from.
+var errGridIndexBuilding = errors.New("player career index is still building")
+
+// errUnknownGrid is returned for malformed grid ids.
+var errUnknownGrid = errors.New("unknown grid")
+
+var (
+	// errGridCellFilled is returned for a second answer to a cell.
+	errGridCellFilled = errors.New("you already filled this cell")
+	// errGridPlayerUsed is returned when a player already fills another cell.
+	errGridPlayerUsed = errors.New("you already used this player on this grid")
+)
+
+// gridMemory keeps generated grids when Redis is off, so a grid id keeps
+// naming the same puzzle while the career index grows.
+var gridMemory = struct {
+	sync.Mutex
+	grids map[string]memoryGrid
+}{grids: map[string]memoryGrid{}}
+
+type memoryGrid struct {
+	grid    *GridPuzzle
+	expires time.Time // zero for daily grids, which never change
+}
+
+// gridCriterion is a non-team grid header, matched against a player's
+// landing and their NHL regular seasons (merged across mid-season trades).
+type gridCriterion struct {
+	Key   string
+	Label string
+	match func(l *PlayerLanding, seasons []PlayerStatLine) bool
+}
+
+func anySeason(pred func(PlayerStatLine) bool) func(*PlayerLanding, []PlayerStatLine) bool {
+	return func(_ *PlayerLanding, seasons []PlayerStatLine) bool {
+		for _, s := range seasons {
+			if pred(s) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func bornIn(country string) func(*PlayerLanding, []PlayerStatLine) bool {
+	return func(l *PlayerLanding, _ []PlayerStatLine) bool { return l.BirthCountry == country }
+}
+
+var gridCriteria = []gridCriterion{
+	{"pts100", "100-point season", anySeason(func(s PlayerStatLine) bool { return s.Points >= 100 })},
+	{"g50", "50-goal season", anySeason(func(s PlayerStatLine) bool { return s.Goals >= 50 })},
+	{"g30", "30-goal season", anySeason(func(s PlayerStatLine) bool { return s.Goals >= 30 })},
+	{"a50", "50-assist season", anySeason(func(s PlayerStatLine) bool { return s.Assists >= 50 })},
+	{"w30", "30-win season (G)", anySeason(func(s PlayerStatLine) bool { return s.Wins >= 30 })},
+	{"gp1000", "1,000 career games", func(l *PlayerLanding, _ []PlayerStatLine) bool {
+		return l.CareerTotals.RegularSeason.GamesPlayed >= 1000
+	}},
+	{"pts500", "500 career points", func(l *PlayerLanding, _ []PlayerStatLine) bool {
+		return l.CareerTotals.RegularSeason.Points >= 500
+	}},
+	{"first-overall", "1st overall pick", func(l *PlayerLanding, _ []PlayerStatLine) bool {
+		return l.DraftDetails != nil && l.DraftDetails.OverallPick == 1
+	}},
+	{"first-round", "1st-round pick", func(l *PlayerLanding, _ []PlayerStatLine) bool {
+		return l.DraftDetails != nil && l.DraftDetails.Round == 1
+	}},
+	{"undrafted", "Undrafted", func(l *PlayerLanding, _ []PlayerStatLine) bool { return l.DraftDetails == nil }},
+	{"defense", "Defenseman", func(l *PlayerLanding, _ []PlayerStatLine) bool { return l.Position == "D" }},
+	{"goalie", "Goalie", func(l *PlayerLanding, _ []PlayerStatLine) bool { return l.Position == "G" }},
+	{"born-USA", "Born in the USA", bornIn("USA")},
+	{"born-SWE", "Born in Sweden", bornIn("SWE")},
+	{"born-FIN", "Born in Finland", bornIn("FIN")},
+	{"born-RUS", "Born in Russia", bornIn("RUS")},
+	{"born-CZE", "Born in Czechia", bornIn("CZE")},
+}
+
+// careerProfile is what the grid knows about a player: every header key
+// (team:ABBR or a criterion key) they satisfy.
+type careerProfile struct {
+	ID       int
+	Name     string
+	Headshot string
+	Keys     map[string]bool
+}
+
+// seasonTeamAbbrev resolves a season row's club the way /api/player
+// enriches seasonTotals: by team name, falling back to team id.
+func seasonTeamAbbrev(st PlayerSeasonTotal) string {
+	if abbr, ok := teamNameToAbbr[st.TeamName.Default]; ok {
+		return abbr
+	}
+	return teamIDToAbbr[st.TeamID]
+}
+
+// buildCareerProfile indexes a landing's NHL regular-season career. Players
+// with no NHL games are skipped.
+func buildCareerProfile(l *PlayerLanding) *careerProfile {
+	p := &careerProfile{
+		ID:       l.PlayerID,
+		Name:     strings.TrimSpace(l.FirstName.Default + " " + l.LastName.Default),
+		Headshot: l.Headshot,
+		Keys:     map[string]bool{},
+	}
+	bySeason := map[int]PlayerStatLine{}
+	for _, st := range l.SeasonTotals {
+		if st.LeagueAbbrev != "NHL" || st.GameTypeID != 2 {
+			continue
+		}
+		if abbr := seasonTeamAbbrev(st); abbr != "" {
+			p.Keys[gridTeamPrefix+abbr] = true
+		}
+		bySeason[st.Season] = mergeStatLine(bySeason[st.Season], st.PlayerStatLine)
+	}
+	if len(bySeason) == 0 {
+		return nil
+	}
+	seasons := make([]PlayerStatLine, 0, len(bySeason))
+	for _, s := range bySeason {
+		seasons = append(seasons, s)
+	}
+	for _, c := range gridCriteria {
+		if c.match(l, seasons) {
+			p.Keys[c.Key] = true
+		}
+	}
+	return p
+}
+
+// careerIndex holds a profile for every player on a current roster plus any
+// landing already cached, rebuilt in the background.
+type careerIndex struct {
+	mu      sync.RWMutex
+	players map[int]*careerProfile
+}
+
+var gridCareers = &careerIndex{players: map[int]*careerProfile{}}
+
+func (idx *careerIndex) get(id int) *careerProfile {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.players[id]
+}
+
+func (idx *careerIndex) size() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.players)
+}
+
+func (idx *careerIndex) put(p *careerProfile) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.players[p.ID] = p
+}
+
+// sorted returns the indexed profiles by id, so a seed picks the same grid
+// from the same index.
+func (idx *careerIndex) sorted() []*careerProfile {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	out := make([]*careerProfile, 0, len(idx.players))
+	for _, p := range idx.players {
+		out = append(out, p)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// profileFor returns a player's profile, loading and indexing their landing
+// when they are not indexed yet.
+func (idx *careerIndex) profileFor(id int) (*careerProfile, error) {
+	if p := idx.get(id); p != nil {
+		return p, nil
+	}
+	l, err := GetPlayerLanding(strconv.Itoa(id))
+	if err != nil {
+		return nil, err
+	}
+	p := buildCareerProfile(l)
+	if p == nil {
+		return nil, fmt.Errorf("player %d has no NHL career", id)
+	}
+	idx.put(p)
+	return p, nil
+}
+
+// indexCareers adds every current roster player and every cached landing.
+func (idx *careerIndex) indexCareers() {
+	abbrevs := make([]string, 0, len(abbrevToTeamID))
+	for abbr := range abbrevToTeamID {
+		abbrevs = append(abbrevs, abbr)
+	}
+	sort.Strings(abbrevs)
+	for _, abbr := range abbrevs {
+		roster, err := GetRoster(abbr)
+		if err != nil {
+			log.Printf("career index: roster %s: %v", abbr, err)
+			continue
+		}
+		for _, rp := range roster.Players {
+			l, err := GetPlayerLanding(strconv.Itoa(rp.ID))
+			if err != nil {
+				continue
+			}
+			if p := buildCareerProfile(l); p != nil {
+				idx.put(p)
+			}
+		}
+	}
+
+	if redisClient == nil {
+		return
+	}
+	iter := redisClient.Scan(redisCtx, 0, "player:*", 500).Iterator()
+	for iter.Next(redisCtx) {
+		id, err := strconv.Atoi(strings.TrimPrefix(iter.Val(), "player:"))
+		if err != nil || idx.get(id) != nil {
+			continue
+		}
+		data, err := getCachedRaw(iter.Val())
+		if err != nil {
+			continue
+		}
+		var l PlayerLanding
+		if err := json.Unmarshal(data, &l); err != nil || l.PlayerID == 0 {
+			continue
+		}
+		if p := buildCareerProfile(&l); p != nil {
+			idx.put(p)
+		}
+	}
+	if err := iter.Err(); err != nil {
+		log.Printf("career index: scanning player landings: %v", err)
+	}
+}
+
+// startCareerIndexer builds the career index in the background and refreshes
+// it every interval.
+func startCareerIndexer(interval time.Duration) {
+	go func() {
+		for {
+			start := time.Now()
+			gridCareers.indexCareers()
+			log.Printf("career index: %d players in %s", gridCareers.size(), time.Since(start).Round(time.Millisecond))
+			time.Sleep(interval)
+		}
+	}()
+}
+
+// GridHeader is a row or column of the grid: a team or a criterion.
+type GridHeader struct {
+	Key   string `json:"key"`
+	Type  string `json:"type"` // team or criterion
+	Label string `json:"label"`
+	Team  string `json:"team,omitempty"`
+}
+
+// GridPuzzle is a solvable grid. Answers counts the indexed players that fit
+// each cell when the grid was made, row by row.
+type GridPuzzle struct {
+	ID      string       `json:"id"`
+	Date    string       `json:"date,omitempty"`
+	Rows    []GridHeader `json:"rows"`
+	Cols    []GridHeader `json:"cols"`
+	Answers [][]int      `json:"answers"`
+}
+
+func teamHeader(abbr string) GridHeader {
+	label := teamFullName(abbr)
+	return GridHeader{Key: gridTeamPrefix + abbr, Type: "team", Label: label, Team: abbr}
+}
+
+// gridSolvable reports whether every cell can get a different player, by
+// finding a perfect matching of cells to players with augmenting paths.
+func gridSolvable(cells [][]int) bool {
+	owner := map[int]int{} // player id -> cell
+	var try func(cell int, seen map[int]bool) bool
+	try = func(cell int, seen map[int]bool) bool {
+		for _, id := range cells[cell] {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			prev, taken := owner[id]
+			if !taken || try(prev, seen) {
+				owner[id] = cell
+				return true
+			}
+		}
+		return false
+	}
+	for cell := range cells {
+		if !try(cell, map[int]bool{}) {
+			return false
+		}
+	}
+	return true
+}
+
+// generateGrid draws grids from a seed until one is solvable with at least
+// minCellAnswers known players per cell. Rows are teams; columns mix teams
+// and one or two criteria.
+func generateGrid(id, date string, seed int64, players []*careerProfile) (*GridPuzzle, error) {
+	if len(players) < minGridIndexSize {
+		return nil, errGridIndexBuilding
+	}
+	rng := rand.New(rand.NewSource(seed))
+	teams := make([]string, 0, len(abbrevToTeamID))
+	for abbr := range abbrevToTeamID {
+		teams = append(teams, abbr)
+	}
+	sort.Strings(teams)
+
+	for attempt := 0; attempt < maxGridAttempts; attempt++ {
+		order := rng.Perm(len(teams))
+		criteria := rng.Perm(len(gridCriteria))
+		numCriteria := 1 + rng.Intn(2)
+
+		g := &GridPuzzle{ID: id, Date: date}
+		for i := 0; i < gridSize; i++ {
+			g.Rows = append(g.Rows, teamHeader(teams[order[i]]))
+		}
+		for i := 0; i < gridSize-numCriteria; i++ {
+			g.Cols = append(g.Cols, teamHeader(teams[order[gridSize+i]]))
+		}
+		for i := 0; i < numCriteria; i++ {
+			c := gridCriteria[criteria[i]]
+			g.Cols = append(g.Cols, GridHeader{Key: c.Key, Type: "criterion", Label: c.Label})
+		}
+		rng.Shuffle(len(g.Cols), func(i, j int) { g.Cols[i], g.Cols[j] = g.Cols[j], g.Cols[i] })
+
+		cells := make([][]int, 0, gridSize*gridSize)
+		ok := true
+		g.Answers = make([][]int, gridSize)
+		for r := 0; r < gridSize && ok; r++ {
+			g.Answers[r] = make([]int, gridSize)
+			for c := 0; c < gridSize; c++ {
+				var fits []int
+				for _, p := range players {
+					if p.Keys[g.Rows[r].Key] && p.Keys[g.Cols[c].Key] {
+						fits = append(fits, p.ID)
+					}
+				}
+				if len(fits) < minCellAnswers {
+					ok = false
+					break
+				}
+				g.Answers[r][c] = len(fits)
+				cells = append(cells, fits)
+			}
+		}
+		if ok && gridSolvable(cells) {
+			return g, nil
+		}
+	}
+	return nil, fmt.Errorf("no solvable grid found for seed %d", seed)
+}
+
+func gridSeed(s string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte("grid|" + s))
+	return int64(h.Sum64() & (1<<63 - 1))
+}
+
+// loadGrid returns a grid by id ("daily-YYYYMMDD" or "r{seed}"), generating
+// and storing it on first use. Daily grids are kept for good so the puzzle
+// does not shift as the career index grows.
+func loadGrid(id string) (*GridPuzzle, error) {
+	var (
+		seed int64
+		date string
+		ttl  = gridRandomTTL
+	)
+	switch {
+	case strings.HasPrefix(id, "daily-"):
+		date = strings.TrimPrefix(id, "daily-")
+		if _, err := time.Parse("20060102", date); err != nil {
+			return nil, errUnknownGrid
+		}
+		seed, ttl = gridSeed(date), determineTTL("static")
+	case strings.HasPrefix(id, "r"):
+		s, err := strconv.ParseInt(strings.TrimPrefix(id, "r"), 10, 64)
+		if err != nil || s < 0 {
+			return nil, errUnknownGrid
+		}
+		seed = s
+	default:
+		return nil, errUnknownGrid
+	}
+
+	if redisClient == nil {
+		return rememberGrid(id, ttl, func() (*GridPuzzle, error) {
+			return generateGrid(id, date, seed, gridCareers.sorted())
+		})
+	}
+	data, err := getCachedOrFetchWithBackoff("grid:"+id, func() ([]byte, error) {
+		g, err := generateGrid(id, date, seed, gridCareers.sorted())
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(g)
+	}, ttl)
+	if err != nil {
+		return nil, err
+	}
+	var g GridPuzzle
+	if err := json.Unmarshal(data, &g); err != nil {
+		return nil, fmt.Errorf("parsing cached grid: %w", err)
+	}
+	return &g, nil
+}
+
+// rememberGrid returns the in-memory copy of a grid, generating it on first
+// use. Expired random grids are dropped first, and random grids stop being
+// remembered once maxMemoryGrids are held.
+func rememberGrid(id string, ttl time.Duration, generate func() (*GridPuzzle, error)) (*GridPuzzle, error) {
+	gridMemory.Lock()
+	if m, ok := gridMemory.grids[id]; ok && (m.expires.IsZero() || time.Now().Before(m.expires)) {
+		gridMemory.Unlock()
+		return m.grid, nil
+	}
+	gridMemory.Unlock()
+
+	g, err := generate()
+	if err != nil {
+		return nil, err
+	}
+	gridMemory.Lock()
+	defer gridMemory.Unlock()
+	now := time.Now()
+	for k, m := range gridMemory.grids {
+		if !m.expires.IsZero() && !now.Before(m.expires) {
+			delete(gridMemory.grids, k)
+		}
+	}
+	// Another request may have generated it meanwhile; the first one wins.
+	if m, ok := gridMemory.grids[id]; ok {
+		return m.grid, nil
+	}
+	m := memoryGrid{grid: g}
+	if ttl > 0 {
+		m.expires = now.Add(ttl)
+	}
+	if m.expires.IsZero() || len(gridMemory.grids) < maxMemoryGrids {
+		gridMemory.grids[id] = m
+	}
+	return g, nil
+}
+
+func writeGridError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errUnknownGrid):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, errGridIndexBuilding):
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+	default:
+		http.Error(w, err.Error(), http.StatusBadGateway)
+	}
+}
+
+func writeGridJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Error writing grid JSON: %v", err)
+	}
+}
+
+// handleAPIDailyGrid returns the day's grid, the same for everyone.
+// Optional query param: date (YYYYMMDD, today or earlier).
+func handleAPIDailyGrid(w http.ResponseWriter, r *http.Request) {
+	today := time.Now().Format("20060102")
+	date := r.URL.Query().Get("date")
+	if date == "" {
+		date = today
+	}
+	if _, err := time.Parse("20060102", date); err != nil || date > today {
+		http.Error(w, "invalid date", http.StatusBadRequest)
+		return
+	}
+	g, err := loadGrid("daily-" + date)
+	if err != nil {
+		writeGridError(w, err)
+		return
+	}
+	writeGridJSON(w, g)
+}
+
+// handleAPIRandomGrid returns a fresh practice grid.
+func handleAPIRandomGrid(w http.ResponseWriter, r *http.Request) {
+	g, err := loadGrid(fmt.Sprintf("r%d", rand.Int63n(1<<40)))
+	if err != nil {
+		writeGridError(w, err)
+		return
+	}
+	writeGridJSON(w, g)
+}
+
+func handleAPIGrid(w http.ResponseWriter, r *http.Request) {
+	g, err := loadGrid(mux.Vars(r)["gridId"])
+	if err != nil {
+		writeGridError(w, err)
+		return
+	}
+	writeGridJSON(w, g)
+}
+
+// GridGuessResult is the verdict on one guess. Rarity is the share of
+// correct answers to the cell that named this player, including this one;
+// it is omitted when submissions cannot be recorded.
+type GridGuessResult struct {
+	Correct  bool     `json:"correct"`
+	PlayerID int      `json:"playerId"`
+	Name     string   `json:"name"`
+	Headshot string   `json:"headshot,omitempty"`
+	Rarity   *float64 `json:"rarity,omitempty"`
+	Picks    int64    `json:"picks,omitempty"`
+	Total    int64    `json:"total,omitempty"`
+}
+
+func gridPicksKey(gridID string) string {
+	return "grid-picks:" + gridID
+}
+
+// recordGridPick counts a correct answer for a cell and returns how many
+// times this player and the cell as a whole have been answered.
+func recordGridPick(gridID string, row, col, playerID int) (picks, total int64, err error) {
+	if redisClient == nil {
+		return 0, 0, fmt.Errorf("redis not available")
+	}
+	key := gridPicksKey(gridID)
+	cell := fmt.Sprintf("%d%d", row, col)
+	pipe := redisClient.TxPipeline()
+	pickCmd := pipe.HIncrBy(redisCtx, key, fmt.Sprintf("pick:%s:%d", cell, playerID), 1)
+	totalCmd := pipe.HIncrBy(redisCtx, key, "cell:"+cell, 1)
+	pipe.Expire(redisCtx, key, gridPicksTTL)
+	if _, err := pipe.Exec(redisCtx); err != nil {
+		return 0, 0, err
+	}
+	return pickCmd.Val(), totalCmd.Val(), nil
+}
+
+func gridBoardKey(gridID, client string) string {
+	return "grid-board:" + gridID + ":" + client
+}
+
+// claimGridCell records a client's correct answer to a cell. Each client
+// gets one answer per cell and each player once per board, so the same
+// pick cannot be counted twice toward a cell's rarity.
+func claimGridCell(gridID, client string, row, col, playerID int) error {
+	key := gridBoardKey(gridID, client)
+	cell := fmt.Sprintf("%d%d", row, col)
+	player := strconv.Itoa(playerID)
+	ok, err := redisClient.HSetNX(redisCtx, key, "player:"+player, cell).Result()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		if used, _ := redisClient.HGet(redisCtx, key, "player:"+player).Result(); used == cell {
+			return errGridCellFilled
+		}
+		return errGridPlayerUsed
+	}
+	ok, err = redisClient.HSetNX(redisCtx, key, "cell:"+cell, player).Result()
+	if err == nil && !ok {
+		err = errGridCellFilled
+	}
+	if err != nil {
+		redisClient.HDel(redisCtx, key, "player:"+player)
+		return err
+	}
+	redisClient.Expire(redisCtx, key, gridBoardTTL)
+	return nil
+}
+
+func rarityPct(picks, total int64) float64 {
+	return math.Round(float64(picks)/float64(total)*1000) / 10
+}
+
+// handleAPIGridGuess checks a guess ({"row","col","playerId"}) against the
+// cell's two headers and, when it is right, records it for rarity. A cell
+// the client already filled, or a player already on its board, gets a 409.
+func handleAPIGridGuess(w http.ResponseWriter, r *http.Request) {
+	g, err := loadGrid(mux.Vars(r)["gridId"])
+	if err != nil {
+		writeGridError(w, err)
+		return
+	}
+	var req struct {
+		Row      int `json:"row"`
+		Col      int `json:"col"`
+		PlayerID int `json:"playerId"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxGridGuessBytes)).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.Row < 0 || req.Row >= len(g.Rows) || req.Col < 0 || req.Col >= len(g.Cols) || req.PlayerID <= 0 {
+		http.Error(w, "invalid cell or player", http.StatusBadRequest)
+		return
+	}
+	p, err := gridCareers.profileFor(req.PlayerID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	res := GridGuessResult{
+		Correct:  p.Keys[g.Rows[req.Row].Key] && p.Keys[g.Cols[req.Col].Key],
+		PlayerID: p.ID,
+		Name:     p.Name,
+		Headshot: p.Headshot,
+	}
+	if res.Correct && redisClient != nil {
+		client, err := clientKey(w, r)
+		if err == nil {
+			err = claimGridCell(g.ID, client, req.Row, req.Col, p.ID)
+		}
+		if errors.Is(err, errGridCellFilled) || errors.Is(err, errGridPlayerUsed) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		picks, total, err := recordGridPick(g.ID, req.Row, req.Col, p.ID)
+		if err != nil {
+			log.Printf("grid %s: recording pick: %v", g.ID, err)
+		} else {
+			rarity := rarityPct(picks, total)
+			res.Rarity, res.Picks, res.Total = &rarity, picks, total
+		}
+	}
+	writeGridJSON(w, res)
+}
+
+// GridCellStats is one cell's submissions: total correct answers and the
+// most common picks.
+type GridCellStats struct {
+	Row   int               `json:"row"`
+	Col   int               `json:"col"`
+	Total int64             `json:"total"`
+	Top   []GridGuessResult `json:"top"`
+}
+
+// handleAPIGridStats returns every cell's most common answers with their
+// share of the cell's submissions.
+func handleAPIGridStats(w http.ResponseWriter, r *http.Request) {
+	g, err := loadGrid(mux.Vars(r)["gridId"])
+	if err != nil {
+		writeGridError(w, err)
+		return
+	}
+	if redisClient == nil {
+		http.Error(w, "grid stats unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	fields, err := redisClient.HGetAll(redisCtx, gridPicksKey(g.ID)).Result()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	cells := make([]GridCellStats, 0, gridSize*gridSize)
+	for row := range g.Rows {
+		for col := range g.Cols {
+			cell := fmt.Sprintf("%d%d", row, col)
+			stats := GridCellStats{Row: row, Col: col, Top: []GridGuessResult{}}
+			stats.Total, _ = strconv.ParseInt(fields["cell:"+cell], 10, 64)
+			prefix := "pick:" + cell + ":"
+			for k, v := range fields {
+				if !strings.HasPrefix(k, prefix) {
+					continue
+				}
+				id, err := strconv.Atoi(strings.TrimPrefix(k, prefix))
+				if err != nil {
+					continue
+				}
+				n, _ := strconv.ParseInt(v, 10, 64)
+				stats.Top = append(stats.Top, GridGuessResult{Correct: true, PlayerID: id, Picks: n, Total: stats.Total})
+			}
+			sort.Slice(stats.Top, func(i, j int) bool {
+				if stats.Top[i].Picks != stats.Top[j].Picks {
+					return stats.Top[i].Picks > stats.Top[j].Picks
+				}
+				return stats.Top[i].PlayerID < stats.Top[j].PlayerID
+			})
+			if len(stats.Top) > gridTopPicks {
+				stats.Top = stats.Top[:gridTopPicks]
+			}
+			for i := range stats.Top {
+				t := &stats.Top[i]
+				rarity := rarityPct(t.Picks, stats.Total)
+				t.Rarity = &rarity
+				if p := gridCareers.get(t.PlayerID); p != nil {
+					t.Name, t.Headshot = p.Name, p.Headshot
+				}
+			}
+			cells = append(cells, stats)
+		}
+	}
+	writeGridJSON(w, map[string]interface{}{"id": g.ID, "cells": cells})
+}
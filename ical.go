@@ -0,0 +1,337 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	icalGameLength = 3 * time.Hour
+	icalMaxSeasons = 3
+	icalLineOctets = 75
+	icalCacheAge   = 15 * time.Minute
+)
+
+// icalFilter narrows a calendar feed. Dates are the games' local dates,
+// inclusive.
+type icalFilter struct {
+	homeOnly bool
+	awayOnly bool
+	playoffs bool
+	from, to string
+	season   string
+}
+
+func queryFlag(r *http.Request, name string) bool {
+	v, _ := strconv.ParseBool(r.URL.Query().Get(name))
+	return v
+}
+
+// parseICalFilter reads ?home=1, ?away=1, ?playoffs=1, ?from=, ?to= and
+// ?season=.
+func parseICalFilter(r *http.Request) (*icalFilter, error) {
+	q := r.URL.Query()
+	f := &icalFilter{
+		homeOnly: queryFlag(r, "home"),
+		awayOnly: queryFlag(r, "away"),
+		playoffs: queryFlag(r, "playoffs"),
+		from:     q.Get("from"),
+		to:       q.Get("to"),
+		season:   q.Get("season"),
+	}
+	if f.homeOnly && f.awayOnly {
+		return nil, fmt.Errorf("use either home or away, not both")
+	}
+	for _, d := range []string{f.from, f.to} {
+		if _, err := time.Parse("2006-01-02", d); d != "" && err != nil {
+			return nil, fmt.Errorf("from and to must be YYYY-MM-DD")
+		}
+	}
+	if f.from != "" && f.to != "" && f.from > f.to {
+		return nil, fmt.Errorf("from is after to")
+	}
+	if f.season != "" && (f.from != "" || f.to != "") {
+		return nil, fmt.Errorf("use either season or from/to, not both")
+	}
+	if f.season != "" && !validSeason(f.season) {
+		return nil, fmt.Errorf("season must look like 20242025")
+	}
+	if len(f.seasons()) > icalMaxSeasons {
+		return nil, fmt.Errorf("from/to may span at most %d seasons", icalMaxSeasons)
+	}
+	return f, nil
+}
+
+// seasonForDate is the season a game date belongs to, rolling over when
+// currentSeasonID does.
+func seasonForDate(date string) int {
+	d, _ := time.Parse("2006-01-02", date)
+	y := seasonStartYear(d)
+	return y*10000 + y + 1
+}
+
+// seasons lists the club-schedule seasons the filter needs: the requested
+// one, those spanned by from/to, or the current season.
+func (f *icalFilter) seasons() []string {
+	if f.season != "" {
+		return []string{f.season}
+	}
+	if f.from == "" && f.to == "" {
+		return []string{""}
+	}
+	first, last := 0, 0
+	if f.from != "" {
+		first = seasonForDate(f.from)
+	}
+	if f.to != "" {
+		last = seasonForDate(f.to)
+	}
+	current, _ := strconv.Atoi(currentSeasonID())
+	if first == 0 {
+		first = last
+		if current < last {
+			first = current
+		}
+	}
+	if last == 0 {
+		last = first
+		if current > first {
+			last = current
+		}
+	}
+	var out []string
+	for s := first; s <= last; s += 10001 {
+		out = append(out, strconv.Itoa(s))
+	}
+	return out
+}
+
+func (f *icalFilter) keep(g ClubScheduleGame, teams []string) bool {
+	if f.playoffs && g.GameType != 3 {
+		return false
+	}
+	if f.from != "" && g.GameDate < f.from || f.to != "" && g.GameDate > f.to {
+		return false
+	}
+	home := containsString(teams, g.HomeTeam.Abbrev)
+	away := containsString(teams, g.AwayTeam.Abbrev)
+	if f.homeOnly && !home || f.awayOnly && !away {
+		return false
+	}
+	return true
+}
+
+// icalGames collects the filtered games of several teams, each game once,
+// in start order. Any failed fetch fails the feed: calendar apps keep their
+// previous copy on an error but would delete games missing from a partial
+// feed.
+func icalGames(teams []string, f *icalFilter) ([]ClubScheduleGame, error) {
+	seen := make(map[int64]bool)
+	var games []ClubScheduleGame
+	for _, abbr := range teams {
+		for _, season := range f.seasons() {
+			sched, err := GetClubSchedule(abbr, season)
+			if err != nil {
+				return nil, err
+			}
+			for _, g := range sched.Games {
+				if seen[g.ID] || !f.keep(g, teams) {
+					continue
+				}
+				seen[g.ID] = true
+				games = append(games, g)
+			}
+		}
+	}
+	sort.SliceStable(games, func(i, j int) bool {
+		if games[i].StartTimeUTC != games[j].StartTimeUTC {
+			return games[i].StartTimeUTC < games[j].StartTimeUTC
+		}
+		return games[i].ID < games[j].ID
+	})
+	return games, nil
+}
+
+// icalEscape escapes a TEXT value (RFC 5545 §3.3.11).
+func icalEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// writeICalLine writes one content line, folded at 75 octets without
+// splitting a UTF-8 sequence.
+func writeICalLine(b *strings.Builder, line string) {
+	limit := icalLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// Continuation lines start with a space, which counts
+		limit = icalLineOctets - 1
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
+
+// gameTitle is a game's one-line title for calendars and feeds, with the
+// score once it has started.
+func gameTitle(g ClubScheduleGame) string {
+	var prefix string
+	switch g.GameType {
+	case 1:
+		prefix = "Preseason: "
+	case 3:
+		prefix = "Playoffs: "
+	}
+	away, home := g.AwayTeam.Abbrev, g.HomeTeam.Abbrev
+	switch {
+	case g.GameState == "PPD":
+		return "Postponed: " + away + " @ " + home
+	case isGameFinal(g.GameState):
+		final := "Final"
+		if t := g.GameOutcome.LastPeriodType; t == "OT" || t == "SO" {
+			final += "/" + t
+		}
+		return fmt.Sprintf("%s%s %d @ %s %d (%s)", prefix, away, g.AwayTeam.Score, home, g.HomeTeam.Score, final)
+	case isGameLive(g.GameState):
+		return fmt.Sprintf("%s%s %d @ %s %d (Live)", prefix, away, g.AwayTeam.Score, home, g.HomeTeam.Score)
+	}
+	return prefix + away + " @ " + home
+}
+
+func icalTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// writeICalEvent writes one game as a VEVENT. UIDs are derived from the
+// game id alone, so a rescheduled or finished game updates the existing
+// calendar entry instead of adding a new one.
+func writeICalEvent(b *strings.Builder, g ClubScheduleGame, base string, stamp time.Time) {
+	start, err := time.Parse(time.RFC3339, g.StartTimeUTC)
+	if err != nil {
+		return
+	}
+	desc := []string{fmt.Sprintf("%s at %s", teamFullName(g.AwayTeam.Abbrev), teamFullName(g.HomeTeam.Abbrev))}
+	var tv []string
+	for _, bc := range g.TVBroadcasts {
+		if !containsString(tv, bc.Network) {
+			tv = append(tv, bc.Network)
+		}
+	}
+	if len(tv) > 0 {
+		desc = append(desc, "TV: "+strings.Join(tv, ", "))
+	}
+	link := fmt.Sprintf("%s/game/%d", base, g.ID)
+	desc = append(desc, link)
+
+	status := "CONFIRMED"
+	if g.GameState == "PPD" {
+		status = "TENTATIVE"
+	}
+	writeICalLine(b, "BEGIN:VEVENT")
+	writeICalLine(b, fmt.Sprintf("UID:nhl-game-%d@hockey", g.ID))
+	writeICalLine(b, "DTSTAMP:"+icalTime(stamp))
+	writeICalLine(b, "DTSTART:"+icalTime(start))
+	writeICalLine(b, "DTEND:"+icalTime(start.Add(icalGameLength)))
+	writeICalLine(b, "SUMMARY:"+icalEscape(gameTitle(g)))
+	if g.Venue.Default != "" {
+		writeICalLine(b, "LOCATION:"+icalEscape(g.Venue.Default))
+	}
+	writeICalLine(b, "DESCRIPTION:"+icalEscape(strings.Join(desc, "\n")))
+	writeICalLine(b, "URL:"+link)
+	writeICalLine(b, "STATUS:"+status)
+	writeICalLine(b, "CATEGORIES:"+icalEscape(g.AwayTeam.Abbrev)+","+icalEscape(g.HomeTeam.Abbrev))
+	writeICalLine(b, "END:VEVENT")
+}
+
+// buildICal renders a VCALENDAR for the games.
+func buildICal(name string, games []ClubScheduleGame, base string) string {
+	var b strings.Builder
+	stamp := time.Now()
+	writeICalLine(&b, "BEGIN:VCALENDAR")
+	writeICalLine(&b, "VERSION:2.0")
+	writeICalLine(&b, "PRODID:-//hockey//NHL schedule//EN")
+	writeICalLine(&b, "CALSCALE:GREGORIAN")
+	writeICalLine(&b, "METHOD:PUBLISH")
+	writeICalLine(&b, "X-WR-CALNAME:"+icalEscape(name))
+	writeICalLine(&b, "REFRESH-INTERVAL;VALUE=DURATION:PT1H")
+	writeICalLine(&b, "X-PUBLISHED-TTL:PT1H")
+	for _, g := range games {
+		writeICalEvent(&b, g, base, stamp)
+	}
+	writeICalLine(&b, "END:VCALENDAR")
+	return b.String()
+}
+
+func serveICal(w http.ResponseWriter, r *http.Request, teams []string, name, filename string) {
+	filter, err := parseICalFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	games, err := icalGames(teams, filter)
+	if err != nil {
+		log.Printf("Error building calendar for %v: %v", teams, err)
+		http.Error(w, "Failed to fetch schedule", http.StatusBadGateway)
+		return
+	}
+	if filter.homeOnly {
+		name += " (home)"
+	} else if filter.awayOnly {
+		name += " (away)"
+	}
+	if filter.playoffs {
+		name += " Playoffs"
+	}
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", filename))
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(icalCacheAge.Seconds())))
+	if _, err := w.Write([]byte(buildICal(name, games, publicBaseURL(r)))); err != nil {
+		log.Printf("Error writing calendar: %v", err)
+	}
+}
+
+// handleICalTeam serves /ical/team/{teamId}.ics, a subscribable calendar
+// of one team's schedule.
+func handleICalTeam(w http.ResponseWriter, r *http.Request) {
+	abbr, ok := resolveTeamAbbrev(mux.Vars(r)["teamId"])
+	if !ok {
+		http.Error(w, "Unknown team", http.StatusNotFound)
+		return
+	}
+	serveICal(w, r, []string{abbr}, teamFullName(abbr), strings.ToLower(abbr)+".ics")
+}
+
+// handleICalTeams serves /ical/teams.ics?teams=TOR,MTL, one calendar for
+// several teams; games between two of them appear once.
+func handleICalTeams(w http.ResponseWriter, r *http.Request) {
+	var teams []string
+	for _, t := range strings.Split(r.URL.Query().Get("teams"), ",") {
+		if strings.TrimSpace(t) == "" {
+			continue
+		}
+		abbr, ok := resolveTeamAbbrev(t)
+		if !ok {
+			http.Error(w, fmt.Sprintf("Unknown team %q", t), http.StatusBadRequest)
+			return
+		}
+		if !containsString(teams, abbr) {
+			teams = append(teams, abbr)
+		}
+	}
+	if len(teams) == 0 {
+		http.Error(w, "teams must list at least one team, e.g. teams=TOR,MTL", http.StatusBadRequest)
+		return
+	}
+	serveICal(w, r, teams, strings.Join(teams, " · ")+" Schedule", "teams.ics")
+}
@@ -0,0 +1,346 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultLeadersLimit = 25
+	maxLeadersLimit     = 100
+)
+
+// leaderCategory maps one of our category names onto the upstream leaders
+// category and stats summary field that back it.
+type leaderCategory struct {
+	goalie     bool
+	leadersKey string // skater/goalie-stats-leaders category, "" if unsupported
+	statsField string // stats REST summary field
+	ascending  bool   // lower is better (GAA)
+	rate       bool   // needs a games-played qualifier
+}
+
+var leaderCategories = map[string]leaderCategory{
+	"goals":     {leadersKey: "goals", statsField: "goals"},
+	"assists":   {leadersKey: "assists", statsField: "assists"},
+	"points":    {leadersKey: "points", statsField: "points"},
+	"plusMinus": {leadersKey: "plusMinus", statsField: "plusMinus"},
+	"pim":       {leadersKey: "penaltyMins", statsField: "penaltyMinutes"},
+	"shots":     {statsField: "shots"},
+	"wins":      {goalie: true, leadersKey: "wins", statsField: "wins"},
+	"gaa":       {goalie: true, leadersKey: "goalsAgainstAverage", statsField: "goalsAgainstAverage", ascending: true, rate: true},
+	"savePct":   {goalie: true, leadersKey: "savePctg", statsField: "savePct", rate: true},
+	"shutouts":  {goalie: true, leadersKey: "shutouts", statsField: "shutouts"},
+}
+
+// LeadersFilter narrows a leaderboard.
+type LeadersFilter struct {
+	Position string `json:"position,omitempty"` // C, L, R, D or F (forwards)
+	Team     string `json:"team,omitempty"`
+	Rookie   bool   `json:"rookie,omitempty"`
+}
+
+// LeaderEntry is one row of a leaderboard.
+type LeaderEntry struct {
+	Rank        int     `json:"rank"`
+	PlayerID    int     `json:"playerId"`
+	Name        string  `json:"name"`
+	TeamAbbrev  string  `json:"teamAbbrev"`
+	Position    string  `json:"position,omitempty"`
+	Headshot    string  `json:"headshot,omitempty"`
+	GamesPlayed int     `json:"gamesPlayed,omitempty"`
+	Value       float64 `json:"value"`
+}
+
+// Leaders is the /api/leaders response.
+type Leaders struct {
+	Category string        `json:"category"`
+	Group    string        `json:"group"` // skaters or goalies
+	Season   string        `json:"season"`
+	GameType int           `json:"gameType"`
+	Filter   LeadersFilter `json:"filter"`
+	Source   string        `json:"source"` // leaders or stats
+	Leaders  []LeaderEntry `json:"leaders"`
+}
+
+// seasonTTL caches completed seasons without expiry.
+func seasonTTL(season string) time.Duration {
+	if season < currentSeasonID() {
+		return determineTTL("static")
+	}
+	return time.Hour
+}
+
+// fetchUpstreamLeaders reads the api-web leaders endpoint, which already
+// applies the league's qualifiers for rate stats.
+func fetchUpstreamLeaders(cat leaderCategory, season string, gameType, limit int) ([]LeaderEntry, error) {
+	group := "skater"
+	if cat.goalie {
+		group = "goalie"
+	}
+	cacheKey := fmt.Sprintf("leaders:%s:%s:%s:%d:%d", group, cat.leadersKey, season, gameType, limit)
+	data, err := getCachedOrFetchWithBackoff(cacheKey, func() ([]byte, error) {
+		return readURL(fmt.Sprintf("%s/%s-stats-leaders/%s/%d?categories=%s&limit=%d", BaseURL, group, season, gameType, cat.leadersKey, limit))
+	}, seasonTTL(season))
+	if err != nil {
+		return nil, err
+	}
+
+	var resp map[string][]struct {
+		ID         int             `json:"id"`
+		FirstName  LocalizedString `json:"firstName"`
+		LastName   LocalizedString `json:"lastName"`
+		Headshot   string          `json:"headshot"`
+		TeamAbbrev string          `json:"teamAbbrev"`
+		Position   string          `json:"position"`
+		Value      float64         `json:"value"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("parsing leaders response: %w", err)
+	}
+	var out []LeaderEntry
+	for _, p := range resp[cat.leadersKey] {
+		out = append(out, LeaderEntry{
+			PlayerID:   p.ID,
+			Name:       strings.TrimSpace(p.FirstName.Default + " " + p.LastName.Default),
+			TeamAbbrev: p.TeamAbbrev,
+			Position:   p.Position,
+			Headshot:   p.Headshot,
+			Value:      p.Value,
+		})
+	}
+	return out, nil
+}
+
+// fetchStatsSummary reads every skater or goalie summary row for a season
+// from the stats REST API. Rows are plain maps since the category picks
+// the field.
+func fetchStatsSummary(goalie bool, season string, gameType int, rookie bool) ([]map[string]interface{}, error) {
+	group := "skater"
+	if goalie {
+		group = "goalie"
+	}
+	exp := fmt.Sprintf("seasonId=%s and gameTypeId=%d", season, gameType)
+	if rookie {
+		exp += " and isRookie=1"
+	}
+	cacheKey := fmt.Sprintf("stats-summary:%s:%s:%d:%t", group, season, gameType, rookie)
+	data, err := getCachedOrFetchWithBackoff(cacheKey, func() ([]byte, error) {
+		return readURL(fmt.Sprintf("%s/%s/summary?limit=-1&cayenneExp=%s", StatsURL, group, url.QueryEscape(exp)))
+	}, seasonTTL(season))
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Data []map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("parsing stats summary: %w", err)
+	}
+	return resp.Data, nil
+}
+
+// matchesPosition reports whether a position code passes the filter.
+func matchesPosition(code, filter string) bool {
+	switch filter {
+	case "":
+		return true
+	case "F":
+		return code == "C" || code == "L" || code == "R"
+	}
+	return code == filter
+}
+
+// statsLeaders ranks stats summary rows by the category's field after
+// applying the filters.
+func statsLeaders(cat leaderCategory, season string, gameType int, filter LeadersFilter, limit int) ([]LeaderEntry, error) {
+	rows, err := fetchStatsSummary(cat.goalie, season, gameType, filter.Rookie)
+	if err != nil {
+		return nil, err
+	}
+
+	// Rate stats need a qualifier: a quarter of the busiest goalie's games.
+	minGP := 0
+	if cat.rate {
+		for _, row := range rows {
+			if gp := jsonInt(row["gamesPlayed"]); gp/4 > minGP {
+				minGP = gp / 4
+			}
+		}
+	}
+
+	nameField := "skaterFullName"
+	if cat.goalie {
+		nameField = "goalieFullName"
+	}
+	var out []LeaderEntry
+	for _, row := range rows {
+		position, _ := row["positionCode"].(string)
+		teams, _ := row["teamAbbrevs"].(string)
+		if !cat.goalie && !matchesPosition(position, filter.Position) {
+			continue
+		}
+		if filter.Team != "" && !containsTeam(teams, filter.Team) {
+			continue
+		}
+		gp := jsonInt(row["gamesPlayed"])
+		if gp < minGP || gp == 0 {
+			continue
+		}
+		v, ok := row[cat.statsField].(float64)
+		if !ok {
+			continue
+		}
+		name, _ := row[nameField].(string)
+		// Traded players list every club; the last one is the current team.
+		if i := strings.LastIndex(teams, ","); i >= 0 {
+			teams = teams[i+1:]
+		}
+		out = append(out, LeaderEntry{
+			PlayerID:    jsonInt(row["playerId"]),
+			Name:        name,
+			TeamAbbrev:  strings.TrimSpace(teams),
+			Position:    position,
+			GamesPlayed: gp,
+			Value:       v,
+		})
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Value != out[j].Value {
+			if cat.ascending {
+				return out[i].Value < out[j].Value
+			}
+			return out[i].Value > out[j].Value
+		}
+		return out[i].GamesPlayed < out[j].GamesPlayed
+	})
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+// containsTeam reports whether a comma-separated teamAbbrevs list includes team.
+func containsTeam(list, team string) bool {
+	for _, t := range strings.Split(list, ",") {
+		if strings.TrimSpace(t) == team {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonInt reads a JSON number decoded into interface{} as an int.
+func jsonInt(v interface{}) int {
+	if f, ok := v.(float64); ok {
+		return int(f)
+	}
+	return 0
+}
+
+// GetLeaders returns the leaderboard for category. Unfiltered requests use
+// the upstream leaders endpoint; filtered ones (and shots, which it lacks)
+// are ranked from the stats summary.
+func GetLeaders(category, season string, gameType int, filter LeadersFilter, limit int) (*Leaders, error) {
+	cat, ok := leaderCategories[category]
+	if !ok {
+		return nil, fmt.Errorf("unknown category %q", category)
+	}
+	out := &Leaders{
+		Category: category,
+		Group:    "skaters",
+		Season:   season,
+		GameType: gameType,
+		Filter:   filter,
+	}
+	if cat.goalie {
+		out.Group = "goalies"
+	}
+
+	var err error
+	if cat.leadersKey != "" && filter == (LeadersFilter{}) {
+		out.Source = "leaders"
+		out.Leaders, err = fetchUpstreamLeaders(cat, season, gameType, limit)
+	} else {
+		out.Source = "stats"
+		out.Leaders, err = statsLeaders(cat, season, gameType, filter, limit)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if out.Leaders == nil {
+		out.Leaders = []LeaderEntry{}
+	}
+	// Ties share a rank.
+	for i := range out.Leaders {
+		out.Leaders[i].Rank = i + 1
+		if i > 0 && out.Leaders[i].Value == out.Leaders[i-1].Value {
+			out.Leaders[i].Rank = out.Leaders[i-1].Rank
+		}
+	}
+	return out, nil
+}
+
+func handleAPILeaders(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	season, gameType, ok := seasonAndGameType(w, r)
+	if !ok {
+		return
+	}
+
+	category := q.Get("category")
+	if category == "" {
+		category = "points"
+	}
+	if _, ok := leaderCategories[category]; !ok {
+		http.Error(w, fmt.Sprintf("unknown category %q", category), http.StatusBadRequest)
+		return
+	}
+
+	filter := LeadersFilter{
+		Position: strings.ToUpper(q.Get("position")),
+		Rookie:   q.Get("rookie") == "true" || q.Get("rookie") == "1",
+	}
+	switch filter.Position {
+	case "", "C", "L", "R", "D", "F":
+	default:
+		http.Error(w, "position must be C, L, R, D or F", http.StatusBadRequest)
+		return
+	}
+	if t := q.Get("team"); t != "" {
+		abbr, ok := resolveTeamAbbrev(t)
+		if !ok {
+			http.Error(w, "unknown team", http.StatusBadRequest)
+			return
+		}
+		filter.Team = abbr
+	}
+
+	limit := defaultLeadersLimit
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxLeadersLimit {
+			http.Error(w, fmt.Sprintf("limit must be between 1 and %d", maxLeadersLimit), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	leaders, err := GetLeaders(category, season, gameType, filter, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(leaders); err != nil {
+		log.Printf("Error writing leaders JSON: %v", err)
+	}
+}
@@ -0,0 +1,581 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// shiftTypeCode marks a player shift in /shiftcharts; other type codes are
+// goal and penalty annotations.
+const shiftTypeCode = 517
+
+// How many units make up a dressed lineup, and how many season
+// combinations to list per unit type.
+const (
+	forwardLinesPerGame = 4
+	defensePairsPerGame = 3
+	specialUnitsPerGame = 2
+	frequentCombosLimit = 10
+)
+
+// Shift is one player shift from the stats REST shift chart.
+type Shift struct {
+	PlayerID   int    `json:"playerId"`
+	TeamID     int    `json:"teamId"`
+	TeamAbbrev string `json:"teamAbbrev"`
+	Period     int    `json:"period"`
+	StartTime  string `json:"startTime"`
+	EndTime    string `json:"endTime"`
+	TypeCode   int    `json:"typeCode"`
+}
+
+// GetShiftChart fetches every shift for a game. Shift charts are published
+// shortly after the horn, so a final game's chart is only cached without
+// expiry once it has shifts.
+func GetShiftChart(gameID string, final bool) ([]Shift, error) {
+	cacheKey := fmt.Sprintf("shifts:%s", gameID)
+	data, err := getCachedOrFetchWithBackoff(cacheKey, func() ([]byte, error) {
+		return readURL(fmt.Sprintf("%s/shiftcharts?cayenneExp=gameId=%s", StatsURL, gameID))
+	}, determineTTL("game"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shift chart for %s: %w", gameID, err)
+	}
+
+	var resp struct {
+		Data []Shift `json:"data"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("parsing shift chart response: %w", err)
+	}
+	if final && len(resp.Data) > 0 {
+		if setErr := setCachedRaw(cacheKey, data, determineTTL("static")); setErr != nil {
+			log.Printf("Failed to persist final shift chart for %s: %v", gameID, setErr)
+		}
+	}
+	shifts := resp.Data[:0]
+	for _, s := range resp.Data {
+		if s.TypeCode == shiftTypeCode {
+			shifts = append(shifts, s)
+		}
+	}
+	return shifts, nil
+}
+
+// LinePlayer is one player in a line combination.
+type LinePlayer struct {
+	PlayerID      int    `json:"playerId"`
+	Name          string `json:"name"`
+	Position      string `json:"position"`
+	SweaterNumber int    `json:"sweaterNumber"`
+}
+
+// LineCombo is a set of players and their time on ice together. Games
+// counts the games in which the set was one of the team's inferred units.
+type LineCombo struct {
+	Players []LinePlayer `json:"players"`
+	TOI     int          `json:"toi"` // seconds
+	Games   int          `json:"games,omitempty"`
+}
+
+// LineSet groups combinations by unit type.
+type LineSet struct {
+	Forwards    []LineCombo `json:"forwards"`
+	Defense     []LineCombo `json:"defense"`
+	PowerPlay   []LineCombo `json:"powerPlay"`
+	PenaltyKill []LineCombo `json:"penaltyKill"`
+}
+
+// GameLines is one team's inferred lineup for a game. Goalies lists the
+// starter (most TOI) first.
+type GameLines struct {
+	GameID     int64        `json:"gameId"`
+	GameDate   string       `json:"gameDate"`
+	TeamAbbrev string       `json:"teamAbbrev"`
+	Opponent   string       `json:"opponent"`
+	IsHome     bool         `json:"isHome"`
+	Goalies    []LinePlayer `json:"goalies"`
+	LineSet
+}
+
+// TeamLines is the /api/team/{id}/lines response.
+type TeamLines struct {
+	Team          string     `json:"team"`
+	Season        string     `json:"season"`
+	GameType      int        `json:"gameType"`
+	GamesAnalyzed int        `json:"gamesAnalyzed"`
+	LastGame      *GameLines `json:"lastGame"`
+	Frequent      LineSet    `json:"frequent"`
+}
+
+// unitKind indexes the four combination tables.
+type unitKind int
+
+const (
+	unitForwards unitKind = iota
+	unitDefense
+	unitPowerPlay
+	unitPenaltyKill
+)
+
+// comboTOI accumulates seconds together per combination key.
+type comboTOI map[string]int
+
+// comboKey is the sorted, comma-joined player ids of a combination.
+func comboKey(ids []int) string {
+	sorted := append([]int(nil), ids...)
+	sort.Ints(sorted)
+	parts := make([]string, len(sorted))
+	for i, id := range sorted {
+		parts[i] = strconv.Itoa(id)
+	}
+	return strings.Join(parts, ",")
+}
+
+func comboIDs(key string) []int {
+	parts := strings.Split(key, ",")
+	ids := make([]int, 0, len(parts))
+	for _, p := range parts {
+		if id, err := strconv.Atoi(p); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// gameTeamTOI is one team's combination TOI and goalie TOI for a game.
+type gameTeamTOI struct {
+	units  [4]comboTOI
+	goalie map[int]int
+}
+
+// shiftSeconds places a shift on the game clock. Regular-season shootouts
+// (period 5) are dropped.
+func shiftSeconds(s Shift, gameType int) (int, int, bool) {
+	if s.Period < 1 || (gameType != 3 && s.Period > 4) {
+		return 0, 0, false
+	}
+	base := (s.Period - 1) * 20 * 60
+	start, end := base+clockSeconds(s.StartTime), base+clockSeconds(s.EndTime)
+	return start, end, end > start
+}
+
+// computeShiftTOI sweeps a game's shifts into segments with a constant set of
+// players on the ice and credits each segment to the matching combination:
+// trios and pairs at 5v5 and full units on the power play and penalty kill.
+// Segments with an empty net are ignored.
+func computeShiftTOI(pbp *PlayByPlay, shifts []Shift) map[int]*gameTeamTOI {
+	positions := make(map[int]string, len(pbp.RosterSpots))
+	for _, rs := range pbp.RosterSpots {
+		positions[rs.PlayerID] = rs.PositionCode
+	}
+
+	type change struct {
+		at, delta, playerID, teamID int
+	}
+	var changes []change
+	for _, s := range shifts {
+		start, end, ok := shiftSeconds(s, pbp.GameType)
+		if !ok || positions[s.PlayerID] == "" {
+			continue
+		}
+		changes = append(changes, change{start, 1, s.PlayerID, s.TeamID}, change{end, -1, s.PlayerID, s.TeamID})
+	}
+	// Players leaving are applied before players arriving at the same second
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].at != changes[j].at {
+			return changes[i].at < changes[j].at
+		}
+		return changes[i].delta < changes[j].delta
+	})
+
+	out := map[int]*gameTeamTOI{}
+	for _, id := range []int{pbp.HomeTeam.ID, pbp.AwayTeam.ID} {
+		t := &gameTeamTOI{goalie: map[int]int{}}
+		for i := range t.units {
+			t.units[i] = comboTOI{}
+		}
+		out[id] = t
+	}
+
+	onIce := map[int]int{}
+	playerTeam := map[int]int{}
+	for i := 0; i < len(changes); {
+		at := changes[i].at
+		for ; i < len(changes) && changes[i].at == at; i++ {
+			c := changes[i]
+			onIce[c.playerID] += c.delta
+			playerTeam[c.playerID] = c.teamID
+			if onIce[c.playerID] <= 0 {
+				delete(onIce, c.playerID)
+			}
+		}
+		if i == len(changes) {
+			break
+		}
+		secs := changes[i].at - at
+		if secs <= 0 {
+			continue
+		}
+
+		skaters := map[int][]int{}
+		forwards := map[int][]int{}
+		defense := map[int][]int{}
+		goalies := map[int][]int{}
+		for id := range onIce {
+			team := playerTeam[id]
+			switch positions[id] {
+			case "G":
+				goalies[team] = append(goalies[team], id)
+				continue
+			case "D":
+				defense[team] = append(defense[team], id)
+			default:
+				forwards[team] = append(forwards[team], id)
+			}
+			skaters[team] = append(skaters[team], id)
+		}
+		for team, t := range out {
+			if len(goalies[team]) == 1 {
+				t.goalie[goalies[team][0]] += secs
+			}
+		}
+		if len(goalies[pbp.HomeTeam.ID]) != 1 || len(goalies[pbp.AwayTeam.ID]) != 1 {
+			continue
+		}
+		for team, t := range out {
+			opp := pbp.AwayTeam.ID
+			if team == opp {
+				opp = pbp.HomeTeam.ID
+			}
+			own, against := len(skaters[team]), len(skaters[opp])
+			switch {
+			case own == 5 && against == 5:
+				if len(forwards[team]) == 3 {
+					t.units[unitForwards][comboKey(forwards[team])] += secs
+				}
+				if len(defense[team]) == 2 {
+					t.units[unitDefense][comboKey(defense[team])] += secs
+				}
+			case own > against && against >= 3:
+				t.units[unitPowerPlay][comboKey(skaters[team])] += secs
+			case own < against && own >= 3:
+				t.units[unitPenaltyKill][comboKey(skaters[team])] += secs
+			}
+		}
+	}
+	return out
+}
+
+// pickUnits greedily takes the highest-TOI combinations that share no
+// players, up to limit.
+func pickUnits(toi comboTOI, limit int) []string {
+	keys := make([]string, 0, len(toi))
+	for k := range toi {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if toi[keys[i]] != toi[keys[j]] {
+			return toi[keys[i]] > toi[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	used := map[int]bool{}
+	var picked []string
+	for _, k := range keys {
+		if len(picked) == limit {
+			break
+		}
+		ids := comboIDs(k)
+		clash := false
+		for _, id := range ids {
+			if used[id] {
+				clash = true
+				break
+			}
+		}
+		if clash {
+			continue
+		}
+		for _, id := range ids {
+			used[id] = true
+		}
+		picked = append(picked, k)
+	}
+	return picked
+}
+
+// forwardSlotOrder sorts a forward trio as LW, C, RW using roster
+// positions, falling back to whoever is left for an unfilled slot.
+func forwardSlotOrder(players []LinePlayer) []LinePlayer {
+	if len(players) != 3 {
+		return players
+	}
+	slots := make([]*LinePlayer, 3)
+	want := map[string]int{"L": 0, "C": 1, "R": 2}
+	var rest []LinePlayer
+	for _, p := range players {
+		if i, ok := want[p.Position]; ok && slots[i] == nil {
+			p := p
+			slots[i] = &p
+		} else {
+			rest = append(rest, p)
+		}
+	}
+	out := make([]LinePlayer, 0, 3)
+	for _, s := range slots {
+		if s == nil {
+			s, rest = &rest[0], rest[1:]
+		}
+		out = append(out, *s)
+	}
+	return out
+}
+
+// unitPlayers resolves a combination key into players, forwards first.
+func unitPlayers(key string, players map[int]LinePlayer, kind unitKind) []LinePlayer {
+	var list []LinePlayer
+	for _, id := range comboIDs(key) {
+		list = append(list, players[id])
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		return (list[i].Position != "D") && (list[j].Position == "D")
+	})
+	if kind == unitForwards {
+		list = forwardSlotOrder(list)
+	}
+	return list
+}
+
+// gameRosterPlayers maps the dressed players of a game for one team.
+func gameRosterPlayers(pbp *PlayByPlay, teamID int) map[int]LinePlayer {
+	out := map[int]LinePlayer{}
+	for _, rs := range pbp.RosterSpots {
+		if rs.TeamID != teamID {
+			continue
+		}
+		out[rs.PlayerID] = LinePlayer{
+			PlayerID:      rs.PlayerID,
+			Name:          strings.TrimSpace(rs.FirstName.Default + " " + rs.LastName.Default),
+			Position:      rs.PositionCode,
+			SweaterNumber: rs.SweaterNumber,
+		}
+	}
+	return out
+}
+
+var unitLimits = [4]int{forwardLinesPerGame, defensePairsPerGame, specialUnitsPerGame, specialUnitsPerGame}
+
+// inferGameLines picks a team's units for one game from its combination TOI.
+func inferGameLines(pbp *PlayByPlay, teamID int, t *gameTeamTOI) (*GameLines, [4][]string) {
+	players := gameRosterPlayers(pbp, teamID)
+	gl := &GameLines{
+		GameID:     pbp.ID,
+		GameDate:   pbp.GameDate,
+		TeamAbbrev: pbp.AwayTeam.Abbrev,
+		Opponent:   pbp.HomeTeam.Abbrev,
+		Goalies:    []LinePlayer{},
+	}
+	if teamID == pbp.HomeTeam.ID {
+		gl.TeamAbbrev, gl.Opponent, gl.IsHome = pbp.HomeTeam.Abbrev, pbp.AwayTeam.Abbrev, true
+	}
+
+	var picked [4][]string
+	lists := [4]*[]LineCombo{&gl.Forwards, &gl.Defense, &gl.PowerPlay, &gl.PenaltyKill}
+	for kind := range picked {
+		picked[kind] = pickUnits(t.units[kind], unitLimits[kind])
+		*lists[kind] = []LineCombo{}
+		for _, k := range picked[kind] {
+			*lists[kind] = append(*lists[kind], LineCombo{
+				Players: unitPlayers(k, players, unitKind(kind)),
+				TOI:     t.units[kind][k],
+			})
+		}
+	}
+
+	goalieIDs := make([]int, 0, len(t.goalie))
+	for id := range t.goalie {
+		goalieIDs = append(goalieIDs, id)
+	}
+	for id, p := range players {
+		if p.Position == "G" && t.goalie[id] == 0 {
+			goalieIDs = append(goalieIDs, id)
+		}
+	}
+	sort.Slice(goalieIDs, func(i, j int) bool {
+		return t.goalie[goalieIDs[i]] > t.goalie[goalieIDs[j]]
+	})
+	for _, id := range goalieIDs {
+		gl.Goalies = append(gl.Goalies, players[id])
+	}
+	return gl, picked
+}
+
+// computeGameLines loads play-by-play and shifts for a game and infers both
+// teams' lines, keyed by team id.
+func computeGameLines(gameID string) (map[int]*GameLines, map[int]*gameTeamTOI, map[int][4][]string, *PlayByPlay, error) {
+	pbp, err := GetPlayByPlay(gameID)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	shifts, err := GetShiftChart(gameID, isGameFinal(pbp.GameState))
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	if len(shifts) == 0 {
+		return nil, nil, nil, nil, fmt.Errorf("no shift chart for game %s", gameID)
+	}
+	toi := computeShiftTOI(pbp, shifts)
+	lines := map[int]*GameLines{}
+	picked := map[int][4][]string{}
+	for teamID, t := range toi {
+		lines[teamID], picked[teamID] = inferGameLines(pbp, teamID, t)
+	}
+	return lines, toi, picked, pbp, nil
+}
+
+// GetGameLines infers both teams' lines for a single game.
+func GetGameLines(gameID string) ([]*GameLines, error) {
+	lines, _, _, pbp, err := computeGameLines(gameID)
+	if err != nil {
+		return nil, err
+	}
+	return []*GameLines{lines[pbp.AwayTeam.ID], lines[pbp.HomeTeam.ID]}, nil
+}
+
+// GetTeamLines infers lines for every finished game of the given type in a
+// team's season. The most recent game's lineup is returned alongside the
+// combinations used most often across the season.
+func GetTeamLines(teamAbbrev, season string, gameType int) (*TeamLines, error) {
+	sched, err := GetClubSchedule(teamAbbrev, season)
+	if err != nil {
+		return nil, err
+	}
+	teamID := abbrevToTeamID[teamAbbrev]
+	out := &TeamLines{Team: teamAbbrev, Season: season, GameType: gameType}
+
+	var seasonTOI, seasonGames [4]comboTOI
+	for i := range seasonTOI {
+		seasonTOI[i], seasonGames[i] = comboTOI{}, comboTOI{}
+	}
+	players := map[int]LinePlayer{}
+
+	for _, g := range sched.Games {
+		if g.GameType != gameType || !isGameFinal(g.GameState) {
+			continue
+		}
+		lines, toi, picked, pbp, err := computeGameLines(strconv.FormatInt(g.ID, 10))
+		if err != nil {
+			log.Printf("GetTeamLines: skipping game %d for %s: %v", g.ID, teamAbbrev, err)
+			continue
+		}
+		t, ok := toi[teamID]
+		if !ok {
+			continue
+		}
+		for id, p := range gameRosterPlayers(pbp, teamID) {
+			players[id] = p
+		}
+		for kind := range t.units {
+			for k, secs := range t.units[kind] {
+				seasonTOI[kind][k] += secs
+			}
+			for _, k := range picked[teamID][kind] {
+				seasonGames[kind][k]++
+			}
+		}
+		// Schedules are in date order, so the last one wins
+		out.LastGame = lines[teamID]
+		out.GamesAnalyzed++
+	}
+
+	lists := [4]*[]LineCombo{&out.Frequent.Forwards, &out.Frequent.Defense, &out.Frequent.PowerPlay, &out.Frequent.PenaltyKill}
+	for kind := range seasonGames {
+		keys := make([]string, 0, len(seasonGames[kind]))
+		for k := range seasonGames[kind] {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			a, b := keys[i], keys[j]
+			if seasonGames[kind][a] != seasonGames[kind][b] {
+				return seasonGames[kind][a] > seasonGames[kind][b]
+			}
+			return seasonTOI[kind][a] > seasonTOI[kind][b]
+		})
+		if len(keys) > frequentCombosLimit {
+			keys = keys[:frequentCombosLimit]
+		}
+		*lists[kind] = []LineCombo{}
+		for _, k := range keys {
+			*lists[kind] = append(*lists[kind], LineCombo{
+				Players: unitPlayers(k, players, unitKind(kind)),
+				TOI:     seasonTOI[kind][k],
+				Games:   seasonGames[kind][k],
+			})
+		}
+	}
+	return out, nil
+}
+
+// handleAPIGameLines returns both teams' inferred lines for a game.
+func handleAPIGameLines(w http.ResponseWriter, r *http.Request) {
+	gameID := mux.Vars(r)["gameId"]
+	if _, err := strconv.ParseInt(gameID, 10, 64); err != nil {
+		http.Error(w, "invalid game id", http.StatusBadRequest)
+		return
+	}
+
+	lines, err := GetGameLines(gameID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{"teams": lines}); err != nil {
+		log.Printf("Error writing game lines JSON: %v", err)
+	}
+}
+
+var teamLinesBuilds = newBackgroundBuilds()
+
+// handleAPITeamLines returns a team's most recent and most used lines.
+// Optional query params: season (e.g. 20242025) and gameType (2 regular, 3 playoffs).
+func handleAPITeamLines(w http.ResponseWriter, r *http.Request) {
+	abbr, ok := resolveTeamAbbrev(mux.Vars(r)["teamId"])
+	if !ok {
+		http.Error(w, "unknown team", http.StatusNotFound)
+		return
+	}
+	season, gameType, ok := seasonAndGameType(w, r)
+	if !ok {
+		return
+	}
+
+	// A season is a play-by-play and a shift chart per game, so it is built
+	// in the background and the first request answers 503 until it is ready.
+	cacheKey := fmt.Sprintf("lines:team:%s:%s:%d", abbr, season, gameType)
+	data, err := teamLinesBuilds.get(cacheKey, time.Hour, func() ([]byte, error) {
+		lines, err := GetTeamLines(abbr, season, gameType)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(lines)
+	})
+	if err != nil {
+		writeStillBuilding(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(data); err != nil {
+		log.Printf("Error writing team lines JSON: %v", err)
+	}
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strings"
+)
+
+// PlayerInfo.Stats has no ice time, so rates per 60 assume a typical
+// all-situations TOI per game for the position.
+var assumedTOIPerGame = map[string]float64{"F": 16, "D": 21}
+
+// Individual points percentage (share of on-ice goals for a player earns a
+// point on) used to estimate on-ice goals for from points.
+var assumedIPP = map[string]float64{"F": 0.70, "D": 0.40}
+
+// Points/60 ranges mapped onto a 0-1 score per position.
+var pointsPer60Range = map[string][2]float64{"F": {1.0, 3.0}, "D": {0.5, 1.8}}
+
+const (
+	goalShareFloor = 0.40 // goal share scoring 0
+	goalShareSpan  = 0.20 // goal share above the floor scoring 1
+	savePctFloor   = 0.880
+	savePctSpan    = 0.040
+	warningPenalty = 0.10
+	lowSampleGames = 10
+)
+
+// lineupLineOrder lists the coach page's lines in display order.
+var lineupLineOrder = []struct{ Tab, Line string }{
+	{"even-strength", "1"}, {"even-strength", "2"}, {"even-strength", "3"}, {"even-strength", "4"},
+	{"even-strength", "d1"}, {"even-strength", "d2"}, {"even-strength", "d3"},
+	{"powerplay", "pp1"}, {"powerplay", "pp2"},
+	{"penalty-kill", "pk1"}, {"penalty-kill", "pk2"},
+	{"overtime", "ot1"}, {"overtime", "ot2"},
+	{"goalies", "starter"}, {"goalies", "backup"},
+}
+
+// LineEvaluation scores one line of a lineup.
+type LineEvaluation struct {
+	Tab         string   `json:"tab"`
+	Line        string   `json:"line"`
+	PlayerIDs   []int    `json:"playerIds"`
+	PointsPer60 float64  `json:"pointsPer60,omitempty"`
+	GoalShare   float64  `json:"goalShare,omitempty"`
+	SavePct     float64  `json:"savePct,omitempty"`
+	Score       float64  `json:"score"`
+	Grade       string   `json:"grade"`
+	Warnings    []string `json:"warnings"`
+}
+
+// LineupEvaluation is the /api/lineups/evaluate response. Warnings holds
+// problems that are not tied to one line, such as players off the roster.
+type LineupEvaluation struct {
+	Team     string           `json:"team"`
+	Grade    string           `json:"grade"`
+	Score    float64          `json:"score"`
+	Lines    []LineEvaluation `json:"lines"`
+	Warnings []string         `json:"warnings"`
+}
+
+func clamp01(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
+
+func letterGrade(score float64) string {
+	switch {
+	case score >= 0.8:
+		return "A"
+	case score >= 0.6:
+		return "B"
+	case score >= 0.4:
+		return "C"
+	case score >= 0.2:
+		return "D"
+	default:
+		return "F"
+	}
+}
+
+// skaterRates estimates a skater's points/60 and on-ice goals for and
+// against per game from season totals.
+func skaterRates(p PlayerInfo) (pointsPer60, gfPerGame, gaPerGame float64) {
+	if p.Stats == nil || p.Stats.Games == 0 {
+		return 0, 0, 0
+	}
+	games := float64(p.Stats.Games)
+	pointsPer60 = float64(p.Stats.Points) / games * 60 / assumedTOIPerGame[p.Position]
+	gf := float64(p.Stats.Points) / assumedIPP[p.Position]
+	ga := math.Max(0, gf-float64(p.Stats.PlusMinus))
+	return pointsPer60, gf / games, ga / games
+}
+
+// handednessWarnings flags off-hand wingers and same-side or reversed D
+// pairs at even strength.
+func handednessWarnings(slots []LineupSlot, players map[int]PlayerInfo) []string {
+	var warnings []string
+	side := map[string]string{}
+	for _, s := range slots {
+		p := players[s.PlayerID]
+		shoots := p.ShootsCatches
+		if shoots == "" {
+			continue
+		}
+		switch s.Position {
+		case "LW", "RW":
+			if want := s.Position[:1]; shoots != want {
+				warnings = append(warnings, fmt.Sprintf("%s shoots %s on the %s (off-hand winger)", p.Name, shoots, s.Position))
+			}
+		case "LD", "RD":
+			side[s.Position] = shoots
+		}
+	}
+	ld, rd := side["LD"], side["RD"]
+	switch {
+	case ld != "" && ld == rd:
+		warnings = append(warnings, fmt.Sprintf("both defensemen shoot %s (same-side pair)", ld))
+	case ld == "R" && rd == "L":
+		warnings = append(warnings, "pair is reversed: a right shot on the left and a left shot on the right")
+	}
+	return warnings
+}
+
+// fitWarnings checks positional fit from roster positions: skaters in
+// special-teams slots meant for the other position and missing bodies.
+func fitWarnings(tab, line string, slots []LineupSlot, players map[int]PlayerInfo) []string {
+	var warnings []string
+	defense := 0
+	for _, s := range slots {
+		p := players[s.PlayerID]
+		if p.Position == "D" {
+			defense++
+		}
+		switch {
+		case s.Position == "F" && p.Position == "D":
+			warnings = append(warnings, fmt.Sprintf("%s is a defenseman in a forward spot", p.Name))
+		case s.Position == "D" && p.Position == "F":
+			warnings = append(warnings, fmt.Sprintf("%s is a forward on the point", p.Name))
+		}
+	}
+	capacity := 0
+	for _, n := range lineupLayout[tab][line] {
+		capacity += n
+	}
+	if len(slots) < capacity {
+		warnings = append(warnings, fmt.Sprintf("%d of %d spots filled", len(slots), capacity))
+	}
+	if tab == "penalty-kill" && len(slots) == capacity && defense == 0 {
+		warnings = append(warnings, "no defenseman on the penalty kill")
+	}
+	return warnings
+}
+
+// evaluateLine grades one line. Skater lines blend points/60 and estimated
+// goal share (penalty kills use goal share alone); goalies are graded on
+// save percentage. Each warning costs a step of the score.
+func evaluateLine(tab, line string, slots []LineupSlot, players map[int]PlayerInfo) LineEvaluation {
+	ev := LineEvaluation{Tab: tab, Line: line, PlayerIDs: []int{}, Warnings: []string{}}
+	for _, s := range slots {
+		ev.PlayerIDs = append(ev.PlayerIDs, s.PlayerID)
+	}
+
+	if tab == "goalies" {
+		if len(slots) == 0 {
+			ev.Warnings = append(ev.Warnings, "no goalie selected")
+		} else if p := players[slots[0].PlayerID]; p.Stats != nil {
+			ev.SavePct = round3(p.Stats.SavePercentage)
+			ev.Score = round3(clamp01((p.Stats.SavePercentage - savePctFloor) / savePctSpan))
+			if p.Stats.GamesStarted < lowSampleGames {
+				ev.Warnings = append(ev.Warnings, fmt.Sprintf("%s has only %d starts this season", p.Name, p.Stats.GamesStarted))
+			}
+		}
+		ev.Grade = letterGrade(ev.Score)
+		return ev
+	}
+
+	var ptsScore, gf, ga float64
+	for _, s := range slots {
+		p := players[s.PlayerID]
+		p60, pgf, pga := skaterRates(p)
+		ev.PointsPer60 += p60
+		gf += pgf
+		ga += pga
+		if r, ok := pointsPer60Range[p.Position]; ok {
+			ptsScore += clamp01((p60 - r[0]) / (r[1] - r[0]))
+		}
+	}
+	ev.PointsPer60 = round3(ev.PointsPer60)
+	if gf+ga > 0 {
+		ev.GoalShare = round3(gf / (gf + ga))
+	}
+	gsScore := clamp01((ev.GoalShare - goalShareFloor) / goalShareSpan)
+	if len(slots) > 0 {
+		ptsScore /= float64(len(slots))
+	}
+
+	if tab == "even-strength" {
+		ev.Warnings = append(ev.Warnings, handednessWarnings(slots, players)...)
+	}
+	ev.Warnings = append(ev.Warnings, fitWarnings(tab, line, slots, players)...)
+
+	if tab == "penalty-kill" {
+		ev.Score = gsScore
+	} else {
+		ev.Score = (ptsScore + gsScore) / 2
+	}
+	if len(slots) == 0 {
+		ev.Score = 0
+	}
+	ev.Score = round3(clamp01(ev.Score - warningPenalty*float64(len(ev.Warnings))))
+	ev.Grade = letterGrade(ev.Score)
+	return ev
+}
+
+// EvaluateLineup grades every line of a lineup against the team's roster.
+// Slots that fail validation are reported and left out of the grades.
+func EvaluateLineup(teamAbbrev string, slots []LineupSlot) (*LineupEvaluation, error) {
+	players, err := rosterPlayers(teamAbbrev)
+	if err != nil {
+		return nil, err
+	}
+	out := &LineupEvaluation{Team: teamAbbrev, Lines: []LineEvaluation{}, Warnings: []string{}}
+	out.Warnings = append(out.Warnings, validateLineup(teamAbbrev, players, slots)...)
+
+	byLine := map[string][]LineupSlot{}
+	for _, s := range slots {
+		p, ok := players[s.PlayerID]
+		if !ok || lineupLayout[s.Tab][s.Line][s.Position] == 0 || !strings.Contains(slotEligible[s.Position], p.Position) {
+			continue
+		}
+		byLine[s.Tab+"_"+s.Line] = append(byLine[s.Tab+"_"+s.Line], s)
+	}
+
+	var total float64
+	for _, l := range lineupLineOrder {
+		ev := evaluateLine(l.Tab, l.Line, byLine[l.Tab+"_"+l.Line], players)
+		out.Lines = append(out.Lines, ev)
+		total += ev.Score
+	}
+	out.Score = round3(total / float64(len(lineupLineOrder)))
+	out.Grade = letterGrade(out.Score)
+	return out, nil
+}
+
+// handleAPIEvaluateLineup scores a lineup posted in the same shape as
+// POST /api/lineups without saving it.
+func handleAPIEvaluateLineup(w http.ResponseWriter, r *http.Request) {
+	var req lineupRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxLineupBodyBytes)).Decode(&req); err != nil {
+		http.Error(w, "invalid lineup JSON", http.StatusBadRequest)
+		return
+	}
+	abbr, ok := resolveTeamAbbrev(req.Team)
+	if !ok {
+		http.Error(w, "unknown team", http.StatusBadRequest)
+		return
+	}
+
+	ev, err := EvaluateLineup(abbr, req.Slots)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(ev); err != nil {
+		log.Printf("Error writing lineup evaluation JSON: %v", err)
+	}
+}
@@ -0,0 +1,490 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	shareIDLength      = 8
+	shareIDAlphabet    = "abcdefghijkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	maxLineupVersions  = 50
+	maxLineupBodyBytes = 64 << 10
+	maxLineupNameLen   = 80
+	// maxLineupSaveAttempts bounds retries when another save changes a
+	// lineup between our read and write.
+	maxLineupSaveAttempts = 3
+)
+
+var (
+	// errLineupNotFound is returned when a share id has no stored lineup.
+	errLineupNotFound = errors.New("lineup not found")
+	// errLineupConflict is returned when concurrent saves kept racing.
+	errLineupConflict = errors.New("lineup was changed by another save, try again")
+)
+
+// lineupLayout mirrors the coach page: for each tab, the lines it holds and
+// how many slots of each position a line has.
+var lineupLayout = map[string]map[string]map[string]int{
+	"even-strength": {
+		"1":  {"LW": 1, "C": 1, "RW": 1},
+		"2":  {"LW": 1, "C": 1, "RW": 1},
+		"3":  {"LW": 1, "C": 1, "RW": 1},
+		"4":  {"LW": 1, "C": 1, "RW": 1},
+		"d1": {"LD": 1, "RD": 1},
+		"d2": {"LD": 1, "RD": 1},
+		"d3": {"LD": 1, "RD": 1},
+	},
+	"powerplay": {
+		"pp1": {"F": 3, "D": 2},
+		"pp2": {"F": 3, "D": 2},
+	},
+	"penalty-kill": {
+		"pk1": {"F": 2, "D": 2},
+		"pk2": {"F": 2, "D": 2},
+	},
+	"overtime": {
+		"ot1": {"F": 2, "D": 1},
+		"ot2": {"F": 2, "D": 1},
+	},
+	"goalies": {
+		"starter": {"G": 1},
+		"backup":  {"G": 1},
+	},
+}
+
+// slotEligible lists the roster positions (F, D, G) each slot accepts.
+// Special-teams slots take any skater, as on the coach page.
+var slotEligible = map[string]string{
+	"LW": "F", "C": "F", "RW": "F",
+	"LD": "D", "RD": "D",
+	"F": "FD", "D": "FD",
+	"G": "G",
+}
+
+// LineupSlot places one player in a coach-page slot.
+type LineupSlot struct {
+	Tab      string `json:"tab"`
+	Line     string `json:"line"`
+	Position string `json:"position"`
+	PlayerID int    `json:"playerId"`
+}
+
+// LineupVersion is one saved revision of a lineup.
+type LineupVersion struct {
+	Version int          `json:"version"`
+	Name    string       `json:"name,omitempty"`
+	Slots   []LineupSlot `json:"slots"`
+	SavedAt time.Time    `json:"savedAt"`
+}
+
+// storedLineup is the persisted record. Only a hash of the edit token is kept.
+type storedLineup struct {
+	ShareID   string          `json:"shareId"`
+	Team      string          `json:"team"`
+	TokenHash string          `json:"tokenHash"`
+	CreatedAt time.Time       `json:"createdAt"`
+	Versions  []LineupVersion `json:"versions"`
+}
+
+// LineupVersionInfo summarizes a version in the history list.
+type LineupVersionInfo struct {
+	Version int       `json:"version"`
+	Name    string    `json:"name,omitempty"`
+	SavedAt time.Time `json:"savedAt"`
+}
+
+// Lineup is the API view of a stored lineup at one version. EditToken is
+// only returned when the lineup is created.
+type Lineup struct {
+	ShareID   string              `json:"shareId"`
+	Team      string              `json:"team"`
+	Name      string              `json:"name,omitempty"`
+	Version   int                 `json:"version"`
+	Slots     []LineupSlot        `json:"slots"`
+	URL       string              `json:"url"`
+	CreatedAt time.Time           `json:"createdAt"`
+	UpdatedAt time.Time           `json:"updatedAt"`
+	Versions  []LineupVersionInfo `json:"versions"`
+	EditToken string              `json:"editToken,omitempty"`
+}
+
+// lineupRequest is the body of POST and PUT /api/lineups.
+type lineupRequest struct {
+	Team  string       `json:"team"`
+	Name  string       `json:"name"`
+	Slots []LineupSlot `json:"slots"`
+}
+
+func lineupKey(shareID string) string {
+	return "lineup:" + shareID
+}
+
+func randomString(alphabet string, n int) (string, error) {
+	var b strings.Builder
+	max := big.NewInt(int64(len(alphabet)))
+	for i := 0; i < n; i++ {
+		idx, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b.WriteByte(alphabet[idx.Int64()])
+	}
+	return b.String(), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func loadLineup(shareID string) (*storedLineup, error) {
+	data, err := getCachedRaw(lineupKey(shareID))
+	return parseStoredLineup(shareID, data, err)
+}
+
+// parseStoredLineup decodes the result of reading a lineup key.
+func parseStoredLineup(shareID string, data []byte, err error) (*storedLineup, error) {
+	if errors.Is(err, redis.Nil) {
+		return nil, errLineupNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var rec storedLineup
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, fmt.Errorf("parsing stored lineup %s: %w", shareID, err)
+	}
+	return &rec, nil
+}
+
+// createLineupRecord stores a new lineup, failing with false when its share
+// id is already taken.
+func createLineupRecord(rec *storedLineup) (bool, error) {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return false, err
+	}
+	return redisClient.SetNX(redisCtx, lineupKey(rec.ShareID), data, 0).Result()
+}
+
+// updateLineupRecord applies change to the stored lineup and writes it back
+// only if nobody else saved it in between (WATCH/MULTI), retrying a few
+// times. Replicas share the store, so a process-local lock would not do.
+func updateLineupRecord(shareID string, change func(rec *storedLineup)) (*storedLineup, error) {
+	key := lineupKey(shareID)
+	var out *storedLineup
+	for attempt := 0; attempt < maxLineupSaveAttempts; attempt++ {
+		err := redisClient.Watch(redisCtx, func(tx *redis.Tx) error {
+			data, err := tx.Get(redisCtx, key).Bytes()
+			rec, err := parseStoredLineup(shareID, data, err)
+			if err != nil {
+				return err
+			}
+			change(rec)
+			data, err = json.Marshal(rec)
+			if err != nil {
+				return err
+			}
+			_, err = tx.TxPipelined(redisCtx, func(pipe redis.Pipeliner) error {
+				pipe.Set(redisCtx, key, data, 0)
+				return nil
+			})
+			out = rec
+			return err
+		}, key)
+		if !errors.Is(err, redis.TxFailedErr) {
+			return out, err
+		}
+	}
+	return nil, errLineupConflict
+}
+
+// rosterPlayers maps a team's current roster by player id.
+func rosterPlayers(teamAbbrev string) (map[int]PlayerInfo, error) {
+	roster, err := GetRoster(teamAbbrev)
+	if err != nil {
+		return nil, err
+	}
+	players := make(map[int]PlayerInfo, len(roster.Players))
+	for _, p := range roster.Players {
+		players[p.ID] = p
+	}
+	return players, nil
+}
+
+// validateLineup checks every slot against the coach layout and the team's
+// current roster: known slots, slot capacity, position eligibility and no
+// player used twice in a tab.
+func validateLineup(teamAbbrev string, players map[int]PlayerInfo, slots []LineupSlot) []string {
+	var problems []string
+	filled := map[string]int{}
+	usedInTab := map[string]map[int]bool{}
+	for _, s := range slots {
+		where := fmt.Sprintf("%s %s %s", s.Tab, s.Line, s.Position)
+		capacity := lineupLayout[s.Tab][s.Line][s.Position]
+		if capacity == 0 {
+			problems = append(problems, fmt.Sprintf("unknown slot %s", where))
+			continue
+		}
+		slotKey := s.Tab + "_" + s.Line + "_" + s.Position
+		if filled[slotKey]++; filled[slotKey] > capacity {
+			problems = append(problems, fmt.Sprintf("too many players in %s", where))
+		}
+		p, ok := players[s.PlayerID]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("player %d in %s is not on the %s roster", s.PlayerID, where, teamAbbrev))
+			continue
+		}
+		if p.Position == "" || !strings.Contains(slotEligible[s.Position], p.Position) {
+			problems = append(problems, fmt.Sprintf("%s (%s) cannot play %s", p.Name, p.Position, where))
+		}
+		if usedInTab[s.Tab] == nil {
+			usedInTab[s.Tab] = map[int]bool{}
+		}
+		if usedInTab[s.Tab][s.PlayerID] {
+			problems = append(problems, fmt.Sprintf("%s is used more than once in %s", p.Name, s.Tab))
+		}
+		usedInTab[s.Tab][s.PlayerID] = true
+	}
+	return problems
+}
+
+// lineupView renders a stored lineup at a version (0 means latest).
+func lineupView(rec *storedLineup, version int) (*Lineup, bool) {
+	if len(rec.Versions) == 0 {
+		return nil, false
+	}
+	v := rec.Versions[len(rec.Versions)-1]
+	if version != 0 {
+		found := false
+		for _, cand := range rec.Versions {
+			if cand.Version == version {
+				v, found = cand, true
+				break
+			}
+		}
+		if !found {
+			return nil, false
+		}
+	}
+	out := &Lineup{
+		ShareID:   rec.ShareID,
+		Team:      rec.Team,
+		Name:      v.Name,
+		Version:   v.Version,
+		Slots:     v.Slots,
+		URL:       "/coach/" + rec.ShareID,
+		CreatedAt: rec.CreatedAt,
+		UpdatedAt: rec.Versions[len(rec.Versions)-1].SavedAt,
+		Versions:  make([]LineupVersionInfo, 0, len(rec.Versions)),
+	}
+	for _, h := range rec.Versions {
+		out.Versions = append(out.Versions, LineupVersionInfo{Version: h.Version, Name: h.Name, SavedAt: h.SavedAt})
+	}
+	return out, true
+}
+
+// decodeLineupRequest parses and validates a create/update body, writing the
+// error response itself when it fails.
+func decodeLineupRequest(w http.ResponseWriter, r *http.Request) (*lineupRequest, bool) {
+	var req lineupRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxLineupBodyBytes)).Decode(&req); err != nil {
+		http.Error(w, "invalid lineup JSON", http.StatusBadRequest)
+		return nil, false
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if len(req.Name) > maxLineupNameLen {
+		http.Error(w, "lineup name is too long", http.StatusBadRequest)
+		return nil, false
+	}
+	abbr, ok := resolveTeamAbbrev(req.Team)
+	if !ok {
+		http.Error(w, "unknown team", http.StatusBadRequest)
+		return nil, false
+	}
+	req.Team = abbr
+	if req.Slots == nil {
+		req.Slots = []LineupSlot{}
+	}
+	players, err := rosterPlayers(abbr)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return nil, false
+	}
+	if problems := validateLineup(abbr, players, req.Slots); len(problems) > 0 {
+		http.Error(w, strings.Join(problems, "; "), http.StatusBadRequest)
+		return nil, false
+	}
+	return &req, true
+}
+
+// lineupForEdit loads a lineup and checks the request's edit token, writing
+// the error response itself when either fails.
+func lineupForEdit(w http.ResponseWriter, r *http.Request) (*storedLineup, bool) {
+	rec, err := loadLineup(mux.Vars(r)["shareId"])
+	if err != nil {
+		writeLineupError(w, err)
+		return nil, false
+	}
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" || subtle.ConstantTimeCompare([]byte(hashToken(token)), []byte(rec.TokenHash)) != 1 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return nil, false
+	}
+	return rec, true
+}
+
+func writeLineupError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errLineupNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, errLineupConflict):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case redisClient == nil:
+		http.Error(w, "lineup storage unavailable", http.StatusServiceUnavailable)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func writeLineupJSON(w http.ResponseWriter, status int, l *Lineup) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(l); err != nil {
+		log.Printf("Error writing lineup JSON: %v", err)
+	}
+}
+
+// handleAPICreateLineup stores a new lineup and returns its share id and the
+// edit token needed to update or delete it.
+func handleAPICreateLineup(w http.ResponseWriter, r *http.Request) {
+	if redisClient == nil {
+		http.Error(w, "lineup storage unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	req, ok := decodeLineupRequest(w, r)
+	if !ok {
+		return
+	}
+	token, err := randomString(shareIDAlphabet, 32)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now().UTC()
+	rec := &storedLineup{
+		Team:      req.Team,
+		TokenHash: hashToken(token),
+		CreatedAt: now,
+		Versions:  []LineupVersion{{Version: 1, Name: req.Name, Slots: req.Slots, SavedAt: now}},
+	}
+	// SETNX claims the share id, so two creates can never share one.
+	created := false
+	for attempt := 0; attempt < 5 && !created; attempt++ {
+		if rec.ShareID, err = randomString(shareIDAlphabet, shareIDLength); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if created, err = createLineupRecord(rec); err != nil {
+			writeLineupError(w, err)
+			return
+		}
+	}
+	if !created {
+		http.Error(w, "could not allocate a share id", http.StatusInternalServerError)
+		return
+	}
+	view, _ := lineupView(rec, 0)
+	view.EditToken = token
+	writeLineupJSON(w, http.StatusCreated, view)
+}
+
+// handleAPIGetLineup returns a lineup at its latest or a given ?version=.
+func handleAPIGetLineup(w http.ResponseWriter, r *http.Request) {
+	version := 0
+	if v := r.URL.Query().Get("version"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			http.Error(w, "invalid version", http.StatusBadRequest)
+			return
+		}
+		version = n
+	}
+	rec, err := loadLineup(mux.Vars(r)["shareId"])
+	if err != nil {
+		writeLineupError(w, err)
+		return
+	}
+	view, ok := lineupView(rec, version)
+	if !ok {
+		http.Error(w, "version not found", http.StatusNotFound)
+		return
+	}
+	writeLineupJSON(w, http.StatusOK, view)
+}
+
+// handleAPIUpdateLineup saves a new version of a lineup. The team cannot
+// change. The body is validated against the roster before the stored record
+// is touched; the save itself is a compare-and-set on the record.
+func handleAPIUpdateLineup(w http.ResponseWriter, r *http.Request) {
+	rec, ok := lineupForEdit(w, r)
+	if !ok {
+		return
+	}
+	req, ok := decodeLineupRequest(w, r)
+	if !ok {
+		return
+	}
+	if req.Team != rec.Team {
+		http.Error(w, "a lineup's team cannot change", http.StatusBadRequest)
+		return
+	}
+
+	rec, err := updateLineupRecord(rec.ShareID, func(rec *storedLineup) {
+		last := rec.Versions[len(rec.Versions)-1]
+		rec.Versions = append(rec.Versions, LineupVersion{
+			Version: last.Version + 1,
+			Name:    req.Name,
+			Slots:   req.Slots,
+			SavedAt: time.Now().UTC(),
+		})
+		if len(rec.Versions) > maxLineupVersions {
+			rec.Versions = rec.Versions[len(rec.Versions)-maxLineupVersions:]
+		}
+	})
+	if err != nil {
+		writeLineupError(w, err)
+		return
+	}
+	view, _ := lineupView(rec, 0)
+	writeLineupJSON(w, http.StatusOK, view)
+}
+
+// handleAPIDeleteLineup removes a lineup and its history.
+func handleAPIDeleteLineup(w http.ResponseWriter, r *http.Request) {
+	rec, ok := lineupForEdit(w, r)
+	if !ok {
+		return
+	}
+	if err := redisClient.Del(redisCtx, lineupKey(rec.ShareID)).Err(); err != nil {
+		writeLineupError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -1,13 +1,16 @@
 package main
 
 import (
+	"crypto/subtle"
 	"embed"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
 	"log"
 	"net/http"
+	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -38,9 +41,25 @@ func main() {
 	router.HandleFunc("/team/{teamId}", handleTeam).Methods("GET")
 	router.HandleFunc("/team-schedule/{teamId}", handleTeamSchedule).Methods("GET")
 	router.HandleFunc("/trivia", handleTrivia).Methods("GET")
+	router.HandleFunc("/trivia/rooms", handleTriviaRooms).Methods("GET")
+	router.HandleFunc("/trivia/rooms/{code}", handleTriviaRooms).Methods("GET")
+	router.HandleFunc("/grid", handleGridPage).Methods("GET")
 	router.HandleFunc("/coach", handleCoach).Methods("GET")
+	router.HandleFunc("/coach/{shareId}", handleCoach).Methods("GET")
 	router.HandleFunc("/player/{playerId}", handlePlayer).Methods("GET")
 	router.HandleFunc("/game/{gameId}", handleGamePage).Methods("GET")
+	router.HandleFunc("/matchup/{teamA}/{teamB}", handleMatchupPage).Methods("GET")
+	router.HandleFunc("/leaders", handleLeadersPage).Methods("GET")
+	router.HandleFunc("/compare", handleComparePage).Methods("GET")
+	router.HandleFunc("/goalies", handleGoaliesPage).Methods("GET")
+	router.HandleFunc("/login", handleLoginPage).Methods("GET")
+	router.HandleFunc("/auth/oidc/login", handleOIDCLogin).Methods("GET")
+	router.HandleFunc("/auth/oidc/callback", handleOIDCCallback).Methods("GET")
+	router.HandleFunc("/ical/team/{teamId:[^/.]+}.ics", handleICalTeam).Methods("GET")
+	router.HandleFunc("/ical/teams.ics", handleICalTeams).Methods("GET")
+	router.HandleFunc("/feeds/team/{teamId}/news.{format:rss|atom}", handleTeamNewsFeed).Methods("GET")
+	router.HandleFunc("/feeds/team/{teamId}/transactions.{format:rss|atom}", handleTeamTransactionsFeed).Methods("GET")
+	router.HandleFunc("/feeds/scores.{format:rss|atom}", handleScoresFeed).Methods("GET")
 	router.HandleFunc("/api/teams", handleAPITeams).Methods("GET")
 	router.HandleFunc("/api/team/{teamId}", handleAPITeamDetails).Methods("GET")
 	router.HandleFunc("/api/roster/{teamId}", handleAPIRoster).Methods("GET")
@@ -53,6 +72,75 @@ func main() {
 	router.HandleFunc("/api/team-news/{teamId}", handleAPITeamNews).Methods("GET")
 	router.HandleFunc("/api/team-transactions/{teamId}", handleAPITeamTransactions).Methods("GET")
 	router.HandleFunc("/api/videos/{gameId}", handleAPIVideos).Methods("GET")
+	router.HandleFunc("/api/game/{gameId}/advanced", handleAPIGameAdvanced).Methods("GET")
+	router.HandleFunc("/api/team/{teamId}/advanced", handleAPITeamAdvanced).Methods("GET")
+	router.HandleFunc("/api/game/{gameId}/xg", handleAPIGameXG).Methods("GET")
+	router.HandleFunc("/api/team/{teamId}/xg", handleAPITeamXG).Methods("GET")
+	router.HandleFunc("/api/player/{playerId}/xg", handleAPIPlayerXG).Methods("GET")
+	router.HandleFunc("/api/player/{playerId}/game-log", handleAPIPlayerGameLog).Methods("GET")
+	router.HandleFunc("/api/goalie/{playerId}/analytics", handleAPIGoalieAnalytics).Methods("GET")
+	router.HandleFunc("/api/goalies/analytics", handleAPILeagueGoalies).Methods("GET")
+	router.HandleFunc("/api/xg/model", handleAPIXGModel).Methods("GET")
+	router.HandleFunc("/api/xg/retrain", handleAPIXGRetrain).Methods("POST")
+	router.HandleFunc("/api/playoff-odds", handleAPIPlayoffOdds).Methods("GET")
+	router.HandleFunc("/api/power-rankings", handleAPIPowerRankings).Methods("GET")
+	router.HandleFunc("/api/leaders", handleAPILeaders).Methods("GET")
+	router.HandleFunc("/api/compare", handleAPICompare).Methods("GET")
+	router.HandleFunc("/api/search", handleAPISearch).Methods("GET")
+	router.HandleFunc("/api/matchup/{teamA}/{teamB}", handleAPIMatchup).Methods("GET")
+	router.HandleFunc("/api/team/{teamId}/standings-history", handleAPITeamStandingsHistory).Methods("GET")
+	router.HandleFunc("/api/team/{teamId}/stats", handleAPITeamStats).Methods("GET")
+	router.HandleFunc("/api/team/{teamId}/lines", handleAPITeamLines).Methods("GET")
+	router.HandleFunc("/api/game/{gameId}/lines", handleAPIGameLines).Methods("GET")
+	router.HandleFunc("/api/lineups", handleAPICreateLineup).Methods("POST")
+	router.HandleFunc("/api/lineups/evaluate", handleAPIEvaluateLineup).Methods("POST")
+	router.HandleFunc("/api/lineups/{shareId}", handleAPIGetLineup).Methods("GET")
+	router.HandleFunc("/api/lineups/{shareId}", handleAPIUpdateLineup).Methods("PUT")
+	router.HandleFunc("/api/lineups/{shareId}", handleAPIDeleteLineup).Methods("DELETE")
+	router.HandleFunc("/api/grid/daily", handleAPIDailyGrid).Methods("GET")
+	router.HandleFunc("/api/grid/random", handleAPIRandomGrid).Methods("GET")
+	router.HandleFunc("/api/grid/{gridId}", handleAPIGrid).Methods("GET")
+	router.HandleFunc("/api/grid/{gridId}/guess", handleAPIGridGuess).Methods("POST")
+	router.HandleFunc("/api/grid/{gridId}/stats", handleAPIGridStats).Methods("GET")
+	router.HandleFunc("/api/auth/providers", handleAPIAuthProviders).Methods("GET")
+	router.HandleFunc("/api/auth/register", handleAPIRegister).Methods("POST")
+	router.HandleFunc("/api/auth/login", handleAPILogin).Methods("POST")
+	router.HandleFunc("/api/auth/logout", handleAPILogout).Methods("POST")
+	router.HandleFunc("/api/account", handleAPIAccount).Methods("GET")
+	router.HandleFunc("/api/account/favourites", handleAPIPutFavourites).Methods("PUT")
+	router.HandleFunc("/api/account/favourites/teams/{teamId}", handleAPIFavouriteTeam).Methods("POST", "DELETE")
+	router.HandleFunc("/api/account/favourites/players/{playerId}", handleAPIFavouritePlayer).Methods("POST", "DELETE")
+	router.HandleFunc("/api/webhooks", handleAPIListWebhooks).Methods("GET")
+	router.HandleFunc("/api/webhooks", handleAPICreateWebhook).Methods("POST")
+	router.HandleFunc("/api/webhooks/deliveries/{deliveryId}", handleAPIWebhookDelivery).Methods("GET")
+	router.HandleFunc("/api/webhooks/deliveries/{deliveryId}/replay", handleAPIReplayWebhookDelivery).Methods("POST")
+	router.HandleFunc("/api/webhooks/{webhookId}", handleAPIGetWebhook).Methods("GET")
+	router.HandleFunc("/api/webhooks/{webhookId}", handleAPIDeleteWebhook).Methods("DELETE")
+	router.HandleFunc("/api/webhooks/{webhookId}/deliveries", handleAPIWebhookDeliveries).Methods("GET")
+	router.HandleFunc("/api/webhooks/{webhookId}/ping", handleAPIPingWebhook).Methods("POST")
+	router.HandleFunc("/api/chat/slack", handleSlackCommand).Methods("POST")
+	router.HandleFunc("/api/chat/discord", handleDiscordInteraction).Methods("POST")
+	router.HandleFunc("/api/chat/discord/commands", handleAPIRegisterDiscordCommands).Methods("POST")
+	router.HandleFunc("/api/trivia/check", handleAPITriviaCheck).Methods("POST")
+	router.HandleFunc("/api/trivia/rooms", handleAPICreateTriviaRoom).Methods("POST")
+	router.HandleFunc("/api/trivia/rooms/{code}", handleAPITriviaRoom).Methods("GET")
+	router.HandleFunc("/api/trivia/rooms/{code}/join", handleAPIJoinTriviaRoom).Methods("POST")
+	router.HandleFunc("/api/trivia/rooms/{code}/ws", handleTriviaRoomSocket).Methods("GET")
+	router.HandleFunc("/api/trivia/{teamId}", handleAPITrivia).Methods("GET")
+	router.HandleFunc("/api/trivia/{teamId}/daily", handleAPIDailyTrivia).Methods("GET")
+
+	// Recompute playoff odds whenever a game goes final
+	startPlayoffOddsWatcher(5 * time.Minute)
+	// Rate teams for /api/power-rankings and schedule win probabilities
+	startPowerRatingsRefresher(5 * time.Minute)
+	// Index rosters, prospects and cached landings for /api/search
+	startSearchIndexer(time.Hour)
+	// Build the league goalie table for /api/goalies/analytics
+	startGoalieIndexer(time.Hour)
+	// Index NHL careers from rosters and cached landings for the grid puzzle
+	startCareerIndexer(12 * time.Hour)
+	// Deliver webhooks and watch games and transactions feeds for their events
+	startWebhookWatcher(time.Minute, 15*time.Minute)
 
 	port := "8080"
 	fmt.Printf("Server starting on http://localhost:%s\n", port)
@@ -73,6 +161,59 @@ func serveEmbeddedFile(w http.ResponseWriter, r *http.Request, filename string)
 	}
 }
 
+// requireAdmin checks the request for the ADMIN_TOKEN bearer token and writes
+// an error response when it is missing or wrong. Admin routes are disabled
+// entirely when ADMIN_TOKEN is unset.
+func requireAdmin(w http.ResponseWriter, r *http.Request) bool {
+	token := os.Getenv("ADMIN_TOKEN")
+	if token == "" {
+		http.Error(w, "admin API disabled", http.StatusForbidden)
+		return false
+	}
+	got := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+// validSeason reports whether season is an id like 20242025: eight digits,
+// the second year right after the first.
+func validSeason(season string) bool {
+	if len(season) != 8 || strings.Trim(season, "0123456789") != "" {
+		return false
+	}
+	start, _ := strconv.Atoi(season[:4])
+	end, _ := strconv.Atoi(season[4:])
+	return end == start+1
+}
+
+// seasonAndGameType reads the optional season and gameType query params,
+// writing a 400 and returning false when either is malformed. The season
+// defaults to the current one so cache keys stay stable; gameType is 2
+// (regular season) or 3 (playoffs).
+func seasonAndGameType(w http.ResponseWriter, r *http.Request) (string, int, bool) {
+	season := r.URL.Query().Get("season")
+	if season == "" {
+		season = currentSeasonID()
+	}
+	if !validSeason(season) {
+		http.Error(w, "invalid season, expected e.g. 20242025", http.StatusBadRequest)
+		return "", 0, false
+	}
+	gameType := 2
+	if v := r.URL.Query().Get("gameType"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || (n != 2 && n != 3) {
+			http.Error(w, "invalid gameType, expected 2 or 3", http.StatusBadRequest)
+			return "", 0, false
+		}
+		gameType = n
+	}
+	return season, gameType, true
+}
+
 func handleIndex(w http.ResponseWriter, r *http.Request) {
 	serveEmbeddedFile(w, r, "index.html")
 }
@@ -108,6 +249,14 @@ func handleTrivia(w http.ResponseWriter, r *http.Request) {
 	serveEmbeddedFile(w, r, "trivia.html")
 }
 
+func handleTriviaRooms(w http.ResponseWriter, r *http.Request) {
+	serveEmbeddedFile(w, r, "trivia-room.html")
+}
+
+func handleGridPage(w http.ResponseWriter, r *http.Request) {
+	serveEmbeddedFile(w, r, "grid.html")
+}
+
 func handleCoach(w http.ResponseWriter, r *http.Request) {
 	serveEmbeddedFile(w, r, "coach.html")
 }
@@ -120,12 +269,71 @@ func handleTeamSchedule(w http.ResponseWriter, r *http.Request) {
 	serveEmbeddedFile(w, r, "team-schedule.html")
 }
 
+func handleMatchupPage(w http.ResponseWriter, r *http.Request) {
+	serveEmbeddedFile(w, r, "matchup.html")
+}
+
+func handleLeadersPage(w http.ResponseWriter, r *http.Request) {
+	serveEmbeddedFile(w, r, "leaders.html")
+}
+
+func handleComparePage(w http.ResponseWriter, r *http.Request) {
+	serveEmbeddedFile(w, r, "compare.html")
+}
+
+func handleGoaliesPage(w http.ResponseWriter, r *http.Request) {
+	serveEmbeddedFile(w, r, "goalies.html")
+}
+
+func handleLoginPage(w http.ResponseWriter, r *http.Request) {
+	serveEmbeddedFile(w, r, "login.html")
+}
+
 func handleAPITeams(w http.ResponseWriter, r *http.Request) {
-	teams, err := GetAllTeams()
+	format, err := exportFormat(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	date := r.URL.Query().Get("date")
+	season := r.URL.Query().Get("season")
+	switch {
+	case date != "" && season != "":
+		http.Error(w, "use either date or season, not both", http.StatusBadRequest)
+		return
+	case date != "":
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			http.Error(w, "date must be YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		if date > getStandingsDate() {
+			http.Error(w, "date is in the future", http.StatusBadRequest)
+			return
+		}
+	case season != "":
+		d, err := standingsDateForSeason(season)
+		if err != nil {
+			status := http.StatusBadGateway
+			if errors.Is(err, errUnknownSeason) {
+				status = http.StatusNotFound
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+		date = d
+	default:
+		date = getStandingsDate()
+	}
+
+	teams, err := GetStandingsForDate(date)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	if format != "" {
+		exportTeams(w, format, date, teams)
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := teams.WriteJSON(w); err != nil {
@@ -143,6 +351,17 @@ func handleAPITeamDetails(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Season stats refresh on their own TTL, so attach them outside the
+	// team details cache
+	for i := range team.Teams {
+		stats, err := GetTeamSeasonStats(team.Teams[i].Abbreviation, currentSeasonID(), 2)
+		if err != nil {
+			log.Printf("Error fetching %s season stats: %v", team.Teams[i].Abbreviation, err)
+			continue
+		}
+		team.Teams[i].Stats = stats
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	if err := team.WriteJSON(w); err != nil {
 		log.Printf("Error writing team JSON: %v", err)
@@ -152,6 +371,11 @@ func handleAPITeamDetails(w http.ResponseWriter, r *http.Request) {
 func handleAPIRoster(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	teamID := vars["teamId"]
+	format, err := exportFormat(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	roster, err := GetRoster(teamID)
 	if err != nil {
@@ -163,6 +387,10 @@ func handleAPIRoster(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), status)
 		return
 	}
+	if format != "" {
+		exportRoster(w, format, teamID, roster)
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := roster.WriteJSON(w); err != nil {
@@ -173,12 +401,23 @@ func handleAPIRoster(w http.ResponseWriter, r *http.Request) {
 func handleAPIProspects(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	teamAbbrev := vars["teamAbbrev"]
+	format, err := exportFormat(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	data, err := GetProspects(teamAbbrev)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadGateway)
 		return
 	}
+	if format != "" {
+		if err := exportProspects(w, format, teamAbbrev, data); err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+		}
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if _, err := w.Write(data); err != nil {
@@ -189,6 +428,15 @@ func handleAPIProspects(w http.ResponseWriter, r *http.Request) {
 func handleAPIPlayer(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	playerID := vars["playerId"]
+	format, err := exportFormat(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if format != "" {
+		handlePlayerSeasonsExport(w, format, playerID)
+		return
+	}
 
 	// Construct NHL API URL
 	url := fmt.Sprintf("%s/player/%s/landing", BaseURL, playerID)
@@ -394,7 +642,7 @@ func handleAPISchedule(w http.ResponseWriter, r *http.Request) {
 			if cacheErr == nil {
 				log.Printf("Found cached schedule for %s in Redis", date)
 				w.Header().Set("Content-Type", "application/json")
-				if _, writeErr := w.Write(cachedData); writeErr != nil {
+				if _, writeErr := w.Write(addWinProbabilities(cachedData)); writeErr != nil {
 					log.Printf("Error writing cached schedule response: %v", writeErr)
 				}
 				return
@@ -417,7 +665,7 @@ func handleAPISchedule(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	if _, err := w.Write(data); err != nil {
+	if _, err := w.Write(addWinProbabilities(data)); err != nil {
 		log.Printf("Error writing schedule data: %v", err)
 		return
 	}
@@ -532,6 +780,15 @@ func handleAPIGameLanding(w http.ResponseWriter, r *http.Request) {
 func handleAPITeamSchedule(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	teamID := vars["teamId"]
+	format, err := exportFormat(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if format != "" {
+		handleTeamScheduleExport(w, r, format, teamID)
+		return
+	}
 
 	// Get team abbreviation from ID
 	teamDetails, err := GetTeamDetails(teamID)
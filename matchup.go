@@ -0,0 +1,358 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	defaultMatchupSeasons = 5
+	maxMatchupSeasons     = 20
+	matchupTopScorers     = 10
+	// firstNHLSeason bounds the all-time walk back through club schedules
+	firstNHLSeason = "19171918"
+)
+
+// MatchupRecord is a record in the matchup from team A's point of view.
+type MatchupRecord struct {
+	GamesPlayed  int `json:"gamesPlayed"`
+	Wins         int `json:"wins"`
+	Losses       int `json:"losses"`
+	OTLosses     int `json:"otLosses"`
+	GoalsFor     int `json:"goalsFor"`
+	GoalsAgainst int `json:"goalsAgainst"`
+}
+
+// MatchupGame is one meeting between the two teams.
+type MatchupGame struct {
+	GameID         int64  `json:"gameId"`
+	Season         string `json:"season"`
+	GameType       int    `json:"gameType"`
+	GameDate       string `json:"gameDate"`
+	StartTimeUTC   string `json:"startTimeUTC,omitempty"`
+	Venue          string `json:"venue,omitempty"`
+	Home           string `json:"home"`
+	Away           string `json:"away"`
+	HomeScore      int    `json:"homeScore"`
+	AwayScore      int    `json:"awayScore"`
+	LastPeriodType string `json:"lastPeriodType,omitempty"`
+	Winner         string `json:"winner,omitempty"`
+}
+
+// MatchupSeason is one season's series; Record counts regular-season games
+// only.
+type MatchupSeason struct {
+	Season string        `json:"season"`
+	Record MatchupRecord `json:"record"`
+	Games  []MatchupGame `json:"games"`
+}
+
+// MatchupScorer is a player's production in games between the two teams.
+type MatchupScorer struct {
+	PlayerID int    `json:"playerId"`
+	Name     string `json:"name"`
+	Team     string `json:"team"`
+	Goals    int    `json:"goals"`
+	Assists  int    `json:"assists"`
+	Points   int    `json:"points"`
+}
+
+// Matchup is the /api/matchup/{teamA}/{teamB} response. Records are from
+// team A's point of view and all-time, every season from FirstSeason on,
+// with regular-season and playoff games kept apart. SeasonSeries and
+// TopScorers cover the recent seasons listed in Seasons.
+type Matchup struct {
+	TeamA         string          `json:"teamA"`
+	TeamB         string          `json:"teamB"`
+	FirstSeason   string          `json:"firstSeason"`
+	Seasons       []string        `json:"seasons"`
+	SeasonSeries  []MatchupSeason `json:"seasonSeries"`
+	RegularSeason MatchupRecord   `json:"regularSeason"`
+	Playoffs      MatchupRecord   `json:"playoffs"`
+	TopScorers    []MatchupScorer `json:"topScorers"`
+	NextMeeting   *MatchupGame    `json:"nextMeeting,omitempty"`
+	StandingsA    *Team           `json:"standingsA,omitempty"`
+	StandingsB    *Team           `json:"standingsB,omitempty"`
+}
+
+// add folds a final game into the record from teamA's side.
+func (r *MatchupRecord) add(g MatchupGame, teamA string) {
+	r.GamesPlayed++
+	gf, ga := g.HomeScore, g.AwayScore
+	if g.Away == teamA {
+		gf, ga = ga, gf
+	}
+	r.GoalsFor += gf
+	r.GoalsAgainst += ga
+	switch {
+	case g.Winner == teamA:
+		r.Wins++
+	case g.LastPeriodType == "OT" || g.LastPeriodType == "SO":
+		r.OTLosses++
+	default:
+		r.Losses++
+	}
+}
+
+func matchupGameFrom(g ClubScheduleGame) MatchupGame {
+	mg := MatchupGame{
+		GameID:         g.ID,
+		Season:         strconv.Itoa(g.Season),
+		GameType:       g.GameType,
+		GameDate:       g.GameDate,
+		StartTimeUTC:   g.StartTimeUTC,
+		Venue:          g.Venue.Default,
+		Home:           g.HomeTeam.Abbrev,
+		Away:           g.AwayTeam.Abbrev,
+		HomeScore:      g.HomeTeam.Score,
+		AwayScore:      g.AwayTeam.Score,
+		LastPeriodType: g.GameOutcome.LastPeriodType,
+	}
+	if isGameFinal(g.GameState) {
+		mg.Winner = mg.Home
+		if mg.AwayScore > mg.HomeScore {
+			mg.Winner = mg.Away
+		}
+	}
+	return mg
+}
+
+// matchupScorers totals goals and assists from the play-by-play of games.
+func matchupScorers(games []MatchupGame) []MatchupScorer {
+	scorers := map[int]*MatchupScorer{}
+	for _, g := range games {
+		pbp, err := GetPlayByPlay(strconv.FormatInt(g.GameID, 10))
+		if err != nil {
+			log.Printf("matchupScorers: skipping game %d: %v", g.GameID, err)
+			continue
+		}
+		teamAbbrev := map[int]string{pbp.HomeTeam.ID: pbp.HomeTeam.Abbrev, pbp.AwayTeam.ID: pbp.AwayTeam.Abbrev}
+		spots := map[int]RosterSpot{}
+		for _, rs := range pbp.RosterSpots {
+			spots[rs.PlayerID] = rs
+		}
+		credit := func(playerID int, goal bool) {
+			if playerID == 0 {
+				return
+			}
+			s, ok := scorers[playerID]
+			if !ok {
+				rs := spots[playerID]
+				s = &MatchupScorer{
+					PlayerID: playerID,
+					Name:     rs.FirstName.Default + " " + rs.LastName.Default,
+					Team:     teamAbbrev[rs.TeamID],
+				}
+				scorers[playerID] = s
+			}
+			if goal {
+				s.Goals++
+			} else {
+				s.Assists++
+			}
+			s.Points++
+		}
+		for _, p := range pbp.Plays {
+			// Shootout goals don't count toward player totals.
+			if p.TypeDescKey != eventGoal || p.PeriodDescriptor.PeriodType == "SO" {
+				continue
+			}
+			credit(p.Details.ScoringPlayerID, true)
+			credit(p.Details.Assist1PlayerID, false)
+			credit(p.Details.Assist2PlayerID, false)
+		}
+	}
+
+	out := make([]MatchupScorer, 0, len(scorers))
+	for _, s := range scorers {
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Points != out[j].Points {
+			return out[i].Points > out[j].Points
+		}
+		if out[i].Goals != out[j].Goals {
+			return out[i].Goals > out[j].Goals
+		}
+		return out[i].Name < out[j].Name
+	})
+	if len(out) > matchupTopScorers {
+		out = out[:matchupTopScorers]
+	}
+	return out
+}
+
+// matchupSeason is every meeting of two teams in one season, final or
+// not. Played is false when team A has no games that season.
+type matchupSeason struct {
+	Played bool          `json:"played"`
+	Games  []MatchupGame `json:"games"`
+}
+
+// getMatchupSeason reads one season's meetings from teamA's club schedule.
+// Completed seasons never change, so their meetings are cached for good
+// and an all-time record is a sum of small cached pieces.
+func getMatchupSeason(teamA, teamB, season string) (*matchupSeason, error) {
+	build := func() ([]byte, error) {
+		sched, err := GetClubSchedule(teamA, season)
+		if err != nil {
+			return nil, err
+		}
+		ms := matchupSeason{Played: len(sched.Games) > 0, Games: []MatchupGame{}}
+		for _, g := range sched.Games {
+			if g.GameType != 2 && g.GameType != 3 {
+				continue
+			}
+			if g.HomeTeam.Abbrev == teamB || g.AwayTeam.Abbrev == teamB {
+				ms.Games = append(ms.Games, matchupGameFrom(g))
+			}
+		}
+		return json.Marshal(ms)
+	}
+	var data []byte
+	var err error
+	if season < currentSeasonID() {
+		data, err = getCachedOrFetchWithBackoff(fmt.Sprintf("matchup-season:%s:%s:%s", teamA, teamB, season), build, determineTTL("static"))
+	} else {
+		data, err = build()
+	}
+	if err != nil {
+		return nil, err
+	}
+	var ms matchupSeason
+	if err := json.Unmarshal(data, &ms); err != nil {
+		return nil, fmt.Errorf("parsing matchup season: %w", err)
+	}
+	return &ms, nil
+}
+
+// GetMatchup builds the head-to-head history between teamA and teamB from
+// teamA's club schedules: all-time records, walking back until two seasons
+// in a row without games (one empty season is the 2004-05 lockout), and
+// the series and top scorers of the current season and the seasons-1
+// before it.
+func GetMatchup(teamA, teamB string, seasons int) (*Matchup, error) {
+	m := &Matchup{
+		TeamA:        teamA,
+		TeamB:        teamB,
+		Seasons:      []string{},
+		SeasonSeries: []MatchupSeason{},
+	}
+
+	var finals []MatchupGame
+	empty := 0
+	current := currentSeasonID()
+	for season := current; season >= firstNHLSeason && empty < 2; season = previousSeasonID(season) {
+		recent := len(m.Seasons) < seasons
+		ms, err := getMatchupSeason(teamA, teamB, season)
+		if err != nil {
+			if m.FirstSeason == "" {
+				return nil, err
+			}
+			log.Printf("GetMatchup: %s %s: %v", teamA, season, err)
+			break
+		}
+		if !ms.Played {
+			empty++
+			continue
+		}
+		empty = 0
+		m.FirstSeason = season
+
+		series := MatchupSeason{Season: season, Games: []MatchupGame{}}
+		for _, mg := range ms.Games {
+			if mg.Winner == "" {
+				// Unplayed games of past seasons were cancelled
+				if season == current && (m.NextMeeting == nil || mg.StartTimeUTC < m.NextMeeting.StartTimeUTC) {
+					next := mg
+					m.NextMeeting = &next
+				}
+				continue
+			}
+			if mg.GameType == 3 {
+				m.Playoffs.add(mg, teamA)
+			} else {
+				m.RegularSeason.add(mg, teamA)
+				series.Record.add(mg, teamA)
+			}
+			if recent {
+				series.Games = append(series.Games, mg)
+				finals = append(finals, mg)
+			}
+		}
+		if recent {
+			m.Seasons = append(m.Seasons, season)
+			m.SeasonSeries = append(m.SeasonSeries, series)
+		}
+	}
+
+	m.TopScorers = matchupScorers(finals)
+
+	if teams, err := GetAllTeams(); err == nil {
+		for i := range teams.Teams {
+			t := teams.Teams[i]
+			switch t.Abbrev {
+			case teamA:
+				m.StandingsA = &t
+			case teamB:
+				m.StandingsB = &t
+			}
+		}
+	} else {
+		log.Printf("GetMatchup: standings unavailable: %v", err)
+	}
+	return m, nil
+}
+
+var matchupBuilds = newBackgroundBuilds()
+
+func handleAPIMatchup(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	teamA, okA := resolveTeamAbbrev(vars["teamA"])
+	teamB, okB := resolveTeamAbbrev(vars["teamB"])
+	if !okA || !okB {
+		http.Error(w, "unknown team", http.StatusNotFound)
+		return
+	}
+	if teamA == teamB {
+		http.Error(w, "pick two different teams", http.StatusBadRequest)
+		return
+	}
+	seasons := defaultMatchupSeasons
+	if s := r.URL.Query().Get("seasons"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > maxMatchupSeasons {
+			http.Error(w, fmt.Sprintf("seasons must be between 1 and %d", maxMatchupSeasons), http.StatusBadRequest)
+			return
+		}
+		seasons = n
+	}
+
+	// A cold pair walks every season's club schedule and the recent games'
+	// play-by-play, so it is built in the background and the first request
+	// answers 503 until it is ready.
+	cacheKey := fmt.Sprintf("matchup:%s:%s:%d", teamA, teamB, seasons)
+	data, err := matchupBuilds.get(cacheKey, time.Hour, func() ([]byte, error) {
+		m, err := GetMatchup(teamA, teamB, seasons)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(m)
+	})
+	if err != nil {
+		writeStillBuilding(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(data); err != nil {
+		log.Printf("Error writing matchup JSON: %v", err)
+	}
+}
@@ -26,6 +26,20 @@ type Team struct {
 	LastTen      string  `json:"lastTen,omitempty"`
 	Streak       string  `json:"streak,omitempty"`
 	WinPct       float64 `json:"winPct,omitempty"`
+
+	RegulationWins       int     `json:"regulationWins"`
+	RegulationPlusOtWins int     `json:"regulationPlusOtWins"`
+	PointPct             float64 `json:"pointPctg"`
+
+	// Filled in by ApplyStandingsEngine.
+	LeagueRank      int    `json:"leagueRank,omitempty"`
+	ConferenceRank  int    `json:"conferenceRank,omitempty"`
+	DivisionRank    int    `json:"divisionRank,omitempty"`
+	WildcardRank    int    `json:"wildcardRank,omitempty"`
+	PlayoffSeed     string `json:"playoffSeed,omitempty"`
+	ClinchIndicator string `json:"clinchIndicator,omitempty"`
+	MagicNumber     *int   `json:"magicNumber,omitempty"`
+	TragicNumber    *int   `json:"tragicNumber,omitempty"`
 }
 
 // TeamDetails contains detailed team information
@@ -51,7 +65,7 @@ type TeamDetails struct {
 		FranchiseID int    `json:"franchiseId"`
 		TeamName    string `json:"teamName"`
 	} `json:"franchise"`
-	Stats  []interface{} `json:"stats"`
+	Stats  *TeamSeasonStats `json:"stats,omitempty"`
 	Record []struct {
 		Type           string `json:"type"`
 		Wins           int    `json:"wins"`
@@ -62,6 +76,38 @@ type TeamDetails struct {
 	} `json:"record"`
 }
 
+// TeamSeasonStats is a team's season rates with league ranks (1 = best).
+type TeamSeasonStats struct {
+	Season              string        `json:"season"`
+	GameType            int           `json:"gameType"`
+	GamesPlayed         int           `json:"gamesPlayed"`
+	GoalsFor            int           `json:"goalsFor"`
+	GoalsAgainst        int           `json:"goalsAgainst"`
+	GoalsForPerGame     float64       `json:"goalsForPerGame"`
+	GoalsAgainstPerGame float64       `json:"goalsAgainstPerGame"`
+	ShotsForPerGame     float64       `json:"shotsForPerGame"`
+	ShotsAgainstPerGame float64       `json:"shotsAgainstPerGame"`
+	PowerPlayPct        float64       `json:"powerPlayPct"`
+	PenaltyKillPct      float64       `json:"penaltyKillPct"`
+	FaceoffWinPct       float64       `json:"faceoffWinPct"`
+	ShootingPct         float64       `json:"shootingPct"`
+	SavePct             float64       `json:"savePct"`
+	Ranks               TeamStatRanks `json:"ranks"`
+}
+
+// TeamStatRanks are league ranks for each TeamSeasonStats rate.
+type TeamStatRanks struct {
+	GoalsForPerGame     int `json:"goalsForPerGame"`
+	GoalsAgainstPerGame int `json:"goalsAgainstPerGame"`
+	ShotsForPerGame     int `json:"shotsForPerGame"`
+	ShotsAgainstPerGame int `json:"shotsAgainstPerGame"`
+	PowerPlayPct        int `json:"powerPlayPct"`
+	PenaltyKillPct      int `json:"penaltyKillPct"`
+	FaceoffWinPct       int `json:"faceoffWinPct"`
+	ShootingPct         int `json:"shootingPct"`
+	SavePct             int `json:"savePct"`
+}
+
 // PlayerInfo represents a player on a roster
 type PlayerInfo struct {
 	ID            int          `json:"id"`
@@ -151,3 +197,213 @@ func (td *TeamDetailsResponse) WriteJSON(w io.Writer) error {
 func (r *RosterResponse) WriteJSON(w io.Writer) error {
 	return json.NewEncoder(w).Encode(r)
 }
+
+// LocalizedString is the upstream {"default": "..."} wrapper used for names.
+type LocalizedString struct {
+	Default string `json:"default"`
+}
+
+// PlayByPlay is the typed view of /gamecenter/{id}/play-by-play that we use
+// for analytics. Only fields we consume are decoded.
+type PlayByPlay struct {
+	ID        int64  `json:"id"`
+	Season    int    `json:"season"`
+	GameType  int    `json:"gameType"`
+	GameDate  string `json:"gameDate"`
+	GameState string `json:"gameState"`
+	HomeTeam  struct {
+		ID     int             `json:"id"`
+		Abbrev string          `json:"abbrev"`
+		Score  int             `json:"score"`
+		Name   LocalizedString `json:"commonName"`
+	} `json:"homeTeam"`
+	AwayTeam struct {
+		ID     int             `json:"id"`
+		Abbrev string          `json:"abbrev"`
+		Score  int             `json:"score"`
+		Name   LocalizedString `json:"commonName"`
+	} `json:"awayTeam"`
+	Plays       []PlayEvent  `json:"plays"`
+	RosterSpots []RosterSpot `json:"rosterSpots"`
+}
+
+// PlayEvent is a single entry in the play-by-play `plays` array.
+type PlayEvent struct {
+	EventID          int `json:"eventId"`
+	PeriodDescriptor struct {
+		Number     int    `json:"number"`
+		PeriodType string `json:"periodType"`
+	} `json:"periodDescriptor"`
+	TimeInPeriod          string `json:"timeInPeriod"`
+	SituationCode         string `json:"situationCode"`
+	HomeTeamDefendingSide string `json:"homeTeamDefendingSide"`
+	TypeDescKey           string `json:"typeDescKey"`
+	SortOrder             int    `json:"sortOrder"`
+	Details               struct {
+		EventOwnerTeamID int     `json:"eventOwnerTeamId"`
+		XCoord           float64 `json:"xCoord"`
+		YCoord           float64 `json:"yCoord"`
+		ZoneCode         string  `json:"zoneCode"`
+		ShotType         string  `json:"shotType"`
+		ShootingPlayerID int     `json:"shootingPlayerId"`
+		ScoringPlayerID  int     `json:"scoringPlayerId"`
+		Assist1PlayerID  int     `json:"assist1PlayerId"`
+		Assist2PlayerID  int     `json:"assist2PlayerId"`
+		BlockingPlayerID int     `json:"blockingPlayerId"`
+		GoalieInNetID    int     `json:"goalieInNetId"`
+		HomeScore        int     `json:"homeScore"`
+		AwayScore        int     `json:"awayScore"`
+		Reason           string  `json:"reason"`
+	} `json:"details"`
+}
+
+// RosterSpot is a dressed player listed in the play-by-play payload.
+type RosterSpot struct {
+	TeamID        int             `json:"teamId"`
+	PlayerID      int             `json:"playerId"`
+	FirstName     LocalizedString `json:"firstName"`
+	LastName      LocalizedString `json:"lastName"`
+	SweaterNumber int             `json:"sweaterNumber"`
+	PositionCode  string          `json:"positionCode"`
+	Headshot      string          `json:"headshot"`
+}
+
+// ClubSchedule is the typed view of /club-schedule-season/{team}/{season}.
+type ClubSchedule struct {
+	PreviousSeason int                `json:"previousSeason"`
+	CurrentSeason  int                `json:"currentSeason"`
+	ClubTimezone   string             `json:"clubTimezone"`
+	Games          []ClubScheduleGame `json:"games"`
+}
+
+// ClubScheduleGame is a single game in a club season schedule.
+type ClubScheduleGame struct {
+	ID           int64           `json:"id"`
+	Season       int             `json:"season"`
+	GameType     int             `json:"gameType"`
+	GameDate     string          `json:"gameDate"`
+	StartTimeUTC string          `json:"startTimeUTC"`
+	GameState    string          `json:"gameState"`
+	Venue        LocalizedString `json:"venue"`
+	HomeTeam     ScheduleTeam    `json:"homeTeam"`
+	AwayTeam     ScheduleTeam    `json:"awayTeam"`
+	GameOutcome  struct {
+		LastPeriodType string `json:"lastPeriodType"`
+	} `json:"gameOutcome"`
+	TVBroadcasts []struct {
+		Market  string `json:"market"`
+		Network string `json:"network"`
+	} `json:"tvBroadcasts"`
+}
+
+// ScheduleTeam is one side of a scheduled game.
+type ScheduleTeam struct {
+	ID        int             `json:"id"`
+	Abbrev    string          `json:"abbrev"`
+	Score     int             `json:"score"`
+	PlaceName LocalizedString `json:"placeName"`
+	Logo      string          `json:"logo"`
+}
+
+// PlayerLanding is the typed subset of /player/{id}/landing used for
+// comparisons, search and trivia.
+type PlayerLanding struct {
+	PlayerID           int                 `json:"playerId"`
+	FirstName          LocalizedString     `json:"firstName"`
+	LastName           LocalizedString     `json:"lastName"`
+	Position           string              `json:"position"`
+	BirthDate          string              `json:"birthDate"`
+	Headshot           string              `json:"headshot"`
+	CurrentTeamAbbrev  string              `json:"currentTeamAbbrev"`
+	IsActive           bool                `json:"isActive"`
+	SweaterNumber      int                 `json:"sweaterNumber"`
+	BirthCity          LocalizedString     `json:"birthCity"`
+	BirthStateProvince LocalizedString     `json:"birthStateProvince"`
+	BirthCountry       string              `json:"birthCountry"`
+	DraftDetails       *PlayerDraft        `json:"draftDetails"`
+	SeasonTotals       []PlayerSeasonTotal `json:"seasonTotals"`
+	CareerTotals       struct {
+		RegularSeason PlayerStatLine `json:"regularSeason"`
+		Playoffs      PlayerStatLine `json:"playoffs"`
+	} `json:"careerTotals"`
+}
+
+// PlayerDraft is a landing's draftDetails; nil for undrafted players.
+type PlayerDraft struct {
+	Year        int    `json:"year"`
+	TeamAbbrev  string `json:"teamAbbrev"`
+	Round       int    `json:"round"`
+	PickInRound int    `json:"pickInRound"`
+	OverallPick int    `json:"overallPick"`
+}
+
+// PlayerSeasonTotal is one row of a landing's seasonTotals: a season, league
+// and team, split by game type.
+type PlayerSeasonTotal struct {
+	PlayerStatLine
+	Season       int             `json:"season"`
+	GameTypeID   int             `json:"gameTypeId"`
+	LeagueAbbrev string          `json:"leagueAbbrev"`
+	TeamName     LocalizedString `json:"teamName"`
+	TeamID       int             `json:"teamId"`
+	Sequence     int             `json:"sequence"`
+}
+
+// PlayerStatLine holds counting stats shared by season and career totals.
+// Skater and goalie fields share one struct; the unused side stays zero.
+type PlayerStatLine struct {
+	GamesPlayed     int     `json:"gamesPlayed"`
+	Goals           int     `json:"goals"`
+	Assists         int     `json:"assists"`
+	Points          int     `json:"points"`
+	PlusMinus       int     `json:"plusMinus"`
+	PIM             int     `json:"pim"`
+	Shots           int     `json:"shots"`
+	PowerPlayGoals  int     `json:"powerPlayGoals"`
+	Wins            int     `json:"wins"`
+	Losses          int     `json:"losses"`
+	OTLosses        int     `json:"otLosses"`
+	Shutouts        int     `json:"shutouts"`
+	ShotsAgainst    int     `json:"shotsAgainst"`
+	GoalsAgainst    int     `json:"goalsAgainst"`
+	GoalsAgainstAvg float64 `json:"goalsAgainstAvg"`
+	SavePctg        float64 `json:"savePctg"`
+}
+
+// PlayerGameLog is the typed view of /player/{id}/game-log/{season}/{gameType}.
+// Skater and goalie fields share one struct; the unused side stays zero.
+type PlayerGameLog struct {
+	SeasonID int                  `json:"seasonId"`
+	GameType int                  `json:"gameTypeId"`
+	GameLog  []PlayerGameLogEntry `json:"gameLog"`
+}
+
+// PlayerGameLogEntry is one game's line for a player.
+type PlayerGameLogEntry struct {
+	GameID             int64           `json:"gameId"`
+	TeamAbbrev         string          `json:"teamAbbrev"`
+	HomeRoadFlag       string          `json:"homeRoadFlag"`
+	GameDate           string          `json:"gameDate"`
+	OpponentAbbrev     string          `json:"opponentAbbrev"`
+	OpponentCommonName LocalizedString `json:"opponentCommonName"`
+	Goals              int             `json:"goals"`
+	Assists            int             `json:"assists"`
+	Points             int             `json:"points"`
+	PlusMinus          int             `json:"plusMinus"`
+	PowerPlayGoals     int             `json:"powerPlayGoals"`
+	PowerPlayPoints    int             `json:"powerPlayPoints"`
+	GameWinningGoals   int             `json:"gameWinningGoals"`
+	OTGoals            int             `json:"otGoals"`
+	Shots              int             `json:"shots"`
+	Shifts             int             `json:"shifts"`
+	ShorthandedGoals   int             `json:"shorthandedGoals"`
+	ShorthandedPoints  int             `json:"shorthandedPoints"`
+	PIM                int             `json:"pim"`
+	TOI                string          `json:"toi"`
+	GamesStarted       int             `json:"gamesStarted"`
+	Decision           string          `json:"decision"`
+	ShotsAgainst       int             `json:"shotsAgainst"`
+	GoalsAgainst       int             `json:"goalsAgainst"`
+	SavePctg           float64         `json:"savePctg"`
+	Shutouts           int             `json:"shutouts"`
+}
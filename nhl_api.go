@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -12,6 +13,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -496,6 +498,82 @@ func getCachedOrFetchWithBackoff(cacheKey string, fetchFunc func() ([]byte, erro
 	return nil, fmt.Errorf("failed to fetch %s after all retries", cacheKey)
 }
 
+// errStillBuilding is returned by backgroundBuilds.get while the first build
+// of a key is running; handlers answer 503 with Retry-After.
+var errStillBuilding = errors.New("still building, try again shortly")
+
+// backgroundBuilds runs slow aggregates (a season of play-by-play and the
+// like) off the request path. The last result of each key is kept in memory
+// as well as Redis, so it is served while a rebuild runs and when Redis is
+// off.
+type backgroundBuilds struct {
+	mu      sync.Mutex
+	running map[string]bool
+	results map[string]builtResult
+}
+
+type builtResult struct {
+	data    []byte
+	expires time.Time // zero for results that never expire
+}
+
+func (r builtResult) fresh() bool {
+	return r.expires.IsZero() || time.Now().Before(r.expires)
+}
+
+func newBackgroundBuilds() *backgroundBuilds {
+	return &backgroundBuilds{running: map[string]bool{}, results: map[string]builtResult{}}
+}
+
+// get returns the cached result for key, starting a build when there is
+// none or it has expired. It only returns errStillBuilding when there is
+// nothing at all to serve yet.
+func (b *backgroundBuilds) get(key string, ttl time.Duration, build func() ([]byte, error)) ([]byte, error) {
+	if data, err := getCachedRaw(key); err == nil {
+		return data, nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	res, ok := b.results[key]
+	if ok && res.fresh() {
+		return res.data, nil
+	}
+	if !b.running[key] {
+		b.running[key] = true
+		go b.run(key, ttl, build)
+	}
+	if ok {
+		return res.data, nil
+	}
+	return nil, errStillBuilding
+}
+
+func (b *backgroundBuilds) run(key string, ttl time.Duration, build func() ([]byte, error)) {
+	start := time.Now()
+	// The inflight lock in getCachedOrFetchWithBackoff keeps replicas from
+	// building the same key at once.
+	data, err := getCachedOrFetchWithBackoff(key, build, ttl)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.running, key)
+	if err != nil {
+		log.Printf("background build %s: %v", key, err)
+		return
+	}
+	res := builtResult{data: data}
+	if ttl > 0 {
+		res.expires = time.Now().Add(ttl)
+	}
+	b.results[key] = res
+	log.Printf("background build %s in %s", key, time.Since(start).Round(time.Millisecond))
+}
+
+// writeStillBuilding answers a request whose data is being built.
+func writeStillBuilding(w http.ResponseWriter) {
+	w.Header().Set("Retry-After", "30")
+	http.Error(w, errStillBuilding.Error(), http.StatusServiceUnavailable)
+}
+
 // isValidForCache performs basic validation of fetched payloads before caching.
 // It recognizes known cache key prefixes and ensures critical fields are present.
 func isValidForCache(cacheKey string, data []byte) (bool, string) {
@@ -718,9 +796,11 @@ func isAnyGameLive() (bool, error) {
 	return false, nil
 }
 
-// BaseURL is the upstream NHL API base URL.
+// BaseURL is the upstream NHL API base URL; StatsURL is the stats REST API
+// used for filterable season summaries.
 const (
-	BaseURL = "https://api-web.nhle.com/v1"
+	BaseURL  = "https://api-web.nhle.com/v1"
+	StatsURL = "https://api.nhle.com/stats/rest/en"
 )
 
 // GameLanding represents a typed view of the /gamecenter/{id}/landing JSON we fetch
@@ -763,6 +843,20 @@ type GameLanding struct {
 				PeriodType string `json:"periodType"`
 			} `json:"periodDescriptor"`
 			Goals []struct {
+				EventID      int64           `json:"eventId"`
+				PlayerID     int64           `json:"playerId"`
+				Name         LocalizedString `json:"name"`
+				TeamAbbrev   LocalizedString `json:"teamAbbrev"`
+				TimeInPeriod string          `json:"timeInPeriod"`
+				Strength     string          `json:"strength"`
+				ShotType     string          `json:"shotType"`
+				GoalModifier string          `json:"goalModifier"`
+				HomeScore    int             `json:"homeScore"`
+				AwayScore    int             `json:"awayScore"`
+				Assists      []struct {
+					PlayerID int64           `json:"playerId"`
+					Name     LocalizedString `json:"name"`
+				} `json:"assists"`
 				DiscreteClip            int64  `json:"discreteClip"`
 				DiscreteClipFr          int64  `json:"discreteClipFr"`
 				HighlightClipSharingURL string `json:"highlightClipSharingUrl"`
@@ -907,6 +1001,20 @@ var (
 		"Utah Mammoth": "UTA", "Vancouver Canucks": "VAN", "Vegas Golden Knights": "VGK",
 		"Washington Capitals": "WSH", "Winnipeg Jets": "WPG",
 	}
+	// Each abbreviation's current full name, for titles and chat replies
+	teamAbbrToName = map[string]string{
+		"ANA": "Anaheim Ducks", "ARI": "Arizona Coyotes", "BOS": "Boston Bruins",
+		"BUF": "Buffalo Sabres", "CGY": "Calgary Flames", "CAR": "Carolina Hurricanes",
+		"CHI": "Chicago Blackhawks", "COL": "Colorado Avalanche", "CBJ": "Columbus Blue Jackets",
+		"DAL": "Dallas Stars", "DET": "Detroit Red Wings", "EDM": "Edmonton Oilers",
+		"FLA": "Florida Panthers", "LAK": "Los Angeles Kings", "MIN": "Minnesota Wild",
+		"MTL": "Montréal Canadiens", "NSH": "Nashville Predators", "NJD": "New Jersey Devils",
+		"NYI": "New York Islanders", "NYR": "New York Rangers", "OTT": "Ottawa Senators",
+		"PHI": "Philadelphia Flyers", "PIT": "Pittsburgh Penguins", "SJS": "San Jose Sharks",
+		"SEA": "Seattle Kraken", "STL": "St. Louis Blues", "TBL": "Tampa Bay Lightning",
+		"TOR": "Toronto Maple Leafs", "UTA": "Utah Mammoth", "VAN": "Vancouver Canucks",
+		"VGK": "Vegas Golden Knights", "WSH": "Washington Capitals", "WPG": "Winnipeg Jets",
+	}
 )
 
 // Map team abbreviations to their IDs (reverse of teamIDToAbbr)
@@ -920,20 +1028,19 @@ func init() {
 	}
 }
 
+// seasonStartYear is the year the season containing t began. Seasons roll
+// over on September 1, after the playoffs and before training camp.
+func seasonStartYear(t time.Time) int {
+	if t.Month() < time.September {
+		return t.Year() - 1
+	}
+	return t.Year()
+}
+
 // currentSeasonID returns the active NHL season ID like 20252026.
-// Season rolls over on September 1: months Sep-Dec belong to currentYear-nextYear.
 func currentSeasonID() string {
-	now := time.Now().UTC()
-	year := now.Year()
-	var startYear, endYear int
-	if now.Month() >= time.September { // new season starts in September
-		startYear = year
-		endYear = year + 1
-	} else {
-		startYear = year - 1
-		endYear = year
-	}
-	return fmt.Sprintf("%d%d", startYear, endYear)
+	startYear := seasonStartYear(time.Now().UTC())
+	return fmt.Sprintf("%d%d", startYear, startYear+1)
 }
 
 // getStandingsDate returns today's date in YYYY-MM-DD format
@@ -941,10 +1048,19 @@ func getStandingsDate() string {
 	return time.Now().Format("2006-01-02")
 }
 
-// GetAllTeams fetches all NHL teams from standings
+// GetAllTeams fetches all NHL teams from today's standings
 func GetAllTeams() (*TeamsResponse, error) {
-	standingsDate := getStandingsDate()
+	return GetStandingsForDate(getStandingsDate())
+}
+
+// GetStandingsForDate fetches league standings as they stood on date
+// (YYYY-MM-DD). Past dates never change, so they are cached without expiry.
+func GetStandingsForDate(standingsDate string) (*TeamsResponse, error) {
 	cacheKey := fmt.Sprintf("standings:%s", standingsDate)
+	ttl := determineTTL("standings")
+	if standingsDate < getStandingsDate() {
+		ttl = determineTTL("static")
+	}
 
 	// Try cache first, then fetch with backoff if needed
 	data, err := getCachedOrFetchWithBackoff(cacheKey, func() ([]byte, error) {
@@ -962,7 +1078,7 @@ func GetAllTeams() (*TeamsResponse, error) {
 		}()
 
 		return io.ReadAll(body)
-	}, determineTTL("standings")) // Cache standings with dynamic TTL
+	}, ttl) // Dynamic TTL for today, no expiry for past dates
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to get standings data: %w", err)
@@ -993,6 +1109,10 @@ func GetAllTeams() (*TeamsResponse, error) {
 			StreakCode       string  `json:"streakCode"`
 			StreakCount      int     `json:"streakCount"`
 			WinPctg          float64 `json:"winPctg"`
+			PointPctg        float64 `json:"pointPctg"`
+			RegulationWins   int     `json:"regulationWins"`
+			RegulationPlusOt int     `json:"regulationPlusOtWins"`
+			SeasonID         int     `json:"seasonId"`
 			RecordSummary    struct {
 				LastTen string `json:"lastTen"`
 				Streak  string `json:"streak"`
@@ -1052,9 +1172,19 @@ func GetAllTeams() (*TeamsResponse, error) {
 			LastTen:      lastTen,
 			Streak:       streak,
 			WinPct:       standing.WinPctg,
+
+			RegulationWins:       standing.RegulationWins,
+			RegulationPlusOtWins: standing.RegulationPlusOt,
+			PointPct:             standing.PointPctg,
 		})
 	}
 
+	season := ""
+	if len(standingsResp.Standings) > 0 && standingsResp.Standings[0].SeasonID != 0 {
+		season = strconv.Itoa(standingsResp.Standings[0].SeasonID)
+	}
+	ApplyStandingsEngine(teams, scheduleHeadToHead(season, standingsDate))
+
 	response := &TeamsResponse{Teams: teams}
 	return response, nil
 }
@@ -1178,20 +1308,6 @@ func GetTeamDetails(teamID string) (*TeamDetailsResponse, error) {
 			team.LocationName = standing.TeamCommonName.Default
 			team.Division.Name = standing.DivisionName
 			team.Conference.Name = standing.ConferenceName
-			// Compute diff and extras
-			gd := standing.GoalDifferential
-			if gd == 0 {
-				gd = standing.GoalFor - standing.GoalAgainst
-			}
-			lastTen := ""
-			if standing.L10Wins != 0 || standing.L10Losses != 0 || standing.L10OtLosses != 0 {
-				lastTen = fmt.Sprintf("%d-%d-%d", standing.L10Wins, standing.L10Losses, standing.L10OtLosses)
-			}
-			streak := ""
-			if standing.StreakCode != "" && standing.StreakCount != 0 {
-				streak = fmt.Sprintf("%s%d", strings.ToUpper(standing.StreakCode), standing.StreakCount)
-			}
-
 			team.Record = []struct {
 				Type           string `json:"type"`
 				Wins           int    `json:"wins"`
@@ -1209,16 +1325,6 @@ func GetTeamDetails(teamID string) (*TeamDetailsResponse, error) {
 					Points:         standing.Points,
 				},
 			}
-			// Attach the extra fields to TeamDetails struct if present via heuristics
-			team.Stats = []interface{}{map[string]interface{}{
-				"gamesPlayed":  standing.GamesPlayed,
-				"goalsFor":     standing.GoalFor,
-				"goalsAgainst": standing.GoalAgainst,
-				"goalDiff":     gd,
-				"lastTen":      lastTen,
-				"streak":       streak,
-				"winPct":       standing.WinPctg,
-			}}
 			log.Printf("standings entry abbrev=%q teamName=%q teamCommon=%q", standing.TeamAbbrev.Default, standing.TeamName.Default, standing.TeamCommonName.Default)
 			// Found matching standing; stop searching further to avoid accidental overwrites
 			break
@@ -1622,6 +1728,104 @@ func GetPlayer(playerID string) ([]byte, error) {
 	return data, nil
 }
 
+// GetPlayerLanding decodes the cached player landing payload.
+func GetPlayerLanding(playerID string) (*PlayerLanding, error) {
+	data, err := GetPlayer(playerID)
+	if err != nil {
+		return nil, err
+	}
+	var landing PlayerLanding
+	if err := json.Unmarshal(data, &landing); err != nil {
+		return nil, fmt.Errorf("parsing player landing: %w", err)
+	}
+	return &landing, nil
+}
+
+// readURL fetches url through the rate limiter and returns the full body.
+func readURL(url string) ([]byte, error) {
+	body, err := fetchURL(url)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if cerr := body.Close(); cerr != nil {
+			log.Printf("Error closing response body: %v", cerr)
+		}
+	}()
+	return io.ReadAll(body)
+}
+
+// GetPlayByPlay fetches and decodes /gamecenter/{id}/play-by-play. Finished
+// games never change, so once a game is final its payload is cached without expiry.
+func GetPlayByPlay(gameID string) (*PlayByPlay, error) {
+	cacheKey := fmt.Sprintf("pbp:%s", gameID)
+	data, err := getCachedOrFetchWithBackoff(cacheKey, func() ([]byte, error) {
+		return readURL(fmt.Sprintf("%s/gamecenter/%s/play-by-play", BaseURL, gameID))
+	}, determineTTL("game"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get play-by-play for %s: %w", gameID, err)
+	}
+
+	var pbp PlayByPlay
+	if err := json.Unmarshal(data, &pbp); err != nil {
+		return nil, fmt.Errorf("parsing play-by-play response: %w", err)
+	}
+	if isGameFinal(pbp.GameState) {
+		if setErr := setCachedRaw(cacheKey, data, determineTTL("static")); setErr != nil {
+			log.Printf("Failed to persist final play-by-play for %s: %v", gameID, setErr)
+		}
+	}
+	return &pbp, nil
+}
+
+// GetClubSchedule fetches a team's season schedule. An empty season means the
+// current one. It shares cache keys with the team schedule handler.
+func GetClubSchedule(teamAbbrev, season string) (*ClubSchedule, error) {
+	if season == "" {
+		season = "now"
+	}
+	teamAbbrev = strings.ToUpper(teamAbbrev)
+	cacheKey := fmt.Sprintf("team-schedule:%s:%s", teamAbbrev, season)
+	// Completed seasons never change.
+	ttl := time.Hour
+	if season != "now" && season < currentSeasonID() {
+		ttl = determineTTL("static")
+	}
+	data, err := getCachedOrFetchWithBackoff(cacheKey, func() ([]byte, error) {
+		return readURL(fmt.Sprintf("%s/club-schedule-season/%s/%s", BaseURL, teamAbbrev, season))
+	}, ttl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schedule for %s %s: %w", teamAbbrev, season, err)
+	}
+
+	var sched ClubSchedule
+	if err := json.Unmarshal(data, &sched); err != nil {
+		return nil, fmt.Errorf("parsing club schedule response: %w", err)
+	}
+	return &sched, nil
+}
+
+// GetPlayerGameLog fetches a player's game-by-game log for a season and game
+// type (2 regular season, 3 playoffs). An empty season means the current one.
+func GetPlayerGameLog(playerID, season string, gameType int) (*PlayerGameLog, error) {
+	if season == "" {
+		season = currentSeasonID()
+	}
+	cacheKey := fmt.Sprintf("game-log:%s:%s:%d", playerID, season, gameType)
+	data, err := getCachedOrFetchWithBackoff(cacheKey, func() ([]byte, error) {
+		return readURL(fmt.Sprintf("%s/player/%s/game-log/%s/%d", BaseURL, playerID, season, gameType))
+	}, determineTTL("game"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get game log for player %s: %w", playerID, err)
+	}
+
+	var gl PlayerGameLog
+	if err := json.Unmarshal(data, &gl); err != nil {
+		return nil, fmt.Errorf("parsing game log response: %w", err)
+	}
+	return &gl, nil
+}
+
 // parsePlayerFromRawJSON extracts PlayerInfo fields from the full player landing JSON
 func parsePlayerFromRawJSON(rawJSON []byte, basePlayer PlayerInfo) (PlayerInfo, error) {
 	var playerResp struct {
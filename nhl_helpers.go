@@ -1,5 +1,10 @@
 package main
 
+import (
+	"strconv"
+	"strings"
+)
+
 // interfaceSlice converts a []string to []interface{} for redis calls
 func interfaceSlice(s []string) []interface{} {
 	out := make([]interface{}, len(s))
@@ -8,3 +13,39 @@ func interfaceSlice(s []string) []interface{} {
 	}
 	return out
 }
+
+// resolveTeamAbbrev maps a numeric team ID or an abbreviation (any case) to
+// the canonical NHL abbreviation. The bool reports whether it is a known NHL team.
+func resolveTeamAbbrev(teamID string) (string, bool) {
+	if id, err := strconv.Atoi(teamID); err == nil {
+		abbr, ok := teamIDToAbbr[id]
+		return abbr, ok
+	}
+	abbr := strings.ToUpper(strings.TrimSpace(teamID))
+	_, ok := abbrevToTeamID[abbr]
+	return abbr, ok
+}
+
+// teamFullName is a team's current full name for chat replies, calendars
+// and feeds, or the abbreviation when the team is unknown.
+func teamFullName(abbr string) string {
+	if name, ok := teamAbbrToName[abbr]; ok {
+		return name
+	}
+	return abbr
+}
+
+// isGameFinal reports whether an upstream gameState means the result is settled.
+func isGameFinal(gameState string) bool {
+	return gameState == "FINAL" || gameState == "OFF"
+}
+
+// isGameLive reports whether a schedule gameState means the game is under way.
+func isGameLive(gameState string) bool {
+	return gameState == "LIVE" || gameState == "CRIT"
+}
+
+// isRateLimitErr reports whether an upstream error was a 429.
+func isRateLimitErr(err error) bool {
+	return err != nil && (strings.Contains(err.Error(), "429") || strings.Contains(err.Error(), "Too Many Requests"))
+}
@@ -0,0 +1,548 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Simulation tuning. The OT share and OT/SO split are close to recent
+// league-wide rates; home ice is worth a few points of win probability.
+const (
+	playoffOddsSimulations = 10000
+	simOvertimeRate        = 0.23
+	simShootoutShareOfOT   = 0.4
+	simHomeIceEdge         = 0.035
+	// simRegressionGames pulls early-season point% toward .500 by adding
+	// this many games of average results.
+	simRegressionGames = 10
+)
+
+// nhlLotteryOdds are the first-draw odds (percent) for the 16 non-playoff
+// teams, worst record first.
+var nhlLotteryOdds = []float64{18.5, 13.5, 11.5, 9.5, 8.5, 7.5, 6.5, 6.0, 5.0, 3.5, 3.0, 2.5, 2.0, 1.5, 0.5, 0.5}
+
+// TeamPlayoffOdds is one team's simulated outcomes.
+type TeamPlayoffOdds struct {
+	Abbrev              string    `json:"abbrev"`
+	Name                string    `json:"name"`
+	Conference          string    `json:"conference"`
+	Division            string    `json:"division"`
+	Points              int       `json:"points"`
+	GamesPlayed         int       `json:"gamesPlayed"`
+	GamesRemaining      int       `json:"gamesRemaining"`
+	Rating              float64   `json:"rating"`
+	ProjectedPoints     float64   `json:"projectedPoints"`
+	PlayoffPct          float64   `json:"playoffPct"`
+	DivisionTitlePct    float64   `json:"divisionTitlePct"`
+	PresidentsTrophyPct float64   `json:"presidentsTrophyPct"`
+	FirstOverallPct     float64   `json:"firstOverallPct"`
+	LotteryPositionPct  []float64 `json:"lotteryPositionPct"` // pre-lottery draft slot 1..16
+}
+
+// PlayoffOdds is the /api/playoff-odds response.
+type PlayoffOdds struct {
+	Season      string            `json:"season"`
+	GeneratedAt string            `json:"generatedAt"`
+	Simulations int               `json:"simulations"`
+	FinalGames  int               `json:"finalGames"`
+	Teams       []TeamPlayoffOdds `json:"teams"`
+}
+
+// simTeam is the mutable per-simulation state for a team.
+type simTeam struct {
+	idx        int
+	conference string
+	division   string
+	rating     float64
+	points     int
+	wins       int
+	tiebreak   float64
+}
+
+// simGame is a remaining regular-season game between two team indexes.
+type simGame struct {
+	home, away int
+}
+
+// teamRating converts a team's record into a win-probability strength
+// around .500, regressed toward average so small samples don't dominate.
+func teamRating(t Team) float64 {
+	gp := t.GamesPlayed
+	if gp == 0 {
+		gp = t.Record.Wins + t.Record.Losses + t.Record.OvertimeLosses
+	}
+	// Points% shrunk toward .5 plus a small goal-differential nudge.
+	pp := (float64(t.Record.Points) + simRegressionGames) / (2 * float64(gp+simRegressionGames))
+	gd := 0.0
+	if gp > 0 {
+		gd = float64(t.GoalDiff) / float64(gp)
+	}
+	r := pp + 0.05*gd
+	return math.Min(0.8, math.Max(0.2, r))
+}
+
+// log5 returns the probability that a team of strength a beats strength b.
+func log5(a, b float64) float64 {
+	den := a + b - 2*a*b
+	if den == 0 {
+		return 0.5
+	}
+	return (a - a*b) / den
+}
+
+// loadRemainingGames collects every unplayed regular-season game across the
+// league's club schedules, deduplicated by game ID, and counts finals.
+func loadRemainingGames(teams []Team) (map[int64]ClubScheduleGame, int, error) {
+	remaining := map[int64]ClubScheduleGame{}
+	finals := map[int64]bool{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	errs := 0
+	for _, t := range teams {
+		wg.Add(1)
+		go func(abbrev string) {
+			defer wg.Done()
+			sched, err := GetClubSchedule(abbrev, "")
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				log.Printf("loadRemainingGames: schedule for %s: %v", abbrev, err)
+				errs++
+				return
+			}
+			for _, g := range sched.Games {
+				if g.GameType != 2 {
+					continue
+				}
+				if isGameFinal(g.GameState) {
+					finals[g.ID] = true
+					continue
+				}
+				remaining[g.ID] = g
+			}
+		}(t.Abbrev)
+	}
+	wg.Wait()
+	if errs == len(teams) {
+		return nil, 0, fmt.Errorf("no club schedules available")
+	}
+	return remaining, len(finals), nil
+}
+
+// SimulatePlayoffOdds runs the Monte Carlo simulation over the rest of the
+// regular season using the two-conference, divisional wildcard format.
+func SimulatePlayoffOdds(teams []Team, remaining map[int64]ClubScheduleGame, sims int, seed uint64) []TeamPlayoffOdds {
+	byAbbrev := map[string]int{}
+	base := make([]simTeam, len(teams))
+	for i, t := range teams {
+		byAbbrev[t.Abbrev] = i
+		base[i] = simTeam{
+			idx:        i,
+			conference: t.Conference,
+			division:   t.Division,
+			rating:     teamRating(t),
+			points:     t.Record.Points,
+			wins:       t.Record.Wins,
+		}
+	}
+
+	var games []simGame
+	left := make([]int, len(teams))
+	ids := make([]int64, 0, len(remaining))
+	for id := range remaining {
+		ids = append(ids, id)
+	}
+	// Iterate in a fixed order so the same seed gives the same answer.
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, id := range ids {
+		g := remaining[id]
+		h, okH := byAbbrev[g.HomeTeam.Abbrev]
+		a, okA := byAbbrev[g.AwayTeam.Abbrev]
+		if !okH || !okA {
+			continue
+		}
+		games = append(games, simGame{home: h, away: a})
+		left[h]++
+		left[a]++
+	}
+
+	n := len(teams)
+	playoff := make([]int, n)
+	divTitle := make([]int, n)
+	presidents := make([]int, n)
+	firstOverall := make([]int, n)
+	pointsSum := make([]float64, n)
+	lottery := make([][]int, n)
+	for i := range lottery {
+		lottery[i] = make([]int, len(nhlLotteryOdds))
+	}
+
+	rng := rand.New(rand.NewPCG(seed, seed^0x9e3779b97f4a7c15))
+	state := make([]simTeam, n)
+	for s := 0; s < sims; s++ {
+		copy(state, base)
+		for i := range state {
+			state[i].tiebreak = rng.Float64()
+		}
+		for _, g := range games {
+			h, a := &state[g.home], &state[g.away]
+			pHome := math.Min(0.95, log5(h.rating, a.rating)+simHomeIceEdge)
+			if rng.Float64() < simOvertimeRate {
+				// Extra time: loser still banks a point; OT/SO outcomes are
+				// closer to a coin flip than regulation.
+				h.points++
+				a.points++
+				pExtra := 0.5 + (pHome-0.5)*0.5
+				if rng.Float64() < simShootoutShareOfOT {
+					pExtra = 0.5
+				}
+				if rng.Float64() < pExtra {
+					h.points++
+					h.wins++
+				} else {
+					a.points++
+					a.wins++
+				}
+				continue
+			}
+			if rng.Float64() < pHome {
+				h.points += 2
+				h.wins++
+			} else {
+				a.points += 2
+				a.wins++
+			}
+		}
+
+		order := make([]simTeam, n)
+		copy(order, state)
+		sort.Slice(order, func(i, j int) bool { return simTeamBetter(order[i], order[j]) })
+
+		presidents[order[0].idx]++
+		for _, t := range order {
+			pointsSum[t.idx] += float64(t.points)
+		}
+
+		made := make([]bool, n)
+		for _, conf := range []string{"Eastern", "Western"} {
+			divCount := map[string]int{}
+			var wildcard []simTeam
+			for _, t := range order {
+				if t.conference != conf {
+					continue
+				}
+				if divCount[t.division] == 0 {
+					divTitle[t.idx]++
+				}
+				if divCount[t.division] < 3 {
+					made[t.idx] = true
+				} else {
+					wildcard = append(wildcard, t)
+				}
+				divCount[t.division]++
+			}
+			for i := 0; i < 2 && i < len(wildcard); i++ {
+				made[wildcard[i].idx] = true
+			}
+		}
+
+		// Non-playoff teams, worst record first, form the pre-lottery order.
+		var lotteryTeams []int
+		for i := len(order) - 1; i >= 0; i-- {
+			if !made[order[i].idx] {
+				lotteryTeams = append(lotteryTeams, order[i].idx)
+			} else {
+				playoff[order[i].idx]++
+			}
+		}
+		for pos, idx := range lotteryTeams {
+			if pos < len(nhlLotteryOdds) {
+				lottery[idx][pos]++
+			}
+		}
+		if winner := drawLottery(rng, lotteryTeams); winner >= 0 {
+			firstOverall[winner]++
+		}
+	}
+
+	out := make([]TeamPlayoffOdds, n)
+	for i, t := range teams {
+		lp := make([]float64, len(nhlLotteryOdds))
+		for p, c := range lottery[i] {
+			lp[p] = pctOf(c, sims)
+		}
+		out[i] = TeamPlayoffOdds{
+			Abbrev:              t.Abbrev,
+			Name:                t.Name,
+			Conference:          t.Conference,
+			Division:            t.Division,
+			Points:              t.Record.Points,
+			GamesPlayed:         t.GamesPlayed,
+			GamesRemaining:      left[i],
+			Rating:              round3(base[i].rating),
+			ProjectedPoints:     math.Round(pointsSum[i]/float64(sims)*10) / 10,
+			PlayoffPct:          pctOf(playoff[i], sims),
+			DivisionTitlePct:    pctOf(divTitle[i], sims),
+			PresidentsTrophyPct: pctOf(presidents[i], sims),
+			FirstOverallPct:     pctOf(firstOverall[i], sims),
+			LotteryPositionPct:  lp,
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Conference != out[j].Conference {
+			return out[i].Conference < out[j].Conference
+		}
+		if out[i].PlayoffPct != out[j].PlayoffPct {
+			return out[i].PlayoffPct > out[j].PlayoffPct
+		}
+		return out[i].ProjectedPoints > out[j].ProjectedPoints
+	})
+	return out
+}
+
+// simTeamBetter orders simulated teams by points, then wins, then a random
+// per-simulation tiebreak standing in for the remaining official tiebreakers.
+func simTeamBetter(a, b simTeam) bool {
+	if a.points != b.points {
+		return a.points > b.points
+	}
+	if a.wins != b.wins {
+		return a.wins > b.wins
+	}
+	return a.tiebreak > b.tiebreak
+}
+
+// drawLottery simulates the first lottery draw and returns the winning team
+// index. A winner can move up at most 10 spots, so a team drawn from outside
+// the bottom 11 leaves the first pick with the worst team.
+func drawLottery(rng *rand.Rand, worstFirst []int) int {
+	if len(worstFirst) == 0 {
+		return -1
+	}
+	total := 0.0
+	for i := range worstFirst {
+		if i < len(nhlLotteryOdds) {
+			total += nhlLotteryOdds[i]
+		}
+	}
+	r := rng.Float64() * total
+	for i := range worstFirst {
+		if i >= len(nhlLotteryOdds) {
+			break
+		}
+		r -= nhlLotteryOdds[i]
+		if r < 0 {
+			if i > 10 {
+				return worstFirst[0]
+			}
+			return worstFirst[i]
+		}
+	}
+	return worstFirst[0]
+}
+
+// pctOf returns count/total as a percentage with one decimal.
+func pctOf(count, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(count)/float64(total)*1000) / 10
+}
+
+var (
+	playoffOddsMu     sync.RWMutex
+	playoffOddsLatest *PlayoffOdds
+)
+
+// playoffOddsCacheKey keys results by season and the number of finished
+// games, so any new FINAL produces a fresh simulation.
+func playoffOddsCacheKey(season string, finals int) string {
+	return fmt.Sprintf("playoff-odds:%s:%d", season, finals)
+}
+
+// latestPlayoffOdds returns the last simulated odds without blocking, or
+// nil before the watcher's first run finishes.
+func latestPlayoffOdds() *PlayoffOdds {
+	playoffOddsMu.RLock()
+	defer playoffOddsMu.RUnlock()
+	return playoffOddsLatest
+}
+
+// refreshPlayoffOdds simulates odds for the current standings and makes
+// them the ones served. Results are reused until another regular-season
+// game goes final, and shared with other servers through Redis.
+func refreshPlayoffOdds() (*PlayoffOdds, error) {
+	teamsResp, err := GetAllTeams()
+	if err != nil {
+		return nil, err
+	}
+	var teams []Team
+	for _, t := range teamsResp.Teams {
+		if _, ok := abbrevToTeamID[t.Abbrev]; ok {
+			teams = append(teams, t)
+		}
+	}
+	if len(teams) == 0 {
+		return nil, fmt.Errorf("no standings available")
+	}
+
+	remaining, finals, err := loadRemainingGames(teams)
+	if err != nil {
+		return nil, err
+	}
+	season := currentSeasonID()
+	cacheKey := playoffOddsCacheKey(season, finals)
+
+	if latest := latestPlayoffOdds(); latest != nil && latest.Season == season && latest.FinalGames == finals {
+		return latest, nil
+	}
+	if cached, err := getCachedRaw(cacheKey); err == nil {
+		var odds PlayoffOdds
+		if err := json.Unmarshal(cached, &odds); err == nil {
+			playoffOddsMu.Lock()
+			playoffOddsLatest = &odds
+			playoffOddsMu.Unlock()
+			return &odds, nil
+		}
+	}
+
+	start := time.Now()
+	odds := &PlayoffOdds{
+		Season:      season,
+		GeneratedAt: time.Now().UTC().Format(time.RFC3339),
+		Simulations: playoffOddsSimulations,
+		FinalGames:  finals,
+		Teams:       SimulatePlayoffOdds(teams, remaining, playoffOddsSimulations, uint64(finals)+1),
+	}
+	log.Printf("Simulated playoff odds (%d games left, %d sims) in %v", len(remaining), playoffOddsSimulations, time.Since(start))
+
+	if data, err := json.Marshal(odds); err == nil {
+		if setErr := setCachedRaw(cacheKey, data, 24*time.Hour); setErr != nil {
+			log.Printf("Failed to cache playoff odds: %v", setErr)
+		}
+	}
+	playoffOddsMu.Lock()
+	playoffOddsLatest = odds
+	playoffOddsMu.Unlock()
+	return odds, nil
+}
+
+// startPlayoffOddsWatcher simulates the odds at startup, then polls today's
+// schedule and, when another game goes final, drops the stale standings and
+// club schedules for the two teams and simulates again. Requests only read
+// the last result, since a simulation reads all 32 club schedules.
+func startPlayoffOddsWatcher(interval time.Duration) {
+	go func() {
+		seen := map[int64]bool{}
+		primed := false
+		// stale stays set until a simulation succeeds, so failures retry
+		stale := true
+		refresh := func() {
+			if _, err := refreshPlayoffOdds(); err != nil {
+				log.Printf("playoff odds watcher: recompute failed: %v", err)
+				return
+			}
+			stale = false
+		}
+		refresh()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			// Yesterday's week covers today too, and catches games that
+			// end after midnight.
+			now := time.Now()
+			yesterday := now.AddDate(0, 0, -1).Format("2006-01-02")
+			today := now.Format("2006-01-02")
+			// Read upstream directly: the cached schedule can lag a FINAL by an hour.
+			data, err := readURL(fmt.Sprintf("%s/schedule/%s", BaseURL, yesterday))
+			if err != nil {
+				log.Printf("playoff odds watcher: schedule: %v", err)
+				continue
+			}
+			var sched struct {
+				GameWeek []struct {
+					Date  string `json:"date"`
+					Games []struct {
+						ID        int64        `json:"id"`
+						GameType  int          `json:"gameType"`
+						GameState string       `json:"gameState"`
+						HomeTeam  ScheduleTeam `json:"homeTeam"`
+						AwayTeam  ScheduleTeam `json:"awayTeam"`
+					} `json:"games"`
+				} `json:"gameWeek"`
+			}
+			if err := json.Unmarshal(data, &sched); err != nil {
+				log.Printf("playoff odds watcher: parsing schedule: %v", err)
+				continue
+			}
+
+			var newlyFinal, dates []string
+			for _, day := range sched.GameWeek {
+				if day.Date != yesterday && day.Date != today {
+					continue
+				}
+				for _, g := range day.Games {
+					if g.GameType != 2 || !isGameFinal(g.GameState) || seen[g.ID] {
+						continue
+					}
+					seen[g.ID] = true
+					newlyFinal = append(newlyFinal, g.HomeTeam.Abbrev, g.AwayTeam.Abbrev)
+					if !containsString(dates, day.Date) {
+						dates = append(dates, day.Date)
+					}
+				}
+			}
+			// The first pass only records games that were already final at startup.
+			if !primed {
+				primed = true
+				newlyFinal = nil
+			}
+			if len(newlyFinal) == 0 {
+				if stale {
+					refresh()
+				}
+				continue
+			}
+			if redisClient == nil {
+				refresh()
+				continue
+			}
+
+			keys := []string{fmt.Sprintf("standings:%s", today)}
+			for _, d := range dates {
+				if d != today {
+					keys = append(keys, fmt.Sprintf("standings:%s", d))
+				}
+			}
+			for _, abbr := range newlyFinal {
+				keys = append(keys, fmt.Sprintf("team-schedule:%s:now", strings.ToUpper(abbr)))
+			}
+			if err := redisClient.Del(redisCtx, keys...).Err(); err != nil {
+				log.Printf("playoff odds watcher: invalidating caches: %v", err)
+			}
+			refresh()
+		}
+	}()
+}
+
+// handleAPIPlayoffOdds returns each team's playoff, division, Presidents'
+// Trophy and draft lottery odds.
+func handleAPIPlayoffOdds(w http.ResponseWriter, r *http.Request) {
+	odds := latestPlayoffOdds()
+	if odds == nil {
+		writeStillBuilding(w)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(odds); err != nil {
+		log.Printf("Error writing playoff odds JSON: %v", err)
+	}
+}
@@ -0,0 +1,387 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Elo tuning. K and home ice are in the range commonly used for NHL Elo
+// models; OT and shootout wins count as partial wins since they are closer
+// to a coin flip than a regulation result.
+const (
+	eloInitialRating = 1500.0
+	eloK             = 6.0
+	eloHomeIce       = 50.0
+	eloOTWinScore    = 0.75
+	eloSOWinScore    = 0.6
+	// eloCarryover is the share of last season's distance from average kept
+	// at the start of a new season.
+	eloCarryover = 2.0 / 3.0
+)
+
+// EloPoint is a team's rating after one game.
+type EloPoint struct {
+	Date     string  `json:"date"`
+	GameID   int64   `json:"gameId"`
+	Opponent string  `json:"opponent"`
+	Rating   float64 `json:"rating"`
+	Change   float64 `json:"change"`
+}
+
+// PowerRanking is one team's line in /api/power-rankings.
+type PowerRanking struct {
+	Rank    int        `json:"rank"`
+	Abbrev  string     `json:"abbrev"`
+	Rating  float64    `json:"rating"`
+	Change7 float64    `json:"change7d"`
+	Games   int        `json:"games"`
+	History []EloPoint `json:"history"`
+}
+
+// PowerRankings is the /api/power-rankings response.
+type PowerRankings struct {
+	Season      string         `json:"season"`
+	GeneratedAt string         `json:"generatedAt"`
+	FinalGames  int            `json:"finalGames"`
+	HomeIce     float64        `json:"homeIce"`
+	K           float64        `json:"k"`
+	Teams       []PowerRanking `json:"teams"`
+
+	ratings map[string]float64
+}
+
+// WinProbability is the pre-game chance each side wins, attached to
+// scheduled games.
+type WinProbability struct {
+	Home       float64 `json:"home"`
+	Away       float64 `json:"away"`
+	HomeRating float64 `json:"homeRating"`
+	AwayRating float64 `json:"awayRating"`
+}
+
+// powerRankingsLatest is the last computed ratings, replaced wholesale by
+// the refresher.
+var (
+	powerRankingsMu     sync.RWMutex
+	powerRankingsLatest *PowerRankings
+)
+
+// eloExpected is the home side's expected score given both ratings.
+func eloExpected(home, away float64) float64 {
+	return 1 / (1 + math.Pow(10, -(home+eloHomeIce-away)/400))
+}
+
+// eloMarginMultiplier scales the update by goal margin, damped when the
+// favourite wins so strong teams don't inflate by running up scores.
+func eloMarginMultiplier(margin int, winnerDiff float64) float64 {
+	return math.Log(float64(margin)+1) * (2.2 / (winnerDiff*0.001 + 2.2))
+}
+
+// loadSeasonFinals gathers every final regular-season game in season ("" for
+// the current one) from the current clubs' schedules, oldest first. Relocated or renamed clubs are still
+// covered through their opponents' schedules.
+func loadSeasonFinals(season string) ([]ClubScheduleGame, error) {
+	games := map[int64]ClubScheduleGame{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	errs := 0
+	for abbr := range abbrevToTeamID {
+		wg.Add(1)
+		go func(abbrev string) {
+			defer wg.Done()
+			sched, err := GetClubSchedule(abbrev, season)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				log.Printf("loadSeasonFinals: schedule for %s %s: %v", abbrev, season, err)
+				errs++
+				return
+			}
+			for _, g := range sched.Games {
+				if g.GameType == 2 && isGameFinal(g.GameState) {
+					games[g.ID] = g
+				}
+			}
+		}(abbr)
+	}
+	wg.Wait()
+	if errs == len(abbrevToTeamID) {
+		return nil, fmt.Errorf("no club schedules available for %s", season)
+	}
+
+	out := make([]ClubScheduleGame, 0, len(games))
+	for _, g := range games {
+		out = append(out, g)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].StartTimeUTC != out[j].StartTimeUTC {
+			return out[i].StartTimeUTC < out[j].StartTimeUTC
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out, nil
+}
+
+// computeElo replays games in order from the initial ratings (teams not in
+// initial start at eloInitialRating) and returns the final ratings and each
+// team's rating history.
+func computeElo(games []ClubScheduleGame, initial map[string]float64) (map[string]float64, map[string][]EloPoint) {
+	ratings := map[string]float64{}
+	for k, v := range initial {
+		ratings[k] = v
+	}
+	rating := func(abbr string) float64 {
+		if r, ok := ratings[abbr]; ok {
+			return r
+		}
+		return eloInitialRating
+	}
+	history := map[string][]EloPoint{}
+
+	for _, g := range games {
+		home, away := g.HomeTeam.Abbrev, g.AwayTeam.Abbrev
+		rh, ra := rating(home), rating(away)
+		expected := eloExpected(rh, ra)
+
+		homeWon := g.HomeTeam.Score > g.AwayTeam.Score
+		margin := g.HomeTeam.Score - g.AwayTeam.Score
+		if !homeWon {
+			margin = -margin
+		}
+		winScore := 1.0
+		switch g.GameOutcome.LastPeriodType {
+		case "OT":
+			winScore, margin = eloOTWinScore, 1
+		case "SO":
+			winScore, margin = eloSOWinScore, 1
+		}
+		actual := winScore
+		winnerDiff := rh + eloHomeIce - ra
+		if !homeWon {
+			actual = 1 - winScore
+			winnerDiff = -winnerDiff
+		}
+
+		delta := eloK * eloMarginMultiplier(margin, winnerDiff) * (actual - expected)
+		ratings[home] = rh + delta
+		ratings[away] = ra - delta
+
+		history[home] = append(history[home], EloPoint{Date: g.GameDate, GameID: g.ID, Opponent: away, Rating: round1(rh + delta), Change: round1(delta)})
+		history[away] = append(history[away], EloPoint{Date: g.GameDate, GameID: g.ID, Opponent: home, Rating: round1(ra - delta), Change: round1(-delta)})
+	}
+	return ratings, history
+}
+
+// previousSeasonID returns the season before season, e.g. 20242025 -> 20232024.
+func previousSeasonID(season string) string {
+	if len(season) != 8 {
+		return ""
+	}
+	start, err := strconv.Atoi(season[:4])
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%d%d", start-1, start)
+}
+
+// seasonStartRatings regresses the previous season's final ratings toward
+// average. Completed seasons never change, so their ratings are cached
+// without expiry.
+func seasonStartRatings(season string) map[string]float64 {
+	prev := previousSeasonID(season)
+	if prev == "" {
+		return nil
+	}
+	data, err := getCachedOrFetchWithBackoff(fmt.Sprintf("elo:final:%s", prev), func() ([]byte, error) {
+		games, err := loadSeasonFinals(prev)
+		if err != nil {
+			return nil, err
+		}
+		final, _ := computeElo(games, nil)
+		return json.Marshal(final)
+	}, determineTTL("static"))
+	if err != nil {
+		log.Printf("Elo carryover from %s unavailable: %v", prev, err)
+		return nil
+	}
+	var final map[string]float64
+	if err := json.Unmarshal(data, &final); err != nil {
+		return nil
+	}
+	start := map[string]float64{}
+	for abbr, r := range final {
+		start[abbr] = eloInitialRating + (r-eloInitialRating)*eloCarryover
+	}
+	return start
+}
+
+// startPowerRatingsRefresher computes the Elo ratings in the background and
+// recomputes them every interval. Loading a season of finals can sit in 429
+// backoff for minutes, so requests only ever read the last snapshot.
+func startPowerRatingsRefresher(interval time.Duration) {
+	go func() {
+		for {
+			start := time.Now()
+			pr, err := computePowerRankings()
+			if err != nil {
+				log.Printf("power rankings: %v", err)
+			} else {
+				powerRankingsMu.Lock()
+				powerRankingsLatest = pr
+				powerRankingsMu.Unlock()
+				log.Printf("power rankings: %d finals in %s", pr.FinalGames, time.Since(start).Round(time.Millisecond))
+			}
+			time.Sleep(interval)
+		}
+	}()
+}
+
+// latestPowerRankings returns the last computed ratings without blocking,
+// or nil before the first computation finishes.
+func latestPowerRankings() *PowerRankings {
+	powerRankingsMu.RLock()
+	defer powerRankingsMu.RUnlock()
+	return powerRankingsLatest
+}
+
+// computePowerRankings rates every team from the current season's finals.
+func computePowerRankings() (*PowerRankings, error) {
+	season := currentSeasonID()
+	// "now" shares the club schedule keys the playoff odds watcher refreshes.
+	games, err := loadSeasonFinals("")
+	if err != nil {
+		return nil, err
+	}
+	ratings, history := computeElo(games, seasonStartRatings(season))
+
+	weekAgo := time.Now().AddDate(0, 0, -7).Format("2006-01-02")
+	pr := &PowerRankings{
+		Season:      season,
+		GeneratedAt: time.Now().UTC().Format(time.RFC3339),
+		FinalGames:  len(games),
+		HomeIce:     eloHomeIce,
+		K:           eloK,
+		ratings:     ratings,
+	}
+	for abbr := range abbrevToTeamID {
+		r, ok := ratings[abbr]
+		if !ok {
+			r = eloInitialRating
+		}
+		hist := history[abbr]
+		if hist == nil {
+			hist = []EloPoint{}
+		}
+		change := 0.0
+		for _, p := range hist {
+			if p.Date >= weekAgo {
+				change += p.Change
+			}
+		}
+		pr.Teams = append(pr.Teams, PowerRanking{
+			Abbrev:  abbr,
+			Rating:  round1(r),
+			Change7: round1(change),
+			Games:   len(hist),
+			History: hist,
+		})
+	}
+	sort.Slice(pr.Teams, func(i, j int) bool {
+		if pr.Teams[i].Rating != pr.Teams[j].Rating {
+			return pr.Teams[i].Rating > pr.Teams[j].Rating
+		}
+		return pr.Teams[i].Abbrev < pr.Teams[j].Abbrev
+	})
+	for i := range pr.Teams {
+		pr.Teams[i].Rank = i + 1
+	}
+
+	return pr, nil
+}
+
+// WinProbabilityFor returns the pre-game win probability for home vs away,
+// or false when either side has no rating (e.g. exhibition opponents).
+func (pr *PowerRankings) WinProbabilityFor(home, away string) (WinProbability, bool) {
+	rh, okH := pr.ratings[home]
+	ra, okA := pr.ratings[away]
+	if _, ok := abbrevToTeamID[home]; ok && !okH {
+		rh, okH = eloInitialRating, true
+	}
+	if _, ok := abbrevToTeamID[away]; ok && !okA {
+		ra, okA = eloInitialRating, true
+	}
+	if !okH || !okA {
+		return WinProbability{}, false
+	}
+	p := eloExpected(rh, ra)
+	return WinProbability{
+		Home:       round3(p),
+		Away:       round3(1 - p),
+		HomeRating: round1(rh),
+		AwayRating: round1(ra),
+	}, true
+}
+
+// addWinProbabilities decorates every unfinished game in a raw /schedule
+// payload with a winProbability object. The payload is returned unchanged
+// if ratings have not been computed yet or it doesn't parse.
+func addWinProbabilities(data []byte) []byte {
+	pr := latestPowerRankings()
+	if pr == nil {
+		return data
+	}
+	var sched map[string]interface{}
+	if err := json.Unmarshal(data, &sched); err != nil {
+		return data
+	}
+	weeks, _ := sched["gameWeek"].([]interface{})
+	for _, w := range weeks {
+		day, _ := w.(map[string]interface{})
+		games, _ := day["games"].([]interface{})
+		for _, gi := range games {
+			g, _ := gi.(map[string]interface{})
+			if g == nil {
+				continue
+			}
+			if state, _ := g["gameState"].(string); isGameFinal(state) {
+				continue
+			}
+			home, _ := g["homeTeam"].(map[string]interface{})
+			away, _ := g["awayTeam"].(map[string]interface{})
+			ha, _ := home["abbrev"].(string)
+			aa, _ := away["abbrev"].(string)
+			if wp, ok := pr.WinProbabilityFor(ha, aa); ok {
+				g["winProbability"] = wp
+			}
+		}
+	}
+	out, err := json.Marshal(sched)
+	if err != nil {
+		return data
+	}
+	return out
+}
+
+// round1 rounds to one decimal place.
+func round1(v float64) float64 {
+	return math.Round(v*10) / 10
+}
+
+func handleAPIPowerRankings(w http.ResponseWriter, r *http.Request) {
+	pr := latestPowerRankings()
+	if pr == nil {
+		writeStillBuilding(w)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(pr); err != nil {
+		log.Printf("Error writing power rankings JSON: %v", err)
+	}
+}
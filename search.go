@@ -0,0 +1,690 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultSearchLimit = 10
+	maxSearchLimit     = 25
+	maxSearchGames     = 5
+)
+
+// Ranking boosts by source so a current player outranks a prospect or a
+// long-retired player with the same match quality.
+const (
+	searchWeightTeam     = 6
+	searchWeightRoster   = 4
+	searchWeightProspect = 2
+	searchWeightLanding  = 0
+)
+
+// historicalTeamNames are former names and relocated franchises. Successor
+// is today's club, if any, so the result can link somewhere useful.
+var historicalTeamNames = []struct {
+	name, abbrev, successor string
+}{
+	{"Arizona Coyotes", "ARI", "UTA"},
+	{"Phoenix Coyotes", "PHX", "UTA"},
+	{"Utah Hockey Club", "UTA", "UTA"},
+	{"Winnipeg Jets (1972)", "WIN", "UTA"},
+	{"Atlanta Thrashers", "ATL", "WPG"},
+	{"Hartford Whalers", "HFD", "CAR"},
+	{"Quebec Nordiques", "QUE", "COL"},
+	{"Minnesota North Stars", "MNS", "DAL"},
+	{"Mighty Ducks of Anaheim", "MDA", "ANA"},
+	{"Atlanta Flames", "AFM", "CGY"},
+	{"Kansas City Scouts", "KCS", "NJD"},
+	{"Colorado Rockies", "CLR", "NJD"},
+	{"Chicago Black Hawks", "CBH", "CHI"},
+	{"Detroit Cougars", "DCG", "DET"},
+	{"Detroit Falcons", "DFL", "DET"},
+	{"Toronto St. Patricks", "TSP", "TOR"},
+	{"Toronto Arenas", "TAN", "TOR"},
+	{"California Golden Seals", "CGS", ""},
+	{"Oakland Seals", "OAK", ""},
+	{"Cleveland Barons", "CLE", ""},
+	{"Montreal Maroons", "MMR", ""},
+	{"Hamilton Tigers", "HAM", ""},
+	{"Brooklyn Americans", "BRK", ""},
+	{"New York Americans", "NYA", ""},
+	{"Pittsburgh Pirates", "PIR", ""},
+	{"Philadelphia Quakers", "QUA", ""},
+	{"Ottawa Senators (1917)", "SEN", ""},
+	{"St. Louis Eagles", "SLE", ""},
+}
+
+// SearchResult is one hit from /api/search.
+type SearchResult struct {
+	Type     string `json:"type"` // player, team or game
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Subtitle string `json:"subtitle,omitempty"`
+	URL      string `json:"url,omitempty"`
+	Image    string `json:"image,omitempty"`
+	Score    int    `json:"score"`
+}
+
+// SearchResponse is the /api/search response.
+type SearchResponse struct {
+	Query   string         `json:"query"`
+	Results []SearchResult `json:"results"`
+}
+
+// searchEntry is an indexed player or team with its folded match keys.
+type searchEntry struct {
+	result SearchResult
+	keys   []string
+	weight int
+}
+
+// searchIndex is the in-memory index, swapped wholesale on each rebuild.
+type searchIndex struct {
+	mu      sync.RWMutex
+	entries []searchEntry
+}
+
+var globalSearchIndex = &searchIndex{}
+
+// accentFolds maps accented letters found in player and team names onto
+// plain ASCII so "Montreal" finds "Montréal" and "Stutzle" finds "Stützle".
+var accentFolds = map[rune]string{
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'ā': "a", 'ă': "a", 'ą': "a",
+	'æ': "ae", 'ç': "c", 'ć': "c", 'č': "c", 'ď': "d", 'đ': "d",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ē': "e", 'ė': "e", 'ę': "e", 'ě': "e",
+	'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ī': "i", 'į': "i", 'ı': "i",
+	'ľ': "l", 'ĺ': "l", 'ł': "l", 'ñ': "n", 'ń': "n", 'ň': "n",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'ō': "o", 'ő': "o", 'œ': "oe",
+	'ŕ': "r", 'ř': "r", 'ś': "s", 'š': "s", 'ş': "s", 'ß': "ss", 'ť': "t", 'ţ': "t",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ū': "u", 'ů': "u", 'ű': "u",
+	'ý': "y", 'ÿ': "y", 'ź': "z", 'ż': "z", 'ž': "z",
+}
+
+// foldText lowercases s, strips accents and turns punctuation into single
+// spaces.
+func foldText(s string) string {
+	var b strings.Builder
+	space := true
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+			space = false
+		case accentFolds[r] != "":
+			b.WriteString(accentFolds[r])
+			space = false
+		case r == '\'' || r == '’':
+			// O'Reilly and O’Reilly both fold to oreilly.
+		default:
+			if !space {
+				b.WriteByte(' ')
+				space = true
+			}
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// editDistance is the Levenshtein distance between two ASCII strings.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// fuzzyTokenDistance is how far a typed token is from a name token,
+// treating the typed token as a possibly unfinished prefix. It returns -1
+// when the two are too far apart for the token's length.
+func fuzzyTokenDistance(q, t string) int {
+	allowed := 0
+	switch {
+	case len(q) >= 7:
+		allowed = 2
+	case len(q) >= 4:
+		allowed = 1
+	}
+	if strings.HasPrefix(t, q) {
+		return 0
+	}
+	if allowed == 0 {
+		return -1
+	}
+	d := editDistance(q, t)
+	if len(t) > len(q) {
+		d = min(d, editDistance(q, t[:len(q)]))
+	}
+	if d > allowed {
+		return -1
+	}
+	return d
+}
+
+// matchScore rates how well a folded query matches a folded key, from 100
+// (exact) down to fuzzy matches; 0 means no match.
+func matchScore(query string, qTokens []string, key string) int {
+	switch {
+	case key == query:
+		return 100
+	case strings.HasPrefix(key, query):
+		return 90
+	}
+	kTokens := strings.Fields(key)
+	prefix, fuzzy := true, 0
+	for _, q := range qTokens {
+		best := -1
+		for _, t := range kTokens {
+			if d := fuzzyTokenDistance(q, t); d >= 0 && (best < 0 || d < best) {
+				best = d
+			}
+		}
+		if best < 0 {
+			fuzzy = -1
+			break
+		}
+		if best > 0 {
+			prefix = false
+		}
+		fuzzy += best
+	}
+	switch {
+	case fuzzy == 0 && prefix:
+		return 80
+	case len(query) >= 4 && strings.Contains(key, query):
+		return 60
+	case fuzzy > 0:
+		return max(10, 40-10*fuzzy)
+	}
+	return 0
+}
+
+// teamSearchEntries indexes current clubs (named from standings when
+// available) plus historical names.
+func teamSearchEntries(standings *TeamsResponse) []searchEntry {
+	names := map[string]string{}
+	aliases := map[string][]string{}
+	historical := map[string]bool{}
+	for _, h := range historicalTeamNames {
+		historical[h.name] = true
+	}
+	for name, abbr := range teamNameToAbbr {
+		if historical[name] {
+			continue
+		}
+		aliases[abbr] = append(aliases[abbr], name)
+		if names[abbr] == "" || name < names[abbr] {
+			names[abbr] = name
+		}
+	}
+	if standings != nil {
+		for _, t := range standings.Teams {
+			if t.Name != "" {
+				names[t.Abbrev] = t.Name
+				aliases[t.Abbrev] = append(aliases[t.Abbrev], t.Name)
+			}
+		}
+	}
+
+	var out []searchEntry
+	for abbr := range abbrevToTeamID {
+		name := names[abbr]
+		if name == "" {
+			name = abbr
+		}
+		keys := []string{foldText(abbr)}
+		for _, a := range aliases[abbr] {
+			keys = append(keys, foldText(a))
+		}
+		out = append(out, searchEntry{
+			result: SearchResult{
+				Type:  "team",
+				ID:    abbr,
+				Name:  name,
+				URL:   "/team/" + strings.ToLower(abbr),
+				Image: fmt.Sprintf("https://assets.nhle.com/logos/nhl/svg/%s_light.svg", abbr),
+			},
+			keys:   keys,
+			weight: searchWeightTeam,
+		})
+	}
+	for _, h := range historicalTeamNames {
+		e := searchEntry{
+			result: SearchResult{Type: "team", ID: h.abbrev, Name: h.name, Subtitle: "Former team"},
+			keys:   []string{foldText(h.name), foldText(h.abbrev)},
+		}
+		if h.successor != "" {
+			e.result.URL = "/team/" + strings.ToLower(h.successor)
+			if h.successor != h.abbrev {
+				e.result.Subtitle = "Now the " + names[h.successor]
+			}
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+// playerSearchEntry indexes a player under their full and last names.
+func playerSearchEntry(id int, name, subtitle, headshot string, weight int) searchEntry {
+	keys := []string{foldText(name)}
+	if i := strings.LastIndex(name, " "); i > 0 {
+		keys = append(keys, foldText(name[i+1:]))
+	}
+	return searchEntry{
+		result: SearchResult{
+			Type:     "player",
+			ID:       strconv.Itoa(id),
+			Name:     name,
+			Subtitle: subtitle,
+			URL:      fmt.Sprintf("/player/%d", id),
+			Image:    headshot,
+		},
+		keys:   keys,
+		weight: weight,
+	}
+}
+
+// cachedLandingEntries indexes every player landing already in Redis, which
+// covers retired players people have looked at. Players already indexed from
+// a roster or prospect list are skipped.
+func cachedLandingEntries(seen map[int]bool) []searchEntry {
+	if redisClient == nil {
+		return nil
+	}
+	var out []searchEntry
+	iter := redisClient.Scan(redisCtx, 0, "player:*", 500).Iterator()
+	for iter.Next(redisCtx) {
+		id, err := strconv.Atoi(strings.TrimPrefix(iter.Val(), "player:"))
+		if err != nil || seen[id] {
+			continue
+		}
+		data, err := getCachedRaw(iter.Val())
+		if err != nil {
+			continue
+		}
+		var l PlayerLanding
+		if err := json.Unmarshal(data, &l); err != nil || l.PlayerID == 0 {
+			continue
+		}
+		seen[id] = true
+		subtitle := l.Position
+		if l.CurrentTeamAbbrev != "" && l.IsActive {
+			subtitle += " · " + l.CurrentTeamAbbrev
+		} else if !l.IsActive {
+			subtitle += " · Retired"
+		}
+		name := strings.TrimSpace(l.FirstName.Default + " " + l.LastName.Default)
+		out = append(out, playerSearchEntry(id, name, subtitle, l.Headshot, searchWeightLanding))
+	}
+	if err := iter.Err(); err != nil {
+		log.Printf("search index: scanning player landings: %v", err)
+	}
+	return out
+}
+
+// buildSearchIndex indexes teams, every roster and prospect list, and the
+// cached player landings.
+func buildSearchIndex() []searchEntry {
+	standings, err := GetAllTeams()
+	if err != nil {
+		log.Printf("search index: standings: %v", err)
+		standings = nil
+	}
+	entries := teamSearchEntries(standings)
+
+	abbrevs := make([]string, 0, len(abbrevToTeamID))
+	for abbr := range abbrevToTeamID {
+		abbrevs = append(abbrevs, abbr)
+	}
+	sort.Strings(abbrevs)
+
+	seen := map[int]bool{}
+	for _, abbr := range abbrevs {
+		roster, err := GetRoster(abbr)
+		if err != nil {
+			log.Printf("search index: roster %s: %v", abbr, err)
+			continue
+		}
+		for _, p := range roster.Players {
+			if p.ID == 0 || seen[p.ID] {
+				continue
+			}
+			seen[p.ID] = true
+			entries = append(entries, playerSearchEntry(p.ID, p.Name, p.Position+" · "+abbr, p.Photo, searchWeightRoster))
+		}
+	}
+	for _, abbr := range abbrevs {
+		data, err := GetProspects(abbr)
+		if err != nil {
+			log.Printf("search index: prospects %s: %v", abbr, err)
+			continue
+		}
+		var prospects struct {
+			Forwards   []RosterPlayer `json:"forwards"`
+			Defensemen []RosterPlayer `json:"defensemen"`
+			Goalies    []RosterPlayer `json:"goalies"`
+		}
+		if err := json.Unmarshal(data, &prospects); err != nil {
+			log.Printf("search index: parsing prospects %s: %v", abbr, err)
+			continue
+		}
+		for _, group := range [][]RosterPlayer{prospects.Forwards, prospects.Defensemen, prospects.Goalies} {
+			for _, p := range group {
+				if p.ID == 0 || seen[p.ID] {
+					continue
+				}
+				seen[p.ID] = true
+				name := strings.TrimSpace(pickName(p.FirstName) + " " + pickName(p.LastName))
+				entries = append(entries, playerSearchEntry(p.ID, name, p.PositionCode+" · "+abbr+" prospect", p.Headshot, searchWeightProspect))
+			}
+		}
+	}
+	entries = append(entries, cachedLandingEntries(seen)...)
+	return entries
+}
+
+// startSearchIndexer builds the search index in the background and rebuilds
+// it every interval so new landings and roster moves show up.
+func startSearchIndexer(interval time.Duration) {
+	// Teams come from static maps, so they are searchable right away.
+	globalSearchIndex.mu.Lock()
+	globalSearchIndex.entries = teamSearchEntries(nil)
+	globalSearchIndex.mu.Unlock()
+
+	go func() {
+		for {
+			start := time.Now()
+			entries := buildSearchIndex()
+			globalSearchIndex.mu.Lock()
+			globalSearchIndex.entries = entries
+			globalSearchIndex.mu.Unlock()
+			log.Printf("search index: %d entries in %s", len(entries), time.Since(start).Round(time.Millisecond))
+			time.Sleep(interval)
+		}
+	}()
+}
+
+// search ranks the indexed players and teams against a folded query.
+func (idx *searchIndex) search(query string, limit int) []SearchResult {
+	qTokens := strings.Fields(query)
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	var out []SearchResult
+	for _, e := range idx.entries {
+		best := 0
+		for _, k := range e.keys {
+			best = max(best, matchScore(query, qTokens, k))
+		}
+		if best == 0 {
+			continue
+		}
+		r := e.result
+		r.Score = best + e.weight
+		out = append(out, r)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Score != out[j].Score {
+			return out[i].Score > out[j].Score
+		}
+		if len(out[i].Name) != len(out[j].Name) {
+			return len(out[i].Name) < len(out[j].Name)
+		}
+		return out[i].Name < out[j].Name
+	})
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
+
+var (
+	isoDatePattern   = regexp.MustCompile(`\b(\d{4})-(\d{1,2})-(\d{1,2})\b`)
+	slashDatePattern = regexp.MustCompile(`\b(\d{1,2})/(\d{1,2})(?:/(\d{2,4}))?\b`)
+	monthDatePattern = regexp.MustCompile(`(?i)\b(jan(?:uary)?|feb(?:ruary)?|mar(?:ch)?|apr(?:il)?|may|june?|july?|aug(?:ust)?|sep(?:t(?:ember)?)?|oct(?:ober)?|nov(?:ember)?|dec(?:ember)?)\.?\s+(\d{1,2})(?:,?\s+(\d{4}))?\b`)
+)
+
+// parseSearchDate pulls a game date out of a raw query, returning it as
+// YYYY-MM-DD and the query with the date removed. Dates without a year
+// pick the one that falls in the current season.
+func parseSearchDate(q string, now time.Time) (string, string) {
+	seasonYear := func(month int) int {
+		startYear := seasonStartYear(now)
+		if month >= int(time.September) {
+			return startYear
+		}
+		return startYear + 1
+	}
+	build := func(y, m, d int) string {
+		t := time.Date(y, time.Month(m), d, 0, 0, 0, 0, time.UTC)
+		if t.Month() != time.Month(m) {
+			return ""
+		}
+		return t.Format("2006-01-02")
+	}
+
+	if m := isoDatePattern.FindStringSubmatchIndex(q); m != nil {
+		y, _ := strconv.Atoi(q[m[2]:m[3]])
+		mo, _ := strconv.Atoi(q[m[4]:m[5]])
+		d, _ := strconv.Atoi(q[m[6]:m[7]])
+		return build(y, mo, d), q[:m[0]] + " " + q[m[1]:]
+	}
+	if m := slashDatePattern.FindStringSubmatchIndex(q); m != nil {
+		mo, _ := strconv.Atoi(q[m[2]:m[3]])
+		d, _ := strconv.Atoi(q[m[4]:m[5]])
+		y := seasonYear(mo)
+		if m[6] >= 0 {
+			y, _ = strconv.Atoi(q[m[6]:m[7]])
+			if y < 100 {
+				y += 2000
+			}
+		}
+		return build(y, mo, d), q[:m[0]] + " " + q[m[1]:]
+	}
+	if m := monthDatePattern.FindStringSubmatchIndex(q); m != nil {
+		month := q[m[2] : m[2]+3]
+		t, err := time.Parse("Jan", strings.ToUpper(month[:1])+strings.ToLower(month[1:]))
+		if err != nil {
+			return "", q
+		}
+		d, _ := strconv.Atoi(q[m[4]:m[5]])
+		y := seasonYear(int(t.Month()))
+		if m[6] >= 0 {
+			y, _ = strconv.Atoi(q[m[6]:m[7]])
+		}
+		return build(y, int(t.Month()), d), q[:m[0]] + " " + q[m[1]:]
+	}
+	return "", q
+}
+
+// searchTeams resolves the current clubs named in a folded query, such as
+// "tor mtl" or "leafs vs canadiens". Words shared by several clubs ("new",
+// "york") are ignored.
+func searchTeams(query string) []string {
+	var teams []string
+	seen := map[string]bool{}
+	add := func(abbr string) {
+		if !seen[abbr] {
+			seen[abbr] = true
+			teams = append(teams, abbr)
+		}
+	}
+	for _, tok := range strings.Fields(query) {
+		if len(tok) < 3 || tok == "and" {
+			continue
+		}
+		if abbr, ok := resolveTeamAbbrev(tok); ok && len(tok) == 3 {
+			add(abbr)
+			continue
+		}
+		matches := map[string]bool{}
+		for name, abbr := range teamNameToAbbr {
+			if _, current := abbrevToTeamID[abbr]; !current {
+				continue
+			}
+			for _, word := range strings.Fields(foldText(name)) {
+				if word == tok || (len(tok) >= 4 && strings.HasPrefix(word, tok)) {
+					matches[abbr] = true
+				}
+			}
+		}
+		if len(matches) == 1 {
+			for abbr := range matches {
+				add(abbr)
+			}
+		}
+	}
+	return teams
+}
+
+// gameSearchResult describes a scheduled game as a search hit.
+func gameSearchResult(g ClubScheduleGame, score int) SearchResult {
+	name := fmt.Sprintf("%s @ %s", g.AwayTeam.Abbrev, g.HomeTeam.Abbrev)
+	if isGameFinal(g.GameState) {
+		name = fmt.Sprintf("%s %d @ %s %d", g.AwayTeam.Abbrev, g.AwayTeam.Score, g.HomeTeam.Abbrev, g.HomeTeam.Score)
+	}
+	return SearchResult{
+		Type:     "game",
+		ID:       strconv.FormatInt(g.ID, 10),
+		Name:     name,
+		Subtitle: g.GameDate,
+		URL:      fmt.Sprintf("/game/%d", g.ID),
+		Image:    g.HomeTeam.Logo,
+		Score:    score,
+	}
+}
+
+// searchGames finds games on a date, optionally narrowed to the named
+// teams, or the nearest meetings between two teams when no date is given.
+func searchGames(date string, teams []string, now time.Time) []SearchResult {
+	involves := func(g ClubScheduleGame) bool {
+		for _, t := range teams {
+			if g.HomeTeam.Abbrev != t && g.AwayTeam.Abbrev != t {
+				return false
+			}
+		}
+		return true
+	}
+
+	var out []SearchResult
+	switch {
+	case date != "":
+		data, err := GetSchedule(date)
+		if err != nil {
+			log.Printf("search: schedule %s: %v", date, err)
+			return nil
+		}
+		var sched struct {
+			GameWeek []struct {
+				Date  string             `json:"date"`
+				Games []ClubScheduleGame `json:"games"`
+			} `json:"gameWeek"`
+		}
+		if err := json.Unmarshal(data, &sched); err != nil {
+			log.Printf("search: parsing schedule %s: %v", date, err)
+			return nil
+		}
+		for _, day := range sched.GameWeek {
+			if day.Date != date {
+				continue
+			}
+			for _, g := range day.Games {
+				if len(teams) == 0 || involves(g) {
+					g.GameDate = day.Date
+					out = append(out, gameSearchResult(g, 95))
+				}
+			}
+		}
+	case len(teams) >= 2:
+		sched, err := GetClubSchedule(teams[0], "now")
+		if err != nil {
+			log.Printf("search: club schedule %s: %v", teams[0], err)
+			return nil
+		}
+		type dated struct {
+			g    ClubScheduleGame
+			away time.Duration
+		}
+		var games []dated
+		for _, g := range sched.Games {
+			if !involves(g) {
+				continue
+			}
+			day, err := time.Parse("2006-01-02", g.GameDate)
+			if err != nil {
+				continue
+			}
+			away := day.Sub(now)
+			if away < 0 {
+				away = -away
+			}
+			games = append(games, dated{g, away})
+		}
+		sort.Slice(games, func(i, j int) bool { return games[i].away < games[j].away })
+		for i, d := range games {
+			out = append(out, gameSearchResult(d.g, 95-i))
+		}
+	}
+	if len(out) > maxSearchGames {
+		out = out[:maxSearchGames]
+	}
+	return out
+}
+
+func handleAPISearch(w http.ResponseWriter, r *http.Request) {
+	raw := strings.TrimSpace(r.URL.Query().Get("q"))
+	if raw == "" {
+		http.Error(w, "q is required", http.StatusBadRequest)
+		return
+	}
+	limit := defaultSearchLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxSearchLimit {
+			http.Error(w, fmt.Sprintf("limit must be between 1 and %d", maxSearchLimit), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	now := time.Now()
+	date, rest := parseSearchDate(raw, now)
+	query := foldText(rest)
+	resp := SearchResponse{Query: raw, Results: []SearchResult{}}
+
+	teams := searchTeams(query)
+	resp.Results = append(resp.Results, searchGames(date, teams, now)...)
+	if query != "" && date == "" {
+		resp.Results = append(resp.Results, globalSearchIndex.search(query, limit)...)
+	}
+	sort.SliceStable(resp.Results, func(i, j int) bool { return resp.Results[i].Score > resp.Results[j].Score })
+	if len(resp.Results) > limit {
+		resp.Results = resp.Results[:limit]
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("Error writing search JSON: %v", err)
+	}
+}
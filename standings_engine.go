@@ -0,0 +1,300 @@
+package main
+
+import (
+	"log"
+	"sort"
+	"strings"
+)
+
+// regularSeasonGames is the length of an NHL regular season.
+const regularSeasonGames = 82
+
+// Playoff spots per conference: the top three of each division plus two
+// wildcards.
+const (
+	divisionPlayoffSpots = 3
+	wildcardPlayoffSpots = 2
+)
+
+// Clinch and elimination markers, matching the letters NHL.com uses.
+const (
+	clinchPresidents = "p"
+	clinchConference = "z"
+	clinchDivision   = "y"
+	clinchPlayoff    = "x"
+	clinchEliminated = "e"
+)
+
+// headToHeadFunc returns the points a and b earned in finished regular-season
+// games against each other.
+type headToHeadFunc func(a, b string) (int, int)
+
+// scheduleHeadToHead computes head-to-head points from a's club schedule,
+// counting games played on or before throughDate. Schedules are only fetched
+// when a tie survives to that step.
+func scheduleHeadToHead(season, throughDate string) headToHeadFunc {
+	memo := map[string][2]int{}
+	return func(a, b string) (int, int) {
+		key := a + ":" + b
+		if v, ok := memo[key]; ok {
+			return v[0], v[1]
+		}
+		sched, err := GetClubSchedule(a, season)
+		if err != nil {
+			log.Printf("head-to-head %s vs %s: %v", a, b, err)
+			return 0, 0
+		}
+		var games []ClubScheduleGame
+		for _, g := range sched.Games {
+			if throughDate == "" || g.GameDate <= throughDate {
+				games = append(games, g)
+			}
+		}
+		pa, pb := headToHeadPoints(games, a, b)
+		memo[key] = [2]int{pa, pb}
+		memo[b+":"+a] = [2]int{pb, pa}
+		return pa, pb
+	}
+}
+
+// headToHeadPoints totals standings points for a and b in finished
+// regular-season meetings found in games.
+func headToHeadPoints(games []ClubScheduleGame, a, b string) (int, int) {
+	pa, pb := 0, 0
+	for _, g := range games {
+		if g.GameType != 2 || !isGameFinal(g.GameState) {
+			continue
+		}
+		home, away := g.HomeTeam, g.AwayTeam
+		if !(home.Abbrev == a && away.Abbrev == b) && !(home.Abbrev == b && away.Abbrev == a) {
+			continue
+		}
+		winner, loser := home.Abbrev, away.Abbrev
+		if away.Score > home.Score {
+			winner, loser = away.Abbrev, home.Abbrev
+		}
+		extra := g.GameOutcome.LastPeriodType == "OT" || g.GameOutcome.LastPeriodType == "SO"
+		for _, abbr := range []string{winner, loser} {
+			pts := 0
+			if abbr == winner {
+				pts = 2
+			} else if extra {
+				pts = 1
+			}
+			if abbr == a {
+				pa += pts
+			} else {
+				pb += pts
+			}
+		}
+	}
+	return pa, pb
+}
+
+// pointPct returns a team's points percentage, preferring the upstream value.
+func pointPct(t Team) float64 {
+	if t.PointPct > 0 {
+		return t.PointPct
+	}
+	gp := t.GamesPlayed
+	if gp == 0 {
+		return 0
+	}
+	return float64(t.Record.Points) / float64(2*gp)
+}
+
+// maxPoints is the most points a team can still finish with.
+func maxPoints(t Team) int {
+	left := regularSeasonGames - t.GamesPlayed
+	if left < 0 {
+		left = 0
+	}
+	return t.Record.Points + 2*left
+}
+
+// teamRanksAhead applies the NHL ordering: points, then points%, regulation
+// wins, regulation plus overtime wins, total wins, head-to-head points,
+// goal differential and goals for.
+func teamRanksAhead(a, b Team, h2h headToHeadFunc) bool {
+	if a.Record.Points != b.Record.Points {
+		return a.Record.Points > b.Record.Points
+	}
+	if pa, pb := pointPct(a), pointPct(b); pa != pb {
+		return pa > pb
+	}
+	if a.RegulationWins != b.RegulationWins {
+		return a.RegulationWins > b.RegulationWins
+	}
+	if a.RegulationPlusOtWins != b.RegulationPlusOtWins {
+		return a.RegulationPlusOtWins > b.RegulationPlusOtWins
+	}
+	if a.Record.Wins != b.Record.Wins {
+		return a.Record.Wins > b.Record.Wins
+	}
+	if h2h != nil {
+		if pa, pb := h2h(a.Abbrev, b.Abbrev); pa != pb {
+			return pa > pb
+		}
+	}
+	if a.GoalDiff != b.GoalDiff {
+		return a.GoalDiff > b.GoalDiff
+	}
+	if a.GoalsFor != b.GoalsFor {
+		return a.GoalsFor > b.GoalsFor
+	}
+	return a.Abbrev < b.Abbrev
+}
+
+// ApplyStandingsEngine sorts teams into league order and fills in league,
+// conference, division and wildcard ranks, playoff seeds, clinch/elimination
+// markers and magic/tragic numbers.
+func ApplyStandingsEngine(teams []Team, h2h headToHeadFunc) {
+	sort.SliceStable(teams, func(i, j int) bool { return teamRanksAhead(teams[i], teams[j], h2h) })
+
+	confRank := map[string]int{}
+	divRank := map[string]int{}
+	for i := range teams {
+		t := &teams[i]
+		t.LeagueRank = i + 1
+		confRank[t.Conference]++
+		t.ConferenceRank = confRank[t.Conference]
+		divRank[t.Division]++
+		t.DivisionRank = divRank[t.Division]
+		t.PlayoffSeed = ""
+		t.WildcardRank = 0
+		t.ClinchIndicator = ""
+		t.MagicNumber = nil
+		t.TragicNumber = nil
+	}
+
+	// Top three in each division are in; the next two in each conference are wildcards.
+	wcRank := map[string]int{}
+	for i := range teams {
+		t := &teams[i]
+		if t.DivisionRank <= divisionPlayoffSpots {
+			t.PlayoffSeed = divisionSeedPrefix(t.Division) + string(rune('0'+t.DivisionRank))
+			continue
+		}
+		wcRank[t.Conference]++
+		t.WildcardRank = wcRank[t.Conference]
+		if t.WildcardRank <= wildcardPlayoffSpots {
+			t.PlayoffSeed = "WC" + string(rune('0'+t.WildcardRank))
+		}
+	}
+
+	for i := range teams {
+		t := &teams[i]
+		t.ClinchIndicator = clinchStatus(*t, teams)
+		magic, tragic := magicTragic(*t, teams)
+		t.MagicNumber = magic
+		t.TragicNumber = tragic
+	}
+}
+
+// divisionSeedPrefix returns the one-letter division code used in seeds (A1, M2, C3, P1).
+func divisionSeedPrefix(division string) string {
+	if division == "" {
+		return "D"
+	}
+	return strings.ToUpper(division[:1])
+}
+
+// clinchStatus works out the strongest marker a team has locked in. A rival
+// "could finish ahead" when its maximum points reach ours, which keeps the
+// markers conservative when tiebreakers are still open.
+func clinchStatus(t Team, teams []Team) string {
+	myMax := maxPoints(t)
+	var leagueCatch, confCatch int
+	catch := map[string]int{} // conference rivals that could finish ahead, by division
+	ahead := map[string]int{} // conference rivals already out of reach, by division
+	for _, o := range teams {
+		if o.Abbrev == t.Abbrev {
+			continue
+		}
+		catches := maxPoints(o) >= t.Record.Points
+		if catches {
+			leagueCatch++
+		}
+		if o.Conference != t.Conference {
+			continue
+		}
+		if catches {
+			confCatch++
+			catch[o.Division]++
+		}
+		if o.Record.Points > myMax {
+			ahead[o.Division]++
+		}
+	}
+	switch {
+	case leagueCatch == 0:
+		return clinchPresidents
+	case confCatch == 0:
+		return clinchConference
+	case catch[t.Division] == 0:
+		return clinchDivision
+	case !pushedOutOfPlayoffs(t.Division, catch):
+		return clinchPlayoff
+	case pushedOutOfPlayoffs(t.Division, ahead):
+		return clinchEliminated
+	}
+	return ""
+}
+
+// pushedOutOfPlayoffs reports whether conference rivals finishing ahead of a
+// team, counted by division, leave it out: its own division's top three
+// spots are gone and two more teams take both wildcards. Only teams beyond
+// the top three of their own division count as wildcards.
+func pushedOutOfPlayoffs(division string, ahead map[string]int) bool {
+	if ahead[division] < divisionPlayoffSpots {
+		return false
+	}
+	wildcards := 0
+	for _, n := range ahead {
+		if n > divisionPlayoffSpots {
+			wildcards += n - divisionPlayoffSpots
+		}
+	}
+	return wildcards >= wildcardPlayoffSpots
+}
+
+// magicTragic returns the magic number (points gained by the team or lost
+// by the first team out that clinch a spot) for teams in a playoff position,
+// and the tragic number (the same against the last team in) for those
+// outside it. Both are nil when the question is already settled.
+func magicTragic(t Team, teams []Team) (*int, *int) {
+	if t.ClinchIndicator != "" {
+		return nil, nil
+	}
+	var lastIn, firstOut *Team
+	for i := range teams {
+		o := &teams[i]
+		if o.Conference != t.Conference {
+			continue
+		}
+		if o.PlayoffSeed == "WC2" {
+			lastIn = o
+		}
+		if o.PlayoffSeed == "" && o.WildcardRank == 3 {
+			firstOut = o
+		}
+	}
+	if t.PlayoffSeed != "" {
+		if firstOut == nil {
+			return nil, nil
+		}
+		n := maxPoints(*firstOut) - t.Record.Points + 1
+		if n < 0 {
+			n = 0
+		}
+		return &n, nil
+	}
+	if lastIn == nil {
+		return nil, nil
+	}
+	n := maxPoints(t) - lastIn.Record.Points + 1
+	if n < 0 {
+		n = 0
+	}
+	return nil, &n
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+// standingsTeam builds a team in the East (Atlantic "A", Metropolitan "M")
+// or West (Central "C", Pacific "P") conference.
+func standingsTeam(abbrev, division string, points, gamesPlayed int) Team {
+	t := Team{Abbrev: abbrev, Division: division, GamesPlayed: gamesPlayed}
+	t.Conference = "E"
+	if division == "C" || division == "P" {
+		t.Conference = "W"
+	}
+	t.Record.Points = points
+	return t
+}
+
+// standingsTeams builds n teams in a division with the same record.
+func standingsTeams(division string, n, points, gamesPlayed int, prefix string) []Team {
+	var out []Team
+	for i := 0; i < n; i++ {
+		out = append(out, standingsTeam(fmt.Sprintf("%s%s%d", prefix, division, i), division, points, gamesPlayed))
+	}
+	return out
+}
+
+func TestClinchStatus(t *testing.T) {
+	west := standingsTeams("C", 1, 130, 82, "w")
+	tests := []struct {
+		name   string
+		team   Team
+		rivals [][]Team
+		want   string
+	}{
+		{
+			name:   "best record in the league",
+			team:   standingsTeam("T", "A", 120, 82),
+			rivals: [][]Team{standingsTeams("A", 7, 100, 82, ""), standingsTeams("M", 8, 100, 82, ""), standingsTeams("P", 8, 100, 82, "")},
+			want:   clinchPresidents,
+		},
+		{
+			name:   "best record in the conference",
+			team:   standingsTeam("T", "A", 110, 82),
+			rivals: [][]Team{standingsTeams("A", 7, 100, 82, ""), standingsTeams("M", 8, 100, 82, ""), west},
+			want:   clinchConference,
+		},
+		{
+			name:   "best record in the division",
+			team:   standingsTeam("T", "A", 110, 82),
+			rivals: [][]Team{standingsTeams("A", 7, 100, 82, ""), standingsTeams("M", 1, 112, 82, "hi"), standingsTeams("M", 7, 100, 82, ""), west},
+			want:   clinchDivision,
+		},
+		{
+			name:   "top three in the division",
+			team:   standingsTeam("T", "A", 90, 82),
+			rivals: [][]Team{standingsTeams("A", 2, 100, 82, "hi"), standingsTeams("A", 5, 80, 82, ""), standingsTeams("M", 8, 100, 82, ""), west},
+			want:   clinchPlayoff,
+		},
+		{
+			name:   "wildcard with one possible rival for two spots",
+			team:   standingsTeam("T", "A", 90, 82),
+			rivals: [][]Team{standingsTeams("A", 4, 100, 82, "hi"), standingsTeams("A", 3, 80, 82, ""), standingsTeams("M", 3, 100, 82, "hi"), standingsTeams("M", 5, 80, 82, ""), west},
+			want:   clinchPlayoff,
+		},
+		{
+			name:   "five division rivals can still pass",
+			team:   standingsTeam("T", "A", 90, 72),
+			rivals: [][]Team{standingsTeams("A", 5, 85, 72, "hi"), standingsTeams("A", 2, 40, 72, ""), standingsTeams("M", 2, 85, 72, "hi"), standingsTeams("M", 6, 40, 72, ""), west},
+			want:   "",
+		},
+		{
+			name:   "three division leaders and two wildcards out of reach",
+			team:   standingsTeam("T", "A", 60, 82),
+			rivals: [][]Team{standingsTeams("A", 3, 90, 82, "hi"), standingsTeams("A", 4, 50, 82, ""), standingsTeams("M", 5, 90, 82, "hi"), standingsTeams("M", 3, 50, 82, ""), west},
+			want:   clinchEliminated,
+		},
+		{
+			name:   "both wildcards from the own division",
+			team:   standingsTeam("T", "A", 60, 82),
+			rivals: [][]Team{standingsTeams("A", 5, 90, 82, "hi"), standingsTeams("A", 2, 50, 82, ""), standingsTeams("M", 2, 90, 82, "hi"), standingsTeams("M", 6, 50, 82, ""), west},
+			want:   clinchEliminated,
+		},
+		{
+			name:   "one wildcard still open",
+			team:   standingsTeam("T", "A", 60, 72),
+			rivals: [][]Team{standingsTeams("A", 4, 85, 72, "hi"), standingsTeams("A", 3, 70, 72, ""), standingsTeams("M", 3, 85, 72, "hi"), standingsTeams("M", 5, 70, 72, ""), west},
+			want:   "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			teams := []Team{tt.team}
+			for _, group := range tt.rivals {
+				teams = append(teams, group...)
+			}
+			if got := clinchStatus(tt.team, teams); got != tt.want {
+				t.Errorf("clinchStatus() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTeamRanksAhead(t *testing.T) {
+	base := func(abbrev string) Team {
+		t := standingsTeam(abbrev, "A", 90, 82)
+		t.RegulationWins = 30
+		t.RegulationPlusOtWins = 35
+		t.Record.Wins = 40
+		t.GoalDiff = 10
+		t.GoalsFor = 250
+		return t
+	}
+	tests := []struct {
+		name   string
+		change func(a, b *Team)
+		h2h    headToHeadFunc
+		want   bool
+	}{
+		{"more points", func(a, b *Team) { a.Record.Points = 92 }, nil, true},
+		{"fewer points", func(a, b *Team) { b.Record.Points = 92 }, nil, false},
+		{"better points percentage", func(a, b *Team) { a.GamesPlayed, b.GamesPlayed = 80, 81 }, nil, true},
+		{"upstream points percentage wins", func(a, b *Team) { a.PointPct, b.PointPct = 0.55, 0.56 }, nil, false},
+		{"more regulation wins", func(a, b *Team) { a.RegulationWins = 31 }, nil, true},
+		{"more regulation plus overtime wins", func(a, b *Team) { b.RegulationPlusOtWins = 36 }, nil, false},
+		{"more total wins", func(a, b *Team) { a.Record.Wins = 41 }, nil, true},
+		{"head-to-head points", func(a, b *Team) {}, func(x, y string) (int, int) {
+			if x == "AAA" {
+				return 2, 4
+			}
+			return 4, 2
+		}, false},
+		{"head-to-head tied falls to goal differential", func(a, b *Team) { a.GoalDiff = 12 }, func(x, y string) (int, int) { return 3, 3 }, true},
+		{"goal differential without head-to-head data", func(a, b *Team) { b.GoalDiff = 11 }, nil, false},
+		{"more goals for", func(a, b *Team) { a.GoalsFor = 251 }, nil, true},
+		{"dead even falls to abbreviation", func(a, b *Team) {}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := base("AAA"), base("BBB")
+			tt.change(&a, &b)
+			if got := teamRanksAhead(a, b, tt.h2h); got != tt.want {
+				t.Errorf("teamRanksAhead(a, b) = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHeadToHeadPoints(t *testing.T) {
+	game := func(home, away string, homeScore, awayScore int, lastPeriod, state string, gameType int) ClubScheduleGame {
+		g := ClubScheduleGame{GameType: gameType, GameState: state}
+		g.HomeTeam = ScheduleTeam{Abbrev: home, Score: homeScore}
+		g.AwayTeam = ScheduleTeam{Abbrev: away, Score: awayScore}
+		g.GameOutcome.LastPeriodType = lastPeriod
+		return g
+	}
+	tests := []struct {
+		name         string
+		games        []ClubScheduleGame
+		wantA, wantB int
+	}{
+		{"regulation win", []ClubScheduleGame{game("TOR", "MTL", 3, 1, "REG", "OFF", 2)}, 2, 0},
+		{"overtime loss earns a point", []ClubScheduleGame{game("TOR", "MTL", 2, 3, "OT", "FINAL", 2)}, 1, 2},
+		{"shootout loss earns a point", []ClubScheduleGame{game("MTL", "TOR", 2, 1, "SO", "OFF", 2)}, 1, 2},
+		{"other opponents ignored", []ClubScheduleGame{game("TOR", "BOS", 5, 0, "REG", "OFF", 2)}, 0, 0},
+		{"playoff games ignored", []ClubScheduleGame{game("TOR", "MTL", 5, 0, "REG", "OFF", 3)}, 0, 0},
+		{"unfinished games ignored", []ClubScheduleGame{game("TOR", "MTL", 1, 0, "", "LIVE", 2)}, 0, 0},
+		{"season series", []ClubScheduleGame{
+			game("TOR", "MTL", 3, 1, "REG", "OFF", 2),
+			game("MTL", "TOR", 4, 3, "OT", "OFF", 2),
+			game("MTL", "TOR", 1, 2, "SO", "OFF", 2),
+		}, 5, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := headToHeadPoints(tt.games, "TOR", "MTL")
+			if a != tt.wantA || b != tt.wantB {
+				t.Errorf("headToHeadPoints() = %d, %d, want %d, %d", a, b, tt.wantA, tt.wantB)
+			}
+		})
+	}
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// errUnknownSeason is returned when a season id is not in /standings-season.
+var errUnknownSeason = errors.New("unknown season")
+
+// StandingsSeason is the date range a season's standings cover.
+type StandingsSeason struct {
+	ID             int    `json:"id"`
+	StandingsStart string `json:"standingsStart"`
+	StandingsEnd   string `json:"standingsEnd"`
+}
+
+// GetStandingsSeason looks up season (e.g. "20232024") in /standings-season.
+func GetStandingsSeason(season string) (*StandingsSeason, error) {
+	data, err := getCachedOrFetchWithBackoff("standings-season", func() ([]byte, error) {
+		return readURL(fmt.Sprintf("%s/standings-season", BaseURL))
+	}, 24*time.Hour)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get standings seasons: %w", err)
+	}
+
+	var resp struct {
+		Seasons []StandingsSeason `json:"seasons"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("parsing standings seasons: %w", err)
+	}
+	for i := range resp.Seasons {
+		if fmt.Sprint(resp.Seasons[i].ID) == season {
+			return &resp.Seasons[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", errUnknownSeason, season)
+}
+
+// standingsDateForSeason picks the date whose standings represent season:
+// today while it is in progress, otherwise its final day.
+func standingsDateForSeason(season string) (string, error) {
+	ss, err := GetStandingsSeason(season)
+	if err != nil {
+		return "", err
+	}
+	today := getStandingsDate()
+	if today < ss.StandingsStart {
+		return "", fmt.Errorf("%w: %s has not started", errUnknownSeason, season)
+	}
+	if today > ss.StandingsEnd {
+		return ss.StandingsEnd, nil
+	}
+	return today, nil
+}
+
+// StandingsHistoryPoint is a team's standing at the end of one day.
+type StandingsHistoryPoint struct {
+	Date         string  `json:"date"`
+	GamesPlayed  int     `json:"gamesPlayed"`
+	Points       int     `json:"points"`
+	PointPct     float64 `json:"pointPctg"`
+	DivisionRank int     `json:"divisionRank"`
+}
+
+// TeamStandingsHistory is the /api/team/{id}/standings-history response.
+type TeamStandingsHistory struct {
+	Team     string                  `json:"team"`
+	Season   string                  `json:"season"`
+	Division string                  `json:"division"`
+	History  []StandingsHistoryPoint `json:"history"`
+}
+
+// leagueStandingsHistory holds every team's daily series for a season so one
+// build serves all 32 team pages.
+type leagueStandingsHistory struct {
+	Season    string                             `json:"season"`
+	Divisions map[string]string                  `json:"divisions"`
+	Teams     map[string][]StandingsHistoryPoint `json:"teams"`
+}
+
+// applyGameResult adds a final regular-season game to the running records.
+func applyGameResult(running map[string]*Team, g ClubScheduleGame) {
+	home, away := running[g.HomeTeam.Abbrev], running[g.AwayTeam.Abbrev]
+	if home == nil || away == nil {
+		return
+	}
+	winner, loser := home, away
+	if g.AwayTeam.Score > g.HomeTeam.Score {
+		winner, loser = away, home
+	}
+	period := g.GameOutcome.LastPeriodType
+
+	winner.GamesPlayed++
+	winner.Record.Wins++
+	winner.Record.Points += 2
+	if period == "REG" {
+		winner.RegulationWins++
+	}
+	if period != "SO" {
+		winner.RegulationPlusOtWins++
+	}
+
+	loser.GamesPlayed++
+	if period == "OT" || period == "SO" {
+		loser.Record.OvertimeLosses++
+		loser.Record.Points++
+	} else {
+		loser.Record.Losses++
+	}
+
+	home.GoalsFor += g.HomeTeam.Score
+	home.GoalsAgainst += g.AwayTeam.Score
+	away.GoalsFor += g.AwayTeam.Score
+	away.GoalsAgainst += g.HomeTeam.Score
+	home.GoalDiff = home.GoalsFor - home.GoalsAgainst
+	away.GoalDiff = away.GoalsFor - away.GoalsAgainst
+}
+
+// buildStandingsHistory replays a season's final scores day by day. Division
+// membership comes from the standings on the last day covered, so past
+// seasons keep their own alignment.
+func buildStandingsHistory(season string) (*leagueStandingsHistory, error) {
+	ss, err := GetStandingsSeason(season)
+	if err != nil {
+		return nil, err
+	}
+	end := ss.StandingsEnd
+	if today := getStandingsDate(); today < end {
+		end = today
+	}
+	if end < ss.StandingsStart {
+		return nil, fmt.Errorf("%w: %s has not started", errUnknownSeason, season)
+	}
+
+	final, err := GetStandingsForDate(end)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &leagueStandingsHistory{
+		Season:    season,
+		Divisions: map[string]string{},
+		Teams:     map[string][]StandingsHistoryPoint{},
+	}
+	running := map[string]*Team{}
+	byDate := map[string][]ClubScheduleGame{}
+	seen := map[int64]bool{}
+	for _, t := range final.Teams {
+		out.Divisions[t.Abbrev] = t.Division
+		running[t.Abbrev] = &Team{Abbrev: t.Abbrev, Division: t.Division}
+
+		sched, err := GetClubSchedule(t.Abbrev, season)
+		if err != nil {
+			return nil, fmt.Errorf("schedule for %s: %w", t.Abbrev, err)
+		}
+		for _, g := range sched.Games {
+			if g.GameType != 2 || !isGameFinal(g.GameState) || seen[g.ID] {
+				continue
+			}
+			seen[g.ID] = true
+			byDate[g.GameDate] = append(byDate[g.GameDate], g)
+		}
+	}
+
+	start, err := time.Parse("2006-01-02", ss.StandingsStart)
+	if err != nil {
+		return nil, fmt.Errorf("parsing season start: %w", err)
+	}
+	stop, err := time.Parse("2006-01-02", end)
+	if err != nil {
+		return nil, fmt.Errorf("parsing season end: %w", err)
+	}
+
+	for d := start; !d.After(stop); d = d.AddDate(0, 0, 1) {
+		day := d.Format("2006-01-02")
+		for _, g := range byDate[day] {
+			applyGameResult(running, g)
+		}
+
+		divisions := map[string][]Team{}
+		for _, t := range running {
+			divisions[t.Division] = append(divisions[t.Division], *t)
+		}
+		for _, teams := range divisions {
+			sort.SliceStable(teams, func(i, j int) bool { return teamRanksAhead(teams[i], teams[j], nil) })
+			for i, t := range teams {
+				pct := 0.0
+				if t.GamesPlayed > 0 {
+					pct = round3(float64(t.Record.Points) / float64(2*t.GamesPlayed))
+				}
+				out.Teams[t.Abbrev] = append(out.Teams[t.Abbrev], StandingsHistoryPoint{
+					Date:         day,
+					GamesPlayed:  t.GamesPlayed,
+					Points:       t.Record.Points,
+					PointPct:     pct,
+					DivisionRank: i + 1,
+				})
+			}
+		}
+	}
+	return out, nil
+}
+
+var standingsHistoryBuilds = newBackgroundBuilds()
+
+// GetStandingsHistory returns the league's daily standings series for season,
+// cached for an hour while the season runs and forever once it is over. It
+// replays every club schedule, so it is built in the background and returns
+// errStillBuilding until the first build finishes.
+func GetStandingsHistory(season string) (*leagueStandingsHistory, error) {
+	ss, err := GetStandingsSeason(season)
+	if err != nil {
+		return nil, err
+	}
+	today := getStandingsDate()
+	if today < ss.StandingsStart {
+		return nil, fmt.Errorf("%w: %s has not started", errUnknownSeason, season)
+	}
+	ttl := time.Hour
+	if today > ss.StandingsEnd {
+		ttl = determineTTL("static")
+	}
+
+	data, err := standingsHistoryBuilds.get(fmt.Sprintf("standings-history:%s", season), ttl, func() ([]byte, error) {
+		h, err := buildStandingsHistory(season)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(h)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var h leagueStandingsHistory
+	if err := json.Unmarshal(data, &h); err != nil {
+		return nil, fmt.Errorf("parsing standings history: %w", err)
+	}
+	return &h, nil
+}
+
+// GetTeamStandingsHistory returns one team's daily points, points% and
+// division rank for season.
+func GetTeamStandingsHistory(teamAbbrev, season string) (*TeamStandingsHistory, error) {
+	h, err := GetStandingsHistory(season)
+	if err != nil {
+		return nil, err
+	}
+	series, ok := h.Teams[teamAbbrev]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s did not play in %s", errUnknownSeason, teamAbbrev, season)
+	}
+	return &TeamStandingsHistory{
+		Team:     teamAbbrev,
+		Season:   season,
+		Division: h.Divisions[teamAbbrev],
+		History:  series,
+	}, nil
+}
+
+func handleAPITeamStandingsHistory(w http.ResponseWriter, r *http.Request) {
+	abbr, ok := resolveTeamAbbrev(mux.Vars(r)["teamId"])
+	if !ok {
+		http.Error(w, "unknown team", http.StatusNotFound)
+		return
+	}
+	season := r.URL.Query().Get("season")
+	if season == "" {
+		season = currentSeasonID()
+	}
+
+	hist, err := GetTeamStandingsHistory(abbr, season)
+	if errors.Is(err, errStillBuilding) {
+		writeStillBuilding(w)
+		return
+	}
+	if err != nil {
+		status := http.StatusBadGateway
+		if errors.Is(err, errUnknownSeason) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(hist); err != nil {
+		log.Printf("Error writing standings history JSON: %v", err)
+	}
+}
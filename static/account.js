@@ -0,0 +1,106 @@
+// Optional accounts: renders the sign-in/account menu into [data-account-menu]
+// and wires favourite toggles. A toggle's data-favourite-team or
+// data-favourite-player value may be left empty on /team/{id} and
+// /player/{id} pages, where the id is taken from the URL.
+(function() {
+    let account = null;
+    const listeners = [];
+
+    async function fetchAccount() {
+        try {
+            const response = await fetch('/api/account', { credentials: 'same-origin' });
+            if (!response.ok) return null;
+            return await response.json();
+        } catch (e) {
+            return null;
+        }
+    }
+
+    function pathID(prefix) {
+        const m = window.location.pathname.match(new RegExp(`^/${prefix}/([^/]+)`));
+        return m ? decodeURIComponent(m[1]) : '';
+    }
+
+    function renderMenu(el) {
+        el.innerHTML = '';
+        if (!account) {
+            const link = document.createElement('a');
+            link.href = `/login?next=${encodeURIComponent(window.location.pathname + window.location.search)}`;
+            link.className = 'px-4 py-2 bg-white/20 hover:bg-white/30 rounded-lg font-semibold transition backdrop-blur-sm';
+            link.textContent = 'Sign in';
+            el.appendChild(link);
+            return;
+        }
+        const wrap = document.createElement('div');
+        wrap.className = 'flex items-center gap-2';
+        const name = document.createElement('span');
+        name.className = 'font-semibold';
+        name.textContent = `👤 ${account.displayName}`;
+        const out = document.createElement('button');
+        out.className = 'px-3 py-2 bg-white/20 hover:bg-white/30 rounded-lg font-semibold transition backdrop-blur-sm';
+        out.textContent = 'Sign out';
+        out.addEventListener('click', async () => {
+            await fetch('/api/auth/logout', { method: 'POST', credentials: 'same-origin' });
+            window.location.reload();
+        });
+        wrap.append(name, out);
+        el.appendChild(wrap);
+    }
+
+    function isFavourite(kind, id) {
+        if (!account) return false;
+        if (kind === 'team') return account.favourites.teams.includes(String(id).toUpperCase());
+        return account.favourites.players.includes(parseInt(id, 10));
+    }
+
+    function renderToggle(btn) {
+        const kind = btn.dataset.favouriteTeam !== undefined ? 'team' : 'player';
+        const id = (kind === 'team' ? btn.dataset.favouriteTeam : btn.dataset.favouritePlayer) || pathID(kind);
+        if (!id) return;
+        const on = isFavourite(kind, id);
+        btn.textContent = on ? '★ Favourite' : '☆ Add to favourites';
+        btn.setAttribute('aria-pressed', on ? 'true' : 'false');
+        btn.onclick = async () => {
+            if (!account) {
+                window.location.href = `/login?next=${encodeURIComponent(window.location.pathname)}`;
+                return;
+            }
+            const path = kind === 'team' ? 'teams' : 'players';
+            const response = await fetch(`/api/account/favourites/${path}/${encodeURIComponent(id)}`, {
+                method: on ? 'DELETE' : 'POST',
+                credentials: 'same-origin'
+            });
+            if (!response.ok) {
+                btn.textContent = (await response.text()) || 'Could not save';
+                return;
+            }
+            account.favourites = await response.json();
+            renderAll();
+        };
+    }
+
+    function renderAll() {
+        document.querySelectorAll('[data-account-menu]').forEach(renderMenu);
+        document.querySelectorAll('[data-favourite-team], [data-favourite-player]').forEach(renderToggle);
+        listeners.forEach(fn => fn(account));
+    }
+
+    // onAccount(fn) calls fn with the account (or null) once it has loaded
+    // and again whenever favourites change.
+    window.onAccount = function(fn) {
+        listeners.push(fn);
+        if (window.accountLoaded) fn(account);
+    };
+
+    async function init() {
+        account = await fetchAccount();
+        window.accountLoaded = true;
+        renderAll();
+    }
+
+    if (document.readyState === 'loading') {
+        document.addEventListener('DOMContentLoaded', init);
+    } else {
+        init();
+    }
+})();
@@ -229,3 +229,187 @@ function createTeamCard(team) {
     `;
     return a;
 }
+
+// "My Teams" dashboard for signed-in users, built from the same
+// /api/teams, /api/schedule and /api/team-news data as the rest of the site
+if (window.onAccount) {
+    window.onAccount(account => renderDashboard(account));
+}
+
+function isoDate(d) {
+    return d.toISOString().slice(0, 10);
+}
+
+async function fetchJSON(url) {
+    const response = await fetch(url);
+    if (!response.ok) throw new Error(`HTTP ${response.status}`);
+    return response.json();
+}
+
+// Next and previous games for each team from this week and last week's schedules
+async function dashboardGames(teams) {
+    const today = new Date();
+    const lastWeek = new Date(today.getTime() - 7 * 24 * 60 * 60 * 1000);
+    const weeks = await Promise.all([isoDate(lastWeek), isoDate(today)].map(d =>
+        fetchJSON(`/api/schedule/${d}`).catch(() => ({ gameWeek: [] }))));
+    const games = [];
+    const seen = new Set();
+    weeks.forEach(w => (w.gameWeek || []).forEach(day => (day.games || []).forEach(g => {
+        if (seen.has(g.id)) return;
+        seen.add(g.id);
+        games.push(g);
+    })));
+    games.sort((a, b) => new Date(a.startTimeUTC) - new Date(b.startTimeUTC));
+
+    const out = {};
+    teams.forEach(abbr => {
+        const mine = games.filter(g => g.homeTeam?.abbrev === abbr || g.awayTeam?.abbrev === abbr);
+        const done = mine.filter(g => g.gameState === 'FINAL' || g.gameState === 'OFF');
+        out[abbr] = {
+            previous: done[done.length - 1] || null,
+            next: mine.find(g => g.gameState !== 'FINAL' && g.gameState !== 'OFF') || null
+        };
+    });
+    return out;
+}
+
+function gameLine(game, abbr, label) {
+    if (!game) return `<div class="text-sm text-gray-500">${label}: none this week</div>`;
+    const home = game.homeTeam.abbrev === abbr;
+    const opp = home ? game.awayTeam.abbrev : game.homeTeam.abbrev;
+    const vs = home ? 'vs' : '@';
+    let detail;
+    if (game.gameState === 'FINAL' || game.gameState === 'OFF') {
+        const us = home ? game.homeTeam.score : game.awayTeam.score;
+        const them = home ? game.awayTeam.score : game.homeTeam.score;
+        detail = `${us > them ? 'W' : 'L'} ${us}-${them}`;
+    } else if (game.gameState === 'LIVE' || game.gameState === 'CRIT') {
+        detail = `LIVE ${home ? game.homeTeam.score : game.awayTeam.score}-${home ? game.awayTeam.score : game.homeTeam.score}`;
+    } else {
+        detail = new Date(game.startTimeUTC).toLocaleString([], { weekday: 'short', month: 'short', day: 'numeric', hour: 'numeric', minute: '2-digit' });
+    }
+    return `<a href="/game/${game.id}" class="flex items-center justify-between text-sm hover:bg-gray-50 rounded px-2 py-1">
+        <span class="text-gray-500 w-16">${label}</span>
+        <span class="flex items-center gap-2 font-semibold text-gray-800">${vs}
+            <img src="https://assets.nhle.com/logos/nhl/svg/${opp}_light.svg" alt="" class="h-6 w-8 object-contain">${opp}</span>
+        <span class="text-gray-700">${detail}</span>
+    </a>`;
+}
+
+function standingsLine(team) {
+    if (!team) return '';
+    const r = team.record || {};
+    const parts = [`${r.wins}-${r.losses}-${r.overtimeLosses}`, `${r.points} pts`];
+    if (team.divisionRank) parts.push(`${ordinalRank(team.divisionRank)} in ${team.division}`);
+    if (team.streak) parts.push(`Streak ${team.streak}`);
+    if (team.lastTen) parts.push(`L10 ${team.lastTen}`);
+    return parts.join(' · ');
+}
+
+function ordinalRank(n) {
+    const s = ['th', 'st', 'nd', 'rd'];
+    const v = n % 100;
+    return n + (s[(v - 20) % 10] || s[v] || s[0]);
+}
+
+async function renderDashboard(account) {
+    const section = document.getElementById('dashboardSection');
+    if (!section) return;
+    if (!account) {
+        section.classList.add('hidden');
+        return;
+    }
+    section.classList.remove('hidden');
+    const teamsEl = document.getElementById('dashboardTeams');
+    const hint = document.getElementById('dashboardHint');
+    const favTeams = account.favourites.teams || [];
+    const favPlayers = account.favourites.players || [];
+    hint.textContent = favTeams.length || favPlayers.length
+        ? `Signed in as ${account.displayName}`
+        : 'Open a team or player page and tap ☆ to add favourites.';
+
+    const calendar = document.getElementById('dashboardCalendar');
+    if (calendar) {
+        calendar.href = `webcal://${window.location.host}/ical/teams.ics?teams=${favTeams.join(',')}`;
+        calendar.classList.toggle('hidden', favTeams.length === 0);
+    }
+
+    teamsEl.innerHTML = favTeams.length ? '<div class="text-gray-500">Loading your teams...</div>' : '';
+    if (favTeams.length) {
+        const [teamsData, games] = await Promise.all([
+            fetchJSON('/api/teams').catch(() => ({ teams: [] })),
+            dashboardGames(favTeams)
+        ]);
+        const byAbbr = {};
+        (teamsData.teams || []).forEach(t => { byAbbr[(t.abbrev || '').toUpperCase()] = t; });
+        teamsEl.innerHTML = '';
+        favTeams.forEach(abbr => {
+            const team = byAbbr[abbr];
+            const card = document.createElement('div');
+            card.className = 'bg-white rounded-2xl shadow-md p-6';
+            card.innerHTML = `
+                <a href="/team/${abbr.toLowerCase()}" class="flex items-center gap-4 mb-3">
+                    <img src="https://assets.nhle.com/logos/nhl/svg/${abbr}_light.svg" alt="${abbr}" class="h-12 w-16 object-contain">
+                    <div>
+                        <div class="text-xl font-bold text-gray-800">${team ? team.name : abbr}</div>
+                        <div class="text-sm text-gray-600">${standingsLine(team)}</div>
+                    </div>
+                </a>
+                <div class="space-y-1 mb-3">
+                    ${gameLine(games[abbr]?.previous, abbr, 'Last')}
+                    ${gameLine(games[abbr]?.next, abbr, 'Next')}
+                </div>
+                <ul class="dashboard-news space-y-2 text-sm"><li class="text-gray-400">Loading news...</li></ul>`;
+            teamsEl.appendChild(card);
+            loadDashboardNews(abbr, card.querySelector('.dashboard-news'));
+        });
+    }
+
+    const playersWrap = document.getElementById('dashboardPlayersWrap');
+    const playersEl = document.getElementById('dashboardPlayers');
+    playersWrap.classList.toggle('hidden', favPlayers.length === 0);
+    playersEl.innerHTML = '';
+    favPlayers.forEach(async id => {
+        const link = document.createElement('a');
+        link.href = `/player/${id}`;
+        link.className = 'flex items-center gap-3 p-3 rounded-xl border border-gray-200 hover:border-accent transition';
+        link.textContent = `Player ${id}`;
+        playersEl.appendChild(link);
+        try {
+            const p = await fetchJSON(`/api/player/${id}`);
+            const name = `${p.firstName?.default || ''} ${p.lastName?.default || ''}`.trim() || `Player ${id}`;
+            const s = p.featuredStats?.regularSeason?.subSeason;
+            let line = p.currentTeamAbbrev || '';
+            if (s) {
+                line += s.wins !== undefined
+                    ? ` · ${s.wins}W ${s.savePercentage !== undefined ? `· ${Number(s.savePercentage).toFixed(3)} SV%` : ''}`
+                    : ` · ${s.goals}G ${s.assists}A ${s.points}P`;
+            }
+            link.innerHTML = `${p.headshot ? `<img src="${p.headshot}" alt="" class="h-12 w-12 rounded-full object-cover">` : ''}
+                <div><div class="font-semibold text-gray-800"></div><div class="text-xs text-gray-500"></div></div>`;
+            link.querySelector('.font-semibold').textContent = name;
+            link.querySelector('.text-xs').textContent = line;
+        } catch (e) {
+            // Keep the placeholder link
+        }
+    });
+}
+
+async function loadDashboardNews(abbr, list) {
+    try {
+        const data = await fetchJSON(`/api/team-news/${abbr}`);
+        const stories = (data.stories || []).slice(0, 3);
+        list.innerHTML = stories.length ? '' : '<li class="text-gray-400">No recent news</li>';
+        stories.forEach(story => {
+            const li = document.createElement('li');
+            const a = document.createElement('a');
+            a.href = `/team/${abbr.toLowerCase()}`;
+            a.className = 'text-secondary hover:underline';
+            a.textContent = story.title;
+            li.appendChild(a);
+            list.appendChild(li);
+        });
+    } catch (e) {
+        list.innerHTML = '<li class="text-gray-400">News unavailable</li>';
+    }
+}
@@ -1,6 +1,8 @@
-// Get team ID from URL
+// Get team ID from URL, or a shared lineup's id from /coach/{shareId}
 const params = new URLSearchParams(window.location.search);
-const teamId = params.get('team');
+const shareMatch = window.location.pathname.match(/^\/coach\/([A-Za-z0-9]+)\/?$/);
+const sharedId = shareMatch ? shareMatch[1] : null;
+let teamId = params.get('team');
 
 let allPlayers = [];
 let forwards = [];
@@ -10,6 +12,8 @@ let teamAbbrev = '';
 let draggedPlayer = null;
 let currentTab = 'even-strength';
 let teamObj = null; // store fetched team details
+let teamLines = null; // lines inferred from shift charts
+let readOnly = false; // viewing a shared lineup
 
 // Load lineup from localStorage
 function loadSavedLineup() {
@@ -17,23 +21,119 @@ function loadSavedLineup() {
     return saved ? JSON.parse(saved) : null;
 }
 
-// Save lineup to localStorage
-function saveLineup() {
-    const lineup = {};
-    
-    // Collect all assigned players
-    document.querySelectorAll('.drop-zone').forEach(zone => {
+// Every assigned slot as {tab, line, position, playerId}
+function collectSlots() {
+    const slots = [];
+    document.querySelectorAll('.drop-zone[data-player-id]').forEach(zone => {
         const slot = zone.closest('.player-slot');
-        const playerId = zone.dataset.playerId;
-        if (playerId) {
-            const tabName = zone.closest('.tab-content').id.replace('tab-', '');
-            const key = `${tabName}_${slot.dataset.line}_${slot.dataset.position}`;
-            lineup[key] = playerId;
-        }
+        slots.push({
+            tab: zone.closest('.tab-content').id.replace('tab-', ''),
+            line: slot.dataset.line,
+            position: slot.dataset.position,
+            playerId: Number(zone.dataset.playerId)
+        });
     });
-    
-    localStorage.setItem(`lineup_${teamId}`, JSON.stringify(lineup));
-    alert('✅ Lineup saved successfully!');
+    return slots;
+}
+
+// Save lineup locally and to the server; the first save creates a shared
+// lineup and later saves add versions to it
+async function saveLineup() {
+    const slots = collectSlots();
+    const name = document.getElementById('lineupName').value.trim();
+    localStorage.setItem(`lineup_${teamId}`, JSON.stringify({ name, slots }));
+
+    const shareKey = `lineupShare_${teamId}`;
+    const share = JSON.parse(localStorage.getItem(shareKey) || 'null');
+    const body = JSON.stringify({ team: teamAbbrev || teamId, name, slots });
+    try {
+        let response = null;
+        if (share) {
+            response = await fetch(`/api/lineups/${share.shareId}`, {
+                method: 'PUT',
+                headers: { 'Content-Type': 'application/json', 'Authorization': `Bearer ${share.editToken}` },
+                body
+            });
+        }
+        // Start a new shared lineup when there is none or the old one is gone
+        if (!response || response.status === 404 || response.status === 401) {
+            response = await fetch('/api/lineups', {
+                method: 'POST',
+                headers: { 'Content-Type': 'application/json' },
+                body
+            });
+        }
+        if (!response.ok) {
+            throw new Error((await response.text()) || `HTTP error! status: ${response.status}`);
+        }
+        const saved = await response.json();
+        if (saved.editToken) {
+            localStorage.setItem(shareKey, JSON.stringify({ shareId: saved.shareId, editToken: saved.editToken }));
+        }
+        showShareLink(saved.url);
+        alert(`✅ Lineup saved (version ${saved.version})`);
+    } catch (error) {
+        console.error('Error saving lineup:', error);
+        alert(`Lineup saved on this device only: ${error.message}`);
+    }
+}
+
+const lineLabels = {
+    '1': 'Line 1', '2': 'Line 2', '3': 'Line 3', '4': 'Line 4',
+    d1: 'Pair 1', d2: 'Pair 2', d3: 'Pair 3',
+    pp1: 'PP Unit 1', pp2: 'PP Unit 2', pk1: 'PK Unit 1', pk2: 'PK Unit 2',
+    ot1: 'OT Unit 1', ot2: 'OT Unit 2', starter: 'Starting Goalie', backup: 'Backup Goalie'
+};
+const gradeColors = { A: 'bg-green-600', B: 'bg-green-500', C: 'bg-yellow-500', D: 'bg-orange-500', F: 'bg-red-600' };
+
+// Ask the server to grade the lines currently on the board
+async function evaluateLineup(slots) {
+    try {
+        const response = await fetch('/api/lineups/evaluate', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ team: teamAbbrev || teamId, slots: slots || collectSlots() })
+        });
+        if (!response.ok) {
+            throw new Error((await response.text()) || `HTTP error! status: ${response.status}`);
+        }
+        renderEvaluation(await response.json());
+    } catch (error) {
+        console.error('Error evaluating lineup:', error);
+        alert(`Could not evaluate lineup: ${error.message}`);
+    }
+}
+
+function renderEvaluation(ev) {
+    const badge = (grade) => `<span class="px-2 py-0.5 rounded text-white text-sm font-bold ${gradeColors[grade] || 'bg-gray-500'}">${grade}</span>`;
+    const name = (id) => (allPlayers.find(p => p.id == id) || {}).name || `#${id}`;
+    const grade = document.getElementById('lineupEvaluationGrade');
+    grade.textContent = ev.grade;
+    grade.className = `px-3 py-1 rounded-lg text-white text-base ${gradeColors[ev.grade] || 'bg-gray-500'}`;
+    document.getElementById('lineupEvaluationWarnings').innerHTML = (ev.warnings || []).map(w => `<li>${w}</li>`).join('');
+    document.getElementById('lineupEvaluationBody').innerHTML = (ev.lines || []).map(l => {
+        const metrics = l.tab === 'goalies'
+            ? `SV%: ${l.savePct ? l.savePct.toFixed(3).replace(/^0/, '') : '-'}`
+            : `Pts/60: ${(l.pointsPer60 || 0).toFixed(2)} · GF%: ${((l.goalShare || 0) * 100).toFixed(1)}%`;
+        return `
+            <div class="border border-gray-200 rounded-xl p-4">
+                <div class="flex items-center justify-between mb-1">
+                    <span class="font-semibold text-gray-800">${lineLabels[l.line] || l.line}</span>
+                    ${badge(l.grade)}
+                </div>
+                <div class="text-xs text-gray-500 mb-1">${l.playerIds.map(name).join(' · ') || 'Empty'}</div>
+                <div class="text-sm text-gray-700">${metrics}</div>
+                ${(l.warnings || []).length ? `<ul class="mt-2 text-xs text-orange-700 list-disc list-inside">${l.warnings.map(w => `<li>${w}</li>`).join('')}</ul>` : ''}
+            </div>`;
+    }).join('');
+    document.getElementById('lineupEvaluation').classList.remove('hidden');
+}
+
+function showShareLink(url) {
+    const link = document.getElementById('shareLink');
+    link.href = url;
+    link.textContent = `${window.location.origin}${url}`;
+    document.getElementById('shareLinkBar').classList.remove('hidden');
 }
 
 // Reset lineup
@@ -226,6 +326,7 @@ function assignPlayerToSlot(player, zone) {
     // Remove player on click
     playerCard.addEventListener('click', (e) => {
         e.stopPropagation();
+        if (readOnly) return;
         zone.innerHTML = '<div class="text-center text-sm text-gray-400">Click to select</div>';
         delete zone.dataset.playerId;
     });
@@ -234,6 +335,17 @@ function assignPlayerToSlot(player, zone) {
 }
 
 async function loadCoach() {
+    let shared = null;
+    if (sharedId) {
+        try {
+            shared = await fetchLineup(sharedId, params.get('version'));
+            teamId = shared.team;
+            readOnly = true;
+        } catch (error) {
+            showError(`Error loading shared lineup: ${error.message}`);
+            return;
+        }
+    }
     if (!teamId) {
         showError('No team specified');
         return;
@@ -269,13 +381,31 @@ async function loadCoach() {
             defensemen = allPlayers.filter(p => p.position === 'D');
             goalies = allPlayers.filter(p => p.position === 'G');
             
-            // Setup click handlers for slots
-            setupSlotClickHandlers();
-            
-            // Load saved lineup if exists
-            const savedLineup = loadSavedLineup();
-            if (savedLineup) {
-                applyLineup(savedLineup);
+            if (shared) {
+                showSharedLineup(shared);
+            } else {
+                // Setup click handlers for slots
+                setupSlotClickHandlers();
+
+                // Start from a copied shared lineup, the saved lineup, or the last game's lines
+                const from = params.get('from');
+                const savedLineup = from ? null : loadSavedLineup();
+                if (from) {
+                    try {
+                        const copy = await fetchLineup(from, params.get('version'));
+                        applySlots(copy.slots);
+                        document.getElementById('lineupName').value = copy.name || '';
+                    } catch (error) {
+                        console.error('Error copying shared lineup:', error);
+                    }
+                } else if (savedLineup && Array.isArray(savedLineup.slots)) {
+                    applySlots(savedLineup.slots);
+                    document.getElementById('lineupName').value = savedLineup.name || '';
+                } else if (savedLineup) {
+                    // Lineups saved before slots were stored as a list
+                    applyLineup(savedLineup);
+                }
+                loadTeamLines(!from && !savedLineup);
             }
             
             // Hide loading, show coach
@@ -338,6 +468,141 @@ function applyLineup(lineup) {
     });
 }
 
+async function fetchLineup(shareId, version) {
+    const query = version ? `?version=${encodeURIComponent(version)}` : '';
+    const response = await fetch(`/api/lineups/${shareId}${query}`);
+    if (!response.ok) {
+        throw new Error((await response.text()) || `HTTP error! status: ${response.status}`);
+    }
+    return response.json();
+}
+
+// Fill slots from a saved list, taking the first free slot of each kind
+function applySlots(slots) {
+    clearLineupSlots();
+    (slots || []).forEach(s => {
+        const player = allPlayers.find(p => p.id == s.playerId);
+        const tabContent = document.getElementById(`tab-${s.tab}`);
+        if (!player || !tabContent) return;
+        const zone = [...tabContent.querySelectorAll(`.player-slot[data-line="${s.line}"][data-position="${s.position}"] .drop-zone`)]
+            .find(z => !z.dataset.playerId);
+        if (zone) assignPlayerToSlot(player, zone);
+    });
+}
+
+// Read-only view of a shared lineup with its version history
+function showSharedLineup(lineup) {
+    document.getElementById('lineupActions').classList.add('hidden');
+    document.getElementById('sharedLineupBar').classList.remove('hidden');
+    document.getElementById('sharedLineupName').textContent = lineup.name || '';
+    const select = document.getElementById('sharedLineupVersion');
+    select.innerHTML = [...lineup.versions].reverse().map(v =>
+        `<option value="${v.version}">v${v.version} — ${new Date(v.savedAt).toLocaleString()}</option>`
+    ).join('');
+    const render = (l) => {
+        select.value = l.version;
+        applySlots(l.slots);
+        evaluateLineup(l.slots);
+        document.getElementById('sharedLineupEdit').href = `/coach?team=${encodeURIComponent(l.team)}&from=${l.shareId}&version=${l.version}`;
+    };
+    render(lineup);
+    select.onchange = async () => {
+        try {
+            render(await fetchLineup(lineup.shareId, select.value));
+        } catch (error) {
+            console.error('Error loading lineup version:', error);
+        }
+    };
+}
+
+// Fetch the lines the real coach used, inferred from shift charts. A team's
+// first request starts a season-long build; the server answers 503 until it
+// is ready, so try again after Retry-After, only filling an untouched board.
+async function loadTeamLines(applyLastGame) {
+    try {
+        const response = await fetch(`/api/team/${teamId}/lines`);
+        if (response.status === 503) {
+            const wait = parseInt(response.headers.get('Retry-After'), 10) || 30;
+            setTimeout(() => {
+                const touched = document.querySelector('.drop-zone[data-player-id]');
+                loadTeamLines(applyLastGame && !touched);
+            }, wait * 1000);
+            return;
+        }
+        if (!response.ok) return;
+        teamLines = await response.json();
+        if (!teamLines.lastGame) return;
+        const last = teamLines.lastGame;
+        document.getElementById('realLinesInfo').textContent =
+            `Last game ${last.isHome ? 'vs' : '@'} ${last.opponent} on ${last.gameDate}; most used across ${teamLines.gamesAnalyzed} games`;
+        document.getElementById('realLinesBar').classList.remove('hidden');
+        if (applyLastGame) applyRealLines(last, last.goalies);
+    } catch (error) {
+        console.error('Error loading team lines:', error);
+    }
+}
+
+// Most used combinations that don't share players, in order of games together
+function pickFrequentUnits(combos, limit) {
+    const used = new Set();
+    const picked = [];
+    for (const combo of combos || []) {
+        if (picked.length === limit) break;
+        const ids = combo.players.map(p => p.playerId);
+        if (ids.some(id => used.has(id))) continue;
+        ids.forEach(id => used.add(id));
+        picked.push(combo);
+    }
+    return picked;
+}
+
+function clearLineupSlots() {
+    document.querySelectorAll('.drop-zone').forEach(zone => {
+        zone.innerHTML = '<div class="text-center text-sm text-gray-400">Click to select</div>';
+        delete zone.dataset.playerId;
+    });
+}
+
+// Fill a tab's slots for one line in DOM order; players no longer on the
+// roster leave their slot empty
+function fillLineSlots(tabName, line, players) {
+    const tabContent = document.getElementById(`tab-${tabName}`);
+    if (!tabContent) return;
+    const slots = tabContent.querySelectorAll(`.player-slot[data-line="${line}"]`);
+    players.forEach((p, i) => {
+        const player = allPlayers.find(r => r.id == p.playerId);
+        if (player && slots[i]) assignPlayerToSlot(player, slots[i].querySelector('.drop-zone'));
+    });
+}
+
+function applyRealLines(set, goaliesList) {
+    clearLineupSlots();
+    (set.forwards || []).forEach((combo, i) => fillLineSlots('even-strength', `${i + 1}`, combo.players));
+    (set.defense || []).forEach((combo, i) => {
+        // Put a right shot on the right side when the pair allows it
+        const pair = [...combo.players];
+        const shoots = id => (allPlayers.find(p => p.id == id) || {}).shootsCatches;
+        if (pair.length === 2 && shoots(pair[0].playerId) === 'R' && shoots(pair[1].playerId) === 'L') pair.reverse();
+        fillLineSlots('even-strength', `d${i + 1}`, pair);
+    });
+    (set.powerPlay || []).forEach((combo, i) => fillLineSlots('powerplay', `pp${i + 1}`, combo.players));
+    (set.penaltyKill || []).forEach((combo, i) => fillLineSlots('penalty-kill', `pk${i + 1}`, combo.players));
+    const [starter, backup] = goaliesList || [];
+    if (starter) fillLineSlots('goalies', 'starter', [starter]);
+    if (backup) fillLineSlots('goalies', 'backup', [backup]);
+}
+
+function applyFrequentLines() {
+    if (!teamLines) return;
+    const f = teamLines.frequent || {};
+    applyRealLines({
+        forwards: pickFrequentUnits(f.forwards, 4),
+        defense: pickFrequentUnits(f.defense, 3),
+        powerPlay: pickFrequentUnits(f.powerPlay, 2),
+        penaltyKill: pickFrequentUnits(f.penaltyKill, 2)
+    }, teamLines.lastGame ? teamLines.lastGame.goalies : []);
+}
+
 function showError(message) {
     document.getElementById('loading').classList.add('hidden');
     const errorDiv = document.getElementById('error');
@@ -377,6 +642,14 @@ document.querySelectorAll('.tab-btn').forEach(btn => {
 document.getElementById('backToTeam')?.addEventListener('click', goBackToTeam);
 document.getElementById('saveLineup')?.addEventListener('click', saveLineup);
 document.getElementById('resetLineup')?.addEventListener('click', resetLineup);
+document.getElementById('evaluateLineup')?.addEventListener('click', () => evaluateLineup());
+document.getElementById('loadLastGameLines')?.addEventListener('click', () => {
+    if (teamLines && teamLines.lastGame) applyRealLines(teamLines.lastGame, teamLines.lastGame.goalies);
+});
+document.getElementById('loadFrequentLines')?.addEventListener('click', applyFrequentLines);
+document.getElementById('copyShareLink')?.addEventListener('click', () => {
+    navigator.clipboard?.writeText(document.getElementById('shareLink').textContent);
+});
 
 // Load on page load
 loadCoach();
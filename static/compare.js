@@ -0,0 +1,223 @@
+// NHL Fan Hub - Player Comparison
+const compareColors = ['#0d47a1', '#e53935', '#ffb300', '#2e7d32'];
+let compareData = null;
+
+document.addEventListener('DOMContentLoaded', function() {
+    const params = new URLSearchParams(window.location.search);
+    const ids = params.get('players') || '';
+    document.getElementById('compareIds').value = ids;
+    if (params.get('rate')) document.getElementById('compareRate').value = params.get('rate');
+
+    document.getElementById('compareForm').addEventListener('submit', function(e) {
+        e.preventDefault();
+        loadComparison();
+    });
+    document.getElementById('compareRate').addEventListener('change', function() {
+        updateURL();
+        if (compareData) renderComparison(compareData);
+    });
+
+    // A single id comes from a player page's "Compare with..." link
+    if (ids.split(',').filter(Boolean).length >= 2) loadComparison();
+});
+
+function selectedIds() {
+    return document.getElementById('compareIds').value.split(/[\s,]+/).filter(Boolean).join(',');
+}
+
+function updateURL() {
+    const params = new URLSearchParams({ players: selectedIds(), rate: document.getElementById('compareRate').value });
+    history.replaceState(null, '', `/compare?${params.toString()}`);
+}
+
+async function loadComparison() {
+    updateURL();
+    const loading = document.getElementById('loading');
+    const errorDiv = document.getElementById('error');
+    const container = document.getElementById('compareContainer');
+    loading.classList.remove('hidden');
+    errorDiv.classList.add('hidden');
+
+    try {
+        const response = await fetch(`/api/compare?players=${encodeURIComponent(selectedIds())}`);
+        if (!response.ok) {
+            throw new Error((await response.text()) || `HTTP error! status: ${response.status}`);
+        }
+        compareData = await response.json();
+        renderComparison(compareData);
+        loading.classList.add('hidden');
+        container.classList.remove('hidden');
+    } catch (error) {
+        console.error('Error loading comparison:', error);
+        loading.classList.add('hidden');
+        container.classList.add('hidden');
+        errorDiv.textContent = `Error loading comparison: ${error.message}`;
+        errorDiv.classList.remove('hidden');
+    }
+}
+
+// Columns per player type; rate stats (SV%, GAA) always come from totals
+function statColumns(goalie) {
+    if (goalie) {
+        return [
+            { label: 'W', key: 'wins' },
+            { label: 'SO', key: 'shutouts' },
+            { label: 'GA', key: 'goalsAgainst' },
+            { label: 'SV%', key: 'savePctg', totalsOnly: true, format: v => Number(v).toFixed(3).replace(/^0/, '') },
+            { label: 'GAA', key: 'goalsAgainstAvg', totalsOnly: true, format: v => Number(v).toFixed(2) }
+        ];
+    }
+    return [
+        { label: 'G', key: 'goals' },
+        { label: 'A', key: 'assists' },
+        { label: 'P', key: 'points' },
+        { label: '+/-', key: 'plusMinus' },
+        { label: 'PIM', key: 'pim' },
+        { label: 'S', key: 'shots' }
+    ];
+}
+
+function statValue(line, col, rate) {
+    if (!line) return '-';
+    const source = col.totalsOnly || rate === 'totals' ? line.totals : line[rate];
+    const v = source ? source[col.key] : undefined;
+    if (v === undefined || v === null) return '-';
+    if (col.format) return col.format(v);
+    if (rate === 'perGame') return Number(v).toFixed(2);
+    if (rate === 'per82') return Number(v).toFixed(1);
+    return v;
+}
+
+function renderComparison(data) {
+    const rate = document.getElementById('compareRate').value;
+    const players = data.players || [];
+    document.title = `${players.map(p => p.name).join(' vs ')} - NHL Fan Hub`;
+
+    document.getElementById('comparePlayers').innerHTML = players.map((p, i) => `
+        <a href="/player/${p.playerId}" class="bg-white rounded-2xl shadow-md p-4 flex items-center gap-3 hover:shadow-lg transition border-t-4" style="border-color:${compareColors[i]}">
+            ${p.headshot ? `<img src="${p.headshot}" alt="" class="w-14 h-14 rounded-full object-cover bg-gray-100">` : ''}
+            <div>
+                <div class="font-bold text-gray-900">${p.name}</div>
+                <div class="text-sm text-gray-600">${p.position}${p.teamAbbrev ? ` · ${p.teamAbbrev}` : ''} · Born ${p.birthDate}</div>
+            </div>
+        </a>`).join('');
+
+    renderLineTable('compareCareer', players, p => p.career, rate);
+    renderLineTable('comparePlayoffs', players, p => p.playoffs, rate);
+    renderByAgeTable(data, rate);
+    renderChart(data, rate);
+}
+
+// One row per player with GP and the type's stat columns
+function renderLineTable(id, players, pick, rate) {
+    const goalies = players.every(p => p.goalie);
+    const cols = statColumns(goalies);
+    const table = document.getElementById(id);
+    table.innerHTML = `
+        <thead class="bg-gradient-to-r from-primary to-secondary text-white">
+            <tr>
+                <th class="px-6 py-3 text-left text-sm font-bold uppercase tracking-wider">Player</th>
+                <th class="px-3 py-3 text-center text-sm font-bold uppercase tracking-wider">GP</th>
+                ${cols.map(c => `<th class="px-3 py-3 text-center text-sm font-bold uppercase tracking-wider">${c.label}</th>`).join('')}
+            </tr>
+        </thead>
+        <tbody class="divide-y divide-gray-200">
+            ${players.map((p, i) => {
+                const line = pick(p);
+                const gp = line && line.totals ? line.totals.gamesPlayed : 0;
+                return `
+                <tr class="hover:bg-gray-50">
+                    <td class="px-6 py-3 font-semibold" style="color:${compareColors[i]}">${p.name}</td>
+                    <td class="px-3 py-3 text-center text-gray-700">${gp}</td>
+                    ${cols.map(c => `<td class="px-3 py-3 text-center text-gray-700">${gp ? statValue(line, c, rate) : '-'}</td>`).join('')}
+                </tr>`;
+            }).join('')}
+        </tbody>`;
+}
+
+// Age rows, with each player's season as a compact block
+function renderByAgeTable(data, rate) {
+    const players = data.players || [];
+    const table = document.getElementById('compareByAge');
+    const cell = (p, line) => {
+        if (!line) return '<td class="px-4 py-3 text-center text-gray-400">-</td>';
+        const cols = statColumns(p.goalie);
+        const stats = cols.map(c => `${c.label} ${statValue(line, c, rate)}`).join(' · ');
+        return `
+            <td class="px-4 py-3 text-sm text-gray-700">
+                <div class="font-semibold">${line.season.slice(0, 4)}-${line.season.slice(6)} ${(line.teams || []).join('/')} · ${line.totals.gamesPlayed} GP</div>
+                <div>${stats}</div>
+            </td>`;
+    };
+    table.innerHTML = `
+        <thead class="bg-gradient-to-r from-primary to-secondary text-white">
+            <tr>
+                <th class="px-4 py-3 text-left text-sm font-bold uppercase tracking-wider">Age</th>
+                ${players.map(p => `<th class="px-4 py-3 text-left text-sm font-bold uppercase tracking-wider">${p.name}</th>`).join('')}
+            </tr>
+        </thead>
+        <tbody class="divide-y divide-gray-200">
+            ${(data.byAge || []).map(row => `
+                <tr class="hover:bg-gray-50">
+                    <td class="px-4 py-3 font-bold text-gray-700">${row.age}</td>
+                    ${players.map((p, i) => cell(p, row.lines[i])).join('')}
+                </tr>`).join('')}
+        </tbody>`;
+}
+
+// SVG line chart of points (or SV% when every player is a goalie) by age
+function renderChart(data, rate) {
+    const players = data.players || [];
+    const goalies = players.every(p => p.goalie);
+    const rows = data.byAge || [];
+    const metric = goalies
+        ? { label: 'Save %', value: l => l.totals.savePctg, format: v => v.toFixed(3).replace(/^0/, '') }
+        : { label: rate === 'totals' ? 'Points' : rate === 'perGame' ? 'Points per game' : 'Points per 82', value: l => (rate === 'totals' ? l.totals : l[rate]).points, format: v => rate === 'perGame' ? v.toFixed(2) : v.toFixed(0) };
+    document.getElementById('compareChartTitle').firstChild.textContent = `${metric.label} by Age `;
+
+    const chart = document.getElementById('compareChart');
+    if (rows.length === 0) {
+        chart.innerHTML = '<p class="text-gray-500">No NHL seasons to chart.</p>';
+        document.getElementById('compareLegend').innerHTML = '';
+        return;
+    }
+
+    const width = 800, height = 300, pad = { top: 20, right: 20, bottom: 30, left: 50 };
+    const ages = rows.map(r => r.age);
+    const minAge = Math.min(...ages), maxAge = Math.max(...ages);
+    const values = [];
+    rows.forEach(r => r.lines.forEach((l, i) => {
+        if (l && players[i].goalie === goalies) values.push(metric.value(l));
+    }));
+    let lo = goalies ? Math.min(...values) : 0;
+    let hi = Math.max(...values);
+    if (hi === lo) hi = lo + 1;
+    const x = age => pad.left + (maxAge === minAge ? 0.5 : (age - minAge) / (maxAge - minAge)) * (width - pad.left - pad.right);
+    const y = v => height - pad.bottom - ((v - lo) / (hi - lo)) * (height - pad.top - pad.bottom);
+
+    let svg = `<svg viewBox="0 0 ${width} ${height}" class="w-full min-w-[32rem]" role="img" aria-label="${metric.label} by age">`;
+    for (let t = 0; t <= 4; t++) {
+        const v = lo + (hi - lo) * t / 4;
+        svg += `<line x1="${pad.left}" x2="${width - pad.right}" y1="${y(v)}" y2="${y(v)}" stroke="#e5e7eb"/>`;
+        svg += `<text x="${pad.left - 6}" y="${y(v) + 4}" text-anchor="end" font-size="11" fill="#6b7280">${metric.format(v)}</text>`;
+    }
+    ages.forEach(age => {
+        svg += `<text x="${x(age)}" y="${height - 8}" text-anchor="middle" font-size="11" fill="#6b7280">${age}</text>`;
+    });
+    players.forEach((p, i) => {
+        if (p.goalie !== goalies) return;
+        const pts = rows.filter(r => r.lines[i]).map(r => [x(r.age), y(metric.value(r.lines[i])), r]);
+        if (pts.length === 0) return;
+        svg += `<polyline fill="none" stroke="${compareColors[i]}" stroke-width="2.5" points="${pts.map(pt => `${pt[0]},${pt[1]}`).join(' ')}"/>`;
+        pts.forEach(pt => {
+            svg += `<circle cx="${pt[0]}" cy="${pt[1]}" r="4" fill="${compareColors[i]}"><title>${p.name}, age ${pt[2].age}: ${metric.format(metric.value(pt[2].lines[i]))}</title></circle>`;
+        });
+    });
+    svg += '</svg>';
+    chart.innerHTML = svg;
+
+    document.getElementById('compareLegend').innerHTML = players.map((p, i) => `
+        <span class="flex items-center gap-2 ${p.goalie !== goalies ? 'opacity-40' : ''}">
+            <span class="inline-block w-4 h-1 rounded" style="background:${compareColors[i]}"></span>${p.name}
+        </span>`).join('');
+}
@@ -931,3 +931,89 @@ async function renderGameVideos(gameId) {
         // ignore
     }
 }
+
+// Fetch and render the expected goals summary, cumulative timeline and top shooters
+async function renderGameXG(gameId) {
+    try {
+        const resp = await fetch(`/api/game/${gameId}/xg`);
+        if (!resp.ok) return;
+        const data = await resp.json();
+        const timeline = data.timeline || [];
+        if (timeline.length === 0) return;
+
+        const summary = document.getElementById('xgSummary');
+        if (summary) {
+            const card = (t) => `
+                <div class="bg-white rounded p-3 text-center">
+                    <div class="text-sm text-gray-500 font-semibold">${t.abbrev}</div>
+                    <div class="text-2xl font-extrabold text-gray-900">${Number(t.xgf).toFixed(2)} xG</div>
+                    <div class="text-xs text-gray-500">${t.goalsFor} G • ${Number(t.xgfPct).toFixed(1)}% xGF • 5v5 ${Number(t.xgf5v5).toFixed(2)}</div>
+                </div>`;
+            summary.innerHTML = card(data.away) + card(data.home);
+        }
+
+        const chart = document.getElementById('xgChart');
+        if (chart) chart.innerHTML = buildXGTimelineSVG(data);
+
+        const playersEl = document.getElementById('xgPlayers');
+        if (playersEl) {
+            const top = (data.players || []).slice(0, 8);
+            playersEl.innerHTML = top.length === 0 ? '' : `
+                <table class="w-full text-sm">
+                    <thead><tr class="text-left text-gray-500"><th class="py-1">Player</th><th>Team</th><th class="text-right">Shots</th><th class="text-right">G</th><th class="text-right">ixG</th></tr></thead>
+                    <tbody>${top.map(p => `
+                        <tr class="border-t border-gray-200">
+                            <td class="py-1"><a href="/player/${p.playerId}" class="hover:text-primary font-semibold">${p.name || p.playerId}</a></td>
+                            <td>${p.teamAbbrev}</td>
+                            <td class="text-right">${p.shots}</td>
+                            <td class="text-right">${p.goals}</td>
+                            <td class="text-right font-semibold">${Number(p.ixg).toFixed(2)}</td>
+                        </tr>`).join('')}
+                    </tbody>
+                </table>`;
+        }
+
+        document.getElementById('xgSection').classList.remove('hidden');
+    } catch (e) {
+        // ignore
+    }
+}
+
+// Build a simple step chart of cumulative xG for both teams; goals are marked with dots
+function buildXGTimelineSVG(data) {
+    const timeline = data.timeline || [];
+    const width = 600, height = 220, pad = 30;
+    const maxSecs = Math.max(3600, ...timeline.map(p => p.gameSeconds));
+    const maxXG = Math.max(1, ...timeline.map(p => Math.max(p.homeXg, p.awayXg)));
+    const x = (s) => pad + (s / maxSecs) * (width - pad * 2);
+    const y = (v) => height - pad - (v / maxXG) * (height - pad * 2);
+
+    const pathFor = (key) => {
+        let d = `M${x(0)},${y(0)}`;
+        let prev = 0;
+        timeline.forEach(p => {
+            d += ` L${x(p.gameSeconds)},${y(prev)} L${x(p.gameSeconds)},${y(p[key])}`;
+            prev = p[key];
+        });
+        return d + ` L${x(maxSecs)},${y(prev)}`;
+    };
+    const goals = timeline.filter(p => p.goal).map(p => {
+        const isHome = p.teamAbbrev === data.home.abbrev;
+        const v = isHome ? p.homeXg : p.awayXg;
+        return `<circle cx="${x(p.gameSeconds)}" cy="${y(v)}" r="4" fill="${isHome ? '#0d47a1' : '#ffb300'}"><title>${p.teamAbbrev} goal ${p.timeInPeriod} P${p.period}</title></circle>`;
+    }).join('');
+    const periodLines = [1200, 2400, 3600].filter(s => s < maxSecs).map(s =>
+        `<line x1="${x(s)}" y1="${pad}" x2="${x(s)}" y2="${height - pad}" stroke="#e5e7eb" stroke-dasharray="4"/>`).join('');
+
+    return `
+        <svg viewBox="0 0 ${width} ${height}" class="w-full h-auto" role="img" aria-label="Cumulative expected goals">
+            ${periodLines}
+            <line x1="${pad}" y1="${height - pad}" x2="${width - pad}" y2="${height - pad}" stroke="#9ca3af"/>
+            <text x="${pad}" y="${pad - 8}" font-size="11" fill="#6b7280">${maxXG.toFixed(1)} xG</text>
+            <path d="${pathFor('homeXg')}" fill="none" stroke="#0d47a1" stroke-width="2"/>
+            <path d="${pathFor('awayXg')}" fill="none" stroke="#ffb300" stroke-width="2"/>
+            ${goals}
+            <text x="${width - pad}" y="${height - 8}" font-size="11" text-anchor="end" fill="#0d47a1">${data.home.abbrev}</text>
+            <text x="${width - pad - 40}" y="${height - 8}" font-size="11" text-anchor="end" fill="#b45309">${data.away.abbrev}</text>
+        </svg>`;
+}
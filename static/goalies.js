@@ -0,0 +1,106 @@
+// NHL Fan Hub - Goalie Analytics
+let goaliesData = null;
+let goaliesSort = 'gsax';
+
+document.addEventListener('DOMContentLoaded', function() {
+    populateSeasons();
+    const params = new URLSearchParams(window.location.search);
+    if (params.get('season')) document.getElementById('goaliesSeason').value = params.get('season');
+    if (params.get('gameType')) document.getElementById('goaliesGameType').value = params.get('gameType');
+    if (params.get('minStarts')) document.getElementById('goaliesMinStarts').value = params.get('minStarts');
+
+    ['goaliesSeason', 'goaliesGameType', 'goaliesMinStarts'].forEach(id => {
+        document.getElementById(id)?.addEventListener('change', loadGoalies);
+    });
+    document.querySelectorAll('th[data-sort]').forEach(th => {
+        th.addEventListener('click', () => {
+            goaliesSort = th.dataset.sort;
+            if (goaliesData) renderGoalies(goaliesData);
+        });
+    });
+    loadGoalies();
+});
+
+// Current season first, going back ten seasons
+function populateSeasons() {
+    const select = document.getElementById('goaliesSeason');
+    const now = new Date();
+    let start = now.getMonth() >= 8 ? now.getFullYear() : now.getFullYear() - 1;
+    for (let i = 0; i < 10; i++, start--) {
+        const opt = document.createElement('option');
+        opt.value = `${start}${start + 1}`;
+        opt.textContent = `${start}-${String(start + 1).slice(2)}`;
+        select.appendChild(opt);
+    }
+}
+
+function formatSavePct(v) {
+    return v === undefined || v === null ? '-' : Number(v).toFixed(3).replace(/^0/, '');
+}
+
+function formatSigned(v, digits) {
+    const n = Number(v || 0);
+    return `${n > 0 ? '+' : ''}${n.toFixed(digits)}`;
+}
+
+async function loadGoalies() {
+    const params = new URLSearchParams({
+        season: document.getElementById('goaliesSeason').value,
+        gameType: document.getElementById('goaliesGameType').value,
+        minStarts: document.getElementById('goaliesMinStarts').value || '5'
+    });
+    history.replaceState(null, '', `/goalies?${params.toString()}`);
+
+    const loading = document.getElementById('loading');
+    const errorDiv = document.getElementById('error');
+    const container = document.getElementById('goaliesContainer');
+    loading.classList.remove('hidden');
+    errorDiv.classList.add('hidden');
+
+    try {
+        const response = await fetch(`/api/goalies/analytics?${params.toString()}`);
+        if (!response.ok) {
+            throw new Error((await response.text()) || `HTTP error! status: ${response.status}`);
+        }
+        goaliesData = await response.json();
+        renderGoalies(goaliesData);
+        loading.classList.add('hidden');
+        container.classList.remove('hidden');
+    } catch (error) {
+        console.error('Error loading goalies:', error);
+        loading.classList.add('hidden');
+        container.classList.add('hidden');
+        errorDiv.textContent = `Error loading goalie analytics: ${error.message}`;
+        errorDiv.classList.remove('hidden');
+    }
+}
+
+function renderGoalies(data) {
+    document.getElementById('goaliesLeagueSv').textContent = `League SV%: ${formatSavePct(data.leagueSavePct)}`;
+    const tbody = document.getElementById('goaliesBody');
+    const goalies = [...(data.goalies || [])].sort((a, b) => (b[goaliesSort] || 0) - (a[goaliesSort] || 0));
+    if (goalies.length === 0) {
+        tbody.innerHTML = '<tr><td colspan="11" class="px-4 py-6 text-center text-gray-500">No goalies meet the minimum starts.</td></tr>';
+        return;
+    }
+    const split = (g, key) => formatSavePct((g.byStrength || []).find(s => s.key === key)?.savePct);
+    const rankBadge = (rank, total) => rank ? `<span class="ml-1 text-xs text-gray-400">#${rank}${total ? `/${total}` : ''}</span>` : '';
+    tbody.innerHTML = goalies.map(g => {
+        const r = g.ranks || {};
+        const gsaxClass = g.gsax >= 0 ? 'text-green-700' : 'text-red-700';
+        return `
+            <tr class="hover:bg-gray-50 transition">
+                <td class="px-6 py-3"><a href="/player/${g.playerId}" class="font-semibold text-gray-900 hover:text-primary">${g.name}</a></td>
+                <td class="px-3 py-3 text-center">${g.teamAbbrev ? `<a href="/team/${g.teamAbbrev.toLowerCase()}" class="hover:text-primary">${g.teamAbbrev}</a>` : '-'}</td>
+                <td class="px-3 py-3 text-center text-gray-700">${g.gamesStarted}</td>
+                <td class="px-3 py-3 text-center text-gray-700">${formatSavePct(g.savePct)}${rankBadge(r.savePct)}</td>
+                <td class="px-3 py-3 text-center font-bold ${gsaxClass}">${formatSigned(g.gsax, 1)}${rankBadge(r.gsax)}</td>
+                <td class="px-3 py-3 text-center text-gray-700">${formatSigned(g.gsaxPer60, 2)}${rankBadge(r.gsaxPer60)}</td>
+                <td class="px-3 py-3 text-center text-gray-700">${(g.qualityStartPct * 100).toFixed(0)}%${rankBadge(r.qualityStartPct)}</td>
+                <td class="px-3 py-3 text-center text-gray-700">${g.reallyBadStarts}</td>
+                <td class="px-3 py-3 text-center text-gray-700">${g.backToBackStarts}</td>
+                <td class="px-3 py-3 text-center text-gray-700">${split(g, 'EV')}</td>
+                <td class="px-3 py-3 text-center text-gray-700">${split(g, 'SH')}</td>
+            </tr>`;
+    }).join('');
+}
@@ -0,0 +1,285 @@
+// Career grid: each cell needs a player who fits its row and column.
+// Guesses are checked on the server; progress is kept per grid in localStorage.
+const GRID_SIZE = 3;
+const MAX_GUESSES = 9;
+const params = new URLSearchParams(window.location.search);
+
+let grid = null;
+let progress = null; // {guessesLeft, cells: {"rc": {playerId, name, headshot, rarity}}, ended}
+let activeCell = null;
+let searchTimer = null;
+
+function progressKey(id) {
+    return `grid_${id}`;
+}
+
+function loadProgress(id) {
+    try {
+        const saved = JSON.parse(localStorage.getItem(progressKey(id)) || 'null');
+        if (saved) return saved;
+    } catch (e) {}
+    return { guessesLeft: MAX_GUESSES, cells: {}, ended: false };
+}
+
+function saveProgress() {
+    localStorage.setItem(progressKey(grid.id), JSON.stringify(progress));
+}
+
+async function loadGrid(url) {
+    document.getElementById('error').classList.add('hidden');
+    document.getElementById('gridContainer').classList.add('hidden');
+    document.getElementById('gridDone').classList.add('hidden');
+    document.getElementById('loading').classList.remove('hidden');
+    try {
+        const response = await fetch(url);
+        if (!response.ok) {
+            throw new Error((await response.text()) || 'Failed to load grid');
+        }
+        grid = await response.json();
+    } catch (error) {
+        showError(error.message);
+        return;
+    }
+    progress = loadProgress(grid.id);
+    history.replaceState(null, '', `/grid?id=${encodeURIComponent(grid.id)}`);
+    document.getElementById('loading').classList.add('hidden');
+    document.getElementById('gridContainer').classList.remove('hidden');
+    renderGrid();
+    if (progress.ended) showStats();
+}
+
+function headerHTML(h) {
+    if (h.type === 'team') {
+        return `<img src="https://assets.nhle.com/logos/nhl/svg/${h.team}_light.svg" alt="${h.label}" title="${h.label}" class="h-12 w-16 object-contain mx-auto">
+            <div class="text-xs text-gray-600 mt-1 hidden sm:block">${h.label}</div>`;
+    }
+    return `<div class="text-sm font-bold text-primary leading-tight">${h.label}</div>`;
+}
+
+function renderGrid() {
+    const el = document.getElementById('grid');
+    el.innerHTML = '<div></div>';
+    grid.cols.forEach(c => {
+        el.insertAdjacentHTML('beforeend', `<div class="flex flex-col items-center justify-end text-center p-2">${headerHTML(c)}</div>`);
+    });
+    grid.rows.forEach((r, ri) => {
+        el.insertAdjacentHTML('beforeend', `<div class="flex flex-col items-center justify-center text-center p-2">${headerHTML(r)}</div>`);
+        grid.cols.forEach((c, ci) => {
+            const cell = document.createElement('button');
+            cell.id = `cell-${ri}${ci}`;
+            cell.className = 'aspect-square rounded-xl border-2 border-gray-200 bg-gray-50 hover:border-secondary transition flex flex-col items-center justify-center p-1 overflow-hidden';
+            cell.addEventListener('click', () => openPicker(ri, ci));
+            el.appendChild(cell);
+            renderCell(ri, ci);
+        });
+    });
+    updateScore();
+}
+
+function renderCell(r, c) {
+    const cell = document.getElementById(`cell-${r}${c}`);
+    const answer = progress.cells[`${r}${c}`];
+    if (answer) {
+        cell.disabled = true;
+        cell.className = 'aspect-square rounded-xl border-2 border-green-500 bg-green-50 flex flex-col items-center justify-center p-1 overflow-hidden';
+        cell.innerHTML = `${answer.headshot ? `<img src="${answer.headshot}" alt="" class="h-14 w-14 rounded-full object-cover">` : ''}
+            <div class="text-xs font-semibold text-gray-800 text-center leading-tight mt-1">${answer.name}</div>
+            ${answer.rarity != null ? `<div class="text-[10px] text-gray-500">${answer.rarity}%</div>` : ''}`;
+        return;
+    }
+    cell.disabled = progress.ended || progress.guessesLeft <= 0;
+    cell.innerHTML = `<div class="text-xs text-gray-400">${grid.answers[r][c]} known</div>`;
+}
+
+// Rarity score: the pick % of each answer, 100 for each empty cell
+function rarityScore() {
+    let total = 0;
+    for (let r = 0; r < GRID_SIZE; r++) {
+        for (let c = 0; c < GRID_SIZE; c++) {
+            const a = progress.cells[`${r}${c}`];
+            total += a ? (a.rarity != null ? a.rarity : 100) : 100;
+        }
+    }
+    return Math.round(total * 10) / 10;
+}
+
+function updateScore() {
+    document.getElementById('guessesLeft').textContent = progress.guessesLeft;
+    document.getElementById('rarityScore').textContent = rarityScore();
+    const filled = Object.keys(progress.cells).length;
+    if (filled === GRID_SIZE * GRID_SIZE || progress.guessesLeft <= 0 || progress.ended) {
+        showDone();
+    }
+}
+
+function openPicker(r, c) {
+    if (progress.ended || progress.guessesLeft <= 0) return;
+    activeCell = { r, c };
+    document.getElementById('pickerPrompt').textContent = `${grid.rows[r].label} × ${grid.cols[c].label}`;
+    document.getElementById('pickerInput').value = '';
+    document.getElementById('pickerResults').innerHTML = '';
+    document.getElementById('pickerModal').classList.remove('hidden');
+    document.getElementById('pickerInput').focus();
+}
+
+function closePicker() {
+    activeCell = null;
+    document.getElementById('pickerModal').classList.add('hidden');
+}
+
+async function searchPlayers(q) {
+    const list = document.getElementById('pickerResults');
+    if (q.length < 2) {
+        list.innerHTML = '';
+        return;
+    }
+    try {
+        const resp = await fetch(`/api/search?q=${encodeURIComponent(q)}&limit=10`);
+        if (!resp.ok) return;
+        const data = await resp.json();
+        const used = new Set(Object.values(progress.cells).map(a => String(a.playerId)));
+        list.innerHTML = '';
+        (data.results || []).filter(x => x.type === 'player').forEach(p => {
+            const li = document.createElement('li');
+            const taken = used.has(p.id);
+            li.className = `flex items-center gap-3 px-2 py-2 ${taken ? 'opacity-40' : 'cursor-pointer hover:bg-gray-50'}`;
+            li.innerHTML = `${p.image ? `<img src="${p.image}" alt="" class="h-8 w-8 rounded-full object-cover">` : '<div class="h-8 w-8"></div>'}
+                <div><div class="font-semibold text-gray-800"></div><div class="text-xs text-gray-500"></div></div>`;
+            li.querySelector('.font-semibold').textContent = p.name;
+            li.querySelector('.text-xs').textContent = taken ? 'Already used' : (p.subtitle || '');
+            if (!taken) li.addEventListener('click', () => guess(parseInt(p.id, 10)));
+            list.appendChild(li);
+        });
+    } catch (e) {
+        // Keep the previous results on a failed search
+    }
+}
+
+async function guess(playerId) {
+    if (!activeCell) return;
+    const { r, c } = activeCell;
+    closePicker();
+    try {
+        const resp = await fetch(`/api/grid/${encodeURIComponent(grid.id)}/guess`, {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ row: r, col: c, playerId })
+        });
+        if (!resp.ok) {
+            throw new Error((await resp.text()) || 'Failed to check guess');
+        }
+        const result = await resp.json();
+        progress.guessesLeft--;
+        if (result.correct) {
+            progress.cells[`${r}${c}`] = {
+                playerId: result.playerId,
+                name: result.name,
+                headshot: result.headshot,
+                rarity: result.rarity
+            };
+        } else {
+            flashCell(r, c, result.name);
+        }
+        saveProgress();
+        for (let i = 0; i < GRID_SIZE; i++) {
+            for (let j = 0; j < GRID_SIZE; j++) renderCell(i, j);
+        }
+        updateScore();
+    } catch (error) {
+        showError(error.message);
+    }
+}
+
+function flashCell(r, c, name) {
+    const cell = document.getElementById(`cell-${r}${c}`);
+    cell.classList.replace('border-gray-200', 'border-red-500');
+    showError(`${name} doesn't fit ${grid.rows[r].label} × ${grid.cols[c].label}`);
+    setTimeout(() => {
+        cell.classList.replace('border-red-500', 'border-gray-200');
+        document.getElementById('error').classList.add('hidden');
+    }, 2500);
+}
+
+function shareText() {
+    let rows = '';
+    for (let r = 0; r < GRID_SIZE; r++) {
+        for (let c = 0; c < GRID_SIZE; c++) rows += progress.cells[`${r}${c}`] ? '🟩' : '⬜';
+        rows += '\n';
+    }
+    const label = grid.date ? `Career Grid ${grid.date.slice(0, 4)}-${grid.date.slice(4, 6)}-${grid.date.slice(6)}` : 'Career Grid';
+    return `🏒 ${label}: ${Object.keys(progress.cells).length}/9 · Rarity ${rarityScore()}\n${rows}`;
+}
+
+function showDone() {
+    const filled = Object.keys(progress.cells).length;
+    document.getElementById('gridDone').classList.remove('hidden');
+    document.getElementById('gridDoneTitle').textContent = filled === GRID_SIZE * GRID_SIZE
+        ? 'Immaculate! 🎉'
+        : `${filled} of 9 filled`;
+    document.getElementById('gridShareText').textContent = shareText();
+}
+
+function endGame() {
+    if (!grid) return;
+    progress.ended = true;
+    saveProgress();
+    for (let i = 0; i < GRID_SIZE; i++) {
+        for (let j = 0; j < GRID_SIZE; j++) renderCell(i, j);
+    }
+    updateScore();
+    showStats();
+}
+
+// After the game, empty cells show the most common answers
+async function showStats() {
+    try {
+        const resp = await fetch(`/api/grid/${encodeURIComponent(grid.id)}/stats`);
+        if (!resp.ok) return;
+        const data = await resp.json();
+        data.cells.forEach(s => {
+            if (progress.cells[`${s.row}${s.col}`] || s.top.length === 0) return;
+            const cell = document.getElementById(`cell-${s.row}${s.col}`);
+            cell.innerHTML = `<div class="text-[10px] uppercase text-gray-400 mb-1">Most picked</div>` +
+                s.top.slice(0, 3).map(t => `<div class="text-xs text-gray-700 leading-tight">${t.name || t.playerId} · ${t.rarity}%</div>`).join('');
+        });
+    } catch (e) {
+        // Stats need Redis; the grid works without them
+    }
+}
+
+async function share() {
+    const text = `${shareText()}${window.location.href}`;
+    const btn = document.getElementById('gridShareBtn');
+    try {
+        if (navigator.share) {
+            await navigator.share({ text });
+            return;
+        }
+        await navigator.clipboard.writeText(text);
+        btn.textContent = 'Copied!';
+        setTimeout(() => { btn.textContent = 'Share'; }, 2000);
+    } catch (e) {}
+}
+
+function showError(message) {
+    document.getElementById('loading').classList.add('hidden');
+    const errorDiv = document.getElementById('error');
+    errorDiv.textContent = message;
+    errorDiv.classList.remove('hidden');
+}
+
+// Event listeners
+document.getElementById('dailyGridBtn')?.addEventListener('click', () => loadGrid('/api/grid/daily'));
+document.getElementById('randomGridBtn')?.addEventListener('click', () => loadGrid('/api/grid/random'));
+document.getElementById('gridShareBtn')?.addEventListener('click', share);
+document.getElementById('gridEndBtn')?.addEventListener('click', endGame);
+document.getElementById('pickerClose')?.addEventListener('click', closePicker);
+document.getElementById('pickerModal')?.addEventListener('click', (e) => {
+    if (e.target.id === 'pickerModal') closePicker();
+});
+document.getElementById('pickerInput')?.addEventListener('input', (e) => {
+    clearTimeout(searchTimer);
+    searchTimer = setTimeout(() => searchPlayers(e.target.value.trim()), 200);
+});
+
+loadGrid(params.get('id') ? `/api/grid/${encodeURIComponent(params.get('id'))}` : '/api/grid/daily');
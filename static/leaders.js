@@ -0,0 +1,116 @@
+// NHL Fan Hub - League Leaders
+const categoryLabels = {
+    points: 'Points', goals: 'Goals', assists: 'Assists', plusMinus: '+/-', pim: 'PIM', shots: 'Shots',
+    wins: 'Wins', gaa: 'GAA', savePct: 'SV%', shutouts: 'Shutouts'
+};
+const goalieCategories = ['wins', 'gaa', 'savePct', 'shutouts'];
+
+document.addEventListener('DOMContentLoaded', function() {
+    populateSeasons();
+    // Allow deep links like /leaders?category=goals&rookie=true
+    const params = new URLSearchParams(window.location.search);
+    if (params.get('category')) document.getElementById('leadersCategory').value = params.get('category');
+    if (params.get('season')) document.getElementById('leadersSeason').value = params.get('season');
+    if (params.get('gameType')) document.getElementById('leadersGameType').value = params.get('gameType');
+    if (params.get('position')) document.getElementById('leadersPosition').value = params.get('position');
+    if (params.get('team')) document.getElementById('leadersTeam').value = params.get('team');
+    if (params.get('rookie') === 'true') document.getElementById('leadersRookie').checked = true;
+
+    ['leadersCategory', 'leadersSeason', 'leadersGameType', 'leadersPosition', 'leadersRookie'].forEach(id => {
+        document.getElementById(id)?.addEventListener('change', loadLeaders);
+    });
+    document.getElementById('leadersTeam')?.addEventListener('change', loadLeaders);
+    loadLeaders();
+});
+
+// Current season first, going back ten seasons
+function populateSeasons() {
+    const select = document.getElementById('leadersSeason');
+    const now = new Date();
+    let start = now.getMonth() >= 8 ? now.getFullYear() : now.getFullYear() - 1;
+    for (let i = 0; i < 10; i++, start--) {
+        const opt = document.createElement('option');
+        opt.value = `${start}${start + 1}`;
+        opt.textContent = `${start}-${String(start + 1).slice(2)}`;
+        select.appendChild(opt);
+    }
+}
+
+function formatValue(category, value) {
+    if (category === 'gaa') return Number(value).toFixed(2);
+    if (category === 'savePct') return Number(value).toFixed(3).replace(/^0/, '');
+    if (category === 'plusMinus' && value > 0) return `+${value}`;
+    return value;
+}
+
+async function loadLeaders() {
+    const category = document.getElementById('leadersCategory').value;
+    const isGoalie = goalieCategories.includes(category);
+    const positionSelect = document.getElementById('leadersPosition');
+    positionSelect.disabled = isGoalie;
+
+    const params = new URLSearchParams({
+        category,
+        season: document.getElementById('leadersSeason').value,
+        gameType: document.getElementById('leadersGameType').value
+    });
+    if (!isGoalie && positionSelect.value) params.set('position', positionSelect.value);
+    const team = document.getElementById('leadersTeam').value.trim();
+    if (team) params.set('team', team.toUpperCase());
+    if (document.getElementById('leadersRookie').checked) params.set('rookie', 'true');
+    history.replaceState(null, '', `/leaders?${params.toString()}`);
+
+    const loading = document.getElementById('loading');
+    const errorDiv = document.getElementById('error');
+    const container = document.getElementById('leadersContainer');
+    loading.classList.remove('hidden');
+    errorDiv.classList.add('hidden');
+
+    try {
+        const response = await fetch(`/api/leaders?${params.toString()}`);
+        if (!response.ok) {
+            throw new Error((await response.text()) || `HTTP error! status: ${response.status}`);
+        }
+        const data = await response.json();
+        renderLeaders(data);
+        loading.classList.add('hidden');
+        container.classList.remove('hidden');
+    } catch (error) {
+        console.error('Error loading leaders:', error);
+        loading.classList.add('hidden');
+        container.classList.add('hidden');
+        errorDiv.textContent = `Error loading leaders: ${error.message}`;
+        errorDiv.classList.remove('hidden');
+    }
+}
+
+function renderLeaders(data) {
+    document.getElementById('leadersValueHeader').textContent = categoryLabels[data.category] || data.category;
+    const tbody = document.getElementById('leadersBody');
+    const rows = data.leaders || [];
+    if (rows.length === 0) {
+        tbody.innerHTML = '<tr><td colspan="6" class="px-4 py-6 text-center text-gray-500">No players match these filters.</td></tr>';
+        return;
+    }
+    tbody.innerHTML = rows.map(p => {
+        const logo = p.teamAbbrev ? `https://assets.nhle.com/logos/nhl/svg/${p.teamAbbrev}_light.svg` : '';
+        return `
+            <tr class="hover:bg-gray-50 transition">
+                <td class="px-4 py-3 font-bold text-gray-700">${p.rank}</td>
+                <td class="px-6 py-3">
+                    <a href="/player/${p.playerId}" class="flex items-center gap-3 hover:text-primary">
+                        ${p.headshot ? `<img src="${p.headshot}" alt="" class="w-10 h-10 rounded-full object-cover bg-gray-100">` : ''}
+                        <span class="font-semibold text-gray-900">${p.name}</span>
+                    </a>
+                </td>
+                <td class="px-3 py-3 text-center">
+                    ${p.teamAbbrev ? `<a href="/team/${p.teamAbbrev.toLowerCase()}" class="inline-flex items-center gap-1 hover:text-primary">${logo ? `<img src="${logo}" alt="" class="w-6 h-6" onerror="this.style.display='none'">` : ''}${p.teamAbbrev}</a>` : '-'}
+                </td>
+                <td class="px-3 py-3 text-center text-gray-700">${p.position || (data.group === 'goalies' ? 'G' : '-')}</td>
+                <td class="px-3 py-3 text-center text-gray-700">${p.gamesPlayed || '-'}</td>
+                <td class="px-4 py-3 text-center">
+                    <span class="inline-flex items-center px-3 py-1 rounded-full text-sm font-bold bg-primary text-white">${formatValue(data.category, p.value)}</span>
+                </td>
+            </tr>`;
+    }).join('');
+}
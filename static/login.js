@@ -0,0 +1,66 @@
+// Sign-in page: local username/password forms plus the optional OIDC button.
+// After signing in the user returns to ?next= (a path on this site).
+const params = new URLSearchParams(window.location.search);
+const nextParam = params.get('next') || '/';
+const next = nextParam.startsWith('/') && !nextParam.startsWith('//') ? nextParam : '/';
+
+async function loadProviders() {
+    try {
+        const response = await fetch('/api/auth/providers');
+        if (!response.ok) return;
+        const providers = await response.json();
+        document.getElementById('unavailable').classList.toggle('hidden', providers.available);
+        if (providers.oidc) {
+            document.getElementById('oidcLoginBtn').href = `/auth/oidc/login?next=${encodeURIComponent(next)}`;
+            document.getElementById('oidcCard').classList.remove('hidden');
+        }
+    } catch (e) {
+        // Local sign-in still works without the provider list
+    }
+}
+
+async function submit(url, body) {
+    document.getElementById('error').classList.add('hidden');
+    try {
+        const response = await fetch(url, {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            credentials: 'same-origin',
+            body: JSON.stringify(body)
+        });
+        if (!response.ok) {
+            throw new Error((await response.text()) || `Request failed (${response.status})`);
+        }
+        window.location.href = next;
+    } catch (error) {
+        showError(error.message);
+    }
+}
+
+function showError(message) {
+    const errorDiv = document.getElementById('error');
+    errorDiv.textContent = message;
+    errorDiv.classList.remove('hidden');
+}
+
+// Event listeners
+document.getElementById('loginForm')?.addEventListener('submit', (e) => {
+    e.preventDefault();
+    submit('/api/auth/login', {
+        username: document.getElementById('loginUsername').value.trim(),
+        password: document.getElementById('loginPassword').value
+    });
+});
+document.getElementById('registerForm')?.addEventListener('submit', (e) => {
+    e.preventDefault();
+    submit('/api/auth/register', {
+        username: document.getElementById('registerUsername').value.trim(),
+        displayName: document.getElementById('registerDisplayName').value.trim(),
+        password: document.getElementById('registerPassword').value
+    });
+});
+
+const oidcError = params.get('error');
+if (oidcError) showError(oidcError);
+
+loadProviders();
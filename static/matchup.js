@@ -0,0 +1,145 @@
+// NHL Fan Hub - Head-to-Head Matchup
+let teamA = null;
+let teamB = null;
+
+document.addEventListener('DOMContentLoaded', function() {
+    // URL shape: /matchup/{teamA}/{teamB}
+    const parts = window.location.pathname.split('/').filter(Boolean);
+    teamA = (parts[1] || '').toUpperCase();
+    teamB = (parts[2] || '').toUpperCase();
+    if (!teamA || !teamB) {
+        showError('Pick two teams to compare.');
+        return;
+    }
+    const back = document.getElementById('backToSchedule');
+    if (back) back.href = `/team-schedule/${teamA.toLowerCase()}`;
+    loadMatchup();
+});
+
+async function loadMatchup() {
+    try {
+        const response = await fetch(`/api/matchup/${teamA}/${teamB}`);
+        if (response.status === 503) {
+            // The all-time history is still being built; keep the spinner up
+            const wait = parseInt(response.headers.get('Retry-After'), 10) || 30;
+            setTimeout(loadMatchup, wait * 1000);
+            return;
+        }
+        if (!response.ok) {
+            throw new Error(`HTTP error! status: ${response.status}`);
+        }
+        const data = await response.json();
+        document.getElementById('loading').classList.add('hidden');
+        document.getElementById('matchupContainer').classList.remove('hidden');
+        document.title = `${data.teamA} vs ${data.teamB} — Hockey`;
+        renderHeader(data);
+        renderSeasonSeries(data);
+        renderTopScorers(data.topScorers || []);
+    } catch (error) {
+        console.error('Error loading matchup:', error);
+        showError(`Error loading matchup: ${error.message}`);
+    }
+}
+
+function formatRecord(r) {
+    if (!r) return '0-0-0';
+    return `${r.wins}-${r.losses}-${r.otLosses}`;
+}
+
+function standingsLine(t) {
+    if (!t || !t.record) return '';
+    const seed = t.playoffSeed ? ` • ${t.playoffSeed}` : '';
+    return `${t.record.wins}-${t.record.losses}-${t.record.overtimeLosses}, ${t.record.points} pts${seed}`;
+}
+
+function teamColumn(abbrev, standing) {
+    const logo = `https://assets.nhle.com/logos/nhl/svg/${abbrev}_light.svg`;
+    return `
+        <a href="/team/${abbrev.toLowerCase()}" class="text-center block hover:text-primary">
+            <img src="${logo}" alt="${abbrev}" class="w-20 h-20 mx-auto mb-2 drop-shadow-md" onerror="this.style.display='none'">
+            <div class="font-bold text-gray-800">${standing ? standing.name : abbrev}</div>
+            <div class="text-xs text-gray-500 mt-1">${standingsLine(standing)}</div>
+        </a>`;
+}
+
+function renderHeader(data) {
+    const a = data.regularSeason || {};
+    const since = data.firstSeason ? ` since ${formatSeason(data.firstSeason)}` : '';
+    const recent = (data.seasons || []).length;
+    document.getElementById('matchupSubtitle').textContent =
+        `${data.teamA} vs ${data.teamB} all-time${since} · series and scorers from the last ${recent} season${recent === 1 ? '' : 's'}`;
+    document.getElementById('matchupTeams').innerHTML = `
+        ${teamColumn(data.teamA, data.standingsA)}
+        <div class="text-center">
+            <div class="text-xs font-semibold text-gray-500 uppercase tracking-wider">${data.teamA} all-time regular season</div>
+            <div class="text-4xl font-extrabold text-primary">${formatRecord(a)}</div>
+            <div class="text-sm text-gray-600 mt-1">Goals ${a.goalsFor || 0} - ${a.goalsAgainst || 0}</div>
+            ${data.playoffs && data.playoffs.gamesPlayed ? `<div class="text-xs text-gray-500 mt-1">Playoffs: ${formatRecord(data.playoffs)}</div>` : ''}
+        </div>
+        ${teamColumn(data.teamB, data.standingsB)}
+    `;
+
+    const next = data.nextMeeting;
+    const nextEl = document.getElementById('nextMeeting');
+    if (next) {
+        const when = next.startTimeUTC ? new Date(next.startTimeUTC).toLocaleString() : next.gameDate;
+        nextEl.innerHTML = `Next meeting: <span class="font-semibold">${next.away} @ ${next.home}</span> — ${when}${next.venue ? ` • ${next.venue}` : ''}`;
+    } else {
+        nextEl.textContent = 'No upcoming meetings scheduled.';
+    }
+}
+
+function formatSeason(s) {
+    return s && s.length === 8 ? `${s.slice(0, 4)}-${s.slice(6)}` : s;
+}
+
+function renderSeasonSeries(data) {
+    const root = document.getElementById('seasonSeries');
+    root.innerHTML = '';
+    (data.seasonSeries || []).forEach(series => {
+        const games = series.games || [];
+        const rows = games.map(g => {
+            const suffix = g.lastPeriodType === 'OT' || g.lastPeriodType === 'SO' ? ` (${g.lastPeriodType})` : '';
+            const tag = g.gameType === 3 ? '<span class="ml-2 text-xs text-accent font-semibold">Playoffs</span>' : '';
+            const won = g.winner === data.teamA;
+            return `
+                <a href="/game/${g.gameId}" class="flex justify-between items-center px-3 py-2 rounded hover:bg-gray-50">
+                    <span class="text-gray-600">${g.gameDate}${tag}</span>
+                    <span class="font-semibold">${g.away} ${g.awayScore} @ ${g.home} ${g.homeScore}${suffix}</span>
+                    <span class="${won ? 'text-green-600' : 'text-red-600'} font-bold">${won ? 'W' : 'L'}</span>
+                </a>`;
+        }).join('');
+        const block = document.createElement('div');
+        block.innerHTML = `
+            <div class="flex justify-between items-center border-b border-gray-200 pb-1 mb-1">
+                <span class="font-bold text-gray-800">${formatSeason(series.season)}</span>
+                <span class="text-sm text-gray-600">${data.teamA} ${formatRecord(series.record)}</span>
+            </div>
+            ${rows || '<div class="text-sm text-gray-500 px-3 py-2">No games played yet.</div>'}
+        `;
+        root.appendChild(block);
+    });
+}
+
+function renderTopScorers(scorers) {
+    const tbody = document.getElementById('topScorers');
+    if (scorers.length === 0) {
+        tbody.innerHTML = '<tr><td colspan="5" class="px-3 py-4 text-center text-gray-500">No goals recorded yet.</td></tr>';
+        return;
+    }
+    tbody.innerHTML = scorers.map(s => `
+        <tr class="hover:bg-gray-50">
+            <td class="px-3 py-2"><a href="/player/${s.playerId}" class="font-semibold text-gray-900 hover:text-primary">${s.name}</a></td>
+            <td class="px-3 py-2 text-center">${s.team || '-'}</td>
+            <td class="px-3 py-2 text-center">${s.goals}</td>
+            <td class="px-3 py-2 text-center">${s.assists}</td>
+            <td class="px-3 py-2 text-center font-bold">${s.points}</td>
+        </tr>`).join('');
+}
+
+function showError(message) {
+    document.getElementById('loading').classList.add('hidden');
+    const errorDiv = document.getElementById('error');
+    errorDiv.textContent = message;
+    errorDiv.classList.remove('hidden');
+}
@@ -111,6 +111,12 @@ async function loadPlayer() {
 
         // Load biography in the background
         loadPlayerBio(id);
+        // Individual expected goals only make sense for skaters
+        if ((data.position || '') !== 'G') loadPlayerXG(id);
+        else loadGoalieAnalytics(id);
+        loadPlayerGameLog(id);
+        const compareLink = document.getElementById('playerCompareLink');
+        if (compareLink) compareLink.href = `/compare?players=${id}`;
 
         // Helper to safely extract string values from nested structures
         const resolve = (val) => {
@@ -817,6 +823,169 @@ async function loadPlayer() {
     }
 }
 
+async function loadPlayerXG(playerId) {
+    const section = document.getElementById('playerXGSection');
+    const el = document.getElementById('playerXG');
+    if (!section || !el) return;
+    try {
+        const resp = await fetch(`/api/player/${playerId}/xg`);
+        if (resp.status === 503) {
+            // The season is still being built; try again when the server says
+            const wait = parseInt(resp.headers.get('Retry-After'), 10) || 30;
+            setTimeout(() => loadPlayerXG(playerId), wait * 1000);
+            return;
+        }
+        if (!resp.ok) return;
+        const data = await resp.json();
+        if (!data.games) return;
+
+        const gax = Number(data.goalsAboveExpected);
+        const gaxClass = gax >= 0 ? 'text-green-600' : 'text-red-600';
+        const tile = (label, value, cls = 'text-gray-900') => `
+            <div class="bg-gray-50 rounded-lg p-3 text-center">
+                <div class="text-xs uppercase tracking-wide text-gray-500 font-semibold">${label}</div>
+                <div class="text-2xl font-extrabold ${cls}">${value}</div>
+            </div>`;
+
+        // Sparkline of per-game ixG with goals marked
+        const games = data.perGame || [];
+        const w = 300, h = 50;
+        const maxV = Math.max(0.5, ...games.map(g => g.ixg));
+        const step = games.length > 1 ? w / (games.length - 1) : w;
+        const pts = games.map((g, i) => `${(i * step).toFixed(1)},${(h - (g.ixg / maxV) * h).toFixed(1)}`).join(' ');
+        const dots = games.map((g, i) => g.goals > 0 ? `<circle cx="${(i * step).toFixed(1)}" cy="${(h - (g.ixg / maxV) * h).toFixed(1)}" r="2.5" fill="#16a34a"><title>${g.gameDate} vs ${g.opponent}: ${g.goals} G, ${g.ixg} ixG</title></circle>` : '').join('');
+
+        el.innerHTML = `
+            <div class="grid grid-cols-2 sm:grid-cols-4 gap-3">
+                ${tile('ixG', Number(data.ixg).toFixed(2))}
+                ${tile('Goals', data.goals)}
+                ${tile('G − ixG', (gax >= 0 ? '+' : '') + gax.toFixed(2), gaxClass)}
+                ${tile('ixG / GP', Number(data.ixgPerGame).toFixed(2))}
+            </div>
+            ${games.length > 1 ? `<svg viewBox="-4 -4 ${w + 8} ${h + 8}" class="w-full h-16 mt-3" role="img" aria-label="ixG by game"><polyline points="${pts}" fill="none" stroke="#0d47a1" stroke-width="1.5"/>${dots}</svg>` : ''}
+        `;
+        section.classList.remove('hidden');
+    } catch (e) {
+        console.error('Error loading player xG:', e);
+    }
+}
+
+// Recent games with streaks, rolling averages and home/road splits
+async function loadPlayerGameLog(playerId) {
+    const section = document.getElementById('playerGameLogSection');
+    const el = document.getElementById('playerGameLog');
+    if (!section || !el) return;
+    try {
+        const resp = await fetch(`/api/player/${playerId}/game-log`);
+        if (!resp.ok) return;
+        const data = await resp.json();
+        const games = data.games || [];
+        if (games.length === 0) return;
+
+        const tile = (label, value) => `
+            <div class="bg-gray-50 rounded-lg p-3 text-center">
+                <div class="text-xs uppercase tracking-wide text-gray-500 font-semibold">${label}</div>
+                <div class="text-2xl font-extrabold text-gray-900">${value}</div>
+            </div>`;
+        const latest = games[0];
+        const splitLine = (s) => data.goalie
+            ? `${s.gamesPlayed} GP, ${s.wins}-${s.losses}-${s.otLosses}, ${Number(s.savePct).toFixed(3).replace(/^0/, '')} SV%`
+            : `${s.gamesPlayed} GP, ${s.goals} G, ${s.assists} A, ${s.points} P`;
+
+        const tiles = data.goalie ? `
+                ${tile('Win Streak', data.streaks.winStreak)}
+                ${tile('Unbeaten', data.streaks.unbeatenStreak)}
+                ${tile('Last 5 SV%', Number(latest.rolling5.savePct).toFixed(3).replace(/^0/, ''))}
+                ${tile('Last 10 GA/GP', Number(latest.rolling10.goalsAgainst).toFixed(2))}` : `
+                ${tile('Point Streak', data.streaks.pointStreak)}
+                ${tile('Goal Streak', data.streaks.goalStreak)}
+                ${tile('Last 5 P/GP', Number(latest.rolling5.points).toFixed(2))}
+                ${tile('Last 10 P/GP', Number(latest.rolling10.points).toFixed(2))}`;
+
+        const header = data.goalie
+            ? '<th class="px-2 py-1 text-left">Date</th><th class="px-2 py-1 text-left">Opp</th><th class="px-2 py-1">Dec</th><th class="px-2 py-1">SA</th><th class="px-2 py-1">Saves</th><th class="px-2 py-1">GA</th><th class="px-2 py-1">SV%</th>'
+            : '<th class="px-2 py-1 text-left">Date</th><th class="px-2 py-1 text-left">Opp</th><th class="px-2 py-1">G</th><th class="px-2 py-1">A</th><th class="px-2 py-1">P</th><th class="px-2 py-1">+/-</th><th class="px-2 py-1">SOG</th><th class="px-2 py-1">TOI</th>';
+        const rows = games.slice(0, 10).map(g => {
+            const opp = `${g.homeRoadFlag === 'H' ? 'vs' : '@'} ${g.opponentAbbrev}`;
+            const cells = data.goalie
+                ? `<td class="px-2 py-1 text-center">${g.decision || '-'}</td><td class="px-2 py-1 text-center">${g.shotsAgainst}</td><td class="px-2 py-1 text-center">${g.saves}</td><td class="px-2 py-1 text-center">${g.goalsAgainst}</td><td class="px-2 py-1 text-center">${Number(g.savePctg || 0).toFixed(3).replace(/^0/, '')}</td>`
+                : `<td class="px-2 py-1 text-center">${g.goals}</td><td class="px-2 py-1 text-center">${g.assists}</td><td class="px-2 py-1 text-center font-semibold">${g.points}</td><td class="px-2 py-1 text-center">${g.plusMinus > 0 ? '+' + g.plusMinus : g.plusMinus}</td><td class="px-2 py-1 text-center">${g.shots}</td><td class="px-2 py-1 text-center">${g.toi || '-'}</td>`;
+            return `<tr class="hover:bg-gray-50"><td class="px-2 py-1"><a href="/game/${g.gameId}" class="hover:text-primary">${g.gameDate}</a></td><td class="px-2 py-1">${opp}</td>${cells}</tr>`;
+        }).join('');
+
+        el.innerHTML = `
+            <div class="grid grid-cols-2 sm:grid-cols-4 gap-3">${tiles}</div>
+            <div class="grid grid-cols-1 sm:grid-cols-2 gap-3 mt-3 text-sm text-gray-700">
+                <div class="bg-gray-50 rounded-lg p-3"><span class="font-semibold">Home:</span> ${splitLine(data.home)}</div>
+                <div class="bg-gray-50 rounded-lg p-3"><span class="font-semibold">Road:</span> ${splitLine(data.road)}</div>
+            </div>
+            <div class="overflow-x-auto mt-3">
+                <table class="min-w-full text-sm">
+                    <thead class="bg-gray-100 text-gray-700"><tr>${header}</tr></thead>
+                    <tbody class="divide-y divide-gray-200">${rows}</tbody>
+                </table>
+            </div>
+        `;
+        section.classList.remove('hidden');
+    } catch (e) {
+        console.error('Error loading player game log:', e);
+    }
+}
+
+// Quality starts, rest and strength splits, and goals saved above expected
+async function loadGoalieAnalytics(playerId) {
+    const section = document.getElementById('playerGoalieSection');
+    const el = document.getElementById('playerGoalie');
+    if (!section || !el) return;
+    try {
+        const resp = await fetch(`/api/goalie/${playerId}/analytics`);
+        if (!resp.ok) return;
+        const data = await resp.json();
+        if (!data.gamesPlayed) return;
+
+        const sv = (v) => Number(v || 0).toFixed(3).replace(/^0/, '');
+        const signed = (v, d) => `${v > 0 ? '+' : ''}${Number(v || 0).toFixed(d)}`;
+        const tile = (label, value, note) => `
+            <div class="bg-gray-50 rounded-lg p-3 text-center">
+                <div class="text-xs uppercase tracking-wide text-gray-500 font-semibold">${label}</div>
+                <div class="text-2xl font-extrabold text-gray-900">${value}</div>
+                ${note ? `<div class="text-xs text-gray-500">${note}</div>` : ''}
+            </div>`;
+        const splitRows = (splits, label) => splits.map(s => `
+            <tr class="hover:bg-gray-50">
+                <td class="px-2 py-1">${label(s.key)}</td>
+                <td class="px-2 py-1 text-center">${s.games || '-'}</td>
+                <td class="px-2 py-1 text-center">${s.shotsAgainst}</td>
+                <td class="px-2 py-1 text-center">${s.goalsAgainst}</td>
+                <td class="px-2 py-1 text-center font-semibold">${sv(s.savePct)}</td>
+            </tr>`).join('');
+        const strengthLabel = { EV: 'Even strength', PP: 'On the power play', SH: 'Shorthanded' };
+        const restLabel = (k) => k === '0' ? 'Back-to-back' : `${k} day${k === '1' ? '' : 's'} rest`;
+
+        el.innerHTML = `
+            <div class="grid grid-cols-2 sm:grid-cols-4 gap-3">
+                ${tile('GSAx', signed(data.gsax, 1), `${signed(data.gsaxPer60, 2)} per 60`)}
+                ${tile('Quality Starts', `${data.qualityStarts}/${data.gamesStarted}`, `${Math.round(data.qualityStartPct * 100)}%`)}
+                ${tile('Really Bad Starts', data.reallyBadStarts, 'below .850')}
+                ${tile('Back-to-Back Starts', data.backToBackStarts, `league SV% ${sv(data.leagueSavePct)}`)}
+            </div>
+            <div class="grid grid-cols-1 md:grid-cols-2 gap-3 mt-3">
+                <table class="min-w-full text-sm">
+                    <thead class="bg-gray-100 text-gray-700"><tr><th class="px-2 py-1 text-left">Strength</th><th class="px-2 py-1">GP</th><th class="px-2 py-1">SA</th><th class="px-2 py-1">GA</th><th class="px-2 py-1">SV%</th></tr></thead>
+                    <tbody class="divide-y divide-gray-200">${splitRows(data.byStrength || [], k => strengthLabel[k] || k)}</tbody>
+                </table>
+                <table class="min-w-full text-sm">
+                    <thead class="bg-gray-100 text-gray-700"><tr><th class="px-2 py-1 text-left">Rest</th><th class="px-2 py-1">GS</th><th class="px-2 py-1">SA</th><th class="px-2 py-1">GA</th><th class="px-2 py-1">SV%</th></tr></thead>
+                    <tbody class="divide-y divide-gray-200">${splitRows(data.byRest || [], restLabel)}</tbody>
+                </table>
+            </div>
+        `;
+        section.classList.remove('hidden');
+    } catch (e) {
+        console.error('Error loading goalie analytics:', e);
+    }
+}
+
 async function loadPlayerBio(playerId) {
     const bioDiv = document.getElementById('playerBiography');
     if (!bioDiv) return;
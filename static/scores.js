@@ -272,6 +272,28 @@ function createGameCard(game) {
         `;
     }
     
+    // Pre-game win probability from the Elo power ratings
+    let winProbHTML = '';
+    const wp = game.winProbability;
+    if (wp && gameState !== 'OFF' && gameState !== 'FINAL') {
+        const homePct = Math.round(wp.home * 100);
+        const awayPct = 100 - homePct;
+        const fav = wp.home >= wp.away ? (homeTeam.abbrev || 'Home') : (awayTeam.abbrev || 'Away');
+        winProbHTML = `
+            <div class="mt-2" title="Pre-game win probability (Elo ${wp.homeRating} vs ${wp.awayRating})">
+                <div class="flex justify-between text-xs font-semibold text-gray-600 mb-1">
+                    <span>${homeTeam.abbrev || ''} ${homePct}%</span>
+                    <span class="text-gray-500">Favourite: ${fav}</span>
+                    <span>${awayPct}% ${awayTeam.abbrev || ''}</span>
+                </div>
+                <div class="flex h-2 rounded-full overflow-hidden bg-gray-100">
+                    <div class="bg-primary" style="width:${homePct}%"></div>
+                    <div class="bg-accent" style="width:${awayPct}%"></div>
+                </div>
+            </div>
+        `;
+    }
+
     // TV broadcasts
     let broadcastHTML = '';
     if (game.tvBroadcasts && game.tvBroadcasts.length > 0) {
@@ -322,6 +344,7 @@ function createGameCard(game) {
                 <div class="text-xs text-gray-500 mt-1">${awayTeam.record || ''}</div>
             </div>
         </div>
+        ${winProbHTML}
         ${broadcastHTML}
         ${venueHTML}
     `;
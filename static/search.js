@@ -0,0 +1,105 @@
+// Global typeahead search; mounts into any element with data-global-search
+(function() {
+    const typeIcons = { player: '🏒', team: '🏟️', game: '📅' };
+
+    function mount(el) {
+        if (!el || el.dataset.mounted) return;
+        el.dataset.mounted = '1';
+        el.classList.add('relative');
+        el.innerHTML = `
+            <label class="sr-only">Search players, teams and games</label>
+            <input type="search" autocomplete="off" placeholder="Search players, teams, games"
+                class="global-search-input w-56 md:w-72 px-4 py-2 bg-white/20 placeholder-white/70 text-white rounded-lg font-semibold focus:outline-none focus:bg-white focus:text-gray-900 focus:placeholder-gray-400 transition">
+            <ul role="listbox" class="global-search-results hidden absolute right-0 mt-2 w-80 max-h-96 overflow-auto bg-white text-gray-900 rounded-xl shadow-xl z-50 divide-y divide-gray-100"></ul>
+        `;
+        const input = el.querySelector('input');
+        const list = el.querySelector('ul');
+        let timer = null;
+        let active = -1;
+        let results = [];
+        let seq = 0;
+
+        const close = () => {
+            list.classList.add('hidden');
+            active = -1;
+        };
+        const highlight = () => {
+            list.querySelectorAll('li').forEach((li, i) => li.classList.toggle('bg-gray-100', i === active));
+        };
+        const render = () => {
+            if (results.length === 0) {
+                list.innerHTML = '<li class="px-4 py-3 text-sm text-gray-500">No matches</li>';
+            } else {
+                list.innerHTML = results.map((r, i) => `
+                    <li role="option" data-index="${i}" class="px-4 py-2 flex items-center gap-3 cursor-pointer hover:bg-gray-100">
+                        ${r.image ? `<img src="${r.image}" alt="" class="w-8 h-8 rounded-full object-contain bg-gray-50" onerror="this.style.display='none'">` : `<span class="w-8 text-center">${typeIcons[r.type] || ''}</span>`}
+                        <span class="min-w-0">
+                            <span class="block font-semibold truncate">${r.name}</span>
+                            ${r.subtitle ? `<span class="block text-xs text-gray-500 truncate">${r.subtitle}</span>` : ''}
+                        </span>
+                    </li>`).join('');
+            }
+            list.classList.remove('hidden');
+            highlight();
+        };
+        const go = (r) => {
+            if (r && r.url) window.location.href = r.url;
+        };
+
+        input.addEventListener('input', () => {
+            clearTimeout(timer);
+            const q = input.value.trim();
+            if (q.length < 2) {
+                close();
+                return;
+            }
+            timer = setTimeout(async () => {
+                const mine = ++seq;
+                try {
+                    const resp = await fetch(`/api/search?q=${encodeURIComponent(q)}`);
+                    if (!resp.ok) throw new Error(`HTTP ${resp.status}`);
+                    const data = await resp.json();
+                    // Ignore responses that arrive after a newer keystroke
+                    if (mine !== seq) return;
+                    results = data.results || [];
+                    active = results.length ? 0 : -1;
+                    render();
+                } catch (error) {
+                    console.error('Search failed:', error);
+                }
+            }, 150);
+        });
+        input.addEventListener('keydown', (e) => {
+            if (list.classList.contains('hidden')) return;
+            if (e.key === 'ArrowDown') {
+                e.preventDefault();
+                active = Math.min(active + 1, results.length - 1);
+                highlight();
+            } else if (e.key === 'ArrowUp') {
+                e.preventDefault();
+                active = Math.max(active - 1, 0);
+                highlight();
+            } else if (e.key === 'Enter') {
+                e.preventDefault();
+                go(results[active]);
+            } else if (e.key === 'Escape') {
+                close();
+            }
+        });
+        list.addEventListener('mousedown', (e) => {
+            const li = e.target.closest('li[data-index]');
+            if (li) go(results[Number(li.dataset.index)]);
+        });
+        input.addEventListener('blur', () => setTimeout(close, 150));
+    }
+
+    window.mountGlobalSearch = function(root) {
+        (root || document).querySelectorAll('[data-global-search]').forEach(mount);
+    };
+
+    if (document.readyState === 'loading') {
+        document.addEventListener('DOMContentLoaded', () => window.mountGlobalSearch());
+    } else {
+        window.mountGlobalSearch();
+    }
+})();
@@ -1,14 +1,67 @@
 // NHL Standings Page
 let allTeams = [];
 let currentFilter = 'league';
-let sortState = { key: 'points', dir: 'desc' }; // default sort
+let sortState = { key: 'rank', dir: 'asc' }; // default sort: tiebreaker-aware rank from the server
 let detailsModal = null;
+let playoffOdds = {}; // abbrev -> simulated odds from /api/playoff-odds
 
 document.addEventListener('DOMContentLoaded', function() {
     loadStandings();
+    loadPlayoffOdds();
     setupFilters();
 });
 
+// Playoff odds come from a slower simulation, so load them separately and re-render when ready
+async function loadPlayoffOdds() {
+    try {
+        const response = await fetch('/api/playoff-odds');
+        if (!response.ok) return;
+        const data = await response.json();
+        playoffOdds = {};
+        (data.teams || []).forEach(t => { playoffOdds[t.abbrev] = t; });
+        if (allTeams.length > 0) renderStandings();
+    } catch (error) {
+        console.error('Error loading playoff odds:', error);
+    }
+}
+
+// Rank from the server-side standings engine for the active filter
+function engineRank(team) {
+    if (currentFilter === 'league') return team.leagueRank || team._originalIndex || 0;
+    if (currentFilter === 'Eastern' || currentFilter === 'Western') return team.conferenceRank || team._originalIndex || 0;
+    return team.divisionRank || team._originalIndex || 0;
+}
+
+function clinchBadge(team) {
+    if (!team.clinchIndicator) return '';
+    const titles = { p: "Clinched Presidents' Trophy", z: 'Clinched conference', y: 'Clinched division', x: 'Clinched playoff spot', e: 'Eliminated' };
+    const color = team.clinchIndicator === 'e' ? 'text-red-600' : 'text-green-700';
+    return `<span class="${color} font-bold mr-1" title="${titles[team.clinchIndicator] || ''}">${team.clinchIndicator} -</span>`;
+}
+
+// Magic number for teams holding a playoff spot, tragic number for teams chasing one
+function formatMagicTragic(team) {
+    if (team.magicNumber !== undefined && team.magicNumber !== null) return `M${team.magicNumber}`;
+    if (team.tragicNumber !== undefined && team.tragicNumber !== null) return `T${team.tragicNumber}`;
+    return '-';
+}
+
+function magicSortValue(team) {
+    if (team.clinchIndicator === 'e') return 1000;
+    if (team.clinchIndicator) return -1;
+    if (team.magicNumber !== undefined && team.magicNumber !== null) return team.magicNumber;
+    if (team.tragicNumber !== undefined && team.tragicNumber !== null) return 500 - team.tragicNumber;
+    return 999;
+}
+
+function formatOddsPct(odds) {
+    if (!odds || odds.playoffPct === undefined) return '-';
+    const v = Number(odds.playoffPct);
+    if (v >= 99.95) return '>99.9%';
+    if (v > 0 && v < 0.1) return '<0.1%';
+    return v.toFixed(1) + '%';
+}
+
 function setupFilters() {
     const filters = {
         'filterLeague': 'league',
@@ -94,7 +147,7 @@ function renderStandings() {
         let va, vb;
         switch (key) {
             case 'rank':
-                va = a._originalIndex || 0; vb = b._originalIndex || 0; break;
+                va = engineRank(a); vb = engineRank(b); break;
             case 'name':
                 va = (a.name || '').toLowerCase(); vb = (b.name || '').toLowerCase(); break;
             case 'gp':
@@ -120,6 +173,12 @@ function renderStandings() {
                 va = parseStreakValue(a.streak); vb = parseStreakValue(b.streak); break;
             case 'winpct':
                 va = a.winPct || 0; vb = b.winPct || 0; break;
+            case 'magic':
+                va = magicSortValue(a); vb = magicSortValue(b); break;
+            case 'playoff':
+                va = playoffOdds[a.abbrev] ? playoffOdds[a.abbrev].playoffPct : -1;
+                vb = playoffOdds[b.abbrev] ? playoffOdds[b.abbrev].playoffPct : -1;
+                break;
             case 'ppct':
                 va = (a.pointPctg !== undefined && a.pointPctg !== null) ? a.pointPctg : calcPointsPct(a);
                 vb = (b.pointPctg !== undefined && b.pointPctg !== null) ? b.pointPctg : calcPointsPct(b);
@@ -133,12 +192,8 @@ function renderStandings() {
         }
         if (va < vb) return sortState.dir === 'asc' ? -1 : 1;
         if (va > vb) return sortState.dir === 'asc' ? 1 : -1;
-        // tiebreakers: points then wins
-        if (a.record && b.record) {
-            if (a.record.points !== b.record.points) return (a.record.points - b.record.points) * -1;
-            if (a.record.wins !== b.record.wins) return (a.record.wins - b.record.wins) * -1;
-        }
-        return 0;
+        // tiebreakers: fall back to the server's official ordering
+        return (a.leagueRank || 0) - (b.leagueRank || 0);
     };
 
     // attach original index for stable rank sorting
@@ -165,8 +220,8 @@ function renderStandings() {
                 <a href="/team/${(team.abbrev || '').toString().toLowerCase()}" class="flex items-center gap-3 hover:text-primary transition group">
                     ${logoUrl ? `<img src="${logoUrl}" alt="${team.abbrev}" class="w-8 h-8 object-contain" onerror="this.style.display='none'">` : ''}
                     <div>
-                        <div class="font-bold text-gray-900 group-hover:text-primary">${team.name}</div>
-                        <div class="text-xs text-gray-500">${team.division}</div>
+                        <div class="font-bold text-gray-900 group-hover:text-primary">${clinchBadge(team)}${team.name}</div>
+                        <div class="text-xs text-gray-500">${team.division}${team.playoffSeed ? ` • ${team.playoffSeed}` : ''}</div>
                     </div>
                 </a>
             </td>
@@ -186,6 +241,8 @@ function renderStandings() {
             <td class="px-4 py-3 whitespace-nowrap text-center text-gray-700">${team.streak || '-'}</td>
             <td class="px-4 py-3 whitespace-nowrap text-center text-gray-700">${(team.winPct !== undefined && team.winPct !== null) ? (Number(team.winPct)*100).toFixed(1)+'%' : '-'}</td>
             <td class="px-4 py-3 whitespace-nowrap text-center text-gray-700 font-medium">${pointsPct}</td>
+            <td class="px-4 py-3 whitespace-nowrap text-center text-gray-700">${formatMagicTragic(team)}</td>
+            <td class="px-4 py-3 whitespace-nowrap text-center text-gray-700" title="${playoffOdds[team.abbrev] ? `Division ${playoffOdds[team.abbrev].divisionTitlePct}% • Presidents' ${playoffOdds[team.abbrev].presidentsTrophyPct}% • 1st overall ${playoffOdds[team.abbrev].firstOverallPct}%` : ''}">${formatOddsPct(playoffOdds[team.abbrev])}</td>
         `;
         tbody.appendChild(row);
 
@@ -204,7 +261,7 @@ function renderStandings() {
             // show rank then abbrev instead of full name on mobile to save space
             const abbrev = (team.abbrev || '').toUpperCase() || ((team.name||'').split(' ').slice(-1)[0] || '').toUpperCase();
             const rankBadge = `<span class="text-sm font-bold text-gray-700 mr-2">#${index+1}</span>`;
-            left.innerHTML = `${rankBadge}${imgHtml}<div class="min-w-0"><div class="font-semibold text-sm text-gray-900 truncate">${abbrev}</div><div class="text-[10px] text-gray-500 truncate">${team.division}</div></div>`;
+            left.innerHTML = `${rankBadge}${imgHtml}<div class="min-w-0"><div class="font-semibold text-sm text-gray-900 truncate">${clinchBadge(team)}${abbrev}</div><div class="text-[10px] text-gray-500 truncate">${team.division}</div></div>`;
             const right = document.createElement('div');
             right.className = 'text-[11px] ml-auto text-right space-y-0';
             const gf = team.goalsFor !== undefined && team.goalsFor !== null ? team.goalsFor : '-';
@@ -284,7 +341,7 @@ function showDetailsModal(team, rank) {
     content.innerHTML = `
         <div class="flex items-center justify-between">
             <div>
-                <div class="text-lg font-bold">#${rank} ${team.abbrev ? team.abbrev.toUpperCase() : team.name}</div>
+                <div class="text-lg font-bold">#${rank} ${clinchBadge(team)}${team.abbrev ? team.abbrev.toUpperCase() : team.name}</div>
                 <div class="text-sm text-gray-500">${team.name} — ${team.division}</div>
             </div>
         </div>
@@ -301,6 +358,12 @@ function showDetailsModal(team, rank) {
             <div>Diff: <span class="font-semibold">${diffHtml}</span></div>
             <div>L10: <span class="font-semibold">${l10}</span></div>
             <div>Streak: <span class="font-semibold">${strk}</span></div>
+            <div>Seed: <span class="font-semibold">${team.playoffSeed || '-'}</span></div>
+            <div>Magic/Tragic: <span class="font-semibold">${formatMagicTragic(team)}</span></div>
+            <div>RW: <span class="font-semibold">${team.regulationWins ?? '-'}</span></div>
+            <div>ROW: <span class="font-semibold">${team.regulationPlusOtWins ?? '-'}</span></div>
+            <div>Playoff: <span class="font-semibold">${formatOddsPct(playoffOdds[team.abbrev])}</span></div>
+            <div>Proj. Pts: <span class="font-semibold">${playoffOdds[team.abbrev] ? playoffOdds[team.abbrev].projectedPoints : '-'}</span></div>
         </div>
     `;
     detailsModal.classList.remove('hidden');
@@ -18,6 +18,7 @@
                             </div>
                         </div>
                         <div class="flex w-full sm:w-auto flex-col sm:flex-row gap-2 sm:gap-2">
+                            <div data-global-search></div>
                             <button id="scheduleBtn" class="w-full sm:w-auto px-4 py-2 bg-purple-600 hover:bg-purple-700 text-white rounded-lg font-semibold transition">📅 Schedule</button>
                             <button id="coachBtn" class="w-full sm:w-auto px-4 py-2 bg-green-600 hover:bg-green-700 text-white rounded-lg font-semibold transition">📋 Coach</button>
                             <button id="triviaBtn" class="w-full sm:w-auto px-4 py-2 bg-accent hover:bg-accent/90 text-gray-900 rounded-lg font-semibold transition">🎯 Trivia</button>
@@ -27,6 +28,8 @@
                     </div>
                 </section>
         `;
+        // Rebuilding the header replaces the search box, so mount it again
+        if (window.mountGlobalSearch) window.mountGlobalSearch(container);
     }
 
     if (document.readyState === 'loading') {
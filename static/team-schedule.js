@@ -325,10 +325,12 @@ function createDayCell(day) {
                 resultHtml = `<div class="game-time-result">${gameTime}</div>`;
             }
             
+            const opponent = isHome ? awayAbbrev : homeAbbrev;
             cellHtml += `
                 <div class="game-item-calendar ${gameStatus.class}" data-game-id="${game.id}">
                     ${logoHtml}
                     ${resultHtml}
+                    <a href="/matchup/${currentTeamAbbrev}/${opponent}" class="matchup-link text-xs font-semibold text-primary hover:underline" title="Head-to-head history">H2H</a>
                 </div>
             `;
         });
@@ -336,6 +338,8 @@ function createDayCell(day) {
         
         // Add click handlers to game items - navigate to dedicated game page instead of modal
         cell.addEventListener('click', (e) => {
+            // Let the head-to-head link navigate on its own
+            if (e.target.closest('.matchup-link')) return;
             const gameItem = e.target.closest('.game-item-calendar');
             if (gameItem) {
                 const gameId = gameItem.dataset.gameId;
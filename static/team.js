@@ -35,6 +35,14 @@ document.addEventListener('DOMContentLoaded', function() {
     if (currentTeamId) {
         loadTeamDetails();
         loadRoster();
+        const calendarLink = document.getElementById('calendarLink');
+        if (calendarLink) {
+            calendarLink.href = `webcal://${window.location.host}/ical/team/${encodeURIComponent(currentTeamId)}.ics`;
+            calendarLink.classList.remove('hidden');
+        }
+        document.querySelectorAll('[data-feed]').forEach(link => {
+            link.href = `/feeds/team/${encodeURIComponent(currentTeamId)}/${link.dataset.feed}.rss`;
+        });
     } else {
         showError('No team selected. Please go back and select a team.');
     }
@@ -87,6 +95,7 @@ async function loadTeamDetails() {
             // Load standings to get division/conference ranks
             await loadStandingsForRanks(team);
             displayTeamDetails(team);
+            loadStandingsHistory();
         } else {
             showError('Team not found');
         }
@@ -114,7 +123,9 @@ async function loadStandingsForRanks(team) {
                 return b.record.wins - a.record.wins;
             });
         
-        const divisionRank = divisionTeams.findIndex(t => t.id === team.id) + 1;
+        // Prefer the server's tiebreaker-aware ranks when present
+        const standing = data.teams.find(t => t.id === team.id);
+        const divisionRank = (standing && standing.divisionRank) || divisionTeams.findIndex(t => t.id === team.id) + 1;
         
         // Calculate conference rank
         const conferenceTeams = data.teams
@@ -126,7 +137,7 @@ async function loadStandingsForRanks(team) {
                 return b.record.wins - a.record.wins;
             });
         
-        const conferenceRank = conferenceTeams.findIndex(t => t.id === team.id) + 1;
+        const conferenceRank = (standing && standing.conferenceRank) || conferenceTeams.findIndex(t => t.id === team.id) + 1;
         
         // Store ranks on team object
         team.divisionRank = divisionRank;
@@ -183,11 +194,95 @@ function displayTeamDetails(team) {
         document.getElementById('gamesPlayed').textContent = record.gamesPlayed || 0;
         teamRecordSection.classList.remove('hidden');
     }
+
+    if (team.stats) {
+        displayTeamStats(team.stats);
+    }
     
     // teamHeader visibility handled by shared renderer
     loading.classList.add('hidden');
 }
 
+// Season rates with league ranks from /api/team
+function displayTeamStats(stats) {
+    const pct = v => `${(Number(v || 0) * 100).toFixed(1)}%`;
+    const perGame = v => Number(v || 0).toFixed(2);
+    const savePct = v => Number(v || 0).toFixed(3).replace(/^0/, '');
+    const ranks = stats.ranks || {};
+    const tiles = [
+        ['Goals For / GP', perGame(stats.goalsForPerGame), ranks.goalsForPerGame],
+        ['Goals Against / GP', perGame(stats.goalsAgainstPerGame), ranks.goalsAgainstPerGame],
+        ['Shots For / GP', perGame(stats.shotsForPerGame), ranks.shotsForPerGame],
+        ['Shots Against / GP', perGame(stats.shotsAgainstPerGame), ranks.shotsAgainstPerGame],
+        ['Power Play', pct(stats.powerPlayPct), ranks.powerPlayPct],
+        ['Penalty Kill', pct(stats.penaltyKillPct), ranks.penaltyKillPct],
+        ['Faceoffs', pct(stats.faceoffWinPct), ranks.faceoffWinPct],
+        ['Shooting', pct(stats.shootingPct), ranks.shootingPct],
+        ['Save %', savePct(stats.savePct), ranks.savePct]
+    ];
+    document.getElementById('teamStatsGrid').innerHTML = tiles.map(([label, value, rank]) => `
+        <div class="bg-gradient-to-br from-gray-50 to-gray-100 border border-gray-200 rounded-xl p-4 text-center">
+            <div class="text-xs font-semibold text-gray-700 uppercase tracking-wider mb-1">${label}</div>
+            <div class="text-2xl font-extrabold text-gray-800">${value}</div>
+            ${rank ? `<div class="text-xs text-gray-500 mt-1">(${formatRank(rank)})</div>` : ''}
+        </div>`).join('');
+    document.getElementById('teamStatsSection').classList.remove('hidden');
+}
+
+// Points over time with division rank, for charting the playoff race
+async function loadStandingsHistory() {
+    const section = document.getElementById('standingsHistorySection');
+    const chart = document.getElementById('standingsHistoryChart');
+    if (!section || !chart) return;
+    try {
+        const response = await fetch(`/api/team/${currentTeamId}/standings-history`);
+        if (response.status === 503) {
+            // The season is still being replayed; try again when the server says
+            const wait = parseInt(response.headers.get('Retry-After'), 10) || 30;
+            setTimeout(loadStandingsHistory, wait * 1000);
+            return;
+        }
+        if (!response.ok) return;
+        const data = await response.json();
+        const history = (data.history || []).filter(p => p.gamesPlayed > 0);
+        if (history.length < 2) return;
+        chart.innerHTML = buildStandingsHistorySVG(history);
+        const last = history[history.length - 1];
+        document.getElementById('standingsHistorySummary').textContent =
+            `${last.points} pts in ${last.gamesPlayed} GP (${(last.pointPctg * 100).toFixed(1)}%) — ${formatRank(last.divisionRank)} in the ${data.division}`;
+        section.classList.remove('hidden');
+    } catch (error) {
+        console.error('Error loading standings history:', error);
+    }
+}
+
+function buildStandingsHistorySVG(history) {
+    const width = 600, height = 220, pad = 30;
+    const maxPts = Math.max(10, ...history.map(p => p.points));
+    const maxRank = Math.max(8, ...history.map(p => p.divisionRank));
+    const x = (i) => pad + (i / (history.length - 1)) * (width - pad * 2);
+    const yPts = (v) => height - pad - (v / maxPts) * (height - pad * 2);
+    // Rank 1 at the top of the chart
+    const yRank = (r) => pad + ((r - 1) / (maxRank - 1)) * (height - pad * 2);
+
+    const ptsPath = history.map((p, i) => `${i === 0 ? 'M' : 'L'}${x(i)},${yPts(p.points)}`).join(' ');
+    const rankPath = history.map((p, i) => `${i === 0 ? 'M' : 'L'}${x(i)},${yRank(p.divisionRank)}`).join(' ');
+    const cutoff = `<line x1="${pad}" y1="${yRank(3.5)}" x2="${width - pad}" y2="${yRank(3.5)}" stroke="#e5e7eb" stroke-dasharray="4"><title>Top 3 in division</title></line>`;
+    const first = history[0].date, last = history[history.length - 1].date;
+
+    return `
+        <svg viewBox="0 0 ${width} ${height}" class="w-full h-auto" role="img" aria-label="Points and division rank over the season">
+            ${cutoff}
+            <line x1="${pad}" y1="${height - pad}" x2="${width - pad}" y2="${height - pad}" stroke="#9ca3af"/>
+            <text x="${pad}" y="${pad - 8}" font-size="11" fill="#0d47a1">${maxPts} pts</text>
+            <text x="${width - pad}" y="${pad - 8}" font-size="11" text-anchor="end" fill="#b45309">Division rank</text>
+            <path d="${ptsPath}" fill="none" stroke="#0d47a1" stroke-width="2"/>
+            <path d="${rankPath}" fill="none" stroke="#ffb300" stroke-width="2"/>
+            <text x="${pad}" y="${height - 8}" font-size="11" fill="#6b7280">${first}</text>
+            <text x="${width - pad}" y="${height - 8}" font-size="11" text-anchor="end" fill="#6b7280">${last}</text>
+        </svg>`;
+}
+
 function switchTab(tab) {
     const newsTab = document.getElementById('newsTab');
     const transactionsTab = document.getElementById('transactionsTab');
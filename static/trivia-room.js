@@ -0,0 +1,324 @@
+// Multiplayer trivia: /trivia/rooms?team={id} to host or join,
+// /trivia/rooms/{code} to play.
+const params = new URLSearchParams(window.location.search);
+const teamId = params.get('team');
+const pathCode = (window.location.pathname.match(/^\/trivia\/rooms\/([A-Za-z0-9]+)/) || [])[1];
+
+let roomCode = pathCode ? pathCode.toUpperCase() : '';
+let session = null; // {role: 'host'|'player', token, playerId}
+let state = null;
+let clockOffset = 0; // server time minus local time, in ms
+let socket = null;
+let reconnectDelay = 1000;
+let timerHandle = null;
+
+function sessionKey(code) {
+    return `triviaRoom_${code}`;
+}
+
+function loadSession(code) {
+    try {
+        return JSON.parse(localStorage.getItem(sessionKey(code)) || 'null');
+    } catch (e) {
+        return null;
+    }
+}
+
+function saveSession(code, s) {
+    localStorage.setItem(sessionKey(code), JSON.stringify(s));
+}
+
+async function postJSON(url, body) {
+    const response = await fetch(url, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify(body)
+    });
+    if (!response.ok) {
+        throw new Error((await response.text()) || `Request failed (${response.status})`);
+    }
+    return response.json();
+}
+
+async function loadTeamHeader(id) {
+    if (!id) return;
+    try {
+        const response = await fetch(`/api/team/${id}`);
+        if (!response.ok) return;
+        const data = await response.json();
+        if (data.teams && data.teams.length > 0) {
+            const team = data.teams[0];
+            try { document.title = `${team.name} — Trivia Rooms`; } catch (e) {}
+            if (window.populateSharedHeader) window.populateSharedHeader(team);
+        }
+    } catch (e) {
+        // Header is optional
+    }
+}
+
+function showSetup() {
+    document.getElementById('roomSetup').classList.remove('hidden');
+    document.getElementById('roomSection').classList.add('hidden');
+    // Hosting needs a team; joining does not
+    document.getElementById('hostCard').classList.toggle('hidden', !teamId);
+    if (roomCode) document.getElementById('joinCode').value = roomCode;
+    document.getElementById('joinName').value = localStorage.getItem('triviaRoomName') || '';
+}
+
+async function createRoom() {
+    hideError();
+    try {
+        const data = await postJSON('/api/trivia/rooms', {
+            team: teamId,
+            difficulty: document.getElementById('hostDifficulty').value,
+            count: parseInt(document.getElementById('hostCount').value, 10) || 0,
+            seconds: parseInt(document.getElementById('hostSeconds').value, 10) || 0
+        });
+        saveSession(data.code, { role: 'host', token: data.hostToken });
+        window.location.href = `/trivia/rooms/${data.code}`;
+    } catch (error) {
+        showError(error.message);
+    }
+}
+
+async function joinRoom() {
+    hideError();
+    const code = document.getElementById('joinCode').value.trim().toUpperCase();
+    const name = document.getElementById('joinName').value.trim();
+    if (!code || !name) {
+        showError('Enter a room code and your name');
+        return;
+    }
+    try {
+        const data = await postJSON(`/api/trivia/rooms/${code}/join`, { name });
+        localStorage.setItem('triviaRoomName', name);
+        saveSession(code, { role: 'player', token: data.playerToken, playerId: data.playerId });
+        if (code !== roomCode) {
+            window.location.href = `/trivia/rooms/${code}`;
+            return;
+        }
+        session = loadSession(code);
+        enterRoom();
+    } catch (error) {
+        showError(error.message);
+    }
+}
+
+function enterRoom() {
+    document.getElementById('roomSetup').classList.add('hidden');
+    document.getElementById('roomSection').classList.remove('hidden');
+    document.getElementById('roomCode').textContent = roomCode;
+    document.getElementById('roomLink').value = `${window.location.origin}/trivia/rooms/${roomCode}`;
+    document.getElementById('roomRole').textContent = session
+        ? (session.role === 'host' ? 'You are hosting' : 'You are playing')
+        : 'Watching';
+    connect();
+}
+
+// The socket reconnects with backoff, so a server restart only pauses the
+// game; the room itself is kept server-side
+function connect() {
+    const proto = window.location.protocol === 'https:' ? 'wss' : 'ws';
+    const token = session ? `?token=${encodeURIComponent(session.token)}` : '';
+    socket = new WebSocket(`${proto}://${window.location.host}/api/trivia/rooms/${roomCode}/ws${token}`);
+    setConnection('Connecting...');
+
+    socket.onopen = () => {
+        reconnectDelay = 1000;
+        setConnection('Live');
+    };
+    socket.onmessage = (event) => {
+        const msg = JSON.parse(event.data);
+        if (msg.type === 'state') {
+            renderState(msg.state);
+        } else if (msg.type === 'error') {
+            showError(msg.error);
+        }
+    };
+    socket.onclose = () => {
+        setConnection('Reconnecting...');
+        setTimeout(connect, reconnectDelay);
+        reconnectDelay = Math.min(reconnectDelay * 2, 15000);
+    };
+}
+
+function send(msg) {
+    if (socket && socket.readyState === WebSocket.OPEN) {
+        socket.send(JSON.stringify(msg));
+    }
+}
+
+function setConnection(text) {
+    document.getElementById('connectionStatus').textContent = text;
+}
+
+function renderState(s) {
+    const first = !state;
+    state = s;
+    clockOffset = new Date(s.serverTime).getTime() - Date.now();
+    if (first) loadTeamHeader(s.team);
+    hideError();
+
+    const isHost = session && session.role === 'host';
+    document.getElementById('roomProgress').textContent = s.status === 'lobby'
+        ? `${s.leaderboard.length} player${s.leaderboard.length === 1 ? '' : 's'} in the lobby`
+        : `Question ${s.questionNumber} of ${s.totalQuestions}`;
+
+    document.getElementById('lobbyCard').classList.toggle('hidden', s.status !== 'lobby');
+    document.getElementById('startGameBtn').classList.toggle('hidden', !isHost);
+    document.getElementById('lobbyWaiting').classList.toggle('hidden', isHost);
+    document.getElementById('questionCard').classList.toggle('hidden', s.status !== 'question' && s.status !== 'reveal');
+    document.getElementById('finishedCard').classList.toggle('hidden', s.status !== 'finished');
+    document.getElementById('nextQuestionBtn').classList.toggle('hidden', !isHost || (s.status !== 'question' && s.status !== 'reveal'));
+
+    if (s.question) renderQuestion(s);
+    if (s.status === 'finished') renderFinished(s);
+    renderLeaderboard(s);
+    startTimer();
+}
+
+function renderQuestion(s) {
+    const q = s.question;
+    const img = document.getElementById('roomQuestionImage');
+    if (q.image) {
+        img.src = q.image;
+        img.classList.remove('hidden');
+    } else {
+        img.classList.add('hidden');
+    }
+    document.getElementById('roomQuestionPrompt').textContent = q.prompt;
+
+    const myAnswer = s.reveal && session && session.playerId ? s.reveal.results[session.playerId] : null;
+    const answered = session && session.playerId && s.answered.includes(session.playerId);
+    const choices = document.getElementById('roomChoices');
+    choices.innerHTML = '';
+    q.choices.forEach((choice, i) => {
+        const btn = document.createElement('button');
+        let style = 'border-gray-200 bg-gray-50 hover:border-secondary';
+        if (s.reveal) {
+            if (i === s.reveal.answer) style = 'border-green-500 bg-green-50';
+            else if (myAnswer && myAnswer.choice === i) style = 'border-red-500 bg-red-50';
+        } else if (session && session.picked === `${s.questionNumber}:${i}`) {
+            style = 'border-secondary bg-blue-50';
+        }
+        btn.className = `px-5 py-3 border-2 rounded-xl font-semibold text-gray-800 text-left transition ${style}`;
+        btn.textContent = choice;
+        btn.disabled = !session || session.role !== 'player' || answered || s.status !== 'question';
+        btn.addEventListener('click', () => answer(i));
+        choices.appendChild(btn);
+    });
+
+    const feedback = document.getElementById('roomFeedback');
+    if (s.reveal) {
+        let text = `Answer: ${s.reveal.answerText}. ${s.reveal.explanation}`;
+        if (myAnswer) text = `${myAnswer.correct ? `✅ +${myAnswer.points}` : '❌ 0'} · ${text}`;
+        feedback.className = `mt-6 rounded-xl p-4 text-center ${myAnswer && myAnswer.correct ? 'bg-green-50 text-green-800' : 'bg-gray-50 text-gray-800'}`;
+        feedback.textContent = text;
+    } else if (answered) {
+        feedback.className = 'mt-6 rounded-xl p-4 text-center bg-blue-50 text-blue-800';
+        feedback.textContent = `Answer locked in · ${s.answered.length} of ${s.leaderboard.length} answered`;
+    } else {
+        feedback.className = 'hidden';
+    }
+    document.getElementById('nextQuestionBtn').textContent = s.status === 'reveal' ? 'Next question →' : 'Close question →';
+}
+
+function answer(choice) {
+    session.picked = `${state.questionNumber}:${choice}`;
+    send({ type: 'answer', choice });
+    document.querySelectorAll('#roomChoices button').forEach((b, i) => {
+        b.disabled = true;
+        if (i === choice) b.classList.replace('border-gray-200', 'border-secondary');
+    });
+}
+
+function renderLeaderboard(s) {
+    const list = document.getElementById('leaderboard');
+    list.innerHTML = '';
+    if (s.leaderboard.length === 0) {
+        list.innerHTML = '<li class="text-gray-500 text-sm">No players yet</li>';
+        return;
+    }
+    s.leaderboard.forEach(row => {
+        const li = document.createElement('li');
+        const me = session && session.playerId === row.playerId;
+        const done = s.status === 'question' && s.answered.includes(row.playerId);
+        li.className = `flex items-center justify-between px-3 py-2 rounded-lg ${me ? 'bg-blue-50 font-semibold' : 'bg-gray-50'}`;
+        const name = document.createElement('span');
+        name.textContent = `${row.rank}. ${row.name}${done ? ' ✓' : ''}`;
+        const score = document.createElement('span');
+        score.className = 'font-mono';
+        score.textContent = row.score;
+        li.append(name, score);
+        list.appendChild(li);
+    });
+}
+
+function renderFinished(s) {
+    const top = s.leaderboard[0];
+    document.getElementById('winnerText').textContent = top ? `${top.name} wins with ${top.score}!` : 'Game over';
+    if (s.team) document.getElementById('newRoomLink').href = `/trivia/rooms?team=${encodeURIComponent(s.team)}`;
+}
+
+function startTimer() {
+    clearInterval(timerHandle);
+    const bar = document.getElementById('timerBar');
+    const text = document.getElementById('timerText');
+    if (!state || !state.deadline || (state.status !== 'question' && state.status !== 'reveal')) {
+        text.textContent = '';
+        return;
+    }
+    const deadline = new Date(state.deadline).getTime();
+    const total = state.status === 'question' ? state.seconds * 1000 : 6000;
+    const tick = () => {
+        const left = Math.max(0, deadline - (Date.now() + clockOffset));
+        bar.style.width = `${(left / total) * 100}%`;
+        bar.className = `h-2 rounded-full ${state.status === 'reveal' ? 'bg-gray-400' : 'bg-accent'}`;
+        const secs = Math.ceil(left / 1000);
+        text.textContent = state.status === 'question' ? `${secs}s left` : `Next question in ${secs}s`;
+    };
+    tick();
+    timerHandle = setInterval(tick, 200);
+}
+
+function showError(message) {
+    const errorDiv = document.getElementById('error');
+    errorDiv.textContent = message;
+    errorDiv.classList.remove('hidden');
+}
+
+function hideError() {
+    document.getElementById('error').classList.add('hidden');
+}
+
+// Event listeners
+document.getElementById('createRoomBtn')?.addEventListener('click', createRoom);
+document.getElementById('joinRoomBtn')?.addEventListener('click', joinRoom);
+document.getElementById('startGameBtn')?.addEventListener('click', () => send({ type: 'start' }));
+document.getElementById('nextQuestionBtn')?.addEventListener('click', () => send({ type: 'next' }));
+document.getElementById('copyRoomLink')?.addEventListener('click', async () => {
+    try { await navigator.clipboard.writeText(document.getElementById('roomLink').value); } catch (e) {}
+});
+
+(async function init() {
+    if (!roomCode) {
+        loadTeamHeader(teamId);
+        showSetup();
+        return;
+    }
+    session = loadSession(roomCode);
+    const response = await fetch(`/api/trivia/rooms/${roomCode}`);
+    if (!response.ok) {
+        showSetup();
+        showError(response.status === 404 ? `Room ${roomCode} not found` : await response.text());
+        return;
+    }
+    const current = await response.json();
+    // Players who have not joined yet pick a name first; finished games just show results
+    if (!session && current.status !== 'finished') {
+        showSetup();
+        loadTeamHeader(current.team);
+        return;
+    }
+    enterRoom();
+})();
@@ -2,183 +2,222 @@
 const params = new URLSearchParams(window.location.search);
 const teamId = params.get('team');
 
-let roster = [];
-let currentPlayerIndex = 0;
-let revealStep = 0; // 0=nothing, 1=name, 2=number, 3=position, 4=birthplace
-let teamAbbrev = '';
+let quiz = null;
+let currentIndex = 0;
+let answers = {}; // questionId -> choice index
+let score = 0;
+let shareText = '';
 let teamObj = null; // populated from /api/team response
 
-// Position mapping
-const positionMap = {
-    'C': 'Center',
-    'L': 'Left Wing',
-    'R': 'Right Wing',
-    'D': 'Defenseman',
-    'G': 'Goalie'
+const difficultyStyles = {
+    easy: 'bg-green-100 text-green-800',
+    medium: 'bg-yellow-100 text-yellow-800',
+    hard: 'bg-red-100 text-red-800'
 };
 
-async function loadTrivia() {
+async function loadTeam() {
     if (!teamId) {
         showError('No team specified');
-        return;
+        return false;
     }
-
     try {
-        // Fetch team details to get abbreviation and name
         const teamResponse = await fetch(`/api/team/${teamId}`);
         if (teamResponse.ok) {
             const teamData = await teamResponse.json();
             if (teamData.teams && teamData.teams.length > 0) {
-                const team = teamData.teams[0];
-                teamObj = team;
-                teamAbbrev = team.abbreviation;
-                // Set page title and use shared header
-                try { document.title = `${team.name} — Trivia`; } catch (e) {}
-                if (window.populateSharedHeader) window.populateSharedHeader(team);
+                teamObj = teamData.teams[0];
+                try { document.title = `${teamObj.name} — Trivia`; } catch (e) {}
+                if (window.populateSharedHeader) window.populateSharedHeader(teamObj);
             }
         }
+    } catch (e) {
+        // Header is optional; the quiz still works without it
+    }
+    return true;
+}
+
+async function loadQuiz(daily) {
+    document.getElementById('error').classList.add('hidden');
+    document.getElementById('triviaSection').classList.add('hidden');
+    document.getElementById('loading').classList.remove('hidden');
 
-        // Fetch roster data - same as team page
-        const response = await fetch(`/api/roster/${teamId}`);
+    const difficulty = document.getElementById('difficultySelect').value;
+    const url = daily
+        ? `/api/trivia/${teamId}/daily`
+        : `/api/trivia/${teamId}?difficulty=${encodeURIComponent(difficulty)}`;
+    try {
+        const response = await fetch(url);
         if (!response.ok) {
-            throw new Error('Failed to load roster');
+            throw new Error((await response.text()) || 'Failed to load trivia');
         }
-        
-        const data = await response.json();
-        
-        // Extract all players - matches team.js structure
-        if (data.players && data.players.length > 0) {
-            roster = data.players.filter(player => player.photo); // Only players with photos
-            
-            // Shuffle roster for random order
-            roster = roster.sort(() => Math.random() - 0.5);
-
-            if (roster.length === 0) {
-                showError('No players with photos found for trivia');
-                return;
-            }
+        quiz = await response.json();
+    } catch (error) {
+        showError(error.message);
+        return;
+    }
 
-            // Update total questions count
-            document.getElementById('totalQuestions').textContent = roster.length;
+    currentIndex = 0;
+    answers = {};
+    score = 0;
+    shareText = '';
 
-            // Hide loading, show trivia
-            document.getElementById('loading').classList.add('hidden');
-            document.getElementById('triviaSection').classList.remove('hidden');
+    // Daily puzzles can only be played once; show the saved result instead
+    const saved = quiz.daily ? localStorage.getItem(`trivia_${quiz.id}`) : null;
 
-            // Load first player
-            loadPlayer();
-                if (window.populateSharedHeader && teamObj) window.populateSharedHeader(teamObj)
-        } else {
-            showError('No players found in roster');
-        }
-    } catch (error) {
-        showError(error.message);
+    document.getElementById('quizTitle').textContent = quiz.daily
+        ? `Daily Puzzle · ${formatQuizDate(quiz.date)}`
+        : `${capitalize(quiz.difficulty)} Quiz`;
+    document.getElementById('totalQuestions').textContent = quiz.questions.length;
+    document.getElementById('loading').classList.add('hidden');
+    document.getElementById('triviaSection').classList.remove('hidden');
+    document.getElementById('completionSection').classList.add('hidden');
+    document.getElementById('questionCard').classList.remove('hidden');
+
+    if (saved) {
+        const result = JSON.parse(saved);
+        score = result.score;
+        shareText = result.shareText;
+        showCompletion(result.total);
+        return;
     }
+    showQuestion();
 }
 
-function loadPlayer() {
-    if (currentPlayerIndex >= roster.length) {
-        showCompletion();
-        return;
+function showQuestion() {
+    const q = quiz.questions[currentIndex];
+    document.getElementById('currentQuestion').textContent = currentIndex + 1;
+    document.getElementById('scoreSoFar').textContent = score;
+    document.getElementById('progressBar').style.width = `${(currentIndex / quiz.questions.length) * 100}%`;
+
+    const img = document.getElementById('questionImage');
+    if (q.image) {
+        img.src = q.image;
+        img.classList.remove('hidden');
+    } else {
+        img.classList.add('hidden');
     }
 
-    const player = roster[currentPlayerIndex];
-    revealStep = 0;
-
-    // Update progress
-    document.getElementById('currentQuestion').textContent = currentPlayerIndex + 1;
-    const progress = ((currentPlayerIndex + 1) / roster.length) * 100;
-    document.getElementById('progressBar').style.width = `${progress}%`;
-
-    // Set player image - use 'photo' field from roster data
-    document.getElementById('playerImage').src = player.photo || '/static/img/placeholder-player.png';
-
-    // Set player data (hidden initially)
-    document.getElementById('playerName').textContent = player.name || 'Unknown';
-    document.getElementById('playerNumber').textContent = `#${player.number || 'N/A'}`;
-    document.getElementById('playerPosition').textContent = player.fullPosition || player.position || 'Unknown';
-    
-    // Birth place from player stats/info if available
-    const birthplace = player.birthPlace || 'Unknown';
-    document.getElementById('playerBirthplace').textContent = birthplace;
-
-    // Reset all boxes to show questions
-    const nameBox = document.getElementById('nameQuestion');
-    const numberBox = document.getElementById('numberQuestion');
-    const positionBox = document.getElementById('positionQuestion');
-    const birthplaceBox = document.getElementById('birthplaceQuestion');
-    
-    nameBox.classList.remove('hidden');
-    numberBox.classList.add('hidden');
-    positionBox.classList.add('hidden');
-    birthplaceBox.classList.add('hidden');
-
-    // Show first question
-    document.getElementById('nameLabel').textContent = 'What is their name?';
-    document.getElementById('playerName').classList.add('hidden');
-
-    // Reset buttons
-    document.getElementById('revealBtn').classList.remove('hidden');
+    const badge = document.getElementById('questionDifficulty');
+    badge.textContent = q.difficulty;
+    badge.className = `inline-block text-xs font-semibold uppercase tracking-wider px-2 py-1 rounded mb-3 ${difficultyStyles[q.difficulty] || ''}`;
+    document.getElementById('questionPrompt').textContent = q.prompt;
+
+    const choices = document.getElementById('choices');
+    choices.innerHTML = '';
+    q.choices.forEach((choice, i) => {
+        const btn = document.createElement('button');
+        btn.className = 'choice w-full text-left px-5 py-3 bg-gray-50 border-2 border-gray-200 rounded-xl font-semibold text-gray-800 hover:border-secondary transition';
+        btn.textContent = choice;
+        btn.addEventListener('click', () => answer(i));
+        choices.appendChild(btn);
+    });
+
+    document.getElementById('answerFeedback').classList.add('hidden');
     document.getElementById('nextBtn').classList.add('hidden');
 }
 
-function reveal() {
-    revealStep++;
-
-    switch(revealStep) {
-        case 1:
-            // Reveal name answer and show number question
-            document.getElementById('nameLabel').textContent = 'Name';
-            document.getElementById('playerName').classList.remove('hidden');
-            document.getElementById('numberQuestion').classList.remove('hidden');
-            document.getElementById('numberLabel').textContent = 'What is their number?';
-            document.getElementById('playerNumber').classList.add('hidden');
-            break;
-        case 2:
-            // Reveal number answer and show position question
-            document.getElementById('numberLabel').textContent = 'Number';
-            document.getElementById('playerNumber').classList.remove('hidden');
-            document.getElementById('positionQuestion').classList.remove('hidden');
-            document.getElementById('positionLabel').textContent = 'What position do they play?';
-            document.getElementById('playerPosition').classList.add('hidden');
-            break;
-        case 3:
-            // Reveal position answer and show birthplace question
-            document.getElementById('positionLabel').textContent = 'Position';
-            document.getElementById('playerPosition').classList.remove('hidden');
-            document.getElementById('birthplaceQuestion').classList.remove('hidden');
-            document.getElementById('birthplaceLabel').textContent = 'Where were they born?';
-            document.getElementById('playerBirthplace').classList.add('hidden');
-            break;
-        case 4:
-            // Reveal birthplace answer
-            document.getElementById('birthplaceLabel').textContent = 'Birth Place';
-            document.getElementById('playerBirthplace').classList.remove('hidden');
-            // Show next button, hide reveal
-            document.getElementById('revealBtn').classList.add('hidden');
-            document.getElementById('nextBtn').classList.remove('hidden');
-            break;
+async function answer(choice) {
+    const q = quiz.questions[currentIndex];
+    const buttons = document.querySelectorAll('#choices .choice');
+    buttons.forEach(b => { b.disabled = true; });
+    answers[q.id] = choice;
+
+    let result;
+    try {
+        result = await checkAnswers();
+    } catch (error) {
+        delete answers[q.id];
+        buttons.forEach(b => { b.disabled = false; });
+        showFeedback(false, error.message);
+        return;
+    }
+    score = result.score;
+    shareText = result.shareText || '';
+    document.getElementById('scoreSoFar').textContent = score;
+
+    const verdict = result.results.find(r => r.questionId === q.id);
+    buttons[verdict.answer]?.classList.replace('border-gray-200', 'border-green-500');
+    // A daily question keeps the first choice made, even after a reload
+    if (!verdict.correct) {
+        buttons[verdict.choice]?.classList.replace('border-gray-200', 'border-red-500');
+    }
+    showFeedback(verdict.correct, verdict.explanation);
+
+    const next = document.getElementById('nextBtn');
+    next.textContent = currentIndex + 1 < quiz.questions.length ? 'Next Question →' : 'See Results →';
+    next.classList.remove('hidden');
+
+    if (result.shareText && quiz.daily) {
+        localStorage.setItem(`trivia_${quiz.id}`, JSON.stringify({
+            score: result.score,
+            total: result.total,
+            shareText: result.shareText
+        }));
+    }
+}
+
+// Answers are graded on the server; the quiz payload carries no answers
+async function checkAnswers() {
+    const response = await fetch('/api/trivia/check', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ quizId: quiz.id, answers })
+    });
+    if (!response.ok) {
+        throw new Error((await response.text()) || 'Failed to check answer');
     }
+    return response.json();
+}
+
+function showFeedback(correct, text) {
+    const box = document.getElementById('answerFeedback');
+    box.className = `mt-6 rounded-xl p-4 text-center ${correct ? 'bg-green-50 text-green-800' : 'bg-red-50 text-red-800'}`;
+    box.textContent = `${correct ? '✅ Correct!' : '❌ Not quite.'} ${text || ''}`;
 }
 
-function nextPlayer() {
-    currentPlayerIndex++;
-    loadPlayer();
+function nextQuestion() {
+    currentIndex++;
+    if (currentIndex >= quiz.questions.length) {
+        showCompletion(quiz.questions.length);
+        return;
+    }
+    showQuestion();
 }
 
-function showCompletion() {
-    document.getElementById('triviaSection').querySelector('.bg-white.rounded-2xl.shadow-md.p-8').classList.add('hidden');
-    document.getElementById('triviaSection').querySelector('.bg-white.rounded-2xl.shadow-md.p-6').classList.add('hidden');
+function showCompletion(total) {
+    document.getElementById('progressBar').style.width = '100%';
+    document.getElementById('questionCard').classList.add('hidden');
     document.getElementById('completionSection').classList.remove('hidden');
+    document.getElementById('currentQuestion').textContent = total;
+    document.getElementById('scoreSoFar').textContent = score;
+    document.getElementById('finalScore').textContent = `You scored ${score} out of ${total}`;
+    document.getElementById('shareText').textContent = shareText;
+    document.getElementById('shareBtn').classList.toggle('hidden', !shareText);
 }
 
-function restartTrivia() {
-    currentPlayerIndex = 0;
-    roster = roster.sort(() => Math.random() - 0.5);
-    document.getElementById('completionSection').classList.add('hidden');
-    document.getElementById('triviaSection').querySelector('.bg-white.rounded-2xl.shadow-md.p-8').classList.remove('hidden');
-    document.getElementById('triviaSection').querySelector('.bg-white.rounded-2xl.shadow-md.p-6').classList.remove('hidden');
-    loadPlayer();
+async function shareScore() {
+    const text = quiz.daily ? `${shareText}\n${window.location.href}` : shareText;
+    const btn = document.getElementById('shareBtn');
+    try {
+        if (navigator.share) {
+            await navigator.share({ text });
+            return;
+        }
+        await navigator.clipboard.writeText(text);
+        btn.textContent = 'Copied!';
+        setTimeout(() => { btn.textContent = 'Share Score'; }, 2000);
+    } catch (e) {
+        // Share sheet dismissed or clipboard unavailable
+    }
+}
+
+function formatQuizDate(date) {
+    if (!date || date.length !== 8) return date || '';
+    return `${date.slice(0, 4)}-${date.slice(4, 6)}-${date.slice(6)}`;
+}
+
+function capitalize(s) {
+    return s ? s.charAt(0).toUpperCase() + s.slice(1) : '';
 }
 
 function showError(message) {
@@ -192,15 +231,21 @@ function goBackToTeam() {
     window.location.href = `/team/${teamId}`;
 }
 
+if (teamId) {
+    document.getElementById('roomsLink').href = `/trivia/rooms?team=${encodeURIComponent(teamId)}`;
+}
+
 // Event listeners
-document.getElementById('revealBtn')?.addEventListener('click', reveal);
-document.getElementById('nextBtn')?.addEventListener('click', nextPlayer);
-document.getElementById('backToTeam')?.addEventListener('click', goBackToTeam);
-document.getElementById('restartBtn')?.addEventListener('click', restartTrivia);
+document.getElementById('dailyBtn')?.addEventListener('click', () => loadQuiz(true));
+document.getElementById('newQuizBtn')?.addEventListener('click', () => loadQuiz(false));
+document.getElementById('nextBtn')?.addEventListener('click', nextQuestion);
+document.getElementById('shareBtn')?.addEventListener('click', shareScore);
+document.getElementById('restartBtn')?.addEventListener('click', () => loadQuiz(false));
 document.getElementById('backToTeamBtn')?.addEventListener('click', goBackToTeam);
 
-// Load trivia on page load
-loadTrivia();
+// Open on the daily puzzle unless ?mode=practice
+loadTeam().then(ok => {
+    if (ok) loadQuiz(params.get('mode') !== 'practice');
+});
 
-// Header navigation buttons
 // Header navigation is handled centrally by team-header.js
@@ -2,6 +2,7 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"sort"
@@ -31,47 +32,28 @@ type TeamNewsResponse struct {
 	Stories []TeamNewsStory `json:"stories"`
 }
 
-// handleAPITeamNews fetches the last 10 news stories for a team and follows selfUrl for full content
-func handleAPITeamNews(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	teamID := vars["teamId"]
-	// Accept either numeric team ID or 3-letter abbreviation; map abbrev -> id for Forge tags
-	if _, err := strconv.Atoi(teamID); err != nil {
-		if id, ok := abbrevToTeamID[strings.ToUpper(teamID)]; ok {
-			teamID = strconv.Itoa(id)
-		}
-	}
+// errTeamNewsRateLimited means the news feed answered 429.
+var errTeamNewsRateLimited = errors.New("upstream rate limited")
 
+// GetTeamNews fetches a team's 10 most recent news stories and caches them
+// for an hour. teamID may be a numeric ID or an abbreviation.
+func GetTeamNews(teamID string) (*TeamNewsResponse, error) {
+	teamID = transactionsTeamID(teamID)
 	cacheKey := fmt.Sprintf("team-news:%s", teamID)
 
 	// Build the Forge DAPI URL for team news
 	apiURL := fmt.Sprintf("https://forge-dapi.d3.nhle.com/v2/content/en-us/stories?tags.slug=teamid-%s&$limit=10", teamID)
 	resp, err := rateLimitedGet(apiURL)
 	if err != nil {
-		http.Error(w, "Failed to fetch team news", http.StatusBadGateway)
-		return
+		return nil, fmt.Errorf("fetching team news: %w", err)
 	}
 	defer func() {
 		if cerr := resp.Body.Close(); cerr != nil {
 			fmt.Printf("warning: closing team news resp body: %v\n", cerr)
 		}
 	}()
-
-	// If upstream rate-limits us, try Redis
 	if resp.StatusCode == http.StatusTooManyRequests {
-		cachedData, cacheErr := getCachedRaw(cacheKey)
-		if cacheErr == nil {
-			fmt.Printf("Upstream 429 for team news %s, using Redis cache\n", teamID)
-			w.Header().Set("Content-Type", "application/json")
-			if _, writeErr := w.Write(cachedData); writeErr != nil {
-				fmt.Printf("error writing cached team news response: %v\n", writeErr)
-				http.Error(w, "Encoding error", http.StatusInternalServerError)
-			}
-			return
-		}
-		fmt.Printf("No cached team news for %s in Redis: %v\n", teamID, cacheErr)
-		http.Error(w, "Upstream rate limited", http.StatusBadGateway)
-		return
+		return nil, errTeamNewsRateLimited
 	}
 
 	var apiResp struct {
@@ -85,8 +67,7 @@ func handleAPITeamNews(w http.ResponseWriter, r *http.Request) {
 		} `json:"items"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
-		http.Error(w, "Failed to decode team news", http.StatusBadGateway)
-		return
+		return nil, fmt.Errorf("decoding team news: %w", err)
 	}
 
 	stories := make([]TeamNewsStory, 0, len(apiResp.Items))
@@ -101,7 +82,7 @@ func handleAPITeamNews(w http.ResponseWriter, r *http.Request) {
 		stories = append(stories, story)
 	}
 
-	respObj := TeamNewsResponse{Stories: stories}
+	respObj := &TeamNewsResponse{Stories: stories}
 
 	// Cache successful response in Redis
 	if cacheData, jsonErr := json.Marshal(respObj); jsonErr == nil {
@@ -109,6 +90,36 @@ func handleAPITeamNews(w http.ResponseWriter, r *http.Request) {
 			fmt.Printf("Failed to cache team news for %s: %v\n", teamID, setErr)
 		}
 	}
+	return respObj, nil
+}
+
+// handleAPITeamNews fetches the last 10 news stories for a team and follows selfUrl for full content
+func handleAPITeamNews(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	// Accept either numeric team ID or 3-letter abbreviation; map abbrev -> id for Forge tags
+	teamID := transactionsTeamID(vars["teamId"])
+
+	respObj, err := GetTeamNews(teamID)
+	if errors.Is(err, errTeamNewsRateLimited) {
+		// If upstream rate-limits us, try Redis
+		cachedData, cacheErr := getCachedRaw(fmt.Sprintf("team-news:%s", teamID))
+		if cacheErr == nil {
+			fmt.Printf("Upstream 429 for team news %s, using Redis cache\n", teamID)
+			w.Header().Set("Content-Type", "application/json")
+			if _, writeErr := w.Write(cachedData); writeErr != nil {
+				fmt.Printf("error writing cached team news response: %v\n", writeErr)
+				http.Error(w, "Encoding error", http.StatusInternalServerError)
+			}
+			return
+		}
+		fmt.Printf("No cached team news for %s in Redis: %v\n", teamID, cacheErr)
+		http.Error(w, "Upstream rate limited", http.StatusBadGateway)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to fetch team news", http.StatusBadGateway)
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(respObj); err != nil {
@@ -118,18 +129,64 @@ func handleAPITeamNews(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// handleAPITeamTransactions fetches recent transactions for a team (basic implementation)
-func handleAPITeamTransactions(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	teamID := vars["teamId"]
+// parseContentDate parses a Forge contentDate; the zero time means it was
+// missing or malformed.
+func parseContentDate(s string) time.Time {
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t
+	}
+	return time.Time{}
+}
 
-	// Accept either numeric team ID or 3-letter abbreviation; map abbrev -> id for Forge tags
+// nhlStoryURL turns a Forge selfUrl into the story's nhl.com page, which
+// ends in the same slug.
+func nhlStoryURL(selfURL string) string {
+	slug := selfURL
+	if i := strings.LastIndex(strings.TrimSuffix(selfURL, "/"), "/"); i >= 0 {
+		slug = strings.TrimSuffix(selfURL, "/")[i+1:]
+	}
+	if j := strings.IndexAny(slug, "?#"); j >= 0 {
+		slug = slug[:j]
+	}
+	if slug == "" {
+		return selfURL
+	}
+	return "https://www.nhl.com/news/" + slug
+}
+
+// TeamTransaction is one story from a team's transactions feed.
+type TeamTransaction struct {
+	Title      string    `json:"title"`
+	Date       string    `json:"date"`
+	Thumbnail  string    `json:"thumbnail"`
+	URL        string    `json:"url"`
+	Summary    string    `json:"summary"`
+	DateParsed time.Time `json:"-"`
+}
+
+// TransactionsResponse is the /api/team-transactions payload.
+type TransactionsResponse struct {
+	Transactions []TeamTransaction `json:"transactions"`
+}
+
+// errTransactionsRateLimited means the transactions feed answered 429.
+var errTransactionsRateLimited = errors.New("upstream rate limited")
+
+// transactionsTeamID accepts either a numeric team ID or a 3-letter
+// abbreviation and returns the numeric ID used in Forge tags.
+func transactionsTeamID(teamID string) string {
 	if _, err := strconv.Atoi(teamID); err != nil {
 		if id, ok := abbrevToTeamID[strings.ToUpper(teamID)]; ok {
-			teamID = strconv.Itoa(id)
+			return strconv.Itoa(id)
 		}
 	}
+	return teamID
+}
 
+// GetTeamTransactions fetches a team's 30 most recent transactions, newest
+// first, and caches them for an hour.
+func GetTeamTransactions(teamID string) (*TransactionsResponse, error) {
+	teamID = transactionsTeamID(teamID)
 	cacheKey := fmt.Sprintf("team-transactions:%s", teamID)
 
 	// We'll request stories tagged as transactions and paginate until we have enough items.
@@ -153,28 +210,14 @@ func handleAPITeamTransactions(w http.ResponseWriter, r *http.Request) {
 		apiURL := fmt.Sprintf("https://forge-dapi.d3.nhle.com/v2/content/en-us/stories?tags.slug=teamid-%s&tags.slug=transactions&$limit=%d&$skip=%d", teamID, pageSize, skip)
 		resp, err := rateLimitedGet(apiURL)
 		if err != nil {
-			http.Error(w, "Failed to fetch transactions", http.StatusBadGateway)
-			return
+			return nil, fmt.Errorf("fetching transactions: %w", err)
 		}
 
 		if resp.StatusCode == http.StatusTooManyRequests {
-			// If upstream rate-limits, try Redis
 			if cerr := resp.Body.Close(); cerr != nil {
 				fmt.Printf("warning: closing transactions resp body (rate-limited): %v\n", cerr)
 			}
-			cachedData, cacheErr := getCachedRaw(cacheKey)
-			if cacheErr == nil {
-				fmt.Printf("Upstream 429 for team transactions %s, using Redis cache\n", teamID)
-				w.Header().Set("Content-Type", "application/json")
-				if _, writeErr := w.Write(cachedData); writeErr != nil {
-					fmt.Printf("error writing cached team transactions response: %v\n", writeErr)
-					http.Error(w, "Encoding error", http.StatusInternalServerError)
-				}
-				return
-			}
-			fmt.Printf("No cached team transactions for %s in Redis: %v\n", teamID, cacheErr)
-			http.Error(w, "Upstream rate limited", http.StatusBadGateway)
-			return
+			return nil, errTransactionsRateLimited
 		}
 
 		var pageResp struct {
@@ -195,8 +238,7 @@ func handleAPITeamTransactions(w http.ResponseWriter, r *http.Request) {
 			if cerr := resp.Body.Close(); cerr != nil {
 				fmt.Printf("warning: closing transactions resp body after decode error: %v\n", cerr)
 			}
-			http.Error(w, "Failed to decode transactions", http.StatusBadGateway)
-			return
+			return nil, fmt.Errorf("decoding transactions: %w", err)
 		}
 		if cerr := resp.Body.Close(); cerr != nil {
 			fmt.Printf("warning: closing transactions resp body: %v\n", cerr)
@@ -217,32 +259,15 @@ func handleAPITeamTransactions(w http.ResponseWriter, r *http.Request) {
 		skip += pageSize
 	}
 
-	type TxItem struct {
-		Title      string    `json:"title"`
-		Date       string    `json:"date"`
-		Thumbnail  string    `json:"thumbnail"`
-		URL        string    `json:"url"`
-		Summary    string    `json:"summary"`
-		DateParsed time.Time `json:"-"`
-	}
-
-	txs := make([]TxItem, 0, len(allItems))
+	txs := make([]TeamTransaction, 0, len(allItems))
 	for _, it := range allItems {
-		var dt time.Time
-		if it.ContentDate != "" {
-			if t, err := time.Parse(time.RFC3339Nano, it.ContentDate); err == nil {
-				dt = t
-			} else if t, err := time.Parse(time.RFC3339, it.ContentDate); err == nil {
-				dt = t
-			}
-		}
-		txs = append(txs, TxItem{
+		txs = append(txs, TeamTransaction{
 			Title:      it.Title,
 			Date:       it.ContentDate,
 			Thumbnail:  it.Thumbnail.ThumbnailURL,
 			URL:        it.SelfURL,
 			Summary:    it.Fields.Description,
-			DateParsed: dt,
+			DateParsed: parseContentDate(it.ContentDate),
 		})
 	}
 
@@ -267,11 +292,7 @@ func handleAPITeamTransactions(w http.ResponseWriter, r *http.Request) {
 		txs = txs[:30]
 	}
 
-	type TransactionsResponse struct {
-		Transactions []TxItem `json:"transactions"`
-	}
-
-	respObj := TransactionsResponse{Transactions: txs}
+	respObj := &TransactionsResponse{Transactions: txs}
 
 	// Cache successful response in Redis
 	if cacheData, jsonErr := json.Marshal(respObj); jsonErr == nil {
@@ -279,6 +300,35 @@ func handleAPITeamTransactions(w http.ResponseWriter, r *http.Request) {
 			fmt.Printf("Failed to cache team transactions for %s: %v\n", teamID, setErr)
 		}
 	}
+	return respObj, nil
+}
+
+// handleAPITeamTransactions fetches recent transactions for a team (basic implementation)
+func handleAPITeamTransactions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	teamID := transactionsTeamID(vars["teamId"])
+
+	respObj, err := GetTeamTransactions(teamID)
+	if errors.Is(err, errTransactionsRateLimited) {
+		// If upstream rate-limits, try Redis
+		cachedData, cacheErr := getCachedRaw(fmt.Sprintf("team-transactions:%s", teamID))
+		if cacheErr == nil {
+			fmt.Printf("Upstream 429 for team transactions %s, using Redis cache\n", teamID)
+			w.Header().Set("Content-Type", "application/json")
+			if _, writeErr := w.Write(cachedData); writeErr != nil {
+				fmt.Printf("error writing cached team transactions response: %v\n", writeErr)
+				http.Error(w, "Encoding error", http.StatusInternalServerError)
+			}
+			return
+		}
+		fmt.Printf("No cached team transactions for %s in Redis: %v\n", teamID, cacheErr)
+		http.Error(w, "Upstream rate limited", http.StatusBadGateway)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to fetch transactions", http.StatusBadGateway)
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(respObj); err != nil {
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// errNoTeamStats is returned when a team has no summary row for a season.
+var errNoTeamStats = errors.New("no team stats")
+
+// teamStatsTTL caches completed seasons without expiry and follows the
+// standings TTL (shorter while games are live) for the current one.
+func teamStatsTTL(season string) time.Duration {
+	if season < currentSeasonID() {
+		return determineTTL("static")
+	}
+	return determineTTL("standings")
+}
+
+// fetchTeamSummary reads every team's summary row for a season from the
+// stats REST API.
+func fetchTeamSummary(season string, gameType int) ([]map[string]interface{}, error) {
+	exp := fmt.Sprintf("seasonId=%s and gameTypeId=%d", season, gameType)
+	cacheKey := fmt.Sprintf("team-summary:%s:%d", season, gameType)
+	data, err := getCachedOrFetchWithBackoff(cacheKey, func() ([]byte, error) {
+		return readURL(fmt.Sprintf("%s/team/summary?limit=-1&cayenneExp=%s", StatsURL, url.QueryEscape(exp)))
+	}, teamStatsTTL(season))
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		Data []map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("parsing team summary: %w", err)
+	}
+	return resp.Data, nil
+}
+
+// clubShootingAndSaves totals a team's skater goals and shots and goalie
+// saves and shots against from /club-stats.
+func clubShootingAndSaves(teamAbbrev, season string, gameType int) (goals, shots, saves, shotsAgainst int, err error) {
+	cacheKey := fmt.Sprintf("club-stats:%s:%s:%d", teamAbbrev, season, gameType)
+	data, err := getCachedOrFetchWithBackoff(cacheKey, func() ([]byte, error) {
+		return readURL(fmt.Sprintf("%s/club-stats/%s/%s/%d", BaseURL, teamAbbrev, season, gameType))
+	}, teamStatsTTL(season))
+	if err != nil {
+		return 0, 0, 0, 0, err
+	}
+	var resp struct {
+		Skaters []struct {
+			Goals int `json:"goals"`
+			Shots int `json:"shots"`
+		} `json:"skaters"`
+		Goalies []struct {
+			Saves        int `json:"saves"`
+			ShotsAgainst int `json:"shotsAgainst"`
+		} `json:"goalies"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return 0, 0, 0, 0, fmt.Errorf("parsing club stats: %w", err)
+	}
+	for _, s := range resp.Skaters {
+		goals += s.Goals
+		shots += s.Shots
+	}
+	for _, g := range resp.Goalies {
+		saves += g.Saves
+		shotsAgainst += g.ShotsAgainst
+	}
+	return goals, shots, saves, shotsAgainst, nil
+}
+
+// jsonFloat reads a JSON number decoded into interface{}.
+func jsonFloat(v interface{}) float64 {
+	f, _ := v.(float64)
+	return f
+}
+
+// teamSummaryStats maps a team summary row onto TeamSeasonStats. Shooting
+// and save percentages are estimated from season goals over shots (the
+// unrounded per-game rate times games played) so every team can be ranked
+// from the one summary call.
+func teamSummaryStats(row map[string]interface{}) TeamSeasonStats {
+	s := TeamSeasonStats{
+		GamesPlayed:         jsonInt(row["gamesPlayed"]),
+		GoalsFor:            jsonInt(row["goalsFor"]),
+		GoalsAgainst:        jsonInt(row["goalsAgainst"]),
+		GoalsForPerGame:     round3(jsonFloat(row["goalsForPerGame"])),
+		GoalsAgainstPerGame: round3(jsonFloat(row["goalsAgainstPerGame"])),
+		ShotsForPerGame:     round3(jsonFloat(row["shotsForPerGame"])),
+		ShotsAgainstPerGame: round3(jsonFloat(row["shotsAgainstPerGame"])),
+		PowerPlayPct:        round3(jsonFloat(row["powerPlayPct"])),
+		PenaltyKillPct:      round3(jsonFloat(row["penaltyKillPct"])),
+		FaceoffWinPct:       round3(jsonFloat(row["faceoffWinPct"])),
+	}
+	if shots := jsonFloat(row["shotsForPerGame"]) * float64(s.GamesPlayed); shots > 0 {
+		s.ShootingPct = round3(float64(s.GoalsFor) / shots)
+	}
+	if shots := jsonFloat(row["shotsAgainstPerGame"]) * float64(s.GamesPlayed); shots > 0 {
+		s.SavePct = round3(1 - float64(s.GoalsAgainst)/shots)
+	}
+	return s
+}
+
+// leagueRank is 1 + the number of teams strictly better than v.
+func leagueRank(all []float64, v float64, lowerIsBetter bool) int {
+	r := 1
+	for _, o := range all {
+		if (lowerIsBetter && o < v) || (!lowerIsBetter && o > v) {
+			r++
+		}
+	}
+	return r
+}
+
+// GetTeamSeasonStats returns a team's season rates ranked against the
+// league. Shooting and save percentages shown come from the club's own
+// skater and goalie totals when available, which leave out shootout and
+// empty-net goals; the ranks use the summary estimates every team has.
+func GetTeamSeasonStats(teamAbbrev, season string, gameType int) (*TeamSeasonStats, error) {
+	rows, err := fetchTeamSummary(season, gameType)
+	if err != nil {
+		return nil, err
+	}
+	teamID := abbrevToTeamID[teamAbbrev]
+	league := make([]TeamSeasonStats, 0, len(rows))
+	var out *TeamSeasonStats
+	for _, row := range rows {
+		s := teamSummaryStats(row)
+		league = append(league, s)
+		if jsonInt(row["teamId"]) == teamID {
+			out = &league[len(league)-1]
+		}
+	}
+	if out == nil {
+		return nil, fmt.Errorf("%w: %s in %s", errNoTeamStats, teamAbbrev, season)
+	}
+	stats := *out
+	stats.Season = season
+	stats.GameType = gameType
+
+	column := func(f func(TeamSeasonStats) float64) []float64 {
+		vals := make([]float64, len(league))
+		for i, s := range league {
+			vals[i] = f(s)
+		}
+		return vals
+	}
+	rank := func(f func(TeamSeasonStats) float64, lowerIsBetter bool) int {
+		return leagueRank(column(f), f(stats), lowerIsBetter)
+	}
+	stats.Ranks = TeamStatRanks{
+		GoalsForPerGame:     rank(func(s TeamSeasonStats) float64 { return s.GoalsForPerGame }, false),
+		GoalsAgainstPerGame: rank(func(s TeamSeasonStats) float64 { return s.GoalsAgainstPerGame }, true),
+		ShotsForPerGame:     rank(func(s TeamSeasonStats) float64 { return s.ShotsForPerGame }, false),
+		ShotsAgainstPerGame: rank(func(s TeamSeasonStats) float64 { return s.ShotsAgainstPerGame }, true),
+		PowerPlayPct:        rank(func(s TeamSeasonStats) float64 { return s.PowerPlayPct }, false),
+		PenaltyKillPct:      rank(func(s TeamSeasonStats) float64 { return s.PenaltyKillPct }, false),
+		FaceoffWinPct:       rank(func(s TeamSeasonStats) float64 { return s.FaceoffWinPct }, false),
+		ShootingPct:         rank(func(s TeamSeasonStats) float64 { return s.ShootingPct }, false),
+		SavePct:             rank(func(s TeamSeasonStats) float64 { return s.SavePct }, false),
+	}
+
+	goals, shots, saves, shotsAgainst, err := clubShootingAndSaves(teamAbbrev, season, gameType)
+	if err != nil {
+		log.Printf("GetTeamSeasonStats: club stats for %s: %v", teamAbbrev, err)
+	} else {
+		if shots > 0 {
+			stats.ShootingPct = round3(float64(goals) / float64(shots))
+		}
+		if shotsAgainst > 0 {
+			stats.SavePct = round3(float64(saves) / float64(shotsAgainst))
+		}
+	}
+	return &stats, nil
+}
+
+func handleAPITeamStats(w http.ResponseWriter, r *http.Request) {
+	teamAbbrev, ok := resolveTeamAbbrev(mux.Vars(r)["teamId"])
+	if !ok {
+		http.Error(w, "unknown team", http.StatusNotFound)
+		return
+	}
+	season, gameType, ok := seasonAndGameType(w, r)
+	if !ok {
+		return
+	}
+
+	stats, err := GetTeamSeasonStats(teamAbbrev, season, gameType)
+	if err != nil {
+		status := http.StatusBadGateway
+		if errors.Is(err, errNoTeamStats) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(stats); err != nil {
+		log.Printf("Error writing team stats JSON: %v", err)
+	}
+}
@@ -0,0 +1,773 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Trivia difficulties; "mixed" draws from all three.
+const (
+	triviaEasy   = "easy"
+	triviaMedium = "medium"
+	triviaHard   = "hard"
+	triviaMixed  = "mixed"
+)
+
+const (
+	defaultTriviaCount = 10
+	maxTriviaCount     = 25
+	triviaChoices      = 4
+	triviaQuizTTL      = 6 * time.Hour
+	triviaDailyTTL     = 48 * time.Hour
+)
+
+// dailyTriviaMix is the daily puzzle's questions per difficulty, in order.
+var dailyTriviaMix = []struct {
+	Difficulty string
+	Count      int
+}{{triviaEasy, 4}, {triviaMedium, 4}, {triviaHard, 2}}
+
+// errUnknownQuiz is returned for malformed or unknown quiz ids.
+var errUnknownQuiz = errors.New("unknown quiz")
+
+var countryNames = map[string]string{
+	"CAN": "Canada", "USA": "United States", "SWE": "Sweden", "FIN": "Finland",
+	"RUS": "Russia", "CZE": "Czechia", "SVK": "Slovakia", "CHE": "Switzerland",
+	"DEU": "Germany", "DNK": "Denmark", "NOR": "Norway", "LVA": "Latvia",
+	"AUT": "Austria", "BLR": "Belarus", "FRA": "France", "SVN": "Slovenia",
+	"GBR": "United Kingdom", "AUS": "Australia", "KAZ": "Kazakhstan", "UKR": "Ukraine",
+}
+
+// hockeyNations are the birth-country distractors, so the choices are
+// plausible rather than obviously wrong.
+var hockeyNations = []string{"CAN", "USA", "SWE", "FIN", "RUS", "CZE", "SVK", "CHE", "DEU", "DNK", "LVA"}
+
+func countryName(code string) string {
+	if name, ok := countryNames[code]; ok {
+		return name
+	}
+	return code
+}
+
+// TriviaQuestion is a question as sent to the browser; the answer stays on
+// the server.
+type TriviaQuestion struct {
+	ID         string   `json:"id"`
+	Type       string   `json:"type"`
+	Difficulty string   `json:"difficulty"`
+	Prompt     string   `json:"prompt"`
+	Image      string   `json:"image,omitempty"`
+	Choices    []string `json:"choices"`
+}
+
+// triviaItem is a question with its answer, as cached server-side.
+type triviaItem struct {
+	TriviaQuestion
+	Answer      int    `json:"answer"`
+	Explanation string `json:"explanation"`
+}
+
+// triviaQuiz is a generated quiz with answers.
+type triviaQuiz struct {
+	ID         string       `json:"id"`
+	Team       string       `json:"team"`
+	Difficulty string       `json:"difficulty"`
+	Date       string       `json:"date,omitempty"`
+	Items      []triviaItem `json:"items"`
+}
+
+// TriviaQuiz is the /api/trivia response.
+type TriviaQuiz struct {
+	ID         string           `json:"id"`
+	Team       string           `json:"team"`
+	Difficulty string           `json:"difficulty"`
+	Daily      bool             `json:"daily"`
+	Date       string           `json:"date,omitempty"`
+	Questions  []TriviaQuestion `json:"questions"`
+}
+
+// TriviaAnswerResult is the verdict on one answered question.
+type TriviaAnswerResult struct {
+	QuestionID  string `json:"questionId"`
+	Choice      int    `json:"choice"`
+	Correct     bool   `json:"correct"`
+	Answer      int    `json:"answer"`
+	AnswerText  string `json:"answerText"`
+	Explanation string `json:"explanation"`
+}
+
+// TriviaCheckResult is the /api/trivia/check response. ShareText is set
+// once every question has been answered.
+type TriviaCheckResult struct {
+	QuizID    string               `json:"quizId"`
+	Score     int                  `json:"score"`
+	Answered  int                  `json:"answered"`
+	Total     int                  `json:"total"`
+	Results   []TriviaAnswerResult `json:"results"`
+	ShareText string               `json:"shareText,omitempty"`
+}
+
+func (q *triviaQuiz) public() *TriviaQuiz {
+	out := &TriviaQuiz{
+		ID:         q.ID,
+		Team:       q.Team,
+		Difficulty: q.Difficulty,
+		Daily:      q.Date != "",
+		Date:       q.Date,
+		Questions:  make([]TriviaQuestion, 0, len(q.Items)),
+	}
+	for _, it := range q.Items {
+		out.Questions = append(out.Questions, it.TriviaQuestion)
+	}
+	return out
+}
+
+// triviaPlayer pairs a roster entry with its landing.
+type triviaPlayer struct {
+	info    PlayerInfo
+	landing *PlayerLanding
+}
+
+// loadTriviaPlayers returns a team's roster with landings, sorted by id so
+// generation from a seed is repeatable.
+func loadTriviaPlayers(teamAbbrev string) ([]triviaPlayer, error) {
+	roster, err := GetRoster(teamAbbrev)
+	if err != nil {
+		return nil, err
+	}
+	var players []triviaPlayer
+	for _, p := range roster.Players {
+		landing, err := GetPlayerLanding(strconv.Itoa(p.ID))
+		if err != nil {
+			log.Printf("loadTriviaPlayers: skipping %d: %v", p.ID, err)
+			continue
+		}
+		players = append(players, triviaPlayer{info: p, landing: landing})
+	}
+	if len(players) < 2 {
+		return nil, fmt.Errorf("not enough players on the %s roster for trivia", teamAbbrev)
+	}
+	sort.Slice(players, func(i, j int) bool { return players[i].info.ID < players[j].info.ID })
+	return players, nil
+}
+
+// nhlTeams lists the distinct NHL clubs in a landing's regular seasons, in
+// career order.
+func nhlTeams(l *PlayerLanding) []string {
+	var teams []string
+	seen := map[string]bool{}
+	for _, st := range l.SeasonTotals {
+		name := st.TeamName.Default
+		if st.LeagueAbbrev != "NHL" || st.GameTypeID != 2 || name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		teams = append(teams, name)
+	}
+	return teams
+}
+
+func birthplace(l *PlayerLanding) string {
+	parts := []string{l.BirthCity.Default}
+	if l.BirthStateProvince.Default != "" {
+		parts = append(parts, l.BirthStateProvince.Default)
+	}
+	parts = append(parts, countryName(l.BirthCountry))
+	return strings.Join(parts, ", ")
+}
+
+// triviaChoiceSet shuffles the correct answer in with up to three distinct
+// distractors from the pool. It fails when the pool has none.
+func triviaChoiceSet(rng *rand.Rand, correct string, pool []string) ([]string, int, bool) {
+	var distractors []string
+	seen := map[string]bool{correct: true}
+	for _, i := range rng.Perm(len(pool)) {
+		if len(distractors) == triviaChoices-1 {
+			break
+		}
+		if c := pool[i]; c != "" && !seen[c] {
+			seen[c] = true
+			distractors = append(distractors, c)
+		}
+	}
+	if len(distractors) == 0 {
+		return nil, 0, false
+	}
+	choices := append([]string{correct}, distractors...)
+	rng.Shuffle(len(choices), func(i, j int) { choices[i], choices[j] = choices[j], choices[i] })
+	for i, c := range choices {
+		if c == correct {
+			return choices, i, true
+		}
+	}
+	return nil, 0, false
+}
+
+// nearbyNumbers returns numbers within spread of n (excluding n) no lower
+// than min, as strings for a choice pool.
+func nearbyNumbers(n, spread, min int) []string {
+	var out []string
+	for d := -spread; d <= spread; d++ {
+		if v := n + d; d != 0 && v >= min {
+			out = append(out, strconv.Itoa(v))
+		}
+	}
+	return out
+}
+
+// triviaPools are the distractor pools drawn from the whole roster.
+type triviaPools struct {
+	numbers, cities, countries, nhlTeams []string
+}
+
+func buildTriviaPools(players []triviaPlayer) triviaPools {
+	var pools triviaPools
+	for _, p := range players {
+		if p.info.Number > 0 {
+			pools.numbers = append(pools.numbers, strconv.Itoa(p.info.Number))
+		}
+		if p.landing.BirthCity.Default != "" {
+			pools.cities = append(pools.cities, birthplace(p.landing))
+		}
+		pools.nhlTeams = append(pools.nhlTeams, nhlTeams(p.landing)...)
+	}
+	for _, code := range hockeyNations {
+		pools.countries = append(pools.countries, countryName(code))
+	}
+	return pools
+}
+
+// careerStat is a stat used for head-to-head comparison questions.
+type careerStat struct {
+	label  string
+	goalie bool
+	value  func(PlayerStatLine) int
+}
+
+var careerStats = []careerStat{
+	{"career NHL goals", false, func(s PlayerStatLine) int { return s.Goals }},
+	{"career NHL points", false, func(s PlayerStatLine) int { return s.Points }},
+	{"career NHL games", false, func(s PlayerStatLine) int { return s.GamesPlayed }},
+	{"career NHL wins", true, func(s PlayerStatLine) int { return s.Wins }},
+	{"career NHL shutouts", true, func(s PlayerStatLine) int { return s.Shutouts }},
+}
+
+// comparisonDifficulty grades a head-to-head by how far apart the values are.
+func comparisonDifficulty(a, b int) string {
+	hi, lo := a, b
+	if lo > hi {
+		hi, lo = lo, hi
+	}
+	gap := float64(hi-lo) / float64(hi)
+	switch {
+	case gap >= 0.5:
+		return triviaEasy
+	case gap >= 0.2:
+		return triviaMedium
+	default:
+		return triviaHard
+	}
+}
+
+// triviaCandidates builds every question the roster supports. The rng is
+// consumed in a fixed order so a seed always yields the same questions.
+func triviaCandidates(rng *rand.Rand, players []triviaPlayer) []triviaItem {
+	pools := buildTriviaPools(players)
+	var items []triviaItem
+	add := func(p triviaPlayer, typ, difficulty, prompt, correct string, pool []string, explanation string) {
+		choices, answer, ok := triviaChoiceSet(rng, correct, pool)
+		if !ok {
+			return
+		}
+		items = append(items, triviaItem{
+			TriviaQuestion: TriviaQuestion{
+				Type:       typ,
+				Difficulty: difficulty,
+				Prompt:     prompt,
+				Image:      p.info.Photo,
+				Choices:    choices,
+			},
+			Answer:      answer,
+			Explanation: explanation,
+		})
+	}
+
+	for _, p := range players {
+		name, l := p.info.Name, p.landing
+		if p.info.Number > 0 {
+			n := strconv.Itoa(p.info.Number)
+			add(p, "jersey", triviaEasy, fmt.Sprintf("What number does %s wear?", name), n, pools.numbers,
+				fmt.Sprintf("%s wears #%s.", name, n))
+		}
+		if l.BirthCountry != "" {
+			country := countryName(l.BirthCountry)
+			add(p, "birth-country", triviaEasy, fmt.Sprintf("Which country was %s born in?", name), country, pools.countries,
+				fmt.Sprintf("%s was born in %s.", name, birthplace(l)))
+		}
+		if l.BirthCity.Default != "" {
+			add(p, "birthplace", triviaMedium, fmt.Sprintf("Where was %s born?", name), birthplace(l), pools.cities,
+				fmt.Sprintf("%s was born in %s.", name, birthplace(l)))
+		}
+		if d := l.DraftDetails; d != nil && d.Year > 0 {
+			draft := fmt.Sprintf("%s was drafted %s overall (round %d) by %s in %d.", name, ordinal(d.OverallPick), d.Round, d.TeamAbbrev, d.Year)
+			add(p, "draft-year", triviaMedium, fmt.Sprintf("In which year was %s drafted?", name),
+				strconv.Itoa(d.Year), nearbyNumbers(d.Year, 3, 1963), draft)
+			if d.OverallPick > 0 {
+				add(p, "draft-pick", triviaHard, fmt.Sprintf("What overall pick was %s in the %d draft?", name, d.Year),
+					strconv.Itoa(d.OverallPick), nearbyNumbers(d.OverallPick, 12, 1), draft)
+			}
+			if d.TeamAbbrev != "" && d.TeamAbbrev != l.CurrentTeamAbbrev {
+				var teams []string
+				for abbr := range abbrevToTeamID {
+					teams = append(teams, abbr)
+				}
+				sort.Strings(teams)
+				add(p, "draft-team", triviaMedium, fmt.Sprintf("Which team drafted %s?", name), d.TeamAbbrev, teams, draft)
+			}
+		}
+		if teams := nhlTeams(l); len(teams) > 1 {
+			current := teams[len(teams)-1]
+			past := teams[rng.Intn(len(teams)-1)]
+			var others []string
+			for _, t := range pools.nhlTeams {
+				if !containsString(teams, t) {
+					others = append(others, t)
+				}
+			}
+			add(p, "career-team", triviaHard, fmt.Sprintf("Besides the %s, which NHL team has %s played for?", current, name),
+				past, others, fmt.Sprintf("%s has played for the %s.", name, strings.Join(teams, ", ")))
+		}
+	}
+
+	// One head-to-head per player against a random teammate at the same position group
+	for i, p := range players {
+		goalie := p.info.Position == "G"
+		stats := careerStatsFor(goalie)
+		var partners []int
+		for j, q := range players {
+			if j != i && (q.info.Position == "G") == goalie {
+				partners = append(partners, j)
+			}
+		}
+		if len(partners) == 0 || len(stats) == 0 {
+			continue
+		}
+		q := players[partners[rng.Intn(len(partners))]]
+		stat := stats[rng.Intn(len(stats))]
+		a := stat.value(p.landing.CareerTotals.RegularSeason)
+		b := stat.value(q.landing.CareerTotals.RegularSeason)
+		if a == b {
+			continue
+		}
+		winner := p.info.Name
+		if b > a {
+			winner = q.info.Name
+		}
+		choices := []string{p.info.Name, q.info.Name}
+		if rng.Intn(2) == 1 {
+			choices[0], choices[1] = choices[1], choices[0]
+		}
+		answer := 0
+		if choices[1] == winner {
+			answer = 1
+		}
+		items = append(items, triviaItem{
+			TriviaQuestion: TriviaQuestion{
+				Type:       "stat",
+				Difficulty: comparisonDifficulty(a, b),
+				Prompt:     fmt.Sprintf("Who has more %s: %s or %s?", stat.label, choices[0], choices[1]),
+				Choices:    choices,
+			},
+			Answer:      answer,
+			Explanation: fmt.Sprintf("%s %d, %s %d.", p.info.Name, a, q.info.Name, b),
+		})
+	}
+	return items
+}
+
+func careerStatsFor(goalie bool) []careerStat {
+	var out []careerStat
+	for _, s := range careerStats {
+		if s.goalie == goalie {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func containsString(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// ordinal formats 1 as "1st", 22 as "22nd" and so on.
+func ordinal(n int) string {
+	suffix := "th"
+	if n%100 < 11 || n%100 > 13 {
+		switch n % 10 {
+		case 1:
+			suffix = "st"
+		case 2:
+			suffix = "nd"
+		case 3:
+			suffix = "rd"
+		}
+	}
+	return fmt.Sprintf("%d%s", n, suffix)
+}
+
+// pickTrivia takes up to count shuffled candidates of a difficulty ("mixed"
+// takes any) that are not already in used.
+func pickTrivia(rng *rand.Rand, candidates []triviaItem, difficulty string, count int, used map[int]bool) []triviaItem {
+	var out []triviaItem
+	for _, i := range rng.Perm(len(candidates)) {
+		if len(out) == count {
+			break
+		}
+		c := candidates[i]
+		if used[i] || (difficulty != triviaMixed && c.Difficulty != difficulty) {
+			continue
+		}
+		used[i] = true
+		out = append(out, c)
+	}
+	return out
+}
+
+// generateTriviaQuiz builds a quiz from a seed. The daily puzzle uses a fixed
+// mix of difficulties; other quizzes draw count questions at one difficulty.
+func generateTriviaQuiz(id, teamAbbrev string, seed int64, difficulty string, count int, date string) (*triviaQuiz, error) {
+	players, err := loadTriviaPlayers(teamAbbrev)
+	if err != nil {
+		return nil, err
+	}
+	rng := rand.New(rand.NewSource(seed))
+	candidates := triviaCandidates(rng, players)
+
+	quiz := &triviaQuiz{ID: id, Team: teamAbbrev, Difficulty: difficulty, Date: date}
+	used := map[int]bool{}
+	if date != "" {
+		for _, mix := range dailyTriviaMix {
+			quiz.Items = append(quiz.Items, pickTrivia(rng, candidates, mix.Difficulty, mix.Count, used)...)
+		}
+	} else {
+		quiz.Items = pickTrivia(rng, candidates, difficulty, count, used)
+	}
+	if len(quiz.Items) == 0 {
+		return nil, fmt.Errorf("no %s trivia questions available for %s", difficulty, teamAbbrev)
+	}
+	for i := range quiz.Items {
+		quiz.Items[i].ID = fmt.Sprintf("q%d", i+1)
+	}
+	return quiz, nil
+}
+
+// Quiz ids encode everything needed to regenerate the quiz:
+// TEAM-daily-YYYYMMDD or TEAM-seed-difficulty-count.
+func dailyQuizID(teamAbbrev, date string) string {
+	return fmt.Sprintf("%s-daily-%s", teamAbbrev, date)
+}
+
+func dailySeed(teamAbbrev, date string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(teamAbbrev + "|" + date))
+	return int64(h.Sum64() & (1<<63 - 1))
+}
+
+// loadTriviaQuiz returns a quiz by id, generating and caching it on first use.
+func loadTriviaQuiz(id string) (*triviaQuiz, error) {
+	parts := strings.Split(id, "-")
+	if len(parts) < 3 {
+		return nil, errUnknownQuiz
+	}
+	team, ok := resolveTeamAbbrev(parts[0])
+	if !ok || team != parts[0] {
+		return nil, errUnknownQuiz
+	}
+
+	var (
+		seed       int64
+		difficulty = triviaMixed
+		count      = defaultTriviaCount
+		date       string
+		ttl        = triviaQuizTTL
+	)
+	switch {
+	case parts[1] == "daily" && len(parts) == 3:
+		if _, err := time.Parse("20060102", parts[2]); err != nil {
+			return nil, errUnknownQuiz
+		}
+		date, seed, ttl = parts[2], dailySeed(team, parts[2]), triviaDailyTTL
+	case len(parts) == 4:
+		s, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			return nil, errUnknownQuiz
+		}
+		n, err := strconv.Atoi(parts[3])
+		if err != nil || n < 1 || n > maxTriviaCount {
+			return nil, errUnknownQuiz
+		}
+		switch parts[2] {
+		case triviaEasy, triviaMedium, triviaHard, triviaMixed:
+		default:
+			return nil, errUnknownQuiz
+		}
+		seed, difficulty, count = s, parts[2], n
+	default:
+		return nil, errUnknownQuiz
+	}
+
+	data, err := getCachedOrFetchWithBackoff("trivia:"+id, func() ([]byte, error) {
+		quiz, err := generateTriviaQuiz(id, team, seed, difficulty, count, date)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(quiz)
+	}, ttl)
+	if err != nil {
+		return nil, err
+	}
+	var quiz triviaQuiz
+	if err := json.Unmarshal(data, &quiz); err != nil {
+		return nil, fmt.Errorf("parsing cached trivia quiz: %w", err)
+	}
+	return &quiz, nil
+}
+
+func writeTriviaQuiz(w http.ResponseWriter, id string) {
+	quiz, err := loadTriviaQuiz(id)
+	if err != nil {
+		status := http.StatusBadGateway
+		if errors.Is(err, errUnknownQuiz) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(quiz.public()); err != nil {
+		log.Printf("Error writing trivia JSON: %v", err)
+	}
+}
+
+// handleAPITrivia returns a quiz for a team without answers.
+// Optional query params: difficulty (easy, medium, hard, mixed), count and seed.
+func handleAPITrivia(w http.ResponseWriter, r *http.Request) {
+	abbr, ok := resolveTeamAbbrev(mux.Vars(r)["teamId"])
+	if !ok {
+		http.Error(w, "unknown team", http.StatusNotFound)
+		return
+	}
+	q := r.URL.Query()
+	difficulty := q.Get("difficulty")
+	switch difficulty {
+	case "":
+		difficulty = triviaMixed
+	case triviaEasy, triviaMedium, triviaHard, triviaMixed:
+	default:
+		http.Error(w, "invalid difficulty", http.StatusBadRequest)
+		return
+	}
+	count := defaultTriviaCount
+	if v := q.Get("count"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxTriviaCount {
+			http.Error(w, fmt.Sprintf("count must be 1-%d", maxTriviaCount), http.StatusBadRequest)
+			return
+		}
+		count = n
+	}
+	seed := rand.Int63n(1 << 40)
+	if v := q.Get("seed"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 0 {
+			http.Error(w, "invalid seed", http.StatusBadRequest)
+			return
+		}
+		seed = n
+	}
+	writeTriviaQuiz(w, fmt.Sprintf("%s-%d-%s-%d", abbr, seed, difficulty, count))
+}
+
+// handleAPIDailyTrivia returns a team's daily puzzle, the same for everyone
+// on a given day. Optional query param: date (YYYYMMDD, today or earlier).
+func handleAPIDailyTrivia(w http.ResponseWriter, r *http.Request) {
+	abbr, ok := resolveTeamAbbrev(mux.Vars(r)["teamId"])
+	if !ok {
+		http.Error(w, "unknown team", http.StatusNotFound)
+		return
+	}
+	today := time.Now().Format("20060102")
+	date := r.URL.Query().Get("date")
+	if date == "" {
+		date = today
+	}
+	if _, err := time.Parse("20060102", date); err != nil || date > today {
+		http.Error(w, "invalid date", http.StatusBadRequest)
+		return
+	}
+	writeTriviaQuiz(w, dailyQuizID(abbr, date))
+}
+
+// triviaAnswers holds locked daily answers when Redis is off, by quiz id
+// and client.
+var triviaAnswers = struct {
+	sync.Mutex
+	byQuiz map[string]map[string]map[string]int
+}{byQuiz: map[string]map[string]map[string]int{}}
+
+func triviaAnswersKey(quizID, client string) string {
+	return "trivia-answers:" + quizID + ":" + client
+}
+
+// lockTriviaAnswers records a client's first choice for each question of a
+// daily quiz and returns every choice on record. A choice never changes
+// once made, so resubmitting cannot probe a question's answer.
+func lockTriviaAnswers(quiz *triviaQuiz, client string, answers map[string]int) (map[string]int, error) {
+	valid := map[string]int{}
+	for _, it := range quiz.Items {
+		if choice, ok := answers[it.ID]; ok && choice >= 0 && choice < len(it.Choices) {
+			valid[it.ID] = choice
+		}
+	}
+	if redisClient == nil {
+		triviaAnswers.Lock()
+		defer triviaAnswers.Unlock()
+		// Only today's and yesterday's quizzes can still be played.
+		oldest := time.Now().AddDate(0, 0, -2).Format("20060102")
+		for id := range triviaAnswers.byQuiz {
+			if parts := strings.Split(id, "-"); parts[len(parts)-1] < oldest {
+				delete(triviaAnswers.byQuiz, id)
+			}
+		}
+		clients := triviaAnswers.byQuiz[quiz.ID]
+		if clients == nil {
+			clients = map[string]map[string]int{}
+			triviaAnswers.byQuiz[quiz.ID] = clients
+		}
+		locked := clients[client]
+		if locked == nil {
+			locked = map[string]int{}
+			clients[client] = locked
+		}
+		for id, choice := range valid {
+			if _, ok := locked[id]; !ok {
+				locked[id] = choice
+			}
+		}
+		out := make(map[string]int, len(locked))
+		for id, choice := range locked {
+			out[id] = choice
+		}
+		return out, nil
+	}
+
+	key := triviaAnswersKey(quiz.ID, client)
+	pipe := redisClient.TxPipeline()
+	for id, choice := range valid {
+		pipe.HSetNX(redisCtx, key, id, choice)
+	}
+	pipe.Expire(redisCtx, key, triviaDailyTTL)
+	all := pipe.HGetAll(redisCtx, key)
+	if _, err := pipe.Exec(redisCtx); err != nil {
+		return nil, err
+	}
+	out := map[string]int{}
+	for id, v := range all.Val() {
+		if n, err := strconv.Atoi(v); err == nil {
+			out[id] = n
+		}
+	}
+	return out, nil
+}
+
+// handleAPITriviaCheck grades answers ({"quizId", "answers": {"q1": 2}})
+// against the server-side quiz.
+func handleAPITriviaCheck(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		QuizID  string         `json:"quizId"`
+		Answers map[string]int `json:"answers"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 16<<10)).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+	quiz, err := loadTriviaQuiz(req.QuizID)
+	if err != nil {
+		status := http.StatusBadGateway
+		if errors.Is(err, errUnknownQuiz) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	// Daily quizzes are shared and scored, so each client's answers are
+	// locked in; practice quizzes are graded as sent.
+	if quiz.Date != "" {
+		client, err := clientKey(w, r)
+		if err == nil {
+			req.Answers, err = lockTriviaAnswers(quiz, client, req.Answers)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+	}
+
+	res := TriviaCheckResult{QuizID: quiz.ID, Total: len(quiz.Items), Results: []TriviaAnswerResult{}}
+	var marks strings.Builder
+	for _, it := range quiz.Items {
+		choice, ok := req.Answers[it.ID]
+		if !ok {
+			marks.WriteString("⬜")
+			continue
+		}
+		correct := choice == it.Answer
+		res.Answered++
+		if correct {
+			res.Score++
+			marks.WriteString("🟩")
+		} else {
+			marks.WriteString("🟥")
+		}
+		res.Results = append(res.Results, TriviaAnswerResult{
+			QuestionID:  it.ID,
+			Choice:      choice,
+			Correct:     correct,
+			Answer:      it.Answer,
+			AnswerText:  it.Choices[it.Answer],
+			Explanation: it.Explanation,
+		})
+	}
+	if res.Answered == res.Total {
+		label := quiz.Team + " trivia"
+		if quiz.Date != "" {
+			if d, err := time.Parse("20060102", quiz.Date); err == nil {
+				label = fmt.Sprintf("%s daily trivia %s", quiz.Team, d.Format("2006-01-02"))
+			}
+		}
+		res.ShareText = fmt.Sprintf("🏒 %s: %d/%d\n%s", label, res.Score, res.Total, marks.String())
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		log.Printf("Error writing trivia check JSON: %v", err)
+	}
+}
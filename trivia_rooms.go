@@ -0,0 +1,761 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	roomCodeLength        = 5
+	roomCodeAlphabet      = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	roomTTL               = 6 * time.Hour
+	defaultRoomSeconds    = 20
+	minRoomSeconds        = 5
+	maxRoomSeconds        = 60
+	roomRevealPause       = 6 * time.Second
+	maxRoomPlayers        = 50
+	maxRoomPlayerNameLen  = 24
+	roomCorrectPoints     = 500 // for any correct answer
+	roomSpeedPoints       = 500 // more, scaled by the share of time left
+	roomWriteWait         = 10 * time.Second
+	roomPongWait          = 60 * time.Second
+	roomPingPeriod        = roomPongWait * 9 / 10
+	roomSendBuffer        = 16
+	maxRoomMessageBytes   = 1 << 10
+	maxRoomRequestBytes   = 4 << 10
+	roomStatusLobby       = "lobby"
+	roomStatusQuestion    = "question"
+	roomStatusReveal      = "reveal"
+	roomStatusFinished    = "finished"
+	defaultRoomDifficulty = triviaMixed
+)
+
+var (
+	errRoomNotFound = errors.New("room not found")
+	errRoomState    = errors.New("not allowed right now")
+)
+
+// roomMu serializes read-modify-write cycles on stored rooms.
+var roomMu sync.Mutex
+
+// triviaRoom is a multiplayer room as stored in the cache. Everything needed
+// to carry on after a restart lives here, the quiz's items included: the
+// quiz cache entry expires long before a room does, and a regenerated quiz
+// need not match the one being played.
+type triviaRoom struct {
+	Code          string                `json:"code"`
+	Team          string                `json:"team"`
+	QuizID        string                `json:"quizId"`
+	Items         []triviaItem          `json:"items"`
+	HostTokenHash string                `json:"hostTokenHash"`
+	Seconds       int                   `json:"seconds"`
+	Status        string                `json:"status"`
+	Question      int                   `json:"question"` // index into the quiz, -1 in the lobby
+	Deadline      time.Time             `json:"deadline"` // end of the current question or reveal
+	QuestionAt    time.Time             `json:"questionAt"`
+	Players       []roomPlayer          `json:"players"`
+	Answers       map[string]RoomAnswer `json:"answers"` // current question, by player id
+	CreatedAt     time.Time             `json:"createdAt"`
+}
+
+type roomPlayer struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	TokenHash string `json:"tokenHash"`
+	Score     int    `json:"score"`
+	Correct   int    `json:"correct"`
+	// AnswerMillis totals time taken on correct answers and breaks score ties
+	AnswerMillis int64 `json:"answerMillis"`
+}
+
+// RoomAnswer is a player's answer to one question and the points it earned.
+type RoomAnswer struct {
+	Choice  int   `json:"choice"`
+	Millis  int64 `json:"millis"`
+	Correct bool  `json:"correct"`
+	Points  int   `json:"points"`
+}
+
+// RoomStanding is one leaderboard row.
+type RoomStanding struct {
+	Rank     int    `json:"rank"`
+	PlayerID string `json:"playerId"`
+	Name     string `json:"name"`
+	Score    int    `json:"score"`
+	Correct  int    `json:"correct"`
+}
+
+// RoomReveal is the answer to the question just closed and how each player
+// did on it.
+type RoomReveal struct {
+	Answer      int                   `json:"answer"`
+	AnswerText  string                `json:"answerText"`
+	Explanation string                `json:"explanation"`
+	Results     map[string]RoomAnswer `json:"results"`
+}
+
+// TriviaRoomState is what every client in a room sees. Deadline is paired
+// with ServerTime so clients can correct for clock skew.
+type TriviaRoomState struct {
+	Code           string          `json:"code"`
+	Team           string          `json:"team"`
+	Status         string          `json:"status"`
+	Seconds        int             `json:"seconds"`
+	QuestionNumber int             `json:"questionNumber"`
+	TotalQuestions int             `json:"totalQuestions"`
+	Question       *TriviaQuestion `json:"question,omitempty"`
+	Deadline       *time.Time      `json:"deadline,omitempty"`
+	ServerTime     time.Time       `json:"serverTime"`
+	Answered       []string        `json:"answered"`
+	Reveal         *RoomReveal     `json:"reveal,omitempty"`
+	Leaderboard    []RoomStanding  `json:"leaderboard"`
+}
+
+func roomKey(code string) string {
+	return "trivia-room:" + code
+}
+
+func loadRoom(code string) (*triviaRoom, error) {
+	data, err := getCachedRaw(roomKey(code))
+	if errors.Is(err, redis.Nil) {
+		return nil, errRoomNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var room triviaRoom
+	if err := json.Unmarshal(data, &room); err != nil {
+		return nil, fmt.Errorf("parsing stored room %s: %w", code, err)
+	}
+	return &room, nil
+}
+
+func saveRoom(room *triviaRoom) error {
+	data, err := json.Marshal(room)
+	if err != nil {
+		return err
+	}
+	return setCachedRaw(roomKey(room.Code), data, roomTTL)
+}
+
+// quiz is the room's quiz as stored with it.
+func (room *triviaRoom) quiz() *triviaQuiz {
+	return &triviaQuiz{ID: room.QuizID, Team: room.Team, Items: room.Items}
+}
+
+func (room *triviaRoom) player(id string) *roomPlayer {
+	for i := range room.Players {
+		if room.Players[i].ID == id {
+			return &room.Players[i]
+		}
+	}
+	return nil
+}
+
+// leaderboard ranks players by score, then by less time spent on correct
+// answers. Tied players share a rank.
+func (room *triviaRoom) leaderboard() []RoomStanding {
+	players := append([]roomPlayer(nil), room.Players...)
+	sort.SliceStable(players, func(i, j int) bool {
+		if players[i].Score != players[j].Score {
+			return players[i].Score > players[j].Score
+		}
+		return players[i].AnswerMillis < players[j].AnswerMillis
+	})
+	out := make([]RoomStanding, 0, len(players))
+	for i, p := range players {
+		rank := i + 1
+		if i > 0 && p.Score == players[i-1].Score && p.AnswerMillis == players[i-1].AnswerMillis {
+			rank = out[i-1].Rank
+		}
+		out = append(out, RoomStanding{Rank: rank, PlayerID: p.ID, Name: p.Name, Score: p.Score, Correct: p.Correct})
+	}
+	return out
+}
+
+// state builds the public view of a room. The current question's answer is
+// only included once it has been revealed.
+func (room *triviaRoom) state(quiz *triviaQuiz) *TriviaRoomState {
+	s := &TriviaRoomState{
+		Code:           room.Code,
+		Team:           room.Team,
+		Status:         room.Status,
+		Seconds:        room.Seconds,
+		TotalQuestions: len(quiz.Items),
+		ServerTime:     time.Now().UTC(),
+		Answered:       []string{},
+		Leaderboard:    room.leaderboard(),
+	}
+	if room.Status == roomStatusQuestion || room.Status == roomStatusReveal {
+		item := quiz.Items[room.Question]
+		q := item.TriviaQuestion
+		s.Question = &q
+		s.QuestionNumber = room.Question + 1
+		deadline := room.Deadline
+		s.Deadline = &deadline
+		for id := range room.Answers {
+			s.Answered = append(s.Answered, id)
+		}
+		sort.Strings(s.Answered)
+		if room.Status == roomStatusReveal {
+			s.Reveal = &RoomReveal{
+				Answer:      item.Answer,
+				AnswerText:  item.Choices[item.Answer],
+				Explanation: item.Explanation,
+				Results:     room.Answers,
+			}
+		}
+	}
+	if room.Status == roomStatusFinished {
+		s.QuestionNumber = len(quiz.Items)
+	}
+	return s
+}
+
+// startQuestion opens question i, or finishes the game past the last one.
+func (room *triviaRoom) startQuestion(i int, total int, now time.Time) {
+	if i >= total {
+		room.Status = roomStatusFinished
+		room.Deadline = time.Time{}
+		return
+	}
+	room.Status = roomStatusQuestion
+	room.Question = i
+	room.Answers = map[string]RoomAnswer{}
+	room.QuestionAt = now
+	room.Deadline = now.Add(time.Duration(room.Seconds) * time.Second)
+}
+
+func (room *triviaRoom) reveal(now time.Time) {
+	room.Status = roomStatusReveal
+	room.Deadline = now.Add(roomRevealPause)
+}
+
+// advance moves a room past any deadlines that have passed, so a room
+// loaded after a restart catches up to where its timers would have taken it.
+func (room *triviaRoom) advance(total int, now time.Time) bool {
+	changed := false
+	for !room.Deadline.IsZero() && !now.Before(room.Deadline) {
+		switch room.Status {
+		case roomStatusQuestion:
+			room.reveal(room.Deadline)
+		case roomStatusReveal:
+			room.startQuestion(room.Question+1, total, room.Deadline)
+		default:
+			return changed
+		}
+		changed = true
+	}
+	return changed
+}
+
+// answer scores a player's choice: a correct answer earns the base points
+// plus a speed bonus for the share of the timer left.
+func (room *triviaRoom) answer(playerID string, choice int, item triviaItem, now time.Time) error {
+	if room.Status != roomStatusQuestion || !now.Before(room.Deadline) {
+		return fmt.Errorf("%w: the question is closed", errRoomState)
+	}
+	p := room.player(playerID)
+	if p == nil {
+		return fmt.Errorf("%w: not a player in this room", errRoomState)
+	}
+	if _, ok := room.Answers[playerID]; ok {
+		return fmt.Errorf("%w: already answered", errRoomState)
+	}
+	if choice < 0 || choice >= len(item.Choices) {
+		return fmt.Errorf("%w: invalid choice", errRoomState)
+	}
+	elapsed := now.Sub(room.QuestionAt)
+	limit := time.Duration(room.Seconds) * time.Second
+	a := RoomAnswer{Choice: choice, Millis: elapsed.Milliseconds(), Correct: choice == item.Answer}
+	if a.Correct {
+		left := float64(limit-elapsed) / float64(limit)
+		a.Points = roomCorrectPoints + int(float64(roomSpeedPoints)*clamp01(left)+0.5)
+		p.Score += a.Points
+		p.Correct++
+		p.AnswerMillis += a.Millis
+	}
+	room.Answers[playerID] = a
+	if len(room.Answers) == len(room.Players) {
+		room.reveal(now)
+	}
+	return nil
+}
+
+// roomConn is one WebSocket client. PlayerID is empty for the host and
+// spectators.
+type roomConn struct {
+	ws       *websocket.Conn
+	send     chan []byte
+	playerID string
+	host     bool
+}
+
+// roomHub tracks this process's connections and phase timers per room. It
+// holds no game state; that is always read from the cache.
+type roomHub struct {
+	mu     sync.Mutex
+	conns  map[string]map[*roomConn]bool
+	timers map[string]*time.Timer
+}
+
+var triviaRooms = &roomHub{
+	conns:  map[string]map[*roomConn]bool{},
+	timers: map[string]*time.Timer{},
+}
+
+func (h *roomHub) add(code string, c *roomConn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.conns[code] == nil {
+		h.conns[code] = map[*roomConn]bool{}
+	}
+	h.conns[code][c] = true
+}
+
+func (h *roomHub) remove(code string, c *roomConn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.conns[code][c]; !ok {
+		return
+	}
+	delete(h.conns[code], c)
+	close(c.send)
+	if len(h.conns[code]) == 0 {
+		delete(h.conns, code)
+		if t := h.timers[code]; t != nil {
+			t.Stop()
+			delete(h.timers, code)
+		}
+	}
+}
+
+// broadcast queues a message for every connection in a room, dropping
+// clients too slow to keep up.
+func (h *roomHub) broadcast(code string, msg []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for c := range h.conns[code] {
+		select {
+		case c.send <- msg:
+		default:
+			delete(h.conns[code], c)
+			close(c.send)
+		}
+	}
+}
+
+// schedule arms the room's timer for its next deadline, replacing any
+// earlier one. Timers are only kept while someone is connected; a room
+// with no one watching catches up on its next load instead.
+func (h *roomHub) schedule(room *triviaRoom) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if t := h.timers[room.Code]; t != nil {
+		t.Stop()
+		delete(h.timers, room.Code)
+	}
+	if room.Deadline.IsZero() || len(h.conns[room.Code]) == 0 {
+		return
+	}
+	code := room.Code
+	h.timers[code] = time.AfterFunc(time.Until(room.Deadline), func() {
+		if _, _, err := updateRoom(code, func(*triviaRoom, *triviaQuiz) error { return nil }); err != nil {
+			log.Printf("trivia room %s: timer: %v", code, err)
+		}
+	})
+}
+
+type roomMessage struct {
+	Type  string           `json:"type"`
+	State *TriviaRoomState `json:"state,omitempty"`
+	Error string           `json:"error,omitempty"`
+}
+
+func roomStateMessage(state *TriviaRoomState) []byte {
+	data, _ := json.Marshal(roomMessage{Type: "state", State: state})
+	return data
+}
+
+// updateRoom applies fn to a room under roomMu, catches the room up on any
+// passed deadlines, saves it, and pushes the new state to every client.
+func updateRoom(code string, fn func(*triviaRoom, *triviaQuiz) error) (*triviaRoom, *triviaQuiz, error) {
+	roomMu.Lock()
+	defer roomMu.Unlock()
+	room, err := loadRoom(code)
+	if err != nil {
+		return nil, nil, err
+	}
+	quiz := room.quiz()
+	now := time.Now().UTC()
+	room.advance(len(quiz.Items), now)
+	if err := fn(room, quiz); err != nil {
+		return nil, nil, err
+	}
+	if err := saveRoom(room); err != nil {
+		return nil, nil, err
+	}
+	triviaRooms.broadcast(code, roomStateMessage(room.state(quiz)))
+	triviaRooms.schedule(room)
+	return room, quiz, nil
+}
+
+func writeRoomError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errRoomNotFound), errors.Is(err, errUnknownQuiz):
+		http.Error(w, "room not found", http.StatusNotFound)
+	case errors.Is(err, errRoomState):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case redisClient == nil:
+		http.Error(w, "trivia rooms unavailable", http.StatusServiceUnavailable)
+	default:
+		http.Error(w, err.Error(), http.StatusBadGateway)
+	}
+}
+
+func writeRoomJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Error writing trivia room JSON: %v", err)
+	}
+}
+
+// tokenMatches reports whether token hashes to hash, in constant time.
+func tokenMatches(token, hash string) bool {
+	return token != "" && subtle.ConstantTimeCompare([]byte(hashToken(token)), []byte(hash)) == 1
+}
+
+// handleAPICreateTriviaRoom opens a room for a team
+// ({"team","difficulty","count","seconds"}) and returns its code and the
+// host token that starts and skips questions.
+func handleAPICreateTriviaRoom(w http.ResponseWriter, r *http.Request) {
+	if redisClient == nil {
+		http.Error(w, "trivia rooms unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	var req struct {
+		Team       string `json:"team"`
+		Difficulty string `json:"difficulty"`
+		Count      int    `json:"count"`
+		Seconds    int    `json:"seconds"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRoomRequestBytes)).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+	abbr, ok := resolveTeamAbbrev(req.Team)
+	if !ok {
+		http.Error(w, "unknown team", http.StatusBadRequest)
+		return
+	}
+	if req.Difficulty == "" {
+		req.Difficulty = defaultRoomDifficulty
+	}
+	switch req.Difficulty {
+	case triviaEasy, triviaMedium, triviaHard, triviaMixed:
+	default:
+		http.Error(w, "invalid difficulty", http.StatusBadRequest)
+		return
+	}
+	if req.Count == 0 {
+		req.Count = defaultTriviaCount
+	}
+	if req.Count < 1 || req.Count > maxTriviaCount {
+		http.Error(w, fmt.Sprintf("count must be 1-%d", maxTriviaCount), http.StatusBadRequest)
+		return
+	}
+	if req.Seconds == 0 {
+		req.Seconds = defaultRoomSeconds
+	}
+	if req.Seconds < minRoomSeconds || req.Seconds > maxRoomSeconds {
+		http.Error(w, fmt.Sprintf("seconds must be %d-%d", minRoomSeconds, maxRoomSeconds), http.StatusBadRequest)
+		return
+	}
+
+	quizID := fmt.Sprintf("%s-%d-%s-%d", abbr, rand.Int63n(1<<40), req.Difficulty, req.Count)
+	quiz, err := loadTriviaQuiz(quizID)
+	if err != nil {
+		writeRoomError(w, err)
+		return
+	}
+	token, err := randomString(shareIDAlphabet, 32)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	roomMu.Lock()
+	defer roomMu.Unlock()
+	var code string
+	for attempt := 0; attempt < 5 && code == ""; attempt++ {
+		c, err := randomString(roomCodeAlphabet, roomCodeLength)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if _, err := loadRoom(c); errors.Is(err, errRoomNotFound) {
+			code = c
+		}
+	}
+	if code == "" {
+		http.Error(w, "could not allocate a room code", http.StatusInternalServerError)
+		return
+	}
+	room := &triviaRoom{
+		Code:          code,
+		Team:          abbr,
+		QuizID:        quiz.ID,
+		Items:         quiz.Items,
+		HostTokenHash: hashToken(token),
+		Seconds:       req.Seconds,
+		Status:        roomStatusLobby,
+		Question:      -1,
+		Players:       []roomPlayer{},
+		Answers:       map[string]RoomAnswer{},
+		CreatedAt:     time.Now().UTC(),
+	}
+	if err := saveRoom(room); err != nil {
+		writeRoomError(w, err)
+		return
+	}
+	writeRoomJSON(w, http.StatusCreated, map[string]interface{}{
+		"code":      code,
+		"hostToken": token,
+		"state":     room.state(quiz),
+	})
+}
+
+// handleAPIJoinTriviaRoom adds a player ({"name"}) to a room that has not
+// finished and returns their id and token.
+func handleAPIJoinTriviaRoom(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRoomRequestBytes)).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" || utf8.RuneCountInString(name) > maxRoomPlayerNameLen {
+		http.Error(w, fmt.Sprintf("name must be 1-%d characters", maxRoomPlayerNameLen), http.StatusBadRequest)
+		return
+	}
+	token, err := randomString(shareIDAlphabet, 32)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	playerID, err := randomString(shareIDAlphabet, shareIDLength)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	code := strings.ToUpper(mux.Vars(r)["code"])
+	room, quiz, err := updateRoom(code, func(room *triviaRoom, _ *triviaQuiz) error {
+		if room.Status == roomStatusFinished {
+			return fmt.Errorf("%w: the game is over", errRoomState)
+		}
+		if len(room.Players) >= maxRoomPlayers {
+			return fmt.Errorf("%w: the room is full", errRoomState)
+		}
+		for _, p := range room.Players {
+			if strings.EqualFold(p.Name, name) {
+				return fmt.Errorf("%w: the name %q is taken", errRoomState, name)
+			}
+		}
+		room.Players = append(room.Players, roomPlayer{ID: playerID, Name: name, TokenHash: hashToken(token)})
+		return nil
+	})
+	if err != nil {
+		writeRoomError(w, err)
+		return
+	}
+	writeRoomJSON(w, http.StatusCreated, map[string]interface{}{
+		"playerId":    playerID,
+		"playerToken": token,
+		"state":       room.state(quiz),
+	})
+}
+
+// handleAPITriviaRoom returns a room's current state.
+func handleAPITriviaRoom(w http.ResponseWriter, r *http.Request) {
+	code := strings.ToUpper(mux.Vars(r)["code"])
+	room, quiz, err := updateRoom(code, func(*triviaRoom, *triviaQuiz) error { return nil })
+	if err != nil {
+		writeRoomError(w, err)
+		return
+	}
+	writeRoomJSON(w, http.StatusOK, room.state(quiz))
+}
+
+var roomUpgrader = websocket.Upgrader{ReadBufferSize: 1024, WriteBufferSize: 1024}
+
+// handleTriviaRoomSocket upgrades to a WebSocket that receives the room
+// state on every change. The token query param identifies the host or a
+// player; without one the client only watches. Clients send
+// {"type":"start"} and {"type":"next"} (host) or {"type":"answer","choice":n}.
+func handleTriviaRoomSocket(w http.ResponseWriter, r *http.Request) {
+	code := strings.ToUpper(mux.Vars(r)["code"])
+	room, err := loadRoom(code)
+	if err != nil {
+		writeRoomError(w, err)
+		return
+	}
+	c := &roomConn{send: make(chan []byte, roomSendBuffer)}
+	if token := r.URL.Query().Get("token"); token != "" {
+		if tokenMatches(token, room.HostTokenHash) {
+			c.host = true
+		}
+		for _, p := range room.Players {
+			if tokenMatches(token, p.TokenHash) {
+				c.playerID = p.ID
+			}
+		}
+		if !c.host && c.playerID == "" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	ws, err := roomUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("trivia room %s: upgrade: %v", code, err)
+		return
+	}
+	c.ws = ws
+	triviaRooms.add(code, c)
+	go c.writePump()
+
+	// Sends the current state to the new client and rearms timers lost to a
+	// restart
+	if _, _, err := updateRoom(code, func(*triviaRoom, *triviaQuiz) error { return nil }); err != nil {
+		log.Printf("trivia room %s: %v", code, err)
+	}
+	c.readPump(code)
+}
+
+func (c *roomConn) readPump(code string) {
+	defer func() {
+		triviaRooms.remove(code, c)
+		c.ws.Close()
+	}()
+	c.ws.SetReadLimit(maxRoomMessageBytes)
+	_ = c.ws.SetReadDeadline(time.Now().Add(roomPongWait))
+	c.ws.SetPongHandler(func(string) error {
+		return c.ws.SetReadDeadline(time.Now().Add(roomPongWait))
+	})
+	for {
+		var msg struct {
+			Type   string `json:"type"`
+			Choice int    `json:"choice"`
+		}
+		if err := c.ws.ReadJSON(&msg); err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				log.Printf("trivia room %s: read: %v", code, err)
+			}
+			return
+		}
+		if err := c.handle(code, msg.Type, msg.Choice); err != nil {
+			data, _ := json.Marshal(roomMessage{Type: "error", Error: err.Error()})
+			triviaRooms.sendTo(code, c, data)
+		}
+	}
+}
+
+// sendTo queues a message for one connection if it is still registered.
+func (h *roomHub) sendTo(code string, c *roomConn, msg []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.conns[code][c] {
+		return
+	}
+	select {
+	case c.send <- msg:
+	default:
+	}
+}
+
+func (c *roomConn) handle(code, typ string, choice int) error {
+	_, _, err := updateRoom(code, func(room *triviaRoom, quiz *triviaQuiz) error {
+		now := time.Now().UTC()
+		switch typ {
+		case "start":
+			if !c.host {
+				return fmt.Errorf("%w: only the host can start", errRoomState)
+			}
+			if room.Status != roomStatusLobby {
+				return fmt.Errorf("%w: the game has started", errRoomState)
+			}
+			if len(room.Players) == 0 {
+				return fmt.Errorf("%w: no players have joined", errRoomState)
+			}
+			room.startQuestion(0, len(quiz.Items), now)
+		case "next":
+			if !c.host {
+				return fmt.Errorf("%w: only the host can skip ahead", errRoomState)
+			}
+			switch room.Status {
+			case roomStatusQuestion:
+				room.reveal(now)
+			case roomStatusReveal:
+				room.startQuestion(room.Question+1, len(quiz.Items), now)
+			default:
+				return fmt.Errorf("%w: no question in play", errRoomState)
+			}
+		case "answer":
+			if c.playerID == "" {
+				return fmt.Errorf("%w: only players can answer", errRoomState)
+			}
+			if room.Status != roomStatusQuestion {
+				return fmt.Errorf("%w: the question is closed", errRoomState)
+			}
+			return room.answer(c.playerID, choice, quiz.Items[room.Question], now)
+		default:
+			return fmt.Errorf("%w: unknown message type %q", errRoomState, typ)
+		}
+		return nil
+	})
+	return err
+}
+
+func (c *roomConn) writePump() {
+	ticker := time.NewTicker(roomPingPeriod)
+	defer func() {
+		ticker.Stop()
+		c.ws.Close()
+	}()
+	for {
+		select {
+		case msg, ok := <-c.send:
+			_ = c.ws.SetWriteDeadline(time.Now().Add(roomWriteWait))
+			if !ok {
+				_ = c.ws.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+			if err := c.ws.WriteMessage(websocket.TextMessage, msg); err != nil {
+				return
+			}
+		case <-ticker.C:
+			_ = c.ws.SetWriteDeadline(time.Now().Add(roomWriteWait))
+			if err := c.ws.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
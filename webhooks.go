@@ -0,0 +1,1180 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/redis/go-redis/v9"
+)
+
+// Webhook event types.
+const (
+	webhookEventGameStart   = "game.start"
+	webhookEventGoal        = "game.goal"
+	webhookEventPeriodEnd   = "game.period_end"
+	webhookEventFinal       = "game.final"
+	webhookEventTransaction = "team.transaction"
+	webhookEventPing        = "ping"
+)
+
+var webhookEventTypes = []string{
+	webhookEventGameStart, webhookEventGoal, webhookEventPeriodEnd, webhookEventFinal, webhookEventTransaction,
+}
+
+// Delivery statuses.
+const (
+	deliveryPending   = "pending"
+	deliveryDelivered = "delivered"
+	deliveryFailed    = "failed"
+)
+
+const (
+	webhookIDLength        = 10
+	webhookSecretLength    = 32
+	maxWebhooks            = 100
+	maxWebhookFilters      = 50
+	webhookTimeout         = 10 * time.Second
+	webhookDeliveryTTL     = 7 * 24 * time.Hour
+	webhookLogSize         = 200
+	webhookGameStateTTL    = 48 * time.Hour
+	webhookTxSeenTTL       = 90 * 24 * time.Hour
+	webhookDispatchEvery   = 2 * time.Second
+	webhookDispatchBatch   = 20
+	webhookMaxInFlight     = 8
+	webhookGameLease       = 2 * time.Minute
+	webhookDeliveryLease   = 2 * time.Minute
+	webhookSignatureHeader = "X-Hockey-Signature"
+	webhookQueueKey        = "webhook-queue"
+	webhookIndexKey        = "webhooks"
+)
+
+// webhookRetryDelays are the waits after each failed attempt; a delivery
+// is marked failed once they run out.
+var webhookRetryDelays = []time.Duration{30 * time.Second, 2 * time.Minute, 10 * time.Minute, time.Hour, 6 * time.Hour}
+
+var (
+	errWebhookNotFound  = errors.New("webhook not found")
+	errDeliveryNotFound = errors.New("delivery not found")
+)
+
+var webhookHTTPClient = &http.Client{Timeout: webhookTimeout}
+
+// WebhookSubscription is a registered endpoint. Empty filters match
+// everything; non-empty ones must all match an event.
+type WebhookSubscription struct {
+	ID          string   `json:"id"`
+	URL         string   `json:"url"`
+	Secret      string   `json:"secret,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Events      []string `json:"events"`
+	Teams       []string `json:"teams"`
+	Players     []int    `json:"players"`
+	Games       []int64  `json:"games"`
+	// Format is "json" (signed event bodies) or "slack"/"discord" for
+	// posting readable messages to a channel's incoming webhook.
+	Format    string    `json:"format,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// WebhookEvent is the JSON body POSTed to subscribers. IDs are stable, so
+// receivers can drop duplicates.
+type WebhookEvent struct {
+	ID         string      `json:"id"`
+	Type       string      `json:"type"`
+	OccurredAt time.Time   `json:"occurredAt"`
+	GameID     int64       `json:"gameId,omitempty"`
+	Teams      []string    `json:"teams"`
+	Players    []int       `json:"players,omitempty"`
+	Data       interface{} `json:"data"`
+}
+
+// GameStartData is the data of a game.start event.
+type GameStartData struct {
+	GameID       int64  `json:"gameId"`
+	GameType     int    `json:"gameType"`
+	StartTimeUTC string `json:"startTimeUTC"`
+	Venue        string `json:"venue"`
+	HomeTeam     string `json:"homeTeam"`
+	AwayTeam     string `json:"awayTeam"`
+}
+
+// GoalPlayer is a scorer or assist in GoalData.
+type GoalPlayer struct {
+	PlayerID int64  `json:"playerId"`
+	Name     string `json:"name"`
+}
+
+// GoalData is the data of a game.goal event; scores are after the goal.
+type GoalData struct {
+	GameID       int64        `json:"gameId"`
+	Period       int          `json:"period"`
+	PeriodType   string       `json:"periodType"`
+	TimeInPeriod string       `json:"timeInPeriod"`
+	Team         string       `json:"team"`
+	Scorer       GoalPlayer   `json:"scorer"`
+	Assists      []GoalPlayer `json:"assists"`
+	Strength     string       `json:"strength"`
+	ShotType     string       `json:"shotType"`
+	GoalModifier string       `json:"goalModifier"`
+	HomeTeam     string       `json:"homeTeam"`
+	AwayTeam     string       `json:"awayTeam"`
+	HomeScore    int          `json:"homeScore"`
+	AwayScore    int          `json:"awayScore"`
+}
+
+// PeriodEndData is the data of a game.period_end event.
+type PeriodEndData struct {
+	GameID     int64  `json:"gameId"`
+	Period     int    `json:"period"`
+	PeriodType string `json:"periodType"`
+	HomeTeam   string `json:"homeTeam"`
+	AwayTeam   string `json:"awayTeam"`
+	HomeScore  int    `json:"homeScore"`
+	AwayScore  int    `json:"awayScore"`
+}
+
+// FinalData is the data of a game.final event.
+type FinalData struct {
+	GameID         int64  `json:"gameId"`
+	HomeTeam       string `json:"homeTeam"`
+	AwayTeam       string `json:"awayTeam"`
+	HomeScore      int    `json:"homeScore"`
+	AwayScore      int    `json:"awayScore"`
+	Winner         string `json:"winner"`
+	LastPeriodType string `json:"lastPeriodType"`
+}
+
+// TransactionData is the data of a team.transaction event.
+type TransactionData struct {
+	Team      string `json:"team"`
+	Title     string `json:"title"`
+	Date      string `json:"date"`
+	Summary   string `json:"summary"`
+	URL       string `json:"url"`
+	Thumbnail string `json:"thumbnail"`
+}
+
+// WebhookAttempt is one HTTP attempt at a delivery.
+type WebhookAttempt struct {
+	At         time.Time `json:"at"`
+	StatusCode int       `json:"statusCode,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"durationMs"`
+}
+
+// WebhookDelivery is one event sent to one subscription, with its attempts.
+type WebhookDelivery struct {
+	ID             string           `json:"id"`
+	SubscriptionID string           `json:"subscriptionId"`
+	EventID        string           `json:"eventId"`
+	EventType      string           `json:"eventType"`
+	Payload        json.RawMessage  `json:"payload"`
+	Status         string           `json:"status"`
+	Attempts       []WebhookAttempt `json:"attempts"`
+	NextAttemptAt  *time.Time       `json:"nextAttemptAt,omitempty"`
+	ReplayOf       string           `json:"replayOf,omitempty"`
+	CreatedAt      time.Time        `json:"createdAt"`
+}
+
+func webhookKey(id string) string {
+	return "webhook:" + id
+}
+
+func webhookDeliveryKey(id string) string {
+	return "webhook-delivery:" + id
+}
+
+func webhookLogKey(subID string) string {
+	return "webhook-deliveries:" + subID
+}
+
+func (s *WebhookSubscription) public() WebhookSubscription {
+	out := *s
+	out.Secret = ""
+	return out
+}
+
+// matches reports whether the subscription wants the event.
+func (s *WebhookSubscription) matches(ev *WebhookEvent) bool {
+	if len(s.Events) > 0 && !containsString(s.Events, ev.Type) {
+		return false
+	}
+	if len(s.Teams) > 0 {
+		found := false
+		for _, t := range ev.Teams {
+			if containsString(s.Teams, t) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(s.Players) > 0 {
+		found := false
+		for _, p := range ev.Players {
+			for _, want := range s.Players {
+				if p == want {
+					found = true
+				}
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(s.Games) > 0 {
+		found := false
+		for _, g := range s.Games {
+			if g == ev.GameID {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// postsToChat reports whether deliveries are chat messages rather than
+// event JSON.
+func (s *WebhookSubscription) postsToChat() bool {
+	return s.Format == webhookFormatSlack || s.Format == webhookFormatDiscord
+}
+
+// wantsGame reports whether any game event for this matchup could reach
+// the subscription, so the watcher only fetches landings it needs.
+func (s *WebhookSubscription) wantsGame(gameID int64, home, away string) bool {
+	wantsGameEvents := len(s.Events) == 0
+	for _, e := range s.Events {
+		if strings.HasPrefix(e, "game.") {
+			wantsGameEvents = true
+		}
+	}
+	if !wantsGameEvents {
+		return false
+	}
+	if len(s.Teams) > 0 && !containsString(s.Teams, home) && !containsString(s.Teams, away) {
+		return false
+	}
+	if len(s.Games) > 0 {
+		for _, g := range s.Games {
+			if g == gameID {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func loadWebhook(id string) (*WebhookSubscription, error) {
+	data, err := getCachedRaw(webhookKey(id))
+	if errors.Is(err, redis.Nil) {
+		return nil, errWebhookNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var s WebhookSubscription
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("parsing webhook %s: %w", id, err)
+	}
+	return &s, nil
+}
+
+// loadWebhooks returns every subscription; it needs Redis.
+func loadWebhooks() ([]*WebhookSubscription, error) {
+	if redisClient == nil {
+		return nil, fmt.Errorf("redis unavailable")
+	}
+	ids, err := redisClient.SMembers(redisCtx, webhookIndexKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	subs := make([]*WebhookSubscription, 0, len(ids))
+	for _, id := range ids {
+		s, err := loadWebhook(id)
+		if errors.Is(err, errWebhookNotFound) {
+			redisClient.SRem(redisCtx, webhookIndexKey, id)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, s)
+	}
+	return subs, nil
+}
+
+func loadDelivery(id string) (*WebhookDelivery, error) {
+	data, err := getCachedRaw(webhookDeliveryKey(id))
+	if errors.Is(err, redis.Nil) {
+		return nil, errDeliveryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var d WebhookDelivery
+	if err := json.Unmarshal(data, &d); err != nil {
+		return nil, fmt.Errorf("parsing delivery %s: %w", id, err)
+	}
+	return &d, nil
+}
+
+func saveDelivery(d *WebhookDelivery) error {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	return setCachedRaw(webhookDeliveryKey(d.ID), data, webhookDeliveryTTL)
+}
+
+// queueDelivery records a new delivery in the subscription's log and puts
+// it on the dispatch queue.
+func queueDelivery(sub *WebhookSubscription, eventID, eventType string, payload []byte, replayOf string) (*WebhookDelivery, error) {
+	id, err := randomString(shareIDAlphabet, webhookIDLength)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	d := &WebhookDelivery{
+		ID:             id,
+		SubscriptionID: sub.ID,
+		EventID:        eventID,
+		EventType:      eventType,
+		Payload:        payload,
+		Status:         deliveryPending,
+		Attempts:       []WebhookAttempt{},
+		NextAttemptAt:  &now,
+		ReplayOf:       replayOf,
+		CreatedAt:      now,
+	}
+	if err := saveDelivery(d); err != nil {
+		return nil, err
+	}
+	pipe := redisClient.TxPipeline()
+	pipe.LPush(redisCtx, webhookLogKey(sub.ID), id)
+	pipe.LTrim(redisCtx, webhookLogKey(sub.ID), 0, webhookLogSize-1)
+	pipe.ZAdd(redisCtx, webhookQueueKey, redis.Z{Score: float64(now.UnixMilli()), Member: id})
+	if _, err := pipe.Exec(redisCtx); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// emitWebhookEvent queues a delivery for every subscription that matches.
+func emitWebhookEvent(subs []*WebhookSubscription, ev *WebhookEvent) {
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		log.Printf("webhooks: encoding %s: %v", ev.ID, err)
+		return
+	}
+	for _, sub := range subs {
+		if !sub.matches(ev) {
+			continue
+		}
+		payload := payload
+		if sub.postsToChat() {
+			var ok bool
+			if payload, ok = chatEventPayload(sub.Format, ev); !ok {
+				continue
+			}
+		}
+		if _, err := queueDelivery(sub, ev.ID, ev.Type, payload, ""); err != nil {
+			log.Printf("webhooks: queueing %s for %s: %v", ev.ID, sub.ID, err)
+		}
+	}
+}
+
+// signWebhook returns the signature header value: the HMAC-SHA256 of
+// "{timestamp}.{body}" with the subscription secret, as
+// "t={timestamp},v1={hex}".
+func signWebhook(secret string, ts int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", ts)
+	mac.Write(body)
+	return fmt.Sprintf("t=%d,v1=%s", ts, hex.EncodeToString(mac.Sum(nil)))
+}
+
+// attemptDelivery POSTs a claimed delivery once and records the outcome,
+// re-queueing it with backoff on failure. Deliveries that are gone or
+// already settled leave the queue.
+func attemptDelivery(id string) {
+	d, err := loadDelivery(id)
+	if errors.Is(err, errDeliveryNotFound) || err == nil && d.Status != deliveryPending {
+		redisClient.ZRem(redisCtx, webhookQueueKey, id)
+		return
+	}
+	if err != nil {
+		log.Printf("webhooks: loading delivery %s: %v", id, err)
+		return
+	}
+	sub, err := loadWebhook(d.SubscriptionID)
+	attempt := WebhookAttempt{At: time.Now().UTC()}
+	if err != nil {
+		attempt.Error = err.Error()
+	} else {
+		start := time.Now()
+		attempt.StatusCode, err = postWebhook(sub, d)
+		attempt.DurationMs = time.Since(start).Milliseconds()
+		if err != nil {
+			attempt.Error = err.Error()
+		}
+	}
+	d.Attempts = append(d.Attempts, attempt)
+	d.NextAttemptAt = nil
+
+	switch {
+	case attempt.Error == "" && attempt.StatusCode >= 200 && attempt.StatusCode < 300:
+		d.Status = deliveryDelivered
+	case errors.Is(err, errWebhookNotFound) || len(d.Attempts) > len(webhookRetryDelays):
+		d.Status = deliveryFailed
+	default:
+		next := time.Now().Add(webhookRetryDelays[len(d.Attempts)-1]).UTC()
+		d.NextAttemptAt = &next
+	}
+	// Until the queue entry is updated the lease still holds it, so a
+	// failed save is retried once the lease runs out
+	if err := saveDelivery(d); err != nil {
+		log.Printf("webhooks: saving delivery %s: %v", d.ID, err)
+		return
+	}
+	if d.NextAttemptAt != nil {
+		err = redisClient.ZAdd(redisCtx, webhookQueueKey, redis.Z{Score: float64(d.NextAttemptAt.UnixMilli()), Member: d.ID}).Err()
+	} else {
+		err = redisClient.ZRem(redisCtx, webhookQueueKey, d.ID).Err()
+	}
+	if err != nil {
+		log.Printf("webhooks: re-queueing %s: %v", d.ID, err)
+	}
+}
+
+func postWebhook(sub *WebhookSubscription, d *WebhookDelivery) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, sub.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "hockey-webhooks/1")
+	req.Header.Set("X-Hockey-Event", d.EventType)
+	req.Header.Set("X-Hockey-Delivery", d.ID)
+	req.Header.Set(webhookSignatureHeader, signWebhook(sub.Secret, time.Now().Unix(), d.Payload))
+	resp, err := webhookHTTPClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("endpoint returned %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// claimDelivery leases a due delivery by moving its queue entry a lease
+// timeout into the future. The entry stays queued while the attempt runs,
+// so a server that dies mid-attempt leaves it to be retried once the lease
+// runs out.
+func claimDelivery(id string, now time.Time) (bool, error) {
+	claimed := false
+	err := redisClient.Watch(redisCtx, func(tx *redis.Tx) error {
+		score, err := tx.ZScore(redisCtx, webhookQueueKey, id).Result()
+		if errors.Is(err, redis.Nil) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if score > float64(now.UnixMilli()) {
+			return nil
+		}
+		_, err = tx.TxPipelined(redisCtx, func(pipe redis.Pipeliner) error {
+			pipe.ZAdd(redisCtx, webhookQueueKey, redis.Z{Score: float64(now.Add(webhookDeliveryLease).UnixMilli()), Member: id})
+			return nil
+		})
+		claimed = err == nil
+		return err
+	}, webhookQueueKey)
+	if errors.Is(err, redis.TxFailedErr) {
+		return false, nil
+	}
+	return claimed, err
+}
+
+// dispatchDueDeliveries claims due deliveries from the queue. The claim is
+// a compare-and-set on the entry's score, so each attempt runs once even
+// with several servers.
+func dispatchDueDeliveries(sem chan struct{}) {
+	now := time.Now()
+	ids, err := redisClient.ZRangeByScore(redisCtx, webhookQueueKey, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(now.UnixMilli(), 10),
+		Count: webhookDispatchBatch,
+	}).Result()
+	if err != nil {
+		log.Printf("webhooks: reading queue: %v", err)
+		return
+	}
+	for _, id := range ids {
+		claimed, err := claimDelivery(id, now)
+		if err != nil {
+			log.Printf("webhooks: claiming %s: %v", id, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		sem <- struct{}{}
+		go func(id string) {
+			defer func() { <-sem }()
+			attemptDelivery(id)
+		}(id)
+	}
+}
+
+// webhookGameState is what the watcher has already announced for a game.
+type webhookGameState struct {
+	Started      bool     `json:"started"`
+	Final        bool     `json:"final"`
+	PeriodsEnded int      `json:"periodsEnded"`
+	Goals        []string `json:"goals"`
+}
+
+func loadWebhookGameState(gameID int64) (*webhookGameState, bool) {
+	data, err := getCachedRaw(fmt.Sprintf("webhook-game:%d", gameID))
+	if err != nil {
+		return &webhookGameState{}, false
+	}
+	var st webhookGameState
+	if err := json.Unmarshal(data, &st); err != nil {
+		return &webhookGameState{}, false
+	}
+	return &st, true
+}
+
+func saveWebhookGameState(gameID int64, st *webhookGameState) {
+	data, err := json.Marshal(st)
+	if err != nil {
+		return
+	}
+	if err := setCachedRaw(fmt.Sprintf("webhook-game:%d", gameID), data, webhookGameStateTTL); err != nil {
+		log.Printf("webhooks: saving game %d state: %v", gameID, err)
+	}
+}
+
+// webhookScheduleGame is the slice of a schedule game the watcher reads.
+type webhookScheduleGame struct {
+	ID           int64           `json:"id"`
+	GameType     int             `json:"gameType"`
+	GameState    string          `json:"gameState"`
+	StartTimeUTC string          `json:"startTimeUTC"`
+	Venue        LocalizedString `json:"venue"`
+	HomeTeam     ScheduleTeam    `json:"homeTeam"`
+	AwayTeam     ScheduleTeam    `json:"awayTeam"`
+}
+
+// periodType labels periods the landing no longer describes.
+func inferredPeriodType(n int) string {
+	if n <= 3 {
+		return "REG"
+	}
+	return "OT"
+}
+
+// pollWebhookGames announces game events for yesterday's and today's games
+// (yesterday's so late games crossing midnight are not lost).
+func pollWebhookGames(subs []*WebhookSubscription) {
+	now := time.Now()
+	yesterday := now.AddDate(0, 0, -1).Format("2006-01-02")
+	today := now.Format("2006-01-02")
+	data, err := readURL(fmt.Sprintf("%s/schedule/%s", BaseURL, yesterday))
+	if err != nil {
+		log.Printf("webhooks: schedule: %v", err)
+		return
+	}
+	var sched struct {
+		GameWeek []struct {
+			Date  string                `json:"date"`
+			Games []webhookScheduleGame `json:"games"`
+		} `json:"gameWeek"`
+	}
+	if err := json.Unmarshal(data, &sched); err != nil {
+		log.Printf("webhooks: parsing schedule: %v", err)
+		return
+	}
+	for _, day := range sched.GameWeek {
+		if day.Date != yesterday && day.Date != today {
+			continue
+		}
+		for _, g := range day.Games {
+			if !isGameLive(g.GameState) && !isGameFinal(g.GameState) {
+				continue
+			}
+			wanted := false
+			for _, s := range subs {
+				if s.wantsGame(g.ID, g.HomeTeam.Abbrev, g.AwayTeam.Abbrev) {
+					wanted = true
+					break
+				}
+			}
+			if wanted {
+				pollWebhookGame(subs, g)
+			}
+		}
+	}
+}
+
+// pollWebhookGame announces what is new in one game. The game's state is
+// read, extended and saved under a lease so two servers polling at once
+// cannot both announce the same goal.
+func pollWebhookGame(subs []*WebhookSubscription, g webhookScheduleGame) {
+	lockKey := fmt.Sprintf("webhook-game-lock:%d", g.ID)
+	set, err := redisClient.SetNX(redisCtx, lockKey, time.Now().Unix(), webhookGameLease).Result()
+	if err != nil || !set {
+		return
+	}
+	defer redisClient.Del(redisCtx, lockKey)
+
+	st, known := loadWebhookGameState(g.ID)
+	if st.Final {
+		return
+	}
+	// Games that were already over when first seen are recorded silently
+	if !known && isGameFinal(g.GameState) {
+		saveWebhookGameState(g.ID, &webhookGameState{Started: true, Final: true})
+		return
+	}
+	teams := []string{g.HomeTeam.Abbrev, g.AwayTeam.Abbrev}
+	newEvent := func(typ, suffix string, players []int, data interface{}) *WebhookEvent {
+		return &WebhookEvent{
+			ID:         fmt.Sprintf("%s-%d-%s", typ, g.ID, suffix),
+			Type:       typ,
+			OccurredAt: time.Now().UTC(),
+			GameID:     g.ID,
+			Teams:      teams,
+			Players:    players,
+			Data:       data,
+		}
+	}
+
+	if !st.Started {
+		st.Started = true
+		emitWebhookEvent(subs, newEvent(webhookEventGameStart, "start", nil, GameStartData{
+			GameID:       g.ID,
+			GameType:     g.GameType,
+			StartTimeUTC: g.StartTimeUTC,
+			Venue:        g.Venue.Default,
+			HomeTeam:     g.HomeTeam.Abbrev,
+			AwayTeam:     g.AwayTeam.Abbrev,
+		}))
+	}
+
+	landing, err := GetGameLanding(strconv.FormatInt(g.ID, 10))
+	if err != nil {
+		log.Printf("webhooks: landing %d: %v", g.ID, err)
+		saveWebhookGameState(g.ID, st)
+		return
+	}
+	home, away := landing.HomeTeam.Abbrev.Default, landing.AwayTeam.Abbrev.Default
+
+	for _, sc := range landing.Summary.Scoring {
+		for _, goal := range sc.Goals {
+			key := strconv.FormatInt(goal.EventID, 10)
+			if goal.EventID == 0 {
+				key = fmt.Sprintf("%d-%s-%d", sc.PeriodDescriptor.Number, goal.TimeInPeriod, goal.PlayerID)
+			}
+			if containsString(st.Goals, key) {
+				continue
+			}
+			st.Goals = append(st.Goals, key)
+			players := []int{int(goal.PlayerID)}
+			assists := []GoalPlayer{}
+			for _, a := range goal.Assists {
+				players = append(players, int(a.PlayerID))
+				assists = append(assists, GoalPlayer{PlayerID: a.PlayerID, Name: a.Name.Default})
+			}
+			emitWebhookEvent(subs, newEvent(webhookEventGoal, key, players, GoalData{
+				GameID:       g.ID,
+				Period:       sc.PeriodDescriptor.Number,
+				PeriodType:   sc.PeriodDescriptor.PeriodType,
+				TimeInPeriod: goal.TimeInPeriod,
+				Team:         goal.TeamAbbrev.Default,
+				Scorer:       GoalPlayer{PlayerID: goal.PlayerID, Name: goal.Name.Default},
+				Assists:      assists,
+				Strength:     goal.Strength,
+				ShotType:     goal.ShotType,
+				GoalModifier: goal.GoalModifier,
+				HomeTeam:     home,
+				AwayTeam:     away,
+				HomeScore:    goal.HomeScore,
+				AwayScore:    goal.AwayScore,
+			}))
+		}
+	}
+
+	// A period has ended once the next one starts, during the intermission
+	// after it, or when the game is over
+	period := landing.PeriodDescriptor.Number
+	final := isGameFinal(landing.GameState) || isGameFinal(g.GameState)
+	ended := period - 1
+	if landing.Clock.InIntermission || final {
+		ended = period
+	}
+	for n := st.PeriodsEnded + 1; n <= ended; n++ {
+		typ := inferredPeriodType(n)
+		if n == period {
+			typ = landing.PeriodDescriptor.PeriodType
+		}
+		emitWebhookEvent(subs, newEvent(webhookEventPeriodEnd, strconv.Itoa(n), nil, PeriodEndData{
+			GameID:     g.ID,
+			Period:     n,
+			PeriodType: typ,
+			HomeTeam:   home,
+			AwayTeam:   away,
+			HomeScore:  int(landing.HomeTeam.Score),
+			AwayScore:  int(landing.AwayTeam.Score),
+		}))
+	}
+	if ended > st.PeriodsEnded {
+		st.PeriodsEnded = ended
+	}
+
+	if final {
+		st.Final = true
+		winner := home
+		if landing.AwayTeam.Score > landing.HomeTeam.Score {
+			winner = away
+		}
+		emitWebhookEvent(subs, newEvent(webhookEventFinal, "final", nil, FinalData{
+			GameID:         g.ID,
+			HomeTeam:       home,
+			AwayTeam:       away,
+			HomeScore:      int(landing.HomeTeam.Score),
+			AwayScore:      int(landing.AwayTeam.Score),
+			Winner:         winner,
+			LastPeriodType: landing.PeriodDescriptor.PeriodType,
+		}))
+	}
+	saveWebhookGameState(g.ID, st)
+}
+
+// pollWebhookTransactions announces new stories in the transactions feed of
+// every team a transaction subscription covers. A team's first poll only
+// records what is already there.
+func pollWebhookTransactions(subs []*WebhookSubscription) {
+	teams := map[string]bool{}
+	for _, s := range subs {
+		if len(s.Events) > 0 && !containsString(s.Events, webhookEventTransaction) {
+			continue
+		}
+		if len(s.Teams) == 0 {
+			for abbr := range abbrevToTeamID {
+				teams[abbr] = true
+			}
+			break
+		}
+		for _, t := range s.Teams {
+			teams[t] = true
+		}
+	}
+	for abbr := range teams {
+		resp, err := GetTeamTransactions(abbr)
+		if err != nil {
+			log.Printf("webhooks: transactions %s: %v", abbr, err)
+			continue
+		}
+		seenKey := "webhook-tx-seen:" + abbr
+		primed, err := redisClient.Exists(redisCtx, seenKey).Result()
+		if err != nil {
+			log.Printf("webhooks: transactions %s: %v", abbr, err)
+			continue
+		}
+		// Oldest first so receivers see them in order
+		for i := len(resp.Transactions) - 1; i >= 0; i-- {
+			tx := resp.Transactions[i]
+			key := hashToken(tx.URL + "|" + tx.Title)
+			added, err := redisClient.SAdd(redisCtx, seenKey, key).Result()
+			if err != nil || added == 0 || primed == 0 {
+				continue
+			}
+			emitWebhookEvent(subs, &WebhookEvent{
+				ID:         fmt.Sprintf("%s-%s-%s", webhookEventTransaction, abbr, key[:16]),
+				Type:       webhookEventTransaction,
+				OccurredAt: time.Now().UTC(),
+				Teams:      []string{abbr},
+				Data: TransactionData{
+					Team:      abbr,
+					Title:     tx.Title,
+					Date:      tx.Date,
+					Summary:   tx.Summary,
+					URL:       tx.URL,
+					Thumbnail: tx.Thumbnail,
+				},
+			})
+		}
+		redisClient.Expire(redisCtx, seenKey, webhookTxSeenTTL)
+	}
+}
+
+// startWebhookWatcher dispatches queued deliveries and, while any
+// subscription exists, polls game landings and transactions feeds for
+// events. Everything lives in Redis, so it does nothing without it.
+func startWebhookWatcher(gameInterval, transactionInterval time.Duration) {
+	if redisClient == nil {
+		return
+	}
+	go func() {
+		sem := make(chan struct{}, webhookMaxInFlight)
+		ticker := time.NewTicker(webhookDispatchEvery)
+		defer ticker.Stop()
+		for range ticker.C {
+			dispatchDueDeliveries(sem)
+		}
+	}()
+	// Each cycle takes a lease that lapses just before the next one, so
+	// with several servers only one polls upstream per interval
+	poll := func(name string, interval time.Duration, fn func([]*WebhookSubscription)) {
+		go func() {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for range ticker.C {
+				set, err := redisClient.SetNX(redisCtx, "webhook-poll-lock:"+name, time.Now().Unix(), interval*9/10).Result()
+				if err != nil || !set {
+					continue
+				}
+				subs, err := loadWebhooks()
+				if err != nil {
+					log.Printf("webhooks: loading subscriptions: %v", err)
+					continue
+				}
+				if len(subs) > 0 {
+					fn(subs)
+				}
+			}
+		}()
+	}
+	poll("games", gameInterval, pollWebhookGames)
+	poll("transactions", transactionInterval, pollWebhookTransactions)
+}
+
+// webhookRequest is the body for creating a subscription.
+type webhookRequest struct {
+	URL         string   `json:"url"`
+	Secret      string   `json:"secret"`
+	Description string   `json:"description"`
+	Events      []string `json:"events"`
+	Teams       []string `json:"teams"`
+	Players     []int    `json:"players"`
+	Games       []int64  `json:"games"`
+	Format      string   `json:"format"`
+}
+
+// validate normalises the request into a subscription.
+func (req *webhookRequest) validate() (*WebhookSubscription, error) {
+	u, err := url.Parse(req.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("url must be an absolute http(s) URL")
+	}
+	sub := &WebhookSubscription{
+		URL:         u.String(),
+		Secret:      req.Secret,
+		Description: strings.TrimSpace(req.Description),
+		Events:      []string{},
+		Teams:       []string{},
+		Players:     []int{},
+		Games:       []int64{},
+		Format:      strings.ToLower(strings.TrimSpace(req.Format)),
+	}
+	switch sub.Format {
+	case "", webhookFormatJSON:
+		sub.Format = webhookFormatJSON
+	case webhookFormatSlack, webhookFormatDiscord:
+	default:
+		return nil, fmt.Errorf("format must be json, slack or discord")
+	}
+	for _, e := range req.Events {
+		if !containsString(webhookEventTypes, e) {
+			return nil, fmt.Errorf("unknown event %q (valid: %s)", e, strings.Join(webhookEventTypes, ", "))
+		}
+		if !containsString(sub.Events, e) {
+			sub.Events = append(sub.Events, e)
+		}
+	}
+	for _, t := range req.Teams {
+		abbr, ok := resolveTeamAbbrev(t)
+		if !ok {
+			return nil, fmt.Errorf("unknown team %q", t)
+		}
+		if !containsString(sub.Teams, abbr) {
+			sub.Teams = append(sub.Teams, abbr)
+		}
+	}
+	for _, p := range req.Players {
+		if p <= 0 {
+			return nil, fmt.Errorf("invalid player id %d", p)
+		}
+		sub.Players = append(sub.Players, p)
+	}
+	for _, g := range req.Games {
+		if g <= 0 {
+			return nil, fmt.Errorf("invalid game id %d", g)
+		}
+		sub.Games = append(sub.Games, g)
+	}
+	if len(sub.Teams) > maxWebhookFilters || len(sub.Players) > maxWebhookFilters || len(sub.Games) > maxWebhookFilters {
+		return nil, fmt.Errorf("at most %d teams, players and games per webhook", maxWebhookFilters)
+	}
+	if sub.Secret == "" {
+		secret, err := randomString(shareIDAlphabet, webhookSecretLength)
+		if err != nil {
+			return nil, err
+		}
+		sub.Secret = secret
+	}
+	return sub, nil
+}
+
+func writeWebhookJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Error writing webhook JSON: %v", err)
+	}
+}
+
+func writeWebhookError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errWebhookNotFound), errors.Is(err, errDeliveryNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// requireWebhookAdmin checks the admin token and that Redis is there.
+func requireWebhookAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if !requireAdmin(w, r) {
+		return false
+	}
+	if redisClient == nil {
+		http.Error(w, "webhooks need Redis", http.StatusServiceUnavailable)
+		return false
+	}
+	return true
+}
+
+// handleAPICreateWebhook registers a subscription. The response is the only
+// time the signing secret is shown.
+func handleAPICreateWebhook(w http.ResponseWriter, r *http.Request) {
+	if !requireWebhookAdmin(w, r) {
+		return
+	}
+	var req webhookRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 16<<10)).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+	sub, err := req.validate()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if n, err := redisClient.SCard(redisCtx, webhookIndexKey).Result(); err == nil && n >= maxWebhooks {
+		http.Error(w, fmt.Sprintf("at most %d webhooks", maxWebhooks), http.StatusConflict)
+		return
+	}
+	if sub.ID, err = randomString(shareIDAlphabet, webhookIDLength); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	sub.CreatedAt = time.Now().UTC()
+	data, err := json.Marshal(sub)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := setCachedRaw(webhookKey(sub.ID), data, 0); err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	if err := redisClient.SAdd(redisCtx, webhookIndexKey, sub.ID).Err(); err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	writeWebhookJSON(w, http.StatusCreated, sub)
+}
+
+// handleAPIListWebhooks lists subscriptions without their secrets.
+func handleAPIListWebhooks(w http.ResponseWriter, r *http.Request) {
+	if !requireWebhookAdmin(w, r) {
+		return
+	}
+	subs, err := loadWebhooks()
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	out := make([]WebhookSubscription, 0, len(subs))
+	for _, s := range subs {
+		out = append(out, s.public())
+	}
+	writeWebhookJSON(w, http.StatusOK, map[string]interface{}{"webhooks": out, "eventTypes": webhookEventTypes})
+}
+
+// handleAPIGetWebhook returns one subscription without its secret.
+func handleAPIGetWebhook(w http.ResponseWriter, r *http.Request) {
+	if !requireWebhookAdmin(w, r) {
+		return
+	}
+	sub, err := loadWebhook(mux.Vars(r)["webhookId"])
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	writeWebhookJSON(w, http.StatusOK, sub.public())
+}
+
+// handleAPIDeleteWebhook removes a subscription; its pending deliveries fail
+// on their next attempt.
+func handleAPIDeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	if !requireWebhookAdmin(w, r) {
+		return
+	}
+	id := mux.Vars(r)["webhookId"]
+	if _, err := loadWebhook(id); err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	pipe := redisClient.TxPipeline()
+	pipe.Del(redisCtx, webhookKey(id))
+	pipe.SRem(redisCtx, webhookIndexKey, id)
+	if _, err := pipe.Exec(redisCtx); err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleAPIPingWebhook queues a ping event to one subscription.
+func handleAPIPingWebhook(w http.ResponseWriter, r *http.Request) {
+	if !requireWebhookAdmin(w, r) {
+		return
+	}
+	sub, err := loadWebhook(mux.Vars(r)["webhookId"])
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	now := time.Now().UTC()
+	ev := &WebhookEvent{
+		ID:         fmt.Sprintf("%s-%s-%d", webhookEventPing, sub.ID, now.UnixMilli()),
+		Type:       webhookEventPing,
+		OccurredAt: now,
+		Teams:      []string{},
+		Data:       map[string]string{"webhookId": sub.ID},
+	}
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if sub.postsToChat() {
+		payload, _ = chatEventPayload(sub.Format, ev)
+	}
+	d, err := queueDelivery(sub, ev.ID, ev.Type, payload, "")
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	writeWebhookJSON(w, http.StatusAccepted, d)
+}
+
+// handleAPIWebhookDeliveries returns a subscription's delivery log, newest
+// first (?limit=50, max 200).
+func handleAPIWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	if !requireWebhookAdmin(w, r) {
+		return
+	}
+	id := mux.Vars(r)["webhookId"]
+	if _, err := loadWebhook(id); err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	limit := 50
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = min(n, webhookLogSize)
+	}
+	ids, err := redisClient.LRange(redisCtx, webhookLogKey(id), 0, int64(limit-1)).Result()
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	deliveries := make([]*WebhookDelivery, 0, len(ids))
+	for _, did := range ids {
+		d, err := loadDelivery(did)
+		if err != nil {
+			// Expired deliveries drop out of the log
+			continue
+		}
+		deliveries = append(deliveries, d)
+	}
+	writeWebhookJSON(w, http.StatusOK, map[string]interface{}{"webhookId": id, "deliveries": deliveries})
+}
+
+// handleAPIWebhookDelivery returns one delivery with its attempts.
+func handleAPIWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	if !requireWebhookAdmin(w, r) {
+		return
+	}
+	d, err := loadDelivery(mux.Vars(r)["deliveryId"])
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	writeWebhookJSON(w, http.StatusOK, d)
+}
+
+// handleAPIReplayWebhookDelivery sends a delivery's payload again as a new
+// delivery, whatever the original's outcome.
+func handleAPIReplayWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	if !requireWebhookAdmin(w, r) {
+		return
+	}
+	orig, err := loadDelivery(mux.Vars(r)["deliveryId"])
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	sub, err := loadWebhook(orig.SubscriptionID)
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	d, err := queueDelivery(sub, orig.EventID, orig.EventType, orig.Payload, orig.ID)
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	writeWebhookJSON(w, http.StatusAccepted, d)
+}
@@ -0,0 +1,785 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/redis/go-redis/v9"
+)
+
+//go:embed data/xg_coefficients.json
+var defaultXGCoefficients []byte
+
+// xgModelKey is where a retrained model is persisted in Redis.
+const xgModelKey = "xg:model"
+
+// xgShotTypes lists the non-baseline shot types, in feature-vector order.
+// Wrist shots are the baseline and carry no coefficient.
+var xgShotTypes = []string{"snap", "slap", "backhand", "tip-in", "deflected", "wrap-around", "poke", "bat", "between-legs", "cradle"}
+
+// XGModel is a logistic regression over unblocked shot features. Distance is
+// per foot and angle per degree from the centre line.
+type XGModel struct {
+	Version   string             `json:"version"`
+	TrainedAt string             `json:"trainedAt"`
+	Samples   int                `json:"samples"`
+	Intercept float64            `json:"intercept"`
+	Distance  float64            `json:"distance"`
+	Angle     float64            `json:"angle"`
+	Rebound   float64            `json:"rebound"`
+	Rush      float64            `json:"rush"`
+	EmptyNet  float64            `json:"emptyNet"`
+	Strength  map[string]float64 `json:"strength"`
+	ShotType  map[string]float64 `json:"shotType"`
+}
+
+var (
+	xgModelMu     sync.RWMutex
+	xgModel       *XGModel
+	xgModelLoaded sync.Once
+)
+
+// activeXGModel returns the retrained model from Redis if one was saved,
+// otherwise the embedded default coefficients.
+func activeXGModel() *XGModel {
+	xgModelLoaded.Do(func() {
+		var m XGModel
+		if data, err := getCachedRaw(xgModelKey); err == nil {
+			if err := json.Unmarshal(data, &m); err == nil {
+				setXGModel(&m)
+				return
+			}
+			log.Printf("Stored xG model is unreadable, using embedded default")
+		}
+		if err := json.Unmarshal(defaultXGCoefficients, &m); err != nil {
+			log.Fatalf("embedded xG coefficients are invalid: %v", err)
+		}
+		setXGModel(&m)
+	})
+	xgModelMu.RLock()
+	defer xgModelMu.RUnlock()
+	return xgModel
+}
+
+// cacheTag identifies the model in xG cache keys, so a retrain starts from
+// an empty cache instead of serving numbers from the old coefficients.
+func (m *XGModel) cacheTag() string {
+	if t, err := time.Parse(time.RFC3339, m.TrainedAt); err == nil {
+		return strconv.FormatInt(t.Unix(), 10)
+	}
+	return "default"
+}
+
+func setXGModel(m *XGModel) {
+	xgModelMu.Lock()
+	xgModel = m
+	xgModelMu.Unlock()
+}
+
+// xgFeatures are the model inputs for one unblocked shot.
+type xgFeatures struct {
+	Distance float64
+	Angle    float64
+	ShotType string
+	Rebound  bool
+	Rush     bool
+	EmptyNet bool
+	Strength string
+}
+
+// vector lays the features out in the order used by weights/fromWeights.
+// Distance and angle are scaled by 1/10 so plain gradient descent converges.
+func (f xgFeatures) vector() []float64 {
+	v := make([]float64, 8+len(xgShotTypes))
+	v[0] = 1
+	v[1] = f.Distance / 10
+	v[2] = f.Angle / 10
+	v[3] = boolFloat(f.Rebound)
+	v[4] = boolFloat(f.Rush)
+	v[5] = boolFloat(f.EmptyNet)
+	v[6] = boolFloat(f.Strength == "PP")
+	v[7] = boolFloat(f.Strength == "SH")
+	for i, st := range xgShotTypes {
+		if f.ShotType == st {
+			v[8+i] = 1
+		}
+	}
+	return v
+}
+
+func (m *XGModel) weights() []float64 {
+	w := make([]float64, 8+len(xgShotTypes))
+	w[0] = m.Intercept
+	w[1] = m.Distance * 10
+	w[2] = m.Angle * 10
+	w[3] = m.Rebound
+	w[4] = m.Rush
+	w[5] = m.EmptyNet
+	w[6] = m.Strength["PP"]
+	w[7] = m.Strength["SH"]
+	for i, st := range xgShotTypes {
+		w[8+i] = m.ShotType[st]
+	}
+	return w
+}
+
+func (m *XGModel) fromWeights(w []float64) {
+	m.Intercept = w[0]
+	m.Distance = w[1] / 10
+	m.Angle = w[2] / 10
+	m.Rebound = w[3]
+	m.Rush = w[4]
+	m.EmptyNet = w[5]
+	m.Strength = map[string]float64{"PP": w[6], "SH": w[7]}
+	m.ShotType = make(map[string]float64, len(xgShotTypes))
+	for i, st := range xgShotTypes {
+		m.ShotType[st] = w[8+i]
+	}
+}
+
+// Predict returns the goal probability for a shot.
+func (m *XGModel) Predict(f xgFeatures) float64 {
+	w := m.weights()
+	z := 0.0
+	for i, x := range f.vector() {
+		z += w[i] * x
+	}
+	return 1 / (1 + math.Exp(-z))
+}
+
+func boolFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// round3 rounds to three decimals for JSON output.
+func round3(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
+
+// XGShot is an unblocked shot attempt with its model inputs and xG.
+type XGShot struct {
+	ShotAttempt
+	Distance float64 `json:"distance"`
+	Angle    float64 `json:"angle"`
+	Rebound  bool    `json:"rebound"`
+	Rush     bool    `json:"rush"`
+	XG       float64 `json:"xg"`
+}
+
+func (s XGShot) features() xgFeatures {
+	return xgFeatures{
+		Distance: s.Distance,
+		Angle:    s.Angle,
+		ShotType: s.ShotType,
+		Rebound:  s.Rebound,
+		Rush:     s.Rush,
+		EmptyNet: s.EmptyNet,
+		Strength: s.StrengthState,
+	}
+}
+
+// ExtractXGShots returns every unblocked attempt in a game with distance,
+// angle, rebound and rush flags filled in and scored by the model.
+func ExtractXGShots(pbp *PlayByPlay, model *XGModel) []XGShot {
+	attempts := ExtractShotAttempts(pbp)
+	if len(attempts) == 0 {
+		return nil
+	}
+
+	// Index plays so each attempt can see the event before it and which end
+	// the home team defends in that period.
+	plays := make([]PlayEvent, len(pbp.Plays))
+	copy(plays, pbp.Plays)
+	sort.SliceStable(plays, func(i, j int) bool { return plays[i].SortOrder < plays[j].SortOrder })
+	prevByEvent := make(map[int]PlayEvent, len(plays))
+	sideByEvent := make(map[int]string, len(plays))
+	for i, p := range plays {
+		sideByEvent[p.EventID] = p.HomeTeamDefendingSide
+		if i > 0 {
+			prevByEvent[p.EventID] = plays[i-1]
+		}
+	}
+
+	var shots []XGShot
+	var last *ShotAttempt
+	for i := range attempts {
+		a := attempts[i]
+		prevAttempt := last
+		last = &attempts[i]
+		if !a.IsFenwick() {
+			continue
+		}
+
+		netX := 89.0
+		switch side := sideByEvent[a.EventID]; {
+		case side == "left" && !a.IsHome, side == "right" && a.IsHome:
+			netX = -89
+		case side == "":
+			if a.X < 0 {
+				netX = -89
+			}
+		}
+		dx := math.Abs(netX - a.X)
+		dist := math.Hypot(dx, a.Y)
+		angle := 90.0
+		if dx > 0 {
+			angle = math.Atan(math.Abs(a.Y)/dx) * 180 / math.Pi
+		}
+
+		s := XGShot{ShotAttempt: a, Distance: round3(dist), Angle: round3(angle)}
+		if prevAttempt != nil && prevAttempt.TeamID == a.TeamID && prevAttempt.Period == a.Period &&
+			a.GameSeconds-prevAttempt.GameSeconds <= 3 {
+			s.Rebound = true
+		}
+		if prev, ok := prevByEvent[a.EventID]; ok && prev.PeriodDescriptor.Number == a.Period {
+			prevSecs := (prev.PeriodDescriptor.Number-1)*1200 + clockSeconds(prev.TimeInPeriod)
+			zone := prev.Details.ZoneCode
+			// zoneCode is relative to the event owner; flip it for the other team.
+			if prev.Details.EventOwnerTeamID != 0 && prev.Details.EventOwnerTeamID != a.TeamID {
+				switch zone {
+				case "O":
+					zone = "D"
+				case "D":
+					zone = "O"
+				}
+			}
+			if a.GameSeconds-prevSecs <= 4 && (zone == "N" || zone == "D") {
+				s.Rush = true
+			}
+		}
+		s.XG = round3(model.Predict(s.features()))
+		shots = append(shots, s)
+	}
+	return shots
+}
+
+// XGTeamSummary is one team's expected goals for and against.
+type XGTeamSummary struct {
+	TeamID       int     `json:"teamId"`
+	Abbrev       string  `json:"abbrev"`
+	XGF          float64 `json:"xgf"`
+	XGA          float64 `json:"xga"`
+	XGFPct       float64 `json:"xgfPct"`
+	XGF5v5       float64 `json:"xgf5v5"`
+	XGA5v5       float64 `json:"xga5v5"`
+	GoalsFor     int     `json:"goalsFor"`
+	GoalsAgainst int     `json:"goalsAgainst"`
+}
+
+func (t *XGTeamSummary) add(s XGShot) {
+	isGoal := s.Type == eventGoal
+	if s.TeamID == t.TeamID {
+		t.XGF += s.XG
+		if s.Strength == "5v5" {
+			t.XGF5v5 += s.XG
+		}
+		if isGoal {
+			t.GoalsFor++
+		}
+		return
+	}
+	t.XGA += s.XG
+	if s.Strength == "5v5" {
+		t.XGA5v5 += s.XG
+	}
+	if isGoal {
+		t.GoalsAgainst++
+	}
+}
+
+func (t *XGTeamSummary) finalize() {
+	if t.XGF+t.XGA > 0 {
+		t.XGFPct = math.Round(t.XGF/(t.XGF+t.XGA)*1000) / 10
+	}
+	t.XGF = round3(t.XGF)
+	t.XGA = round3(t.XGA)
+	t.XGF5v5 = round3(t.XGF5v5)
+	t.XGA5v5 = round3(t.XGA5v5)
+}
+
+// XGTimelinePoint is a single shot on the cumulative xG timeline.
+type XGTimelinePoint struct {
+	EventID      int     `json:"eventId"`
+	Period       int     `json:"period"`
+	TimeInPeriod string  `json:"timeInPeriod"`
+	GameSeconds  int     `json:"gameSeconds"`
+	TeamAbbrev   string  `json:"teamAbbrev"`
+	ShooterID    int     `json:"shooterId"`
+	XG           float64 `json:"xg"`
+	Goal         bool    `json:"goal"`
+	HomeXG       float64 `json:"homeXg"`
+	AwayXG       float64 `json:"awayXg"`
+}
+
+// XGPlayer is a skater's individual expected goals.
+type XGPlayer struct {
+	PlayerID   int     `json:"playerId"`
+	Name       string  `json:"name"`
+	TeamAbbrev string  `json:"teamAbbrev"`
+	IXG        float64 `json:"ixg"`
+	Goals      int     `json:"goals"`
+	Shots      int     `json:"shots"`
+}
+
+// GameXG is the /api/game/{id}/xg response.
+type GameXG struct {
+	GameID    int64             `json:"gameId"`
+	GameDate  string            `json:"gameDate"`
+	GameState string            `json:"gameState"`
+	Model     string            `json:"model"`
+	Home      XGTeamSummary     `json:"home"`
+	Away      XGTeamSummary     `json:"away"`
+	Timeline  []XGTimelinePoint `json:"timeline"`
+	Players   []XGPlayer        `json:"players"`
+	Shots     []XGShot          `json:"shots"`
+}
+
+// ComputeGameXG scores every unblocked shot in a game and builds the timeline.
+func ComputeGameXG(pbp *PlayByPlay) *GameXG {
+	model := activeXGModel()
+	shots := ExtractXGShots(pbp, model)
+	out := &GameXG{
+		GameID:    pbp.ID,
+		GameDate:  pbp.GameDate,
+		GameState: pbp.GameState,
+		Model:     model.Version,
+		Home:      XGTeamSummary{TeamID: pbp.HomeTeam.ID, Abbrev: pbp.HomeTeam.Abbrev},
+		Away:      XGTeamSummary{TeamID: pbp.AwayTeam.ID, Abbrev: pbp.AwayTeam.Abbrev},
+		Timeline:  []XGTimelinePoint{},
+		Players:   []XGPlayer{},
+		Shots:     shots,
+	}
+	if out.Shots == nil {
+		out.Shots = []XGShot{}
+	}
+
+	names := make(map[int]string, len(pbp.RosterSpots))
+	for _, rs := range pbp.RosterSpots {
+		names[rs.PlayerID] = strings.TrimSpace(rs.FirstName.Default + " " + rs.LastName.Default)
+	}
+	players := map[int]*XGPlayer{}
+	homeCum, awayCum := 0.0, 0.0
+	for _, s := range shots {
+		out.Home.add(s)
+		out.Away.add(s)
+		if s.IsHome {
+			homeCum += s.XG
+		} else {
+			awayCum += s.XG
+		}
+		out.Timeline = append(out.Timeline, XGTimelinePoint{
+			EventID:      s.EventID,
+			Period:       s.Period,
+			TimeInPeriod: s.TimeInPeriod,
+			GameSeconds:  s.GameSeconds,
+			TeamAbbrev:   s.TeamAbbrev,
+			ShooterID:    s.ShooterID,
+			XG:           s.XG,
+			Goal:         s.Type == eventGoal,
+			HomeXG:       round3(homeCum),
+			AwayXG:       round3(awayCum),
+		})
+
+		p := players[s.ShooterID]
+		if p == nil {
+			p = &XGPlayer{PlayerID: s.ShooterID, Name: names[s.ShooterID], TeamAbbrev: s.TeamAbbrev}
+			players[s.ShooterID] = p
+		}
+		p.IXG += s.XG
+		p.Shots++
+		if s.Type == eventGoal {
+			p.Goals++
+		}
+	}
+	out.Home.finalize()
+	out.Away.finalize()
+
+	for _, p := range players {
+		p.IXG = round3(p.IXG)
+		out.Players = append(out.Players, *p)
+	}
+	sort.Slice(out.Players, func(i, j int) bool {
+		if out.Players[i].IXG != out.Players[j].IXG {
+			return out.Players[i].IXG > out.Players[j].IXG
+		}
+		return out.Players[i].PlayerID < out.Players[j].PlayerID
+	})
+	return out
+}
+
+// GetGameXG fetches play-by-play and computes xG for one game.
+func GetGameXG(gameID string) (*GameXG, error) {
+	pbp, err := GetPlayByPlay(gameID)
+	if err != nil {
+		return nil, err
+	}
+	return ComputeGameXG(pbp), nil
+}
+
+// TeamXGGame is one game's line in a team's season xG log.
+type TeamXGGame struct {
+	GameID   int64   `json:"gameId"`
+	GameDate string  `json:"gameDate"`
+	Opponent string  `json:"opponent"`
+	IsHome   bool    `json:"isHome"`
+	XGF      float64 `json:"xgf"`
+	XGA      float64 `json:"xga"`
+	GF       int     `json:"gf"`
+	GA       int     `json:"ga"`
+}
+
+// TeamXG is the /api/team/{id}/xg response.
+type TeamXG struct {
+	Team     string        `json:"team"`
+	Season   string        `json:"season"`
+	GameType int           `json:"gameType"`
+	Games    int           `json:"games"`
+	Totals   XGTeamSummary `json:"totals"`
+	PerGame  []TeamXGGame  `json:"perGame"`
+}
+
+// GetTeamXG totals a team's xG for and against over its finished games.
+func GetTeamXG(teamAbbrev, season string, gameType int) (*TeamXG, error) {
+	sched, err := GetClubSchedule(teamAbbrev, season)
+	if err != nil {
+		return nil, err
+	}
+	if season == "" && sched.CurrentSeason != 0 {
+		season = strconv.Itoa(sched.CurrentSeason)
+	}
+	out := &TeamXG{
+		Team:     teamAbbrev,
+		Season:   season,
+		GameType: gameType,
+		Totals:   XGTeamSummary{TeamID: abbrevToTeamID[teamAbbrev], Abbrev: teamAbbrev},
+		PerGame:  []TeamXGGame{},
+	}
+	for _, g := range sched.Games {
+		if g.GameType != gameType || !isGameFinal(g.GameState) {
+			continue
+		}
+		gx, err := GetGameXG(strconv.FormatInt(g.ID, 10))
+		if err != nil {
+			log.Printf("GetTeamXG: skipping game %d for %s: %v", g.ID, teamAbbrev, err)
+			continue
+		}
+		side, opp, isHome := gx.Away, gx.Home, false
+		if gx.Home.Abbrev == teamAbbrev {
+			side, opp, isHome = gx.Home, gx.Away, true
+		}
+		out.Totals.XGF += side.XGF
+		out.Totals.XGA += side.XGA
+		out.Totals.XGF5v5 += side.XGF5v5
+		out.Totals.XGA5v5 += side.XGA5v5
+		out.Totals.GoalsFor += side.GoalsFor
+		out.Totals.GoalsAgainst += side.GoalsAgainst
+		out.PerGame = append(out.PerGame, TeamXGGame{
+			GameID:   gx.GameID,
+			GameDate: gx.GameDate,
+			Opponent: opp.Abbrev,
+			IsHome:   isHome,
+			XGF:      side.XGF,
+			XGA:      side.XGA,
+			GF:       side.GoalsFor,
+			GA:       side.GoalsAgainst,
+		})
+		out.Games++
+	}
+	out.Totals.finalize()
+	return out, nil
+}
+
+// PlayerXGGame is one game's line in a player's xG log.
+type PlayerXGGame struct {
+	GameID   int64   `json:"gameId"`
+	GameDate string  `json:"gameDate"`
+	Opponent string  `json:"opponent"`
+	IXG      float64 `json:"ixg"`
+	Goals    int     `json:"goals"`
+	Shots    int     `json:"shots"`
+}
+
+// PlayerXG is the /api/player/{id}/xg response.
+type PlayerXG struct {
+	PlayerID           int            `json:"playerId"`
+	Season             string         `json:"season"`
+	GameType           int            `json:"gameType"`
+	Games              int            `json:"games"`
+	IXG                float64        `json:"ixg"`
+	Goals              int            `json:"goals"`
+	Shots              int            `json:"shots"`
+	GoalsAboveExpected float64        `json:"goalsAboveExpected"`
+	IXGPerGame         float64        `json:"ixgPerGame"`
+	PerGame            []PlayerXGGame `json:"perGame"`
+}
+
+// GetPlayerXG sums a skater's individual xG across the games in their game log.
+func GetPlayerXG(playerID, season string, gameType int) (*PlayerXG, error) {
+	id, err := strconv.Atoi(playerID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid player id: %s", playerID)
+	}
+	gl, err := GetPlayerGameLog(playerID, season, gameType)
+	if err != nil {
+		return nil, err
+	}
+	if season == "" {
+		season = currentSeasonID()
+	}
+	out := &PlayerXG{PlayerID: id, Season: season, GameType: gameType, PerGame: []PlayerXGGame{}}
+	for _, entry := range gl.GameLog {
+		gx, err := GetGameXG(strconv.FormatInt(entry.GameID, 10))
+		if err != nil {
+			log.Printf("GetPlayerXG: skipping game %d for %s: %v", entry.GameID, playerID, err)
+			continue
+		}
+		line := PlayerXGGame{GameID: entry.GameID, GameDate: entry.GameDate, Opponent: entry.OpponentAbbrev}
+		for _, p := range gx.Players {
+			if p.PlayerID == id {
+				line.IXG, line.Goals, line.Shots = p.IXG, p.Goals, p.Shots
+				break
+			}
+		}
+		out.IXG += line.IXG
+		out.Goals += line.Goals
+		out.Shots += line.Shots
+		out.Games++
+		out.PerGame = append(out.PerGame, line)
+	}
+	sort.Slice(out.PerGame, func(i, j int) bool { return out.PerGame[i].GameDate < out.PerGame[j].GameDate })
+	out.GoalsAboveExpected = round3(float64(out.Goals) - out.IXG)
+	if out.Games > 0 {
+		out.IXGPerGame = round3(out.IXG / float64(out.Games))
+	}
+	out.IXG = round3(out.IXG)
+	return out, nil
+}
+
+// xgSample is a labelled training row.
+type xgSample struct {
+	x    []float64
+	goal bool
+}
+
+// collectXGSamples scans every stored play-by-play payload in Redis and
+// returns labelled unblocked shots for training.
+func collectXGSamples() ([]xgSample, error) {
+	if redisClient == nil {
+		return nil, fmt.Errorf("redis not available")
+	}
+	model := activeXGModel()
+	var samples []xgSample
+	var cursor uint64
+	for {
+		keys, next, err := redisClient.Scan(redisCtx, cursor, "pbp:*", 200).Result()
+		if err != nil {
+			return nil, err
+		}
+		for _, k := range keys {
+			data, err := getCachedRaw(k)
+			if err != nil {
+				if err != redis.Nil {
+					log.Printf("collectXGSamples: reading %s: %v", k, err)
+				}
+				continue
+			}
+			var pbp PlayByPlay
+			if err := json.Unmarshal(data, &pbp); err != nil || !isGameFinal(pbp.GameState) {
+				continue
+			}
+			for _, s := range ExtractXGShots(&pbp, model) {
+				samples = append(samples, xgSample{x: s.features().vector(), goal: s.Type == eventGoal})
+			}
+		}
+		cursor = next
+		if cursor == 0 {
+			break
+		}
+	}
+	return samples, nil
+}
+
+// trainXGModel fits logistic regression coefficients by batch gradient
+// descent with light L2 regularisation, starting from the given model.
+func trainXGModel(samples []xgSample, start *XGModel) *XGModel {
+	const (
+		iterations = 500
+		rate       = 0.5
+		l2         = 1e-4
+	)
+	w := start.weights()
+	grad := make([]float64, len(w))
+	n := float64(len(samples))
+	for it := 0; it < iterations; it++ {
+		for i := range grad {
+			grad[i] = 0
+		}
+		for _, s := range samples {
+			z := 0.0
+			for i, x := range s.x {
+				z += w[i] * x
+			}
+			diff := 1/(1+math.Exp(-z)) - boolFloat(s.goal)
+			for i, x := range s.x {
+				grad[i] += diff * x
+			}
+		}
+		for i := range w {
+			reg := 0.0
+			if i > 0 {
+				reg = l2 * w[i]
+			}
+			w[i] -= rate * (grad[i]/n + reg)
+		}
+	}
+
+	m := &XGModel{
+		Version:   "trained-" + time.Now().UTC().Format("20060102"),
+		TrainedAt: time.Now().UTC().Format(time.RFC3339),
+		Samples:   len(samples),
+	}
+	m.fromWeights(w)
+	return m
+}
+
+// minXGTrainingSamples guards against fitting on a handful of games.
+const minXGTrainingSamples = 2000
+
+// handleAPIGameXG returns shot-level xG, the cumulative timeline and player ixG for a game.
+func handleAPIGameXG(w http.ResponseWriter, r *http.Request) {
+	gameID := mux.Vars(r)["gameId"]
+	if _, err := strconv.ParseInt(gameID, 10, 64); err != nil {
+		http.Error(w, "invalid game id", http.StatusBadRequest)
+		return
+	}
+	gx, err := GetGameXG(gameID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(gx); err != nil {
+		log.Printf("Error writing game xG JSON: %v", err)
+	}
+}
+
+// Season xG walks every game's play-by-play, so team and player totals are
+// built in the background and the first request answers 503 until ready.
+var (
+	teamXGBuilds   = newBackgroundBuilds()
+	playerXGBuilds = newBackgroundBuilds()
+)
+
+// handleAPITeamXG returns a team's season xG for and against.
+func handleAPITeamXG(w http.ResponseWriter, r *http.Request) {
+	abbr, ok := resolveTeamAbbrev(mux.Vars(r)["teamId"])
+	if !ok {
+		http.Error(w, "unknown team", http.StatusNotFound)
+		return
+	}
+	season, gameType, ok := seasonAndGameType(w, r)
+	if !ok {
+		return
+	}
+	cacheKey := fmt.Sprintf("xg:team:%s:%s:%s:%d", activeXGModel().cacheTag(), abbr, season, gameType)
+	data, err := teamXGBuilds.get(cacheKey, time.Hour, func() ([]byte, error) {
+		tx, err := GetTeamXG(abbr, season, gameType)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(tx)
+	})
+	if err != nil {
+		writeStillBuilding(w)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(data); err != nil {
+		log.Printf("Error writing team xG JSON: %v", err)
+	}
+}
+
+// handleAPIPlayerXG returns a skater's individual xG over a season.
+func handleAPIPlayerXG(w http.ResponseWriter, r *http.Request) {
+	playerID := mux.Vars(r)["playerId"]
+	if _, err := strconv.Atoi(playerID); err != nil {
+		http.Error(w, "invalid player id", http.StatusBadRequest)
+		return
+	}
+	season, gameType, ok := seasonAndGameType(w, r)
+	if !ok {
+		return
+	}
+	cacheKey := fmt.Sprintf("xg:player:%s:%s:%s:%d", activeXGModel().cacheTag(), playerID, season, gameType)
+	data, err := playerXGBuilds.get(cacheKey, time.Hour, func() ([]byte, error) {
+		px, err := GetPlayerXG(playerID, season, gameType)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(px)
+	})
+	if err != nil {
+		writeStillBuilding(w)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(data); err != nil {
+		log.Printf("Error writing player xG JSON: %v", err)
+	}
+}
+
+// handleAPIXGModel returns the coefficients currently in use.
+func handleAPIXGModel(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(activeXGModel()); err != nil {
+		log.Printf("Error writing xG model JSON: %v", err)
+	}
+}
+
+// handleAPIXGRetrain refits the model on all stored play-by-play and
+// persists the result so every instance picks it up on restart.
+func handleAPIXGRetrain(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	samples, err := collectXGSamples()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	if len(samples) < minXGTrainingSamples {
+		http.Error(w, fmt.Sprintf("not enough stored shots to retrain (%d < %d)", len(samples), minXGTrainingSamples), http.StatusConflict)
+		return
+	}
+
+	m := trainXGModel(samples, activeXGModel())
+	data, err := json.Marshal(m)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := setCachedRaw(xgModelKey, data, 0); err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	setXGModel(m)
+	log.Printf("Retrained xG model on %d shots", len(samples))
+
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(data); err != nil {
+		log.Printf("Error writing xG model JSON: %v", err)
+	}
+}